@@ -0,0 +1,47 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agentcache
+
+import (
+	"github.com/gravitational/satellite/agent/cache"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+
+	"github.com/gravitational/trace"
+)
+
+// NewNotifying wraps inner so that notify is called with every status
+// recorded via UpdateStatus, in addition to it being cached as usual.
+//
+// notify is called synchronously from UpdateStatus and must not block for
+// long - the status collector's next collection cycle waits on it.
+func NewNotifying(inner cache.Cache, notify func(*pb.SystemStatus)) cache.Cache {
+	return &notifyingCache{Cache: inner, notify: notify}
+}
+
+type notifyingCache struct {
+	cache.Cache
+	notify func(*pb.SystemStatus)
+}
+
+// UpdateStatus persists status as usual and additionally reports it to notify.
+func (r *notifyingCache) UpdateStatus(status *pb.SystemStatus) error {
+	if err := r.Cache.UpdateStatus(status); err != nil {
+		return trace.Wrap(err)
+	}
+	r.notify(status)
+	return nil
+}
@@ -0,0 +1,48 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agentcache
+
+import (
+	"testing"
+
+	"github.com/gravitational/satellite/agent/backend/inmemory"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestNotifyingCacheCallsNotifyOnUpdate(t *testing.T) {
+	var notified []*pb.SystemStatus
+	c := NewNotifying(inmemory.New(), func(status *pb.SystemStatus) {
+		notified = append(notified, status)
+	})
+
+	status := &pb.SystemStatus{Status: pb.SystemStatus_Degraded, Timestamp: pb.NewTimestamp()}
+	if err := c.UpdateStatus(status); err != nil {
+		t.Fatalf("UpdateStatus failed: %v", err)
+	}
+
+	if len(notified) != 1 || notified[0] != status {
+		t.Fatalf("expected notify to be called once with status, got %+v", notified)
+	}
+
+	recent, err := c.RecentStatus()
+	if err != nil {
+		t.Fatalf("RecentStatus failed: %v", err)
+	}
+	if recent.Status != pb.SystemStatus_Degraded {
+		t.Errorf("expected wrapped cache to still record the status, got %v", recent.Status)
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package agentcache provides cache.Cache wrappers used by the planet agent.
+package agentcache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gravitational/satellite/agent/cache"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/lib/history"
+
+	"github.com/gravitational/trace"
+)
+
+// StaleSummaryPrefix marks a SystemStatus.Summary rehydrated from timeline
+// history rather than produced by a live collection, so that JSON consumers
+// of the status (e.g. `planet status --output json`) can tell the two apart.
+const StaleSummaryPrefix = "STALE (rehydrated from cluster history): "
+
+// NewRehydrating wraps inner with a rehydration step: on construction, it
+// looks up the most recent cluster-level event recorded in timeline and, if
+// one is found, serves a synthetic status built from it until the first
+// live status is recorded via UpdateStatus.
+//
+// This exists so a freshly restarted agent doesn't report the cluster as
+// unknown for the first collection interval, which rolling agent updates
+// otherwise treat as a failure.
+func NewRehydrating(ctx context.Context, inner cache.Cache, timeline history.Timeline) cache.Cache {
+	return &rehydratingCache{
+		Cache: inner,
+		stale: rehydrate(ctx, timeline),
+	}
+}
+
+type rehydratingCache struct {
+	cache.Cache
+
+	mu    sync.Mutex
+	stale *pb.SystemStatus
+}
+
+// UpdateStatus persists status and discards any rehydrated snapshot - once
+// the first live collection completes, rehydrated data is no longer served.
+func (r *rehydratingCache) UpdateStatus(status *pb.SystemStatus) error {
+	r.mu.Lock()
+	r.stale = nil
+	r.mu.Unlock()
+	return trace.Wrap(r.Cache.UpdateStatus(status))
+}
+
+// RecentStatus returns the rehydrated snapshot until the first live status
+// has been recorded, and defers to the wrapped cache afterwards.
+func (r *rehydratingCache) RecentStatus() (*pb.SystemStatus, error) {
+	r.mu.Lock()
+	stale := r.stale
+	r.mu.Unlock()
+	if stale != nil {
+		return stale, nil
+	}
+	return r.Cache.RecentStatus()
+}
+
+// rehydrate reconstructs an approximate system status from the most recent
+// cluster-level event in timeline, or returns nil if timeline is nil, empty,
+// or has no cluster-level events to work from.
+func rehydrate(ctx context.Context, timeline history.Timeline) *pb.SystemStatus {
+	if timeline == nil {
+		return nil
+	}
+	events, err := timeline.GetEvents(ctx, nil)
+	if err != nil {
+		return nil
+	}
+	// GetEvents returns events sorted by timestamp, so the last matching
+	// entry encountered scanning backwards is the most recent one.
+	for i := len(events) - 1; i >= 0; i-- {
+		switch event := events[i]; {
+		case event.GetClusterHealthy() != nil:
+			return staleStatus(pb.SystemStatus_Running, event)
+		case event.GetClusterDegraded() != nil:
+			return staleStatus(pb.SystemStatus_Degraded, event)
+		}
+	}
+	return nil
+}
+
+// staleStatus builds the synthetic SystemStatus served until the first live
+// collection completes.
+func staleStatus(status pb.SystemStatus_Type, event *pb.TimelineEvent) *pb.SystemStatus {
+	return &pb.SystemStatus{
+		Status:    status,
+		Timestamp: event.GetTimestamp(),
+		Summary:   fmt.Sprintf("%vlast known status as of %v", StaleSummaryPrefix, event.GetTimestamp().ToTime()),
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agentcache
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gravitational/satellite/agent/backend/inmemory"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/lib/history/sqlite"
+)
+
+func newTestTimeline(t *testing.T) *sqlite.Timeline {
+	dbPath := filepath.Join(t.TempDir(), "timeline.db")
+	timeline, err := sqlite.NewTimeline(context.Background(), sqlite.Config{DBPath: dbPath})
+	if err != nil {
+		t.Fatalf("failed to create timeline: %v", err)
+	}
+	return timeline
+}
+
+func TestRehydratingCacheServesLastKnownStatus(t *testing.T) {
+	ctx := context.Background()
+	timeline := newTestTimeline(t)
+	events := []*pb.TimelineEvent{
+		pb.NewClusterHealthy(time.Now().Add(-time.Hour)),
+		pb.NewClusterDegraded(time.Now()),
+	}
+	if err := timeline.RecordEvents(ctx, events); err != nil {
+		t.Fatalf("failed to record events: %v", err)
+	}
+
+	c := NewRehydrating(ctx, inmemory.New(), timeline)
+	status, err := c.RecentStatus()
+	if err != nil {
+		t.Fatalf("RecentStatus failed: %v", err)
+	}
+	if status.Status != pb.SystemStatus_Degraded {
+		t.Errorf("expected rehydrated status Degraded, got %v", status.Status)
+	}
+	if !strings.HasPrefix(status.Summary, StaleSummaryPrefix) {
+		t.Errorf("expected summary to carry staleness marker, got %q", status.Summary)
+	}
+}
+
+func TestRehydratingCacheClearsAfterUpdate(t *testing.T) {
+	ctx := context.Background()
+	timeline := newTestTimeline(t)
+	if err := timeline.RecordEvents(ctx, []*pb.TimelineEvent{pb.NewClusterDegraded(time.Now())}); err != nil {
+		t.Fatalf("failed to record events: %v", err)
+	}
+
+	c := NewRehydrating(ctx, inmemory.New(), timeline)
+	live := &pb.SystemStatus{Status: pb.SystemStatus_Running, Timestamp: pb.NewTimestamp()}
+	if err := c.UpdateStatus(live); err != nil {
+		t.Fatalf("UpdateStatus failed: %v", err)
+	}
+	status, err := c.RecentStatus()
+	if err != nil {
+		t.Fatalf("RecentStatus failed: %v", err)
+	}
+	if status.Summary != "" {
+		t.Errorf("expected live status to carry no staleness marker, got %q", status.Summary)
+	}
+	if status.Status != pb.SystemStatus_Running {
+		t.Errorf("expected live status Running, got %v", status.Status)
+	}
+}
+
+func TestRehydrateWithNoEvents(t *testing.T) {
+	ctx := context.Background()
+	timeline := newTestTimeline(t)
+
+	c := NewRehydrating(ctx, inmemory.New(), timeline)
+	status, err := c.RecentStatus()
+	if err != nil {
+		t.Fatalf("RecentStatus failed: %v", err)
+	}
+	if status != nil {
+		t.Errorf("expected no rehydrated status, got %+v", status)
+	}
+}
@@ -59,6 +59,23 @@ type Config struct {
 	ProcessLabel string
 	// SELinux turns on SELinux support
 	SELinux bool
+	// ReadonlyRootfs mounts the container's rootfs read-only. Writable tmpfs
+	// mounts are added for the paths systemd/etcd need - see
+	// readonlyRootfsTmpfsPaths.
+	ReadonlyRootfs bool
+	// MemoryLimit caps the container's total memory usage, in bytes. Zero
+	// means no limit.
+	MemoryLimit int64
+	// MemoryReservation is the soft memory limit, in bytes, the kernel
+	// tries to keep the container under whenever the host is under memory
+	// pressure, even though MemoryLimit permits more. Zero means none.
+	MemoryReservation int64
+	// CPUQuota is the container's CPU quota, in microseconds of CPU time
+	// allowed per CPUPeriod. Zero means no quota.
+	CPUQuota int64
+	// CPUPeriod is the length of the CPU quota accounting period, in
+	// microseconds. Ignored if CPUQuota is zero.
+	CPUPeriod uint64
 	// FieldLogger specifies the logger
 	log.FieldLogger
 }
@@ -107,6 +124,10 @@ type TTY struct {
 // ProcessConfig is a configuration passed to the process started
 // in the namespace of the container
 type ProcessConfig struct {
+	// In and Out are wired directly to the entered process' console/pipes
+	// via io.Copy (see enter.go) - stdio is relayed as a raw byte stream,
+	// not framed JSON messages, so there is no message boundary for a
+	// reader on the other end to lose track of.
 	In           io.Reader `json:"-"`
 	Out          io.Writer `json:"-"`
 	TTY          *TTY      `json:"tty,omitempty"`
@@ -114,6 +135,31 @@ type ProcessConfig struct {
 	User         string    `json:"user"`
 	Env          EnvVars   `json:"env,omitempty"`
 	ProcessLabel string    `json:"process_label,omitempty"`
+	// UnitCgroupPath, if set, is a cgroup path relative to the container's
+	// own cgroup hierarchy (e.g. "system.slice/kubelet.service") that the
+	// started process is moved into once running, in place of the default
+	// "user" cgroup. See setProcessUnitCgroup.
+	UnitCgroupPath string `json:"unit_cgroup_path,omitempty"`
+	// ReadonlyRootfs, if set, remounts this process' own view of the
+	// container's rootfs read-only before it execs. Other processes already
+	// running in the container, and any future session, are unaffected.
+	ReadonlyRootfs bool `json:"readonly_rootfs,omitempty"`
+	// CapAdd and CapDrop adjust the Linux capabilities this process runs
+	// with relative to the container's own capability set. CapAdd can only
+	// re-add a capability the container itself was started with - enter()
+	// refuses to grant anything wider than that.
+	CapAdd  []string `json:"cap_add,omitempty"`
+	CapDrop []string `json:"cap_drop,omitempty"`
+	// NoNewPrivileges sets PR_SET_NO_NEW_PRIVS on the process, preventing it
+	// and any of its children from gaining privileges (e.g. via a setuid
+	// binary) beyond what it starts with.
+	NoNewPrivileges bool `json:"no_new_privileges,omitempty"`
+	// OutputBuffering, if set, bounds how much of this process' stdout and
+	// stderr planet buffers in memory on its way to Out, so a slow
+	// destination can't leave the process blocked on write(2) to its own
+	// output pipe. Nil preserves today's behavior of relaying output with a
+	// direct, unbounded io.Copy.
+	OutputBuffering *OutputBufferConfig `json:"-"`
 }
 
 // String returns human-readable description of this configuration
@@ -124,6 +170,10 @@ func (e *ProcessConfig) String() string {
 	if e.ProcessLabel != "" {
 		fmt.Fprintf(&buf, ",selinux_domain=%q", e.ProcessLabel)
 	}
+	if e.ReadonlyRootfs || len(e.CapAdd) != 0 || len(e.CapDrop) != 0 || e.NoNewPrivileges {
+		fmt.Fprintf(&buf, ",readonly_rootfs=%v,cap_add=%v,cap_drop=%v,no_new_privileges=%v",
+			e.ReadonlyRootfs, e.CapAdd, e.CapDrop, e.NoNewPrivileges)
+	}
 	fmt.Fprint(&buf, ")")
 	return buf.String()
 }
@@ -262,49 +312,51 @@ type Mount struct {
 
 type Mounts []Mount
 
+// Set parses v as a comma-separated list of src:dst[:options] mounts. A
+// literal ':' or ',' inside src or dst must be escaped as '\:' or '\,'
+// respectively, since both characters are otherwise significant delimiters.
 func (m *Mounts) Set(v string) error {
-	for _, i := range cstrings.SplitComma(v) {
-		if err := m.setItem(i); err != nil {
-			return err
+	for i, entry := range cstrings.SplitComma(v) {
+		if err := m.setItem(unescapeDelim(entry, ',')); err != nil {
+			return trace.Wrap(err, "mount %v (%q)", i+1, entry)
 		}
 	}
 	return nil
 }
 
 func (m *Mounts) setItem(v string) error {
-	vals := strings.Split(v, ":")
-	if len(vals) < 2 {
+	fields := cstrings.Split(':', '\\', v)
+	for i := range fields {
+		fields[i] = unescapeDelim(fields[i], ':')
+	}
+	if len(fields) < 2 {
 		return trace.BadParameter(
 			"expected a mount specified as src:dst[:options], but got %q", v)
 	}
-	mount := Mount{Src: vals[0], Dst: vals[1]}
-	if len(vals) > 2 {
-		options := vals[2:]
-		err := parseMountOptions(options, &mount)
-		if err != nil {
-			return trace.BadParameter("failed to parse mount options %q", options)
+	mount := Mount{Src: fields[0], Dst: fields[1]}
+	if len(fields) > 2 {
+		options := fields[2:]
+		if err := parseMountOptions(options, &mount); err != nil {
+			return trace.BadParameter("failed to parse mount options %q in %q: %v", options, v, err)
 		}
 	}
 	*m = append(*m, mount)
 	return nil
 }
 
+// String formats this object so it round-trips through Set, escaping any
+// ':' or ',' embedded in a mount's src or dst.
 func (m *Mounts) String() string {
 	if len(*m) == 0 {
 		return ""
 	}
-	b := &bytes.Buffer{}
-	for i, v := range *m {
-		fmt.Fprintf(b, "%v:%v", v.Src, v.Dst)
-		options := formatMountOptions(v)
-		if len(options) != 0 {
-			fmt.Fprint(b, ":", strings.Join(options, ":"))
-		}
-		if i != len(*m)-1 {
-			fmt.Fprintf(b, " ")
-		}
+	entries := make([]string, 0, len(*m))
+	for _, v := range *m {
+		fields := []string{escapeDelim(v.Src, ':'), escapeDelim(v.Dst, ':')}
+		fields = append(fields, formatMountOptions(v)...)
+		entries = append(entries, escapeDelim(strings.Join(fields, ":"), ','))
 	}
-	return b.String()
+	return strings.Join(entries, ",")
 }
 
 // DNSOverrides is a command-line flag parser for DNS host/zone overrides
@@ -344,6 +396,21 @@ func (d *DNSOverrides) String() string {
 	return strings.Join(s, ",")
 }
 
+// unescapeDelim undoes the backslash-escaping cstrings.Split expects for
+// delim, converting "\<delim>" back into a literal delim. Any other
+// backslash sequence is left untouched, so escaping done for a different
+// delimiter (at another parsing level) survives.
+func unescapeDelim(s string, delim byte) string {
+	return strings.ReplaceAll(s, "\\"+string(delim), string(delim))
+}
+
+// escapeDelim backslash-escapes any occurrence of delim in s, so the
+// result survives a later cstrings.Split(rune(delim), '\\', s) without
+// being split on an embedded delim.
+func escapeDelim(s string, delim byte) string {
+	return strings.ReplaceAll(s, string(delim), "\\"+string(delim))
+}
+
 func formatMountOptions(mount Mount) (options []string) {
 	if mount.SkipIfMissing {
 		options = append(options, "skip")
@@ -391,7 +458,7 @@ type Device struct {
 
 // Format formats the device to a string
 func (d Device) Format() string {
-	parts := []string{fmt.Sprintf("%v=%v", devicePath, d.Path)}
+	parts := []string{fmt.Sprintf("%v=%v", devicePath, escapeDelim(d.Path, ';'))}
 	if d.Permissions != "" {
 		parts = append(parts, fmt.Sprintf("%v=%v", devicePermissions, d.Permissions))
 	}
@@ -410,11 +477,14 @@ func (d Device) Format() string {
 // Devices represents a list of devices
 type Devices []Device
 
-// Set sets the devices from CLI flags
+// Set parses v as a comma-separated list of devices, each formatted as
+// path=...;permissions=...;... . A literal ';' or ',' inside a value must
+// be escaped as '\;' or '\,' respectively, since both characters are
+// otherwise significant delimiters.
 func (d *Devices) Set(v string) error {
-	for _, i := range cstrings.SplitComma(v) {
-		if err := d.setItem(i); err != nil {
-			return err
+	for i, entry := range cstrings.SplitComma(v) {
+		if err := d.setItem(unescapeDelim(entry, ',')); err != nil {
+			return trace.Wrap(err, "device %v (%q)", i+1, entry)
 		}
 	}
 	return nil
@@ -429,26 +499,28 @@ func (d *Devices) setItem(v string) error {
 	return nil
 }
 
-// String converts devices to a string
+// String converts devices to a string that round-trips through Set,
+// escaping any ';' or ',' embedded in a device's values.
 func (d *Devices) String() string {
 	if len(*d) == 0 {
 		return ""
 	}
-	var formats []string
+	formats := make([]string, 0, len(*d))
 	for _, device := range *d {
-		formats = append(formats, device.Format())
+		formats = append(formats, escapeDelim(device.Format(), ','))
 	}
-	return strings.Join(formats, ";")
+	return strings.Join(formats, ",")
 }
 
 // parseDevice parses a single device value in the format:
 // path=/dev/nvidia*;permissions=rwm;fileMode=0666
 func parseDevice(value string) (*Device, error) {
 	device := &Device{}
-	for _, part := range strings.Split(value, ";") {
-		kv := strings.Split(part, "=")
+	for i, part := range cstrings.Split(';', '\\', value) {
+		part = unescapeDelim(part, ';')
+		kv := strings.SplitN(part, "=", 2)
 		if len(kv) != 2 {
-			return nil, trace.BadParameter("malformed device format: %q", value)
+			return nil, trace.BadParameter("malformed device field %v (%q) in %q: expected key=value", i+1, part, value)
 		}
 		switch kv[0] {
 		case devicePath:
@@ -505,19 +577,22 @@ func (r *envParser) parse() (result EnvVars, err error) {
 		switch mode {
 		case envKey:
 			if tok != scanner.Ident {
-				return nil, trace.BadParameter("expected environment variable but got %v", tokenText)
+				return nil, trace.BadParameter("expected environment variable name but got %q at %v", tokenText, r.s.Position)
 			}
 			name = tokenText
 			mode = envEquals
 		case envEquals:
 			if tok != '=' {
-				return nil, trace.BadParameter("expected '=' but got %q", tokenText)
+				return nil, trace.BadParameter("expected '=' after %q but got %q at %v", name, tokenText, r.s.Position)
 			}
 			mode = envValue
 		case envValue:
 			v := tokenText
 			if tok == scanner.String {
-				v = tokenText[1 : len(tokenText)-1]
+				v, err = strconv.Unquote(tokenText)
+				if err != nil {
+					return nil, trace.BadParameter("malformed quoted value %q for %v at %v: %v", tokenText, name, r.s.Position, err)
+				}
 			}
 			result = append(result, EnvPair{Name: name, Val: v})
 			name = ""
@@ -526,7 +601,7 @@ func (r *envParser) parse() (result EnvVars, err error) {
 		}
 	}
 	if mode != envKey {
-		return nil, trace.BadParameter("unexpected token %v", r.s.TokenText())
+		return nil, trace.BadParameter("unexpected end of input after %q at %v", r.s.TokenText(), r.s.Position)
 	}
 	return result, nil
 }
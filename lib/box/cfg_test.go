@@ -142,6 +142,98 @@ func (*CommandFlagSuite) TestEnvParse(c *check.C) {
 	}
 }
 
+func (*CommandFlagSuite) TestMountsParse(c *check.C) {
+	var cases = []struct {
+		value    string
+		expected Mounts
+		comment  string
+	}{
+		{
+			value:    "/src:/dst",
+			expected: Mounts{{Src: "/src", Dst: "/dst"}},
+			comment:  "simple mount",
+		},
+		{
+			value:    "/src:/dst:ro",
+			expected: Mounts{{Src: "/src", Dst: "/dst", Readonly: true}},
+			comment:  "mount with options",
+		},
+		{
+			value: "/src1:/dst1,/src2:/dst2:ro",
+			expected: Mounts{
+				{Src: "/src1", Dst: "/dst1"},
+				{Src: "/src2", Dst: "/dst2", Readonly: true},
+			},
+			comment: "multiple mounts",
+		},
+		{
+			value:    `/src\:with\:colon:/dst`,
+			expected: Mounts{{Src: "/src:with:colon", Dst: "/dst"}},
+			comment:  "escaped colon in source",
+		},
+		{
+			value: `/a\,b:/dst1,/c:/dst2`,
+			expected: Mounts{
+				{Src: "/a,b", Dst: "/dst1"},
+				{Src: "/c", Dst: "/dst2"},
+			},
+			comment: "escaped comma in source",
+		},
+	}
+
+	for _, tt := range cases {
+		comment := check.Commentf(tt.comment)
+		var m Mounts
+		err := m.Set(tt.value)
+		c.Assert(err, check.IsNil, comment)
+		c.Assert(m, check.DeepEquals, tt.expected, comment)
+	}
+}
+
+func (*CommandFlagSuite) TestMountsRejectsMalformedInput(c *check.C) {
+	var m Mounts
+	err := m.Set("/src-only")
+	c.Assert(err, check.NotNil)
+}
+
+func (*CommandFlagSuite) TestMountsStringRoundTripsThroughSet(c *check.C) {
+	original := Mounts{
+		{Src: "/a,b:c", Dst: "/dst", Readonly: true},
+		{Src: "/e", Dst: "/f", Recursive: true},
+	}
+	var reparsed Mounts
+	err := reparsed.Set(original.String())
+	c.Assert(err, check.IsNil)
+	c.Assert(reparsed, check.DeepEquals, original)
+}
+
+func (*CommandFlagSuite) TestDevicesParse(c *check.C) {
+	var d Devices
+	err := d.Set(`path=/dev/nvidia*;permissions=rwm,path=/dev/kvm`)
+	c.Assert(err, check.IsNil)
+	c.Assert(d, check.DeepEquals, Devices{
+		{Path: "/dev/nvidia*", Permissions: "rwm"},
+		{Path: "/dev/kvm"},
+	})
+}
+
+func (*CommandFlagSuite) TestDevicesRejectsMalformedInput(c *check.C) {
+	var d Devices
+	err := d.Set("not-a-key-value-pair")
+	c.Assert(err, check.NotNil)
+}
+
+func (*CommandFlagSuite) TestDevicesStringRoundTripsThroughSet(c *check.C) {
+	original := Devices{
+		{Path: "/dev/nvidia*", Permissions: "rwm"},
+		{Path: "/dev/kvm"},
+	}
+	var reparsed Devices
+	err := reparsed.Set(original.String())
+	c.Assert(err, check.IsNil)
+	c.Assert(reparsed, check.DeepEquals, original)
+}
+
 func (r *CommandFlagSuite) TestEnvDelete(c *check.C) {
 	var cases = []struct {
 		add         string
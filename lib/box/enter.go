@@ -18,11 +18,13 @@ package box
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -32,6 +34,7 @@ import (
 	"github.com/containerd/cgroups"
 	"github.com/gravitational/trace"
 	"github.com/opencontainers/runc/libcontainer"
+	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/runc/libcontainer/utils"
 	"github.com/opencontainers/selinux/go-selinux"
 	log "github.com/sirupsen/logrus"
@@ -124,6 +127,54 @@ func setProcessUserCgroupImpl(c libcontainer.Container, p *libcontainer.Process)
 	return trace.Wrap(control.Add(cgroups.Process{Pid: pid}))
 }
 
+// setProcessUnitCgroup moves the provided libcontainer process into
+// unitCgroupPath, a cgroup path relative to the container's own cgroup
+// hierarchy (e.g. "system.slice/kubelet.service", as resolved from a
+// systemd unit's main process). Like setProcessUserCgroup, this is done on
+// a best effort basis, so we only log if this fails.
+func setProcessUnitCgroup(c libcontainer.Container, p *libcontainer.Process, unitCgroupPath string) {
+	err := setProcessUnitCgroupImpl(c, p, unitCgroupPath)
+	if err != nil {
+		log.WithError(err).Warn("Error setting process into unit cgroup")
+	}
+}
+
+func setProcessUnitCgroupImpl(c libcontainer.Container, p *libcontainer.Process, unitCgroupPath string) error {
+	pid, err := p.Pid()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	state, err := c.State()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	// See setProcessUserCgroupImpl for why the cpu controller is used as
+	// the reference cgroup path.
+	cgroupPath, ok := state.CgroupPaths["cpu"]
+	if !ok {
+		return trace.NotFound("cpu cgroup controller not found: %v", state.CgroupPaths)
+	}
+
+	if !strings.HasPrefix(cgroupPath, "/sys/fs/cgroup/") {
+		return trace.BadParameter("Cgroup path not mounted to /sys/fs/cgroup: %v", cgroupPath)
+	}
+
+	dirs := strings.Split(cgroupPath, "/")
+	if len(dirs) < 6 {
+		return trace.BadParameter("cgroup path expected to have atleast 6 directory separators '/'").AddField("cgroup_path", cgroupPath)
+	}
+	targetPath := filepath.Join("/", path.Join(dirs[5:]...), unitCgroupPath)
+
+	control, err := cgroups.Load(cgroups.V1, cgroups.StaticPath(targetPath))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	return trace.Wrap(control.Add(cgroups.Process{Pid: pid}))
+}
+
 // Enter is used to exec a process within the running container
 func Enter(config EnterConfig) error {
 	if err := config.checkAndSetDefaults(); err != nil {
@@ -156,6 +207,10 @@ func enter(dataDir string, container libcontainer.Container, config ProcessConfi
 		Label: config.ProcessLabel,
 	}
 
+	if err := applyProcessRestrictions(container, &config, p); err != nil {
+		return trace.Wrap(err)
+	}
+
 	if config.TTY != nil {
 		p.ConsoleHeight = uint16(config.TTY.H)
 		p.ConsoleWidth = uint16(config.TTY.W)
@@ -173,9 +228,9 @@ func enter(dataDir string, container libcontainer.Container, config ProcessConfi
 	forwarder := NewSignalForwarder()
 	var tty *tty
 	if config.TTY != nil {
-		tty, err = setupTTYIO(p, rootuid, rootgid)
+		tty, err = setupTTYIO(p, rootuid, rootgid, &config)
 	} else {
-		tty, err = setupIO(p, rootuid, rootgid)
+		tty, err = setupIO(p, rootuid, rootgid, &config)
 	}
 	if err != nil {
 		return trace.Wrap(err)
@@ -193,7 +248,11 @@ func enter(dataDir string, container libcontainer.Container, config ProcessConfi
 		return trace.Wrap(err)
 	}
 
-	setProcessUserCgroup(container, p)
+	if config.UnitCgroupPath != "" {
+		setProcessUnitCgroup(container, p, config.UnitCgroupPath)
+	} else {
+		setProcessUserCgroup(container, p)
+	}
 
 	err = tty.ClosePostStart()
 	if err != nil {
@@ -215,6 +274,105 @@ func enter(dataDir string, container libcontainer.Container, config ProcessConfi
 	return nil
 }
 
+// applyProcessRestrictions narrows p's capabilities, privilege-escalation,
+// and rootfs visibility according to config. This runs server-side (as part
+// of enter()), rather than being left to the client that assembled config,
+// so a modified or malicious client can't simply omit the restriction and
+// get an unrestricted process. Whatever restrictions actually end up applied
+// are logged, since a diagnostic session handed to an untrusted user should
+// leave an audit trail of exactly what it was allowed to do.
+func applyProcessRestrictions(container libcontainer.Container, config *ProcessConfig, p *libcontainer.Process) error {
+	if len(config.CapAdd) == 0 && len(config.CapDrop) == 0 && !config.NoNewPrivileges && !config.ReadonlyRootfs {
+		return nil
+	}
+
+	var baseCaps []string
+	if capabilities := container.Config().Capabilities; capabilities != nil {
+		baseCaps = capabilities.Bounding
+	}
+	caps, err := adjustCapabilities(baseCaps, config.CapAdd, config.CapDrop)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	p.Capabilities = &configs.Capabilities{
+		Bounding:    caps,
+		Effective:   caps,
+		Inheritable: caps,
+		Permitted:   caps,
+		Ambient:     caps,
+	}
+
+	if config.NoNewPrivileges {
+		noNewPrivileges := true
+		p.NoNewPrivileges = &noNewPrivileges
+	}
+
+	if config.ReadonlyRootfs {
+		p.Args = readonlyRootfsArgs(p.Args)
+	}
+
+	log.WithFields(log.Fields{
+		"cap_add":           config.CapAdd,
+		"cap_drop":          config.CapDrop,
+		"no_new_privileges": config.NoNewPrivileges,
+		"readonly_rootfs":   config.ReadonlyRootfs,
+	}).Info("Applying restrictions to entered process.")
+	return nil
+}
+
+// adjustCapabilities returns the capability set obtained by removing drop
+// from base and adding add back to it, after verifying every capability in
+// add was already present in base - a process can only be granted a subset
+// of what the container itself was started with, never more.
+func adjustCapabilities(base, add, drop []string) ([]string, error) {
+	baseSet := make(map[string]bool, len(base))
+	for _, c := range base {
+		baseSet[c] = true
+	}
+	for _, c := range add {
+		if !baseSet[c] {
+			return nil, trace.BadParameter("cannot add capability %v: not granted to the container", c)
+		}
+	}
+
+	kept := make(map[string]bool, len(base))
+	for _, c := range base {
+		kept[c] = true
+	}
+	for _, c := range drop {
+		delete(kept, c)
+	}
+	for _, c := range add {
+		kept[c] = true
+	}
+
+	caps := make([]string, 0, len(kept))
+	for c := range kept {
+		caps = append(caps, c)
+	}
+	sort.Strings(caps)
+	return caps, nil
+}
+
+// readonlyRootfsArgs wraps args so the exec'd process first unshares its own
+// mount namespace - private to itself, leaving other processes and future
+// sessions in the container unaffected - and remounts the rootfs read-only
+// within it, before exec'ing the real command.
+func readonlyRootfsArgs(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	script := fmt.Sprintf("mount --make-rprivate / && mount -o remount,bind,ro / && exec %s", strings.Join(quoted, " "))
+	return []string{"unshare", "--mount", "--", "sh", "-c", script}
+}
+
+// shellQuote single-quotes s for safe interpolation into a POSIX shell
+// command line.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // EnterConfig specifies the configuration to execute a command inside the container
 type EnterConfig struct {
 	// Process specifies the process configuration to execute
@@ -301,7 +459,7 @@ func isErrorContainerNotFound(err error) bool {
 	return errLibc.Code() == libcontainer.ContainerNotExists
 }
 
-func setupTTYIO(process *libcontainer.Process, rootuid, rootgid int) (*tty, error) {
+func setupTTYIO(process *libcontainer.Process, rootuid, rootgid int, config *ProcessConfig) (*tty, error) {
 	t := &tty{}
 
 	parent, child, err := utils.NewSockPair("console")
@@ -314,7 +472,7 @@ func setupTTYIO(process *libcontainer.Process, rootuid, rootgid int) (*tty, erro
 	t.consoleC = make(chan error, 1)
 
 	go func() {
-		if err := t.recvtty(process, parent); err != nil {
+		if err := t.recvtty(process, parent, config); err != nil {
 			t.consoleC <- err
 		}
 		t.consoleC <- nil
@@ -323,7 +481,7 @@ func setupTTYIO(process *libcontainer.Process, rootuid, rootgid int) (*tty, erro
 	return t, nil
 }
 
-func setupIO(process *libcontainer.Process, rootuid, rootgid int) (*tty, error) {
+func setupIO(process *libcontainer.Process, rootuid, rootgid int, config *ProcessConfig) (*tty, error) {
 	i, err := process.InitializeIO(rootuid, rootgid)
 	if err != nil {
 		return nil, trace.Wrap(err)
@@ -353,8 +511,8 @@ func setupIO(process *libcontainer.Process, rootuid, rootgid int) (*tty, error)
 		i.Stdin.Close()
 	}()
 	t.wg.Add(2)
-	go t.copyIO(os.Stdout, i.Stdout)
-	go t.copyIO(os.Stderr, i.Stderr)
+	go t.copyIO(outputWriter(config, os.Stdout), i.Stdout)
+	go t.copyIO(outputWriter(config, os.Stderr), i.Stderr)
 
 	return t, nil
 }
@@ -0,0 +1,125 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package box
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// OutputBufferConfig bounds how much of an entered process' stdout/stderr
+// planet keeps in memory between the container's output pipe and the
+// destination writer (see ProcessConfig.Out and tty.copyIO). Without it, a
+// slow or stalled destination - an operator's terminal over a laggy
+// connection, a client that has stopped reading - leaves the pipe full and
+// the contained process blocked on write(2) until the destination catches
+// up or goes away.
+type OutputBufferConfig struct {
+	// MaxBytes is the most unwritten output kept buffered in memory. Zero
+	// disables buffering: output is relayed with today's direct, unbounded
+	// io.Copy, and the contained process blocks for as long as the
+	// destination writer does.
+	MaxBytes int
+}
+
+// newBoundedWriter wraps dest so that writes - and so the contained
+// process' writes to its own stdout/stderr pipe - never block on however
+// long dest takes to consume them. Once more than maxBytes is buffered,
+// the oldest bytes are dropped to make room for new output, and a
+// truncation marker naming the drop is written ahead of the next flush to
+// dest, so the loss is visible rather than silent. If maxBytes <= 0, dest
+// is returned unwrapped.
+func newBoundedWriter(dest io.Writer, maxBytes int) io.Writer {
+	if maxBytes <= 0 {
+		return dest
+	}
+	w := &boundedWriter{dest: dest, maxBytes: maxBytes, done: make(chan struct{})}
+	w.cond = sync.NewCond(&w.mu)
+	go w.flushLoop()
+	return w
+}
+
+type boundedWriter struct {
+	dest     io.Writer
+	maxBytes int
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	buf     []byte
+	dropped int64
+	closed  bool
+	done    chan struct{}
+}
+
+// Write never blocks on dest: it appends p to the buffer, dropping the
+// oldest buffered bytes first if there isn't enough room. It always
+// reports all of p as written, so a caller such as io.Copy keeps going.
+func (w *boundedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n := len(p)
+	if len(p) > w.maxBytes {
+		w.dropped += int64(len(p) - w.maxBytes)
+		p = p[len(p)-w.maxBytes:]
+	}
+	if over := len(w.buf) + len(p) - w.maxBytes; over > 0 {
+		w.dropped += int64(over)
+		w.buf = w.buf[over:]
+	}
+	w.buf = append(w.buf, p...)
+	w.cond.Signal()
+	return n, nil
+}
+
+// Close stops accepting writes and blocks until the buffered output has
+// been flushed to dest.
+func (w *boundedWriter) Close() error {
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Signal()
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
+
+// flushLoop is the only goroutine that writes to dest, so a stalled dest
+// blocks it rather than a Write call.
+func (w *boundedWriter) flushLoop() {
+	defer close(w.done)
+	for {
+		w.mu.Lock()
+		for len(w.buf) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if len(w.buf) == 0 && w.closed {
+			w.mu.Unlock()
+			return
+		}
+		chunk := w.buf
+		w.buf = nil
+		dropped := w.dropped
+		w.dropped = 0
+		w.mu.Unlock()
+
+		if dropped > 0 {
+			fmt.Fprintf(w.dest, "[output truncated: %d bytes dropped]\n", dropped)
+		}
+		w.dest.Write(chunk)
+	}
+}
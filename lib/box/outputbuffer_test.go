@@ -0,0 +1,64 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package box
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type OutputBufferSuite struct{}
+
+var _ = Suite(&OutputBufferSuite{})
+
+func (s *OutputBufferSuite) TestNewBoundedWriterPassesThroughUnderLimit(c *C) {
+	var dest bytes.Buffer
+	w := newBoundedWriter(&dest, 1024)
+	bw, ok := w.(*boundedWriter)
+	c.Assert(ok, Equals, true)
+
+	_, err := w.Write([]byte("hello "))
+	c.Assert(err, IsNil)
+	_, err = w.Write([]byte("world"))
+	c.Assert(err, IsNil)
+	c.Assert(bw.Close(), IsNil)
+
+	c.Assert(dest.String(), Equals, "hello world")
+}
+
+func (s *OutputBufferSuite) TestNewBoundedWriterDropsOldestAndMarksTruncation(c *C) {
+	var dest bytes.Buffer
+	w := newBoundedWriter(&dest, 5)
+	bw := w.(*boundedWriter)
+
+	_, err := w.Write([]byte("abcdefghij"))
+	c.Assert(err, IsNil)
+	c.Assert(bw.Close(), IsNil)
+
+	out := dest.String()
+	c.Assert(strings.HasSuffix(out, "fghij"), Equals, true)
+	c.Assert(strings.Contains(out, "[output truncated: 5 bytes dropped]"), Equals, true)
+}
+
+func (s *OutputBufferSuite) TestNewBoundedWriterZeroLimitReturnsDestUnwrapped(c *C) {
+	var dest bytes.Buffer
+	w := newBoundedWriter(&dest, 0)
+	c.Assert(w, Equals, io.Writer(&dest))
+}
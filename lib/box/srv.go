@@ -32,6 +32,7 @@ import (
 
 	"github.com/gravitational/planet/lib/constants"
 	"github.com/gravitational/planet/lib/defaults"
+	"github.com/gravitational/planet/lib/utils"
 
 	"github.com/gravitational/trace"
 	"github.com/jochenvg/go-udev"
@@ -179,7 +180,7 @@ func Start(cfg Config) (*Box, error) {
 	// to start the container's init process (see container.Start below).
 	container, err := root.Create(containerID, config)
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return nil, trace.Wrap(classifyStartError(err))
 	}
 	defer func() {
 		if err != nil {
@@ -204,7 +205,7 @@ func Start(cfg Config) (*Box, error) {
 	// Run the container by starting the init process.
 	err = container.Run(process)
 	if err != nil {
-		return nil, trace.Wrap(err)
+		return nil, trace.Wrap(classifyStartError(err))
 	}
 
 	status, err := container.Status()
@@ -384,16 +385,32 @@ func getLibcontainerConfig(containerID, rootfs string, cfg Config) (*configs.Con
 		Cgroups: &configs.Cgroup{
 			Name: fmt.Sprintf("planet-%v", containerID),
 			Resources: &configs.Resources{
-				AllowAllDevices:  &allowAllDevices,
-				AllowedDevices:   configs.DefaultAllowedDevices,
-				MemorySwappiness: nil,     // nil means "machine-default" and that's what we need because we don't care
-				CpuShares:        2,       // set planet to minimum cpu shares relative to host services
-				PidsLimit:        2000000, // override systemd defaults and set planet scope to unlimited pids
+				AllowAllDevices:   &allowAllDevices,
+				AllowedDevices:    configs.DefaultAllowedDevices,
+				MemorySwappiness:  nil,     // nil means "machine-default" and that's what we need because we don't care
+				CpuShares:         2,       // set planet to minimum cpu shares relative to host services
+				PidsLimit:         2000000, // override systemd defaults and set planet scope to unlimited pids
+				Memory:            cfg.MemoryLimit,
+				MemoryReservation: cfg.MemoryReservation,
+				CpuQuota:          cfg.CPUQuota,
+				CpuPeriod:         cfg.CPUPeriod,
 			},
 		},
 		Devices:  append(configs.DefaultAutoCreatedDevices, append(loopDevices, disks...)...),
 		Hostname: hostname,
 	}
+	if cfg.ReadonlyRootfs {
+		config.Readonlyfs = true
+		for _, path := range readonlyRootfsTmpfsPaths {
+			config.Mounts = append(config.Mounts, &configs.Mount{
+				Source:      "tmpfs",
+				Destination: path,
+				Device:      "tmpfs",
+				Flags:       defaultMountFlags,
+			})
+		}
+	}
+
 	if cfg.SELinux {
 		config.MountLabel = defaults.ContainerFileLabel
 		config.ProcessLabel = cfg.ProcessLabel
@@ -559,23 +576,26 @@ func writeFile(path string, fi File) error {
 }
 
 // WriteEnvironment writes provided environment variables to a file at the
-// specified path.
+// specified path in `export KEY="value"` form, so the file can be sourced
+// by a shell and have the variables inherited by child processes. env is
+// deduplicated by key with last-write-wins semantics before writing - not
+// every EnvVars writer goes through Upsert (e.g. Set/setItem append
+// unconditionally), so env can still carry duplicate keys by the time it
+// reaches here.
 func WriteEnvironment(path string, env EnvVars) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
 		return trace.Wrap(err)
 	}
-	f, err := os.Create(path)
-	if err != nil {
-		return trace.Wrap(err)
-	}
-	defer f.Close()
+	vars := make(map[string]string, len(env))
 	for _, v := range env {
-		// quote value as it may contain spaces
-		if _, err := fmt.Fprintf(f, "%v=%q\n", v.Name, v.Val); err != nil {
-			return trace.Wrap(err)
+		if existing, ok := vars[v.Name]; ok && existing != v.Val {
+			log.WithFields(log.Fields{
+				"name": v.Name, "old": existing, "new": v.Val,
+			}).Debug("Environment variable overridden by a later writer.")
 		}
+		vars[v.Name] = v.Val
 	}
-	return nil
+	return trace.Wrap(utils.WriteEnvFile(path, vars, utils.EnvFileFormatExport, constants.SharedReadMask))
 }
 
 // ReadEnvironment returns a list of all environment variables read from the file
@@ -587,7 +607,10 @@ func ReadEnvironment(path string) (vars EnvVars, err error) {
 	}
 	scanner := bufio.NewScanner(bytes.NewReader(env))
 	for scanner.Scan() {
-		keyVal := strings.SplitN(scanner.Text(), "=", 2)
+		// strip a leading "export " (if the file was written in
+		// EnvFileFormatExport form) before splitting into key/value
+		line := strings.TrimPrefix(scanner.Text(), "export ")
+		keyVal := strings.SplitN(line, "=", 2)
 		if len(keyVal) != 2 {
 			continue
 		}
@@ -624,3 +647,16 @@ func checkPath(path string, executable bool) (absPath string, err error) {
 }
 
 const defaultMountFlags = syscall.MS_NOEXEC | syscall.MS_NOSUID | syscall.MS_NODEV
+
+// readonlyRootfsTmpfsPaths lists the paths mounted as writable tmpfs on top
+// of the container's rootfs when Config.ReadonlyRootfs is set. /run and
+// /run/lock are already tmpfs unconditionally (see getLibcontainerConfig)
+// and are not repeated here. Persistent state (etcd data, kubelet state,
+// etc.) is unaffected - it lives on host bind mounts configured separately
+// via Config.Mounts, not on the rootfs.
+var readonlyRootfsTmpfsPaths = []string{
+	"/tmp",
+	"/var/tmp",
+	"/var/log",
+	"/var/lib/private",
+}
@@ -60,3 +60,26 @@ func (s *SrvSuite) TestWriteReadEnvironment(c *check.C) {
 	c.Assert(env.Get("EMPTY_VAR"), check.Equals, "")
 	c.Assert(env.Get("WITH_QUOTES"), check.Equals, `blah "blah" blah`)
 }
+
+// TestWriteEnvironmentDuplicateKeys verifies that when multiple writers
+// append to the same EnvVars under the same key - as can happen when one
+// writer uses Upsert and another appends via Set/setItem - the last entry
+// for that key wins and the file ends up with a single, deterministic value.
+func (s *SrvSuite) TestWriteEnvironmentDuplicateKeys(c *check.C) {
+	f, err := ioutil.TempFile("", "")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(f.Name())
+
+	env := EnvVars{
+		{Name: "KUBE_MASTER_IP", Val: "192.168.122.176"},
+		{Name: "DOCKER_OPTS", Val: "--storage-driver=devicemapper"},
+		{Name: "KUBE_MASTER_IP", Val: "192.168.122.177"},
+	}
+	err = WriteEnvironment(f.Name(), env)
+	c.Assert(err, check.IsNil)
+
+	got, err := ReadEnvironment(f.Name())
+	c.Assert(err, check.IsNil)
+	c.Assert(got.Get("KUBE_MASTER_IP"), check.Equals, "192.168.122.177")
+	c.Assert(got.Get("DOCKER_OPTS"), check.Equals, "--storage-driver=devicemapper")
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package box
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StartErrorKind classifies a container start failure into a family of
+// related root causes that share a common remediation.
+type StartErrorKind string
+
+const (
+	// StartErrorCgroup indicates the host's cgroup hierarchy could not be
+	// set up for the container (e.g. missing cgroup mount, exhausted
+	// hierarchy, or a cgroup v2-only host).
+	StartErrorCgroup StartErrorKind = "cgroup"
+	// StartErrorNamespace indicates the host kernel is missing support for
+	// a Linux namespace the container requires.
+	StartErrorNamespace StartErrorKind = "namespace"
+	// StartErrorPermission indicates the container process was denied by a
+	// Linux Security Module (AppArmor, SELinux) or lacked the privilege to
+	// perform a setup step.
+	StartErrorPermission StartErrorKind = "permission"
+	// StartErrorRootfs indicates the container's rootfs could not be used
+	// as-is (e.g. it's not a directory, or something else has it busy).
+	StartErrorRootfs StartErrorKind = "rootfs"
+)
+
+// StartError wraps a low-level container start failure with a short
+// explanation and remediation hint, while keeping the original error
+// available via Unwrap for trace/debug output.
+type StartError struct {
+	// Kind identifies the family of failure this was classified as.
+	Kind StartErrorKind
+	// Hint is a short, human-readable explanation and remediation
+	// suggestion.
+	Hint string
+	// Err is the original error returned by libcontainer.
+	Err error
+}
+
+func (e *StartError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Hint, e.Err)
+}
+
+// Unwrap returns the original libcontainer error, so trace.Wrap and
+// errors.Is/As can still see through to it.
+func (e *StartError) Unwrap() error {
+	return e.Err
+}
+
+// startErrorPattern matches a family of related libcontainer error messages.
+type startErrorPattern struct {
+	kind     StartErrorKind
+	hint     string
+	keywords []string
+}
+
+// startErrorPatterns lists the failure families classifyStartError
+// recognizes, most specific first: several of these substrings ("operation
+// not permitted", "permission denied") are generic enough that a
+// more specific match found earlier should win.
+var startErrorPatterns = []startErrorPattern{
+	{
+		kind:     StartErrorCgroup,
+		hint:     "the host's cgroup hierarchy could not be prepared for the container - this commonly means the host is cgroup v2-only (planet requires the cgroup v1 hierarchy) or is out of cgroup mounts",
+		keywords: []string{"cgroup"},
+	},
+	{
+		kind:     StartErrorNamespace,
+		hint:     "the host kernel is missing support for a Linux namespace the container requires - check that user/pid/net namespaces are enabled (CONFIG_NAMESPACES and friends) and not disabled by a boot parameter",
+		keywords: []string{"namespace", "clone flags", "protocol not supported"},
+	},
+	{
+		kind:     StartErrorPermission,
+		hint:     "the container process was denied by a Linux Security Module or lacked the privilege for a setup step - check dmesg for an AppArmor/SELinux denial and confirm planet is running with the capabilities it needs",
+		keywords: []string{"apparmor", "selinux", "permission denied", "operation not permitted"},
+	},
+	{
+		kind:     StartErrorRootfs,
+		hint:     "the container's rootfs could not be used as its root filesystem - confirm the configured rootfs path is a directory and nothing else has it mounted or in use",
+		keywords: []string{"rootfs", "pivot_root", "not a directory", "device or resource busy"},
+	},
+}
+
+// classifyStartError matches err's message against known libcontainer start
+// failure families and, on a match, wraps it in a *StartError carrying a
+// short explanation and remediation hint. err is returned unchanged if it's
+// nil or doesn't match any known family.
+func classifyStartError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range startErrorPatterns {
+		for _, keyword := range pattern.keywords {
+			if strings.Contains(msg, keyword) {
+				return &StartError{Kind: pattern.kind, Hint: pattern.hint, Err: err}
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package box
+
+import (
+	"errors"
+
+	. "gopkg.in/check.v1"
+)
+
+type StartErrorSuite struct{}
+
+var _ = Suite(&StartErrorSuite{})
+
+func (s *StartErrorSuite) TestClassifiesKnownFailureFamilies(c *C) {
+	testCases := []struct {
+		comment string
+		raw     string
+		kind    StartErrorKind
+	}{
+		{
+			comment: "cgroup v2-only host",
+			raw:     `container_linux.go:348: starting container process caused "process_linux.go:402: container init caused \"apply cgroup configuration for process caused \\\"cannot enter cgroupv2 \\\\\\\"/\\\\\\\" with pids\\\"\""`,
+			kind:    StartErrorCgroup,
+		},
+		{
+			comment: "missing cgroup mount",
+			raw:     "failed to write to cgroup: cgroups: cgroup mountpoint does not exist: unknown",
+			kind:    StartErrorCgroup,
+		},
+		{
+			comment: "unshare not permitted",
+			raw:     "nsenter: unshare failed: Operation not permitted",
+			kind:    StartErrorPermission,
+		},
+		{
+			comment: "missing user namespace support",
+			raw:     "user namespaces are not supported by this kernel: clone flags 0x10000000: invalid argument",
+			kind:    StartErrorNamespace,
+		},
+		{
+			comment: "apparmor denial",
+			raw:     `container_linux.go:344: starting container process caused "process_linux.go:293: applying apparmor profile caused \"apparmor failed to apply profile: permission denied\""`,
+			kind:    StartErrorPermission,
+		},
+		{
+			comment: "selinux denial",
+			raw:     "failed to set selinux label: permission denied",
+			kind:    StartErrorPermission,
+		},
+		{
+			comment: "rootfs not a directory",
+			raw:     `container_linux.go:367: starting container process caused "process_linux.go:495: container init caused \"rootfs_linux.go:59: mounting \\\"/ext/state/rootfs\\\" to rootfs \\\"...\\\" caused \\\"not a directory\\\"\""`,
+			kind:    StartErrorRootfs,
+		},
+		{
+			comment: "rootfs busy",
+			raw:     `pivot_root / new_root new_root/old_root caused "device or resource busy"`,
+			kind:    StartErrorRootfs,
+		},
+	}
+
+	for _, tc := range testCases {
+		classified := classifyStartError(errors.New(tc.raw))
+		startErr, ok := classified.(*StartError)
+		c.Assert(ok, Equals, true, Commentf("%v: expected a *StartError, got %T", tc.comment, classified))
+		c.Assert(startErr.Kind, Equals, tc.kind, Commentf(tc.comment))
+		c.Assert(startErr.Unwrap().Error(), Equals, tc.raw, Commentf(tc.comment))
+	}
+}
+
+func (s *StartErrorSuite) TestPassesThroughUnrecognizedErrors(c *C) {
+	original := errors.New("some other libcontainer failure")
+	c.Assert(classifyStartError(original), Equals, original)
+}
+
+func (s *StartErrorSuite) TestPassesThroughNil(c *C) {
+	c.Assert(classifyStartError(nil), IsNil)
+}
@@ -26,7 +26,7 @@ type tty struct {
 	consoleC  chan error
 }
 
-func (t *tty) recvtty(process *libcontainer.Process, socket *os.File) (Err error) {
+func (t *tty) recvtty(process *libcontainer.Process, socket *os.File, config *ProcessConfig) (Err error) {
 	f, err := utils.RecvFd(socket)
 	if err != nil {
 		return trace.Wrap(err)
@@ -55,7 +55,7 @@ func (t *tty) recvtty(process *libcontainer.Process, socket *os.File) (Err error
 	go epoller.Wait()
 	go io.Copy(epollConsole, os.Stdin)
 	t.wg.Add(1)
-	go t.copyIO(os.Stdout, epollConsole)
+	go t.copyIO(outputWriter(config, os.Stdout), epollConsole)
 
 	// set raw mode to stdin and also handle interrupt
 	stdin, err := console.ConsoleFromFile(os.Stdin)
@@ -93,6 +93,18 @@ func (t *tty) copyIO(w io.Writer, r io.ReadCloser) {
 	defer t.wg.Done()
 	io.Copy(w, r)
 	r.Close()
+	if bw, ok := w.(*boundedWriter); ok {
+		bw.Close()
+	}
+}
+
+// outputWriter wraps dest with a bounded buffer per config.OutputBuffering,
+// or returns it unwrapped if no buffering was requested.
+func outputWriter(config *ProcessConfig, dest io.Writer) io.Writer {
+	if config == nil || config.OutputBuffering == nil {
+		return dest
+	}
+	return newBoundedWriter(dest, config.OutputBuffering.MaxBytes)
 }
 
 // Close closes all open fds for the tty and/or restores the original
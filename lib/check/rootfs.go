@@ -0,0 +1,211 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package check
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"github.com/gravitational/trace"
+	"golang.org/x/sys/unix"
+)
+
+// RootFSCheckResult reports the filesystem feature checks performed
+// against a single directory planet needs to store data in or execute
+// from.
+type RootFSCheckResult struct {
+	// Path is the directory checked.
+	Path string
+	// FSType is the filesystem type backing Path's mount point, as
+	// reported by /proc/mounts (e.g. "xfs", "ext4", "overlay").
+	FSType string
+	// MountOptions lists the mount options in effect for Path's mount
+	// point.
+	MountOptions []string
+	// DTypeSupported reports whether the filesystem returns real
+	// directory entry types rather than DT_UNKNOWN, required by docker's
+	// overlay2 storage driver. XFS filesystems created with ftype=0 fail
+	// this check.
+	DTypeSupported bool
+	// FreeInodes is the number of free inodes remaining on the
+	// filesystem.
+	FreeInodes uint64
+}
+
+// Failures returns the preflight failure messages describing which of
+// r's requirements aren't met, or nil if all of them are.
+func (r RootFSCheckResult) Failures() (failures []string) {
+	for _, option := range r.MountOptions {
+		switch option {
+		case "noexec":
+			failures = append(failures, fmt.Sprintf("%v is mounted noexec", r.Path))
+		case "nodev":
+			failures = append(failures, fmt.Sprintf("%v is mounted nodev", r.Path))
+		case "nosuid":
+			failures = append(failures, fmt.Sprintf("%v is mounted nosuid", r.Path))
+		}
+	}
+	if !r.DTypeSupported {
+		if r.FSType == "xfs" {
+			failures = append(failures, fmt.Sprintf("xfs without ftype=1 detected under %v", r.Path))
+		} else {
+			failures = append(failures, fmt.Sprintf("%v (%v) does not support directory entry types (d_type)", r.Path, r.FSType))
+		}
+	}
+	if r.FreeInodes == 0 {
+		failures = append(failures, fmt.Sprintf("%v has no free inodes remaining", r.Path))
+	}
+	return failures
+}
+
+// CheckRootFS inspects the filesystem backing path for the features
+// planet's rootfs and state directory require: directory entry type
+// support (missing on XFS created with ftype=0, which silently corrupts
+// docker's overlay2 storage), execute/device/setuid mount permissions,
+// and available inodes.
+func CheckRootFS(path string) (RootFSCheckResult, error) {
+	fsType, options, err := mountInfoForDir(path)
+	if err != nil {
+		return RootFSCheckResult{}, trace.Wrap(err)
+	}
+	dtypeSupported, err := dTypeSupported(path)
+	if err != nil {
+		return RootFSCheckResult{}, trace.Wrap(err)
+	}
+	freeInodes, err := freeInodes(path)
+	if err != nil {
+		return RootFSCheckResult{}, trace.Wrap(err)
+	}
+	return RootFSCheckResult{
+		Path:           path,
+		FSType:         fsType,
+		MountOptions:   options,
+		DTypeSupported: dtypeSupported,
+		FreeInodes:     freeInodes,
+	}, nil
+}
+
+// mountInfoForDir returns the filesystem type and mount options of the
+// mount point that contains dirPath, by finding the longest matching
+// prefix among the entries of /proc/mounts.
+func mountInfoForDir(dirPath string) (fsType string, options []string, err error) {
+	dirPath, err = filepath.Abs(filepath.Clean(dirPath))
+	if err != nil {
+		return "", nil, trace.Wrap(err)
+	}
+	f, err := os.Open("/proc/mounts")
+	if err != nil {
+		return "", nil, trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+
+	var matchLen int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		mountPoint, mountFS, mountOptions := fields[1], fields[2], fields[3]
+		if !strings.HasPrefix(dirPath, mountPoint) || len(mountPoint) < matchLen {
+			continue
+		}
+		matchLen = len(mountPoint)
+		fsType = mountFS
+		options = strings.Split(mountOptions, ",")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", nil, trace.Wrap(err)
+	}
+	return fsType, options, nil
+}
+
+// freeInodes returns the number of free inodes on the filesystem backing
+// path.
+func freeInodes(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	return stat.Ffree, nil
+}
+
+// direntHeaderSize is the size of a linux_dirent64 header, up to but not
+// including the variable-length name field.
+const direntHeaderSize = int(unsafe.Offsetof(unix.Dirent{}.Name))
+
+// dTypeSupported reports whether the filesystem backing dir returns real
+// directory entry types (DT_REG, DT_DIR, ...) rather than DT_UNKNOWN,
+// which docker's overlay2 storage driver requires. It creates and reads
+// back a probe file, since d_type support can only be observed at
+// runtime, not derived from the filesystem type alone.
+func dTypeSupported(dir string) (bool, error) {
+	probeDir, err := ioutil.TempDir(dir, ".planet-dtype-check-")
+	if err != nil {
+		return false, trace.ConvertSystemError(err)
+	}
+	defer os.RemoveAll(probeDir)
+
+	if err := ioutil.WriteFile(filepath.Join(probeDir, "f"), nil, 0644); err != nil {
+		return false, trace.ConvertSystemError(err)
+	}
+
+	fd, err := unix.Open(probeDir, unix.O_RDONLY|unix.O_DIRECTORY, 0)
+	if err != nil {
+		return false, trace.ConvertSystemError(err)
+	}
+	defer unix.Close(fd)
+
+	buf := make([]byte, 4096)
+	n, err := unix.Getdents(fd, buf)
+	if err != nil {
+		return false, trace.ConvertSystemError(err)
+	}
+	buf = buf[:n]
+
+	for len(buf) >= direntHeaderSize {
+		dirent := (*unix.Dirent)(unsafe.Pointer(&buf[0]))
+		if int(dirent.Reclen) <= 0 || int(dirent.Reclen) > len(buf) {
+			break
+		}
+		name := direntName(dirent)
+		if name != "." && name != ".." {
+			return dirent.Type != unix.DT_UNKNOWN, nil
+		}
+		buf = buf[dirent.Reclen:]
+	}
+	return false, trace.NotFound("failed to locate probe file while checking %v for d_type support", dir)
+}
+
+// direntName extracts the NUL-terminated name from a linux_dirent64.
+func direntName(dirent *unix.Dirent) string {
+	var name [256]byte
+	length := len(dirent.Name)
+	for i := 0; i < length; i++ {
+		if dirent.Name[i] == 0 {
+			length = i
+			break
+		}
+		name[i] = byte(dirent.Name[i])
+	}
+	return string(name[:length])
+}
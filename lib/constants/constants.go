@@ -35,6 +35,12 @@ const (
 	// KubeletConfigPath is the path to kubelet configuration file
 	KubeletConfigPath = "/etc/kubernetes/kubelet.kubeconfig"
 
+	// SecretsMountDir is the directory planet mounts secrets and other
+	// small pieces of runtime state into, shared between the agent and
+	// standalone CLI invocations (e.g. "planet status") since it survives
+	// agent restarts and is readable without an RPC round trip.
+	SecretsMountDir = "/var/state"
+
 	// DNSResourceName specifies the name for the DNS resources
 	DNSResourceName = "kube-dns"
 
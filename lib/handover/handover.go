@@ -0,0 +1,230 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package handover implements a protocol for passing an already-bound TCP
+// listener from one running planet process to another over a unix socket,
+// using SCM_RIGHTS, so the incoming process can start serving it without
+// ever closing the port. It is deliberately narrow: it hands over a single
+// net.Listener and nothing else. It does not know how to migrate
+// connection-level session state, and it is the caller's responsibility to
+// only stop serving (and exit) once a handover has actually succeeded.
+package handover
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// ProtocolVersion identifies the wire format of the handover handshake.
+// Offer refuses a request whose ProtocolVersion doesn't match its own,
+// rather than risk misinterpreting a future, incompatible handshake -
+// bump it whenever the request/response types gain or change fields.
+const ProtocolVersion = 1
+
+// ackTimeout bounds how long Offer waits for the requester to confirm it
+// took over the listener before giving up and returning an error - without
+// this, a requester that crashes right after receiving the fd would leave
+// the offering process waiting forever instead of safely continuing to
+// serve. A var, rather than a const, solely so tests can shorten it.
+var ackTimeout = 30 * time.Second
+
+// ackByte is written by the requester once it is serving the handed-over
+// listener, telling Offer it is now safe to stop serving and exit.
+const ackByte = 1
+
+// request is sent by the process asking for a listener handover.
+type request struct {
+	// ProtocolVersion is the requester's ProtocolVersion.
+	ProtocolVersion int `json:"protocol_version"`
+}
+
+// response is sent back in reply to a request. When OK, a single file
+// descriptor accompanies it as out-of-band (SCM_RIGHTS) data in the same
+// message.
+type response struct {
+	// OK reports whether the handover is proceeding. False means no file
+	// descriptor was sent.
+	OK bool `json:"ok"`
+	// Error explains why the handover was refused, set when !OK.
+	Error string `json:"error,omitempty"`
+}
+
+// Offer serves a single handover request received on l, a unix socket
+// dedicated to handover requests (distinct from target itself). It hands
+// over target's file descriptor to the requester if the requester's
+// ProtocolVersion matches ours, then waits up to ackTimeout for the
+// requester to confirm it is serving before returning.
+//
+// The caller must not stop serving target, or exit, until Offer returns
+// nil - on any error (a version mismatch, a requester that disappears
+// before acknowledging, a dropped connection), target is left completely
+// untouched and still belongs solely to the caller.
+func Offer(l *net.UnixListener, target *net.TCPListener) error {
+	conn, err := l.AcceptUnix()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer conn.Close()
+
+	var req request
+	if err := readJSON(conn, &req); err != nil {
+		return trace.Wrap(err, "failed to read handover request")
+	}
+	if req.ProtocolVersion != ProtocolVersion {
+		refusal := fmt.Sprintf("handover protocol version mismatch: have %v, requester has %v", ProtocolVersion, req.ProtocolVersion)
+		writeJSON(conn, response{Error: refusal})
+		return trace.BadParameter(refusal)
+	}
+
+	file, err := target.File()
+	if err != nil {
+		return trace.Wrap(err, "failed to obtain the listener's file descriptor")
+	}
+	defer file.Close()
+
+	if err := writeResponseWithFile(conn, response{OK: true}, file); err != nil {
+		return trace.Wrap(err, "failed to send the listener to the requester")
+	}
+
+	if err := waitForAck(conn); err != nil {
+		return trace.Wrap(err, "requester never confirmed it took over the listener")
+	}
+	return nil
+}
+
+// Request dials socketPath and asks for the listener it offers. On success
+// it returns the handed-over listener, already usable, having acknowledged
+// receipt so the offering process can stop serving and exit. On any error,
+// no acknowledgement is sent and the offering process keeps serving.
+func Request(socketPath string) (*net.TCPListener, error) {
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer conn.Close()
+
+	if err := writeJSON(conn, request{ProtocolVersion: ProtocolVersion}); err != nil {
+		return nil, trace.Wrap(err, "failed to send handover request")
+	}
+
+	resp, file, err := readResponseWithFile(conn)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to read handover response")
+	}
+	defer file.Close()
+	if !resp.OK {
+		return nil, trace.BadParameter("handover refused: %v", resp.Error)
+	}
+
+	listener, err := net.FileListener(file)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to reconstruct the handed-over listener")
+	}
+	tcpListener, ok := listener.(*net.TCPListener)
+	if !ok {
+		listener.Close()
+		return nil, trace.BadParameter("handed-over listener is %T, expected a TCP listener", listener)
+	}
+
+	if _, err := conn.Write([]byte{ackByte}); err != nil {
+		tcpListener.Close()
+		return nil, trace.Wrap(err, "failed to acknowledge handover")
+	}
+	return tcpListener, nil
+}
+
+// readJSON reads a single newline-terminated JSON value from conn.
+func readJSON(conn *net.UnixConn, v interface{}) error {
+	decoder := json.NewDecoder(conn)
+	return trace.Wrap(decoder.Decode(v))
+}
+
+// writeJSON writes v to conn as a single JSON value. Errors are intentionally
+// ignored by Offer's refusal path, which has already decided to return its
+// own, more specific error regardless of whether the refusal reaches the
+// requester.
+func writeJSON(conn *net.UnixConn, v interface{}) error {
+	return trace.Wrap(json.NewEncoder(conn).Encode(v))
+}
+
+// writeResponseWithFile writes resp as JSON together with file's descriptor
+// as SCM_RIGHTS out-of-band data, in a single sendmsg(2) call so the two
+// can't be observed apart.
+func writeResponseWithFile(conn *net.UnixConn, resp response, file *os.File) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	oob := syscall.UnixRights(int(file.Fd()))
+	_, _, err = conn.WriteMsgUnix(data, oob, nil)
+	return trace.Wrap(err)
+}
+
+// readResponseWithFile reads a response together with the file descriptor
+// sent alongside it by writeResponseWithFile.
+func readResponseWithFile(conn *net.UnixConn) (response, *os.File, error) {
+	data := make([]byte, 4096)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(data, oob)
+	if err != nil {
+		return response{}, nil, trace.Wrap(err)
+	}
+
+	var resp response
+	if err := json.Unmarshal(data[:n], &resp); err != nil {
+		return response{}, nil, trace.Wrap(err, "failed to parse handover response")
+	}
+	if !resp.OK {
+		return resp, nil, nil
+	}
+
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return response{}, nil, trace.Wrap(err, "failed to parse ancillary data")
+	}
+	if len(messages) != 1 {
+		return response{}, nil, trace.BadParameter("expected exactly one control message, got %v", len(messages))
+	}
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	if err != nil {
+		return response{}, nil, trace.Wrap(err, "failed to parse file descriptors")
+	}
+	if len(fds) != 1 {
+		return response{}, nil, trace.BadParameter("expected exactly one file descriptor, got %v", len(fds))
+	}
+	return resp, os.NewFile(uintptr(fds[0]), "handover-listener"), nil
+}
+
+// waitForAck blocks until conn receives ackByte, or ackTimeout elapses.
+func waitForAck(conn *net.UnixConn) error {
+	if err := conn.SetReadDeadline(time.Now().Add(ackTimeout)); err != nil {
+		return trace.Wrap(err)
+	}
+	ack := make([]byte, 1)
+	if _, err := conn.Read(ack); err != nil {
+		return trace.Wrap(err)
+	}
+	if ack[0] != ackByte {
+		return trace.BadParameter("unexpected acknowledgement byte %v", ack[0])
+	}
+	return nil
+}
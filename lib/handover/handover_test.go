@@ -0,0 +1,151 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package handover
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// listenHandoverSocket creates the unix socket Offer/Request communicate
+// over, under a fresh temporary directory so tests don't collide.
+func listenHandoverSocket(t *testing.T) (*net.UnixListener, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "handover.sock")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatalf("failed to listen on %v: %v", path, err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l, path
+}
+
+func TestHandoverRoundTrip(t *testing.T) {
+	handoverSocket, socketPath := listenHandoverSocket(t)
+
+	target, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to create the listener under test: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "pong") })
+	server := &http.Server{Handler: mux}
+	go server.Serve(target)
+
+	offerErr := make(chan error, 1)
+	go func() { offerErr <- Offer(handoverSocket, target) }()
+
+	handedOver, err := Request(socketPath)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer handedOver.Close()
+
+	if err := <-offerErr; err != nil {
+		t.Fatalf("Offer failed: %v", err)
+	}
+
+	// The new process serves the handed-over listener; the old one's Server
+	// is expected to stop accepting and be torn down by its own caller, not
+	// by this package, so target is left as it is here.
+	go server.Serve(handedOver)
+
+	resp, err := http.Get(fmt.Sprintf("http://%v/ping", handedOver.Addr()))
+	if err != nil {
+		t.Fatalf("failed to reach the handed-over listener: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %v", resp.StatusCode)
+	}
+}
+
+func TestHandoverRefusesProtocolVersionMismatch(t *testing.T) {
+	handoverSocket, socketPath := listenHandoverSocket(t)
+
+	target, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to create the listener under test: %v", err)
+	}
+	defer target.Close()
+
+	offerErr := make(chan error, 1)
+	go func() { offerErr <- Offer(handoverSocket, target) }()
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		t.Fatalf("failed to dial %v: %v", socketPath, err)
+	}
+	defer conn.Close()
+	if err := writeJSON(conn, request{ProtocolVersion: ProtocolVersion + 1}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+
+	if err := <-offerErr; err == nil {
+		t.Fatal("expected Offer to refuse a mismatched protocol version")
+	}
+
+	// target must still be usable - the whole point of aborting safely.
+	if _, _, err := net.SplitHostPort(target.Addr().String()); err != nil {
+		t.Fatalf("expected target to remain a valid, open listener: %v", err)
+	}
+}
+
+func TestHandoverAbortsWhenRequesterNeverAcknowledges(t *testing.T) {
+	orig := ackTimeout
+	ackTimeout = 200 * time.Millisecond
+	t.Cleanup(func() { ackTimeout = orig })
+
+	handoverSocket, socketPath := listenHandoverSocket(t)
+
+	target, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("failed to create the listener under test: %v", err)
+	}
+	defer target.Close()
+
+	offerErr := make(chan error, 1)
+	go func() { offerErr <- Offer(handoverSocket, target) }()
+
+	conn, err := net.DialUnix("unix", nil, &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		t.Fatalf("failed to dial %v: %v", socketPath, err)
+	}
+	if err := writeJSON(conn, request{ProtocolVersion: ProtocolVersion}); err != nil {
+		t.Fatalf("failed to send request: %v", err)
+	}
+	// Read the response (and fd, discarded) but never send the ack byte,
+	// then disconnect - simulating a requester that crashes right after
+	// receiving the listener.
+	if _, file, err := readResponseWithFile(conn); err == nil {
+		file.Close()
+	}
+	conn.Close()
+
+	select {
+	case err := <-offerErr:
+		if err == nil {
+			t.Fatal("expected Offer to report an error when the requester never acknowledges")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Offer did not return within its own ack timeout")
+	}
+}
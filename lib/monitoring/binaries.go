@@ -0,0 +1,90 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"os"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+)
+
+// RequiredBinariesCheckerID identifies the checker that verifies the
+// binaries VersionCollector (and the rest of planet) expect to find inside
+// the container actually exist and are executable. It exists so a
+// mis-packaged image fails fast with a clear per-binary probe rather than a
+// cryptic "exec: no such file" surfacing later out of an unrelated checker.
+const RequiredBinariesCheckerID = "required-binaries"
+
+// NewRequiredBinariesChecker returns a checker that verifies every binary
+// InfoCheckerCommands relies on is present and executable.
+func NewRequiredBinariesChecker() health.Checker {
+	return requiredBinariesChecker{paths: requiredBinaryPaths()}
+}
+
+type requiredBinariesChecker struct {
+	paths []string
+}
+
+// Name returns the name of this checker.
+func (r requiredBinariesChecker) Name() string { return RequiredBinariesCheckerID }
+
+// Check verifies each of the checker's paths exists and is executable,
+// reporting one probe per missing or non-executable binary and a single
+// success probe otherwise.
+func (r requiredBinariesChecker) Check(ctx context.Context, reporter health.Reporter) {
+	var failed bool
+	for _, path := range r.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			failed = true
+			reporter.Add(monitoring.NewProbeFromErr(r.Name(), "required binary "+path+" is missing", err))
+			continue
+		}
+		if info.IsDir() || info.Mode()&0111 == 0 {
+			failed = true
+			reporter.Add(&pb.Probe{
+				Checker: r.Name(),
+				Detail:  "required binary " + path + " is not executable",
+				Status:  pb.Probe_Failed,
+			})
+		}
+	}
+	if !failed {
+		reporter.Add(monitoring.NewSuccessProbe(r.Name()))
+	}
+}
+
+// requiredBinaryPaths returns the deduplicated set of binary paths this
+// planet image is expected to ship, derived from the same commands
+// VersionCollector runs since those are exactly the binaries planet
+// depends on at runtime.
+func requiredBinaryPaths() []string {
+	seen := make(map[string]bool, len(infoCheckers))
+	var paths []string
+	for _, checker := range infoCheckers {
+		path := checker.command[0]
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
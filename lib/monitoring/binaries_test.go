@@ -0,0 +1,82 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestRequiredBinariesCheckerReportsRunningWhenAllPresent(t *testing.T) {
+	path := writeExecCheckerScript(t, "#!/bin/sh\nexit 0\n")
+	checker := requiredBinariesChecker{paths: []string{path}}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("expected a single running probe, got %v", reporter)
+	}
+}
+
+func TestRequiredBinariesCheckerReportsFailedPerMissingBinary(t *testing.T) {
+	dir := t.TempDir()
+	checker := requiredBinariesChecker{paths: []string{filepath.Join(dir, "missing-one"), filepath.Join(dir, "missing-two")}}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 2 {
+		t.Fatalf("expected one failed probe per missing binary, got %v", reporter)
+	}
+	for _, probe := range reporter {
+		if probe.Status != pb.Probe_Failed {
+			t.Fatalf("expected a failed probe, got %v", probe)
+		}
+	}
+}
+
+func TestRequiredBinariesCheckerReportsFailedForNonExecutableFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-executable")
+	if err := ioutil.WriteFile(path, []byte("not a binary"), 0644); err != nil {
+		t.Fatalf("failed to write %v: %v", path, err)
+	}
+	checker := requiredBinariesChecker{paths: []string{path}}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a failed probe for a non-executable file, got %v", reporter)
+	}
+}
+
+func TestRequiredBinaryPathsDeduplicates(t *testing.T) {
+	paths := requiredBinaryPaths()
+	seen := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		if seen[path] {
+			t.Fatalf("expected requiredBinaryPaths to deduplicate, found repeated %v", path)
+		}
+		seen[path] = true
+	}
+	if len(paths) == 0 {
+		t.Fatalf("expected at least one required binary path")
+	}
+}
@@ -0,0 +1,321 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// BootTimeCheckerID identifies the checker that reports how long the
+// container took to boot, and which systemd units took longest to start.
+const BootTimeCheckerID = "boot-time"
+
+// defaultBootTimeThreshold is how long startup may take before
+// BootTimeChecker reports a warning instead of an informational probe.
+const defaultBootTimeThreshold = 3 * time.Minute
+
+// defaultSlowUnitCount is how many of the slowest units are included in
+// the probe's Detail and exposed as metrics.
+const defaultSlowUnitCount = 5
+
+// BootTimeCheckerConfig configures NewBootTimeChecker.
+type BootTimeCheckerConfig struct {
+	// Threshold is how long the container may take to boot before this
+	// checker reports a warning. Defaults to defaultBootTimeThreshold.
+	Threshold time.Duration
+	// SlowUnitCount is how many of the slowest systemd units to include
+	// in the probe's Detail. Defaults to defaultSlowUnitCount.
+	SlowUnitCount int
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *BootTimeCheckerConfig) CheckAndSetDefaults() error {
+	if r.Threshold <= 0 {
+		r.Threshold = defaultBootTimeThreshold
+	}
+	if r.SlowUnitCount <= 0 {
+		r.SlowUnitCount = defaultSlowUnitCount
+	}
+	return nil
+}
+
+// NewBootTimeChecker returns a checker that reports the container's boot
+// time and slowest-starting systemd units, as recorded by systemd-analyze.
+// The underlying measurement is taken once per agent process and cached -
+// systemd-analyze describes a boot that already finished, so repeating it
+// later only reproduces the same numbers while adding needless exec calls
+// to every check cycle. See bootTimeCollector for the same measurement
+// exposed as Prometheus gauges.
+func NewBootTimeChecker(config BootTimeCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &bootTimeChecker{BootTimeCheckerConfig: config}, nil
+}
+
+type bootTimeChecker struct {
+	BootTimeCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *bootTimeChecker) Name() string { return BootTimeCheckerID }
+
+// Check reports the cached boot-time measurement, warning if the total
+// boot time exceeded the configured threshold.
+func (r *bootTimeChecker) Check(ctx context.Context, reporter health.Reporter) {
+	measurement := measureBootTime()
+	if measurement.err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to measure boot time", measurement.err))
+		return
+	}
+
+	detail := formatBootTimeDetail(measurement, r.SlowUnitCount)
+	if measurement.total <= r.Threshold {
+		reporter.Add(&pb.Probe{
+			Checker: r.Name(),
+			Status:  pb.Probe_Running,
+			Detail:  detail,
+		})
+		return
+	}
+
+	reporter.Add(&pb.Probe{
+		Checker:  r.Name(),
+		Status:   pb.Probe_Failed,
+		Severity: pb.Probe_Warning,
+		Detail:   detail,
+		Error:    fmt.Sprintf("boot took %v, exceeding the %v threshold", measurement.total, r.Threshold),
+	})
+}
+
+// unitStartupDuration is one line of `systemd-analyze blame` output.
+type unitStartupDuration struct {
+	Unit     string
+	Duration time.Duration
+}
+
+// bootTimeMeasurement is the result of running systemd-analyze once.
+type bootTimeMeasurement struct {
+	total time.Duration
+	units []unitStartupDuration
+	err   error
+}
+
+var (
+	bootTimeOnce   sync.Once
+	bootTimeResult bootTimeMeasurement
+)
+
+// measureBootTime runs systemd-analyze at most once per process and
+// caches the result for both BootTimeChecker and bootTimeCollector.
+func measureBootTime() bootTimeMeasurement {
+	bootTimeOnce.Do(func() {
+		bootTimeResult = doMeasureBootTime()
+	})
+	return bootTimeResult
+}
+
+func doMeasureBootTime() bootTimeMeasurement {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	total, err := systemdAnalyzeTime(ctx)
+	if err != nil {
+		return bootTimeMeasurement{err: trace.Wrap(err, "failed to run systemd-analyze time")}
+	}
+	units, err := systemdAnalyzeBlame(ctx)
+	if err != nil {
+		return bootTimeMeasurement{err: trace.Wrap(err, "failed to run systemd-analyze blame")}
+	}
+	return bootTimeMeasurement{total: total, units: units}
+}
+
+// formatBootTimeDetail renders a measurement as a probe Detail string,
+// limited to the slowUnitCount slowest units.
+func formatBootTimeDetail(m bootTimeMeasurement, slowUnitCount int) string {
+	units := m.units
+	if len(units) > slowUnitCount {
+		units = units[:slowUnitCount]
+	}
+	parts := make([]string, 0, len(units))
+	for _, u := range units {
+		parts = append(parts, fmt.Sprintf("%v (%v)", u.Unit, u.Duration))
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("boot took %v", m.total)
+	}
+	return fmt.Sprintf("boot took %v; slowest units: %v", m.total, strings.Join(parts, ", "))
+}
+
+// systemdAnalyzeTime runs `systemd-analyze time` and returns the total
+// boot time it reports.
+func systemdAnalyzeTime(ctx context.Context) (time.Duration, error) {
+	out, err := exec.CommandContext(ctx, "systemd-analyze", "time").CombinedOutput()
+	if err != nil {
+		return 0, trace.Wrap(err, "systemd-analyze time: %v", string(out))
+	}
+	return parseSystemdAnalyzeTime(string(out))
+}
+
+// parseSystemdAnalyzeTime parses the output of `systemd-analyze time`,
+// e.g. "Startup finished in 2.501s (kernel) + 1min 4.023s (userspace) =
+// 1min 6.524s", returning the total after the final "=".
+func parseSystemdAnalyzeTime(output string) (time.Duration, error) {
+	line := strings.TrimSpace(strings.SplitN(output, "\n", 2)[0])
+	idx := strings.LastIndex(line, "=")
+	if idx < 0 {
+		return 0, trace.BadParameter("unexpected systemd-analyze time output: %q", line)
+	}
+	return parseSystemdDuration(strings.TrimSpace(line[idx+1:]))
+}
+
+// systemdAnalyzeBlame runs `systemd-analyze blame` and returns the
+// per-unit startup durations it reports, slowest first.
+func systemdAnalyzeBlame(ctx context.Context) ([]unitStartupDuration, error) {
+	out, err := exec.CommandContext(ctx, "systemd-analyze", "blame").CombinedOutput()
+	if err != nil {
+		return nil, trace.Wrap(err, "systemd-analyze blame: %v", string(out))
+	}
+	return parseSystemdAnalyzeBlame(string(out)), nil
+}
+
+// parseSystemdAnalyzeBlame parses the output of `systemd-analyze blame`,
+// one "<duration> <unit>" entry per line, e.g. "1min 4.023s
+// kube-apiserver.service". systemd already sorts this slowest first, but
+// the result is re-sorted defensively rather than trusted blindly.
+func parseSystemdAnalyzeBlame(output string) []unitStartupDuration {
+	var units []unitStartupDuration
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		unit := fields[len(fields)-1]
+		duration, err := parseSystemdDuration(strings.Join(fields[:len(fields)-1], " "))
+		if err != nil {
+			continue
+		}
+		units = append(units, unitStartupDuration{Unit: unit, Duration: duration})
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].Duration > units[j].Duration })
+	return units
+}
+
+// systemdDurationToken matches one component of a systemd-formatted
+// duration, e.g. "1min", "4.023s", "500ms".
+var systemdDurationToken = regexp.MustCompile(`([0-9]+(?:\.[0-9]+)?)(y|month|w|d|h|min|ms|us|s)`)
+
+// systemdDurationUnits maps the unit suffixes systemd-analyze uses to
+// their time.Duration equivalent.
+var systemdDurationUnits = map[string]time.Duration{
+	"y":     365 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"w":     7 * 24 * time.Hour,
+	"d":     24 * time.Hour,
+	"h":     time.Hour,
+	"min":   time.Minute,
+	"s":     time.Second,
+	"ms":    time.Millisecond,
+	"us":    time.Microsecond,
+}
+
+// parseSystemdDuration parses a systemd-formatted duration such as
+// "1min 4.023s" into a time.Duration.
+func parseSystemdDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	matches := systemdDurationToken.FindAllStringSubmatch(s, -1)
+	if matches == nil {
+		return 0, trace.BadParameter("unrecognized duration format: %q", s)
+	}
+	var total time.Duration
+	for _, m := range matches {
+		value, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, trace.Wrap(err)
+		}
+		total += time.Duration(math.Round(value * float64(systemdDurationUnits[m[2]])))
+	}
+	return total, nil
+}
+
+// bootTimeTotalDesc and bootTimeUnitDesc describe the Prometheus gauges
+// bootTimeCollector exposes.
+var (
+	bootTimeTotalDesc = prometheus.NewDesc(
+		"planet_boot_time_seconds",
+		"Total time the planet container took to boot, as reported by systemd-analyze.",
+		nil, nil,
+	)
+	bootTimeUnitDesc = prometheus.NewDesc(
+		"planet_boot_unit_seconds",
+		"Startup duration of the slowest systemd units during boot, as reported by systemd-analyze blame.",
+		[]string{"unit"}, nil,
+	)
+)
+
+// bootTimeCollector exposes the same one-shot boot-time measurement
+// BootTimeChecker reports as Prometheus gauges, so control-plane boot
+// time can be trended across the fleet. It shares BootTimeChecker's
+// cached measurement instead of re-running systemd-analyze on every
+// scrape.
+type bootTimeCollector struct {
+	slowUnitCount int
+}
+
+// Describe implements prometheus.Collector.
+func (c bootTimeCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- bootTimeTotalDesc
+	ch <- bootTimeUnitDesc
+}
+
+// Collect implements prometheus.Collector.
+func (c bootTimeCollector) Collect(ch chan<- prometheus.Metric) {
+	measurement := measureBootTime()
+	if measurement.err != nil {
+		log.Warnf("Failed to measure boot time: %v.", measurement.err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(bootTimeTotalDesc, prometheus.GaugeValue, measurement.total.Seconds())
+
+	units := measurement.units
+	if len(units) > c.slowUnitCount {
+		units = units[:c.slowUnitCount]
+	}
+	for _, u := range units {
+		ch <- prometheus.MustNewConstMetric(bootTimeUnitDesc, prometheus.GaugeValue, u.Duration.Seconds(), u.Unit)
+	}
+}
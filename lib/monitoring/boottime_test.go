@@ -0,0 +1,92 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSystemdDuration(t *testing.T) {
+	cases := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"4.023s", 4023 * time.Millisecond},
+		{"1min 4.023s", time.Minute + 4023*time.Millisecond},
+		{"500ms", 500 * time.Millisecond},
+		{"1h 2min 3.456s", time.Hour + 2*time.Minute + 3456*time.Millisecond},
+	}
+	for _, c := range cases {
+		got, err := parseSystemdDuration(c.input)
+		if err != nil {
+			t.Fatalf("parseSystemdDuration(%q): %v", c.input, err)
+		}
+		if got != c.want {
+			t.Fatalf("parseSystemdDuration(%q) = %v, want %v", c.input, got, c.want)
+		}
+	}
+}
+
+func TestParseSystemdDurationRejectsUnrecognizedInput(t *testing.T) {
+	if _, err := parseSystemdDuration("not a duration"); err == nil {
+		t.Fatalf("expected an error for unrecognized input")
+	}
+}
+
+func TestParseSystemdAnalyzeTime(t *testing.T) {
+	output := "Startup finished in 2.501s (kernel) + 1min 4.023s (userspace) = 1min 6.524s\n"
+	got, err := parseSystemdAnalyzeTime(output)
+	if err != nil {
+		t.Fatalf("parseSystemdAnalyzeTime: %v", err)
+	}
+	want := time.Minute + 6524*time.Millisecond
+	if got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestParseSystemdAnalyzeBlameSortsSlowestFirst(t *testing.T) {
+	output := "500ms docker.service\n" +
+		"1min 4.023s kube-apiserver.service\n" +
+		"2.1s etcd.service\n"
+	units := parseSystemdAnalyzeBlame(output)
+	if len(units) != 3 {
+		t.Fatalf("got %v units, want 3", len(units))
+	}
+	if units[0].Unit != "kube-apiserver.service" {
+		t.Fatalf("expected kube-apiserver.service first, got %v", units[0].Unit)
+	}
+	if units[2].Unit != "docker.service" {
+		t.Fatalf("expected docker.service last, got %v", units[2].Unit)
+	}
+}
+
+func TestFormatBootTimeDetailLimitsToSlowUnitCount(t *testing.T) {
+	m := bootTimeMeasurement{
+		total: time.Minute,
+		units: []unitStartupDuration{
+			{Unit: "a.service", Duration: 3 * time.Second},
+			{Unit: "b.service", Duration: 2 * time.Second},
+			{Unit: "c.service", Duration: time.Second},
+		},
+	}
+	detail := formatBootTimeDetail(m, 2)
+	if detail != "boot took 1m0s; slowest units: a.service (3s), b.service (2s)" {
+		t.Fatalf("unexpected detail: %v", detail)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"io/ioutil"
+	"strconv"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// CheckerOverrides maps a checker name (e.g. monitoring.DiskSpaceCheckerID)
+// to a set of parameter overrides, as loaded from a checker configuration
+// file. Both keys and values are strings as read from the file; only the
+// parameters listed in checkerOverrideParams are recognized, and their
+// values are parsed according to the type of the Config field they feed.
+type CheckerOverrides map[string]map[string]string
+
+// checkerOverrideParams lists, per checker name, the parameter names
+// applyCheckerOverrides knows how to apply. It exists so a typo'd checker
+// or parameter name in the overrides file produces a warning rather than
+// silently doing nothing or failing the whole agent start.
+var checkerOverrideParams = map[string][]string{
+	monitoring.DiskSpaceCheckerID: {"low-watermark", "high-watermark"},
+	BootTimeCheckerID:             {"threshold", "slow-unit-count"},
+}
+
+// LoadCheckerOverrides reads a YAML or JSON file mapping checker names to
+// parameter overrides, e.g.:
+//
+//	disk-space:
+//	  low-watermark: "90"
+//	  high-watermark: "95"
+//	boot-time:
+//	  threshold: 5m
+func LoadCheckerOverrides(path string) (CheckerOverrides, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	var overrides CheckerOverrides
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return overrides, nil
+}
+
+// applyCheckerOverrides applies overrides on top of config's own thresholds,
+// ahead of AddCheckers building the actual checker set. Overrides naming an
+// unrecognized checker or parameter - or carrying a value that can't be
+// parsed for the parameter it names - are logged as warnings and otherwise
+// ignored, so a mistake in the overrides file degrades monitoring rather
+// than preventing the agent from starting.
+func applyCheckerOverrides(config *Config, overrides CheckerOverrides) {
+	for checker, params := range overrides {
+		known, ok := checkerOverrideParams[checker]
+		if !ok {
+			log.Warnf("Checker config override: unknown checker %q, ignoring.", checker)
+			continue
+		}
+		for param, value := range params {
+			if !containsString(known, param) {
+				log.Warnf("Checker config override: unknown parameter %q for checker %q, ignoring.", param, checker)
+				continue
+			}
+			if err := applyCheckerOverride(config, checker, param, value); err != nil {
+				log.Warnf("Checker config override: %v=%v for checker %q: %v, ignoring.", param, value, checker, err)
+			}
+		}
+	}
+}
+
+// applyCheckerOverride applies a single checker/param/value override to
+// config. The checker/param combination is assumed to already be validated
+// against checkerOverrideParams.
+func applyCheckerOverride(config *Config, checker, param, value string) error {
+	switch checker {
+	case monitoring.DiskSpaceCheckerID:
+		watermark, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		switch param {
+		case "low-watermark":
+			config.LowWatermark = uint(watermark)
+		case "high-watermark":
+			config.HighWatermark = uint(watermark)
+		}
+	case BootTimeCheckerID:
+		switch param {
+		case "threshold":
+			threshold, err := time.ParseDuration(value)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			config.BootTimeThreshold = threshold
+		case "slow-unit-count":
+			count, err := strconv.Atoi(value)
+			if err != nil {
+				return trace.Wrap(err)
+			}
+			config.BootTimeSlowUnitCount = count
+		}
+	}
+	return nil
+}
+
+// containsString returns true if values contains s.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
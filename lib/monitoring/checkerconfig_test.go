@@ -0,0 +1,97 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitational/satellite/monitoring"
+)
+
+func TestLoadCheckerOverridesParsesYAML(t *testing.T) {
+	path := writeCheckerOverridesFile(t, `
+disk-space:
+  low-watermark: "80"
+  high-watermark: "90"
+boot-time:
+  threshold: 5m
+`)
+	overrides, err := LoadCheckerOverrides(path)
+	if err != nil {
+		t.Fatalf("failed to load overrides: %v", err)
+	}
+	if overrides[monitoring.DiskSpaceCheckerID]["low-watermark"] != "80" {
+		t.Fatalf("unexpected overrides: %v", overrides)
+	}
+	if overrides[BootTimeCheckerID]["threshold"] != "5m" {
+		t.Fatalf("unexpected overrides: %v", overrides)
+	}
+}
+
+func TestApplyCheckerOverridesSetsConfigFields(t *testing.T) {
+	config := &Config{}
+	applyCheckerOverrides(config, CheckerOverrides{
+		monitoring.DiskSpaceCheckerID: {
+			"low-watermark":  "80",
+			"high-watermark": "90",
+		},
+		BootTimeCheckerID: {
+			"threshold":       "5m",
+			"slow-unit-count": "10",
+		},
+	})
+	if config.LowWatermark != 80 || config.HighWatermark != 90 {
+		t.Fatalf("disk-space overrides not applied: %+v", config)
+	}
+	if config.BootTimeThreshold != 5*time.Minute || config.BootTimeSlowUnitCount != 10 {
+		t.Fatalf("boot-time overrides not applied: %+v", config)
+	}
+}
+
+func TestApplyCheckerOverridesIgnoresUnknownCheckerAndParam(t *testing.T) {
+	config := &Config{}
+	applyCheckerOverrides(config, CheckerOverrides{
+		"not-a-checker": {"foo": "bar"},
+		monitoring.DiskSpaceCheckerID: {
+			"not-a-param": "80",
+		},
+	})
+	if config.LowWatermark != 0 || config.HighWatermark != 0 {
+		t.Fatalf("expected unrecognized overrides to be ignored, got %+v", config)
+	}
+}
+
+func TestApplyCheckerOverridesIgnoresUnparsableValue(t *testing.T) {
+	config := &Config{}
+	applyCheckerOverrides(config, CheckerOverrides{
+		monitoring.DiskSpaceCheckerID: {"low-watermark": "not-a-number"},
+	})
+	if config.LowWatermark != 0 {
+		t.Fatalf("expected unparsable override to be ignored, got %+v", config)
+	}
+}
+
+func writeCheckerOverridesFile(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "checkers.yaml")
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write checker overrides fixture: %v", err)
+	}
+	return path
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"strconv"
 	"strings"
@@ -37,10 +38,14 @@ import (
 	"github.com/gravitational/satellite/monitoring"
 	"github.com/gravitational/satellite/monitoring/latency"
 	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+// DefaultNettestServicePort is the default value of Config.NettestServicePort.
+const DefaultNettestServicePort = 8080
+
 // Config represents configuration for setting up monitoring checkers.
 type Config struct {
 	// Role is the current agent's role
@@ -60,6 +65,19 @@ type Config struct {
 	// NettestContainerImage is the name of the container image used for
 	// networking test
 	NettestContainerImage string
+	// NettestServicePort is the port the inter-pod networking test's
+	// service and pods listen on. Defaults to 8080.
+	//
+	// NOTE: the vendored checker this is meant to configure
+	// (vendor/github.com/gravitational/satellite/monitoring/interpod.go)
+	// hardcodes port 8080/TCP in its v1.Service/v1.Pod definitions and
+	// doesn't currently accept a port parameter, so this field isn't wired
+	// through yet - recorded here, with its default validated below, until
+	// the vendored checker is updated to accept one.
+	NettestServicePort int
+	// PauseContainerImage is the name of the pod infra container ("pause")
+	// image every pod depends on to bootstrap its network namespace.
+	PauseContainerImage string
 	// DisableInterPodCheck disables inter-pod communication tests
 	DisableInterPodCheck bool
 	// ETCDConfig defines etcd-specific configuration
@@ -80,6 +98,69 @@ type Config struct {
 	HTTPTimeout time.Duration
 	// CriticalNamespaces lists the namespaces of critical system pods.
 	CriticalNamespaces []string
+	// PodSubnet is the cluster's pod subnet CIDR.
+	PodSubnet net.IPNet
+	// ServiceSubnet is the cluster's service subnet CIDR.
+	ServiceSubnet net.IPNet
+	// RepairIPTables enables automatically recreating the planet-owned
+	// iptables rules (masquerade, FORWARD accepts) when IPTablesChecker
+	// finds them missing.
+	RepairIPTables bool
+	// CriticalSystemdUnits lists the systemd units whose failure marks a
+	// degraded system state as critical rather than a warning.
+	CriticalSystemdUnits []string
+	// FixSysctls enables automatically remediating the IP-forwarding and
+	// br_netfilter sysctl parameters when found missing or misconfigured.
+	FixSysctls bool
+	// DisabledMetricsCollectors lists the names of Prometheus metrics
+	// sub-collectors (see the metricsCollector* constants in metrics.go)
+	// that AddMetrics should skip registering.
+	DisabledMetricsCollectors []string
+	// KubeProxyHealthzAddr is the address of the kube-proxy healthz
+	// endpoint. Defaults to DefaultKubeProxyHealthzAddr.
+	KubeProxyHealthzAddr string
+	// DisableKubeProxyCheck skips the kube-proxy healthz check, reporting
+	// an informational probe instead of failing, for deployments that
+	// intentionally run without kube-proxy (e.g. proxyless CNI setups).
+	DisableKubeProxyCheck bool
+	// ExecCheckerDir is a directory of executable drop-ins, each of which
+	// is registered as its own checker (see DiscoverExecCheckers). Empty
+	// disables discovery.
+	ExecCheckerDir string
+	// ExecCheckerTimeout bounds how long a single drop-in checker under
+	// ExecCheckerDir may run. Defaults to defaultExecCheckerTimeout.
+	ExecCheckerTimeout time.Duration
+	// RootFSCheckPaths lists the directories, as visible from inside the
+	// planet container, to check for the filesystem features planet
+	// needs (see RootFSCheckerConfig). Defaults to the container rootfs
+	// alone.
+	RootFSCheckPaths []string
+	// PKIComponentCerts maps a descriptive component name (e.g. "etcd",
+	// "apiserver") to the path of its certificate, each of which
+	// PKITrustChainChecker verifies chains to ETCDConfig.CAFile. Empty
+	// disables the checker.
+	PKIComponentCerts map[string]string
+	// BootTimeThreshold overrides BootTimeChecker's default warning
+	// threshold. Zero uses defaultBootTimeThreshold.
+	BootTimeThreshold time.Duration
+	// BootTimeSlowUnitCount overrides BootTimeChecker's default number of
+	// slowest units reported. Zero uses defaultSlowUnitCount.
+	BootTimeSlowUnitCount int
+	// CheckerConfigFile is the path to a YAML/JSON file of per-checker
+	// parameter overrides (see LoadCheckerOverrides), applied on top of
+	// the thresholds above before AddCheckers builds the checker set.
+	// Empty disables loading overrides.
+	CheckerConfigFile string
+	// DockerStorageDriver overrides DockerStorageChecker's expected
+	// storage driver. Empty uses DefaultDockerStorageDriver ("overlay2").
+	DockerStorageDriver string
+	// ConntrackWarnThreshold overrides ConntrackChecker's default warning
+	// utilization threshold. Zero uses defaultConntrackWarnThreshold.
+	ConntrackWarnThreshold float64
+	// ConntrackCriticalThreshold overrides ConntrackChecker's default
+	// critical utilization threshold. Zero uses
+	// defaultConntrackCriticalThreshold.
+	ConntrackCriticalThreshold float64
 }
 
 // CheckAndSetDefaults validates monitoring configuration
@@ -87,6 +168,12 @@ func (c *Config) CheckAndSetDefaults() error {
 	if c.HTTPTimeout == 0 {
 		c.HTTPTimeout = constants.HTTPTimeout
 	}
+	if len(c.RootFSCheckPaths) == 0 {
+		c.RootFSCheckPaths = []string{"/"}
+	}
+	if c.NettestServicePort == 0 {
+		c.NettestServicePort = DefaultNettestServicePort
+	}
 	return nil
 }
 
@@ -181,6 +268,14 @@ func getKubeClientFromPath(kubeconfigPath string) (*kubernetes.Clientset, error)
 
 // AddCheckers adds checkers to the agent.
 func AddCheckers(node agent.Agent, config *Config) (err error) {
+	if config.CheckerConfigFile != "" {
+		overrides, err := LoadCheckerOverrides(config.CheckerConfigFile)
+		if err != nil {
+			return trace.Wrap(err, "failed to load checker config overrides from %v", config.CheckerConfigFile)
+		}
+		applyCheckerOverrides(config, overrides)
+	}
+
 	etcdConfig, err := config.NewETCDConfig()
 	if err != nil {
 		return trace.Wrap(err)
@@ -198,6 +293,7 @@ func AddCheckers(node agent.Agent, config *Config) (err error) {
 }
 
 func addToMaster(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDConfig) error {
+	node.AddChecker(NewRequiredBinariesChecker())
 	localTransport, err := config.LocalTransport()
 	if err != nil {
 		return trace.Wrap(err)
@@ -230,12 +326,49 @@ func addToMaster(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDCo
 	}))
 	// Kubelet can use the localhost healthz endpoint though
 	node.AddChecker(monitoring.KubeletHealth(monitoring.DefaultLocalKubeletHealthzAddr))
+	if err := addKubeProxyChecker(node, config, kubeConfig); err != nil {
+		return trace.Wrap(err)
+	}
 	node.AddChecker(monitoring.DockerHealth("/var/run/docker.sock"))
 	node.AddChecker(dockerRegistryHealth(config.RegistryAddr, localClient))
+	registryContentChecker, err := NewRegistryContentChecker(RegistryContentCheckerConfig{
+		Addr:           config.RegistryAddr,
+		Client:         localClient,
+		CriticalImages: criticalRegistryImages(config),
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(registryContentChecker)
+	if len(config.PKIComponentCerts) > 0 {
+		pkiTrustChainChecker, err := NewPKITrustChainChecker(PKITrustChainCheckerConfig{
+			CAFile:         config.ETCDConfig.CAFile,
+			ComponentCerts: config.PKIComponentCerts,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		node.AddChecker(pkiTrustChainChecker)
+	}
 	node.AddChecker(etcdChecker)
+	etcdMembersChecker, err := NewEtcdMembersChecker(EtcdMembersCheckerConfig{ETCDConfig: config.ETCDConfig})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(etcdMembersChecker)
 	node.AddChecker(monitoring.SystemdHealth())
-	node.AddChecker(monitoring.NewIPForwardChecker())
-	node.AddChecker(monitoring.NewBridgeNetfilterChecker())
+	if len(config.CriticalSystemdUnits) > 0 {
+		systemdDegradedChecker, err := NewSystemdDegradedChecker(SystemdDegradedCheckerConfig{
+			CriticalUnits: config.CriticalSystemdUnits,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		node.AddChecker(systemdDegradedChecker)
+	}
+	if err := addSysctlCheckers(node, config); err != nil {
+		return trace.Wrap(err)
+	}
 	node.AddChecker(monitoring.NewMayDetachMountsChecker())
 	node.AddChecker(monitoring.NewInotifyChecker())
 	node.AddChecker(monitoring.NewNodeStatusChecker(monitoring.NodeStatusCheckerConfig{
@@ -243,14 +376,26 @@ func addToMaster(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDCo
 		NodeName:       config.NodeName,
 		CheckCondition: monitoring.CheckNodeCondition,
 	}))
+	nodeSerfStatusChecker, err := NewNodeSerfStatusChecker(NodeSerfStatusCheckerConfig{
+		KubeConfig: kubeConfig,
+		Cluster:    node.GetConfig().Cluster,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(nodeSerfStatusChecker)
 	if !config.DisableInterPodCheck {
+		// config.NettestServicePort isn't passed here: the vendored checker
+		// (vendor/.../satellite/monitoring/interpod.go) hardcodes port 8080
+		// for the test service and pods and has no parameter for it. See
+		// the NettestServicePort doc comment.
 		node.AddChecker(monitoring.InterPodCommunication(kubeConfig, config.NettestContainerImage))
 	}
 	node.AddChecker(NewVersionCollector())
 	if len(config.LocalNameservers) > 0 {
-		node.AddChecker(monitoring.NewDNSChecker([]string{
+		node.AddChecker(NewCodeAssigningChecker(monitoring.NewDNSChecker([]string{
 			"leader.telekube.local.",
-		}, config.LocalNameservers...))
+		}, config.LocalNameservers...), CodeDNSUnresolvable))
 	}
 
 	storageCheckerConfig, err := config.storageCheckerConfig()
@@ -261,7 +406,7 @@ func addToMaster(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDCo
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	node.AddChecker(storageChecker)
+	node.AddChecker(NewCodeAssigningChecker(storageChecker, CodeDiskFull))
 
 	latencyChecker, err := latency.NewChecker(
 		&latency.Config{
@@ -286,12 +431,55 @@ func addToMaster(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDCo
 	}
 	node.AddChecker(timeDriftChecker)
 
+	overlayMTUChecker, err := NewOverlayMTUChecker(OverlayMTUCheckerConfig{
+		NodeName: node.GetConfig().Name,
+		Cluster:  node.GetConfig().Cluster,
+		DialRPC:  node.GetConfig().DialRPC,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(overlayMTUChecker)
+
+	versionSkewChecker, err := NewVersionSkewChecker(VersionSkewCheckerConfig{
+		NodeName: node.GetConfig().Name,
+		Cluster:  node.GetConfig().Cluster,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(versionSkewChecker)
+
+	iptablesChecker, err := NewIPTablesChecker(IPTablesCheckerConfig{
+		PodSubnet:     config.PodSubnet,
+		ServiceSubnet: config.ServiceSubnet,
+		Repair:        config.RepairIPTables,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(iptablesChecker)
+
 	// Add checkers specific to cloud provider backend
 	switch strings.ToLower(config.CloudProvider) {
 	case constants.CloudProviderAWS:
 		node.AddChecker(monitoring.NewAWSHasProfileChecker())
 	}
 
+	cloudMetadataChecker, err := NewCloudMetadataChecker(CloudChecksConfig{CloudProvider: config.CloudProvider})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(cloudMetadataChecker)
+
+	cloudIAMChecker, err := NewCloudIAMChecker(CloudChecksConfig{CloudProvider: config.CloudProvider})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(cloudIAMChecker)
+
+	node.AddChecker(NewResourceLimitsChecker())
+
 	nethealthChecker, err := monitoring.NewNethealthChecker(
 		monitoring.NethealthConfig{
 			NodeName:   config.NodeName,
@@ -316,10 +504,116 @@ func addToMaster(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDCo
 
 	node.AddChecker(monitoring.NewKernelChecker(constants.MinKernelVersion))
 
+	clockSourceChecker, err := NewClockSourceChecker(ClockSourceCheckerConfig{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(clockSourceChecker)
+
+	swapChecker, err := NewSwapChecker(SwapCheckerConfig{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(swapChecker)
+
+	conntrackChecker, err := NewConntrackChecker(ConntrackCheckerConfig{
+		WarnThreshold:     config.ConntrackWarnThreshold,
+		CriticalThreshold: config.ConntrackCriticalThreshold,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(conntrackChecker)
+
+	neighborTableChecker, err := NewNeighborTableChecker(NeighborTableCheckerConfig{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(neighborTableChecker)
+
+	bootTimeChecker, err := NewBootTimeChecker(BootTimeCheckerConfig{
+		Threshold:     config.BootTimeThreshold,
+		SlowUnitCount: config.BootTimeSlowUnitCount,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(bootTimeChecker)
+
+	node.AddChecker(NewOOMChecker(OOMCheckerConfig{}))
+
+	node.AddChecker(NewRootFSChecker(RootFSCheckerConfig{Paths: config.RootFSCheckPaths}))
+
+	dockerStorageChecker, err := NewDockerStorageChecker(DockerStorageCheckerConfig{
+		ExpectedDriver: config.DockerStorageDriver,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(dockerStorageChecker)
+
+	addExecCheckers(node, config)
+
+	return nil
+}
+
+// addSysctlCheckers adds the IP-forwarding and br_netfilter sysctl checkers
+// to node, wrapping them with automatic remediation when config.FixSysctls
+// is set.
+func addSysctlCheckers(node agent.Agent, config *Config) error {
+	ipForwardChecker := monitoring.NewIPForwardChecker()
+	bridgeNetfilterChecker := monitoring.NewBridgeNetfilterChecker()
+	if !config.FixSysctls {
+		node.AddChecker(ipForwardChecker)
+		node.AddChecker(bridgeNetfilterChecker)
+		return nil
+	}
+	remediatingIPForwardChecker, err := NewRemediatingSysctlChecker(RemediatingSysctlCheckerConfig{
+		Checker: ipForwardChecker,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	remediatingBridgeNetfilterChecker, err := NewRemediatingSysctlChecker(RemediatingSysctlCheckerConfig{
+		Checker: bridgeNetfilterChecker,
+		Module:  "br_netfilter",
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(remediatingIPForwardChecker)
+	node.AddChecker(remediatingBridgeNetfilterChecker)
+	return nil
+}
+
+// addKubeProxyChecker adds the kube-proxy healthz and dataplane-rules
+// checkers to node, reporting an informational probe instead of failing
+// when config.DisableKubeProxyCheck is set.
+func addKubeProxyChecker(node agent.Agent, config *Config, kubeConfig monitoring.KubeConfig) error {
+	kubeProxyChecker, err := NewKubeProxyHealthzChecker(KubeProxyHealthzCheckerConfig{
+		Addr:     config.KubeProxyHealthzAddr,
+		Disabled: config.DisableKubeProxyCheck,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(kubeProxyChecker)
+
+	if config.DisableKubeProxyCheck {
+		return nil
+	}
+	kubeProxyRulesChecker, err := NewKubeProxyRulesChecker(KubeProxyRulesCheckerConfig{
+		KubeConfig: kubeConfig,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(kubeProxyRulesChecker)
 	return nil
 }
 
 func addToNode(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDConfig) error {
+	node.AddChecker(NewRequiredBinariesChecker())
 	etcdChecker, err := monitoring.EtcdHealth(etcdConfig)
 	if err != nil {
 		return trace.Wrap(err)
@@ -333,18 +627,31 @@ func addToNode(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDConf
 
 	nodeConfig := monitoring.KubeConfig{Client: nodeClient}
 	node.AddChecker(monitoring.KubeletHealth(monitoring.DefaultLocalKubeletHealthzAddr))
+	if err := addKubeProxyChecker(node, config, nodeConfig); err != nil {
+		return trace.Wrap(err)
+	}
 	node.AddChecker(monitoring.DockerHealth("/var/run/docker.sock"))
 	node.AddChecker(etcdChecker)
 	node.AddChecker(monitoring.SystemdHealth())
+	if len(config.CriticalSystemdUnits) > 0 {
+		systemdDegradedChecker, err := NewSystemdDegradedChecker(SystemdDegradedCheckerConfig{
+			CriticalUnits: config.CriticalSystemdUnits,
+		})
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		node.AddChecker(systemdDegradedChecker)
+	}
 	node.AddChecker(NewVersionCollector())
-	node.AddChecker(monitoring.NewIPForwardChecker())
-	node.AddChecker(monitoring.NewBridgeNetfilterChecker())
+	if err := addSysctlCheckers(node, config); err != nil {
+		return trace.Wrap(err)
+	}
 	node.AddChecker(monitoring.NewMayDetachMountsChecker())
 	node.AddChecker(monitoring.NewInotifyChecker())
 	if len(config.LocalNameservers) > 0 {
-		node.AddChecker(monitoring.NewDNSChecker([]string{
+		node.AddChecker(NewCodeAssigningChecker(monitoring.NewDNSChecker([]string{
 			"leader.telekube.local.",
-		}, config.LocalNameservers...))
+		}, config.LocalNameservers...), CodeDNSUnresolvable))
 	}
 	node.AddChecker(monitoring.NewNodeStatusChecker(monitoring.NodeStatusCheckerConfig{
 		KubeConfig:     nodeConfig,
@@ -360,7 +667,36 @@ func addToNode(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDConf
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	node.AddChecker(storageChecker)
+	node.AddChecker(NewCodeAssigningChecker(storageChecker, CodeDiskFull))
+
+	overlayMTUChecker, err := NewOverlayMTUChecker(OverlayMTUCheckerConfig{
+		NodeName: node.GetConfig().Name,
+		Cluster:  node.GetConfig().Cluster,
+		DialRPC:  node.GetConfig().DialRPC,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(overlayMTUChecker)
+
+	versionSkewChecker, err := NewVersionSkewChecker(VersionSkewCheckerConfig{
+		NodeName: node.GetConfig().Name,
+		Cluster:  node.GetConfig().Cluster,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(versionSkewChecker)
+
+	iptablesChecker, err := NewIPTablesChecker(IPTablesCheckerConfig{
+		PodSubnet:     config.PodSubnet,
+		ServiceSubnet: config.ServiceSubnet,
+		Repair:        config.RepairIPTables,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(iptablesChecker)
 
 	// Add checkers specific to cloud provider backend
 	switch strings.ToLower(config.CloudProvider) {
@@ -368,6 +704,20 @@ func addToNode(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDConf
 		node.AddChecker(monitoring.NewAWSHasProfileChecker())
 	}
 
+	cloudMetadataChecker, err := NewCloudMetadataChecker(CloudChecksConfig{CloudProvider: config.CloudProvider})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(cloudMetadataChecker)
+
+	cloudIAMChecker, err := NewCloudIAMChecker(CloudChecksConfig{CloudProvider: config.CloudProvider})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(cloudIAMChecker)
+
+	node.AddChecker(NewResourceLimitsChecker())
+
 	nethealthChecker, err := monitoring.NewNethealthChecker(
 		monitoring.NethealthConfig{
 			NodeName:   config.NodeName,
@@ -381,9 +731,78 @@ func addToNode(node agent.Agent, config *Config, etcdConfig *monitoring.ETCDConf
 
 	node.AddChecker(monitoring.NewKernelChecker(constants.MinKernelVersion))
 
+	clockSourceChecker, err := NewClockSourceChecker(ClockSourceCheckerConfig{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(clockSourceChecker)
+
+	swapChecker, err := NewSwapChecker(SwapCheckerConfig{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(swapChecker)
+
+	conntrackChecker, err := NewConntrackChecker(ConntrackCheckerConfig{
+		WarnThreshold:     config.ConntrackWarnThreshold,
+		CriticalThreshold: config.ConntrackCriticalThreshold,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(conntrackChecker)
+
+	neighborTableChecker, err := NewNeighborTableChecker(NeighborTableCheckerConfig{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(neighborTableChecker)
+
+	bootTimeChecker, err := NewBootTimeChecker(BootTimeCheckerConfig{
+		Threshold:     config.BootTimeThreshold,
+		SlowUnitCount: config.BootTimeSlowUnitCount,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(bootTimeChecker)
+
+	node.AddChecker(NewOOMChecker(OOMCheckerConfig{}))
+
+	node.AddChecker(NewRootFSChecker(RootFSCheckerConfig{Paths: config.RootFSCheckPaths}))
+
+	dockerStorageChecker, err := NewDockerStorageChecker(DockerStorageCheckerConfig{
+		ExpectedDriver: config.DockerStorageDriver,
+	})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	node.AddChecker(dockerStorageChecker)
+
+	addExecCheckers(node, config)
+
 	return nil
 }
 
+// addExecCheckers registers a checker for every executable drop-in found
+// under config.ExecCheckerDir, if set. Discovery errors (a missing or
+// unreadable directory) are logged and otherwise ignored, since the
+// feature is opt-in and must never prevent the built-in checkers above
+// from being registered.
+func addExecCheckers(node agent.Agent, config *Config) {
+	if config.ExecCheckerDir == "" {
+		return
+	}
+	checkers, err := DiscoverExecCheckers(config.ExecCheckerDir, config.ExecCheckerTimeout)
+	if err != nil {
+		log.WithError(err).WithField("dir", config.ExecCheckerDir).Warn("Failed to discover external checker drop-ins.")
+		return
+	}
+	for _, checker := range checkers {
+		node.AddChecker(checker)
+	}
+}
+
 func dockerRegistryHealth(addr string, client *http.Client) health.Checker {
 	return monitoring.NewHTTPHealthzCheckerWithClient("docker-registry", fmt.Sprintf("%v/v2/", addr), client, noopResponseChecker)
 }
@@ -0,0 +1,39 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import "testing"
+
+func TestCheckAndSetDefaultsFillsNettestServicePort(t *testing.T) {
+	var config Config
+	if err := config.CheckAndSetDefaults(); err != nil {
+		t.Fatalf("CheckAndSetDefaults: %v", err)
+	}
+	if config.NettestServicePort != DefaultNettestServicePort {
+		t.Fatalf("got NettestServicePort %v, want %v", config.NettestServicePort, DefaultNettestServicePort)
+	}
+}
+
+func TestCheckAndSetDefaultsPreservesCustomNettestServicePort(t *testing.T) {
+	config := Config{NettestServicePort: 9090}
+	if err := config.CheckAndSetDefaults(); err != nil {
+		t.Fatalf("CheckAndSetDefaults: %v", err)
+	}
+	if config.NettestServicePort != 9090 {
+		t.Fatalf("got NettestServicePort %v, want 9090", config.NettestServicePort)
+	}
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// ClockSourceCheckerID identifies the checker that verifies the host's
+// clocksource is one known to be reliable under virtualization.
+const ClockSourceCheckerID = "clocksource"
+
+// clockSourceFile is the sysfs file exposing the clocksource currently in
+// use by the kernel.
+const clockSourceFile = "/sys/devices/system/clocksource/clocksource0/current_clocksource"
+
+// defaultReliableClockSources lists clocksources known to provide a stable,
+// monotonic timebase under virtualization. An unstable clocksource (e.g.
+// jiffies, acpi_pm) has been observed to cause etcd leader election churn.
+var defaultReliableClockSources = []string{"tsc", "kvm-clock"}
+
+// ClockSourceCheckerConfig configures NewClockSourceChecker.
+type ClockSourceCheckerConfig struct {
+	// Path is the sysfs file to read the active clocksource from.
+	// Defaults to clockSourceFile.
+	Path string
+	// Reliable lists the clocksource values considered reliable.
+	// Defaults to defaultReliableClockSources.
+	Reliable []string
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *ClockSourceCheckerConfig) CheckAndSetDefaults() error {
+	if r.Path == "" {
+		r.Path = clockSourceFile
+	}
+	if len(r.Reliable) == 0 {
+		r.Reliable = defaultReliableClockSources
+	}
+	return nil
+}
+
+// NewClockSourceChecker returns a checker that warns when the host's active
+// clocksource is not one of the reliable clocksources.
+func NewClockSourceChecker(config ClockSourceCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &clockSourceChecker{ClockSourceCheckerConfig: config}, nil
+}
+
+type clockSourceChecker struct {
+	ClockSourceCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *clockSourceChecker) Name() string { return ClockSourceCheckerID }
+
+// Check reads the active clocksource and reports a warning if it is not
+// among the reliable clocksources.
+func (r *clockSourceChecker) Check(ctx context.Context, reporter health.Reporter) {
+	data, err := ioutil.ReadFile(r.Path)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), fmt.Sprintf("failed to read clocksource from %v", r.Path), trace.ConvertSystemError(err)))
+		return
+	}
+
+	clocksource := strings.TrimSpace(string(data))
+	if isReliableClockSource(clocksource, r.Reliable) {
+		reporter.Add(&pb.Probe{
+			Checker: r.Name(),
+			Status:  pb.Probe_Running,
+			Detail:  fmt.Sprintf("clocksource/%v", clocksource),
+		})
+		return
+	}
+
+	reporter.Add(&pb.Probe{
+		Checker:  r.Name(),
+		Status:   pb.Probe_Failed,
+		Severity: pb.Probe_Warning,
+		Detail:   fmt.Sprintf("clocksource/%v", clocksource),
+		Error: fmt.Sprintf("clocksource %q is not known to be reliable under virtualization (expected one of %v), "+
+			"which can cause etcd leader election churn", clocksource, r.Reliable),
+	})
+}
+
+// isReliableClockSource returns true if clocksource is among reliable.
+func isReliableClockSource(clocksource string, reliable []string) bool {
+	for _, source := range reliable {
+		if clocksource == source {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestIsReliableClockSource(t *testing.T) {
+	reliable := []string{"tsc", "kvm-clock"}
+	for _, source := range []string{"tsc", "kvm-clock"} {
+		if !isReliableClockSource(source, reliable) {
+			t.Errorf("expected %q to be considered reliable", source)
+		}
+	}
+	for _, source := range []string{"jiffies", "acpi_pm"} {
+		if isReliableClockSource(source, reliable) {
+			t.Errorf("expected %q to not be considered reliable", source)
+		}
+	}
+}
+
+func TestClockSourceCheckerWarnsOnUnreliableSource(t *testing.T) {
+	path := writeClockSourceFile(t, "acpi_pm")
+	checker, err := NewClockSourceChecker(ClockSourceCheckerConfig{Path: path})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a single failed probe, got %v", reporter)
+	}
+}
+
+func TestClockSourceCheckerPassesOnReliableSource(t *testing.T) {
+	path := writeClockSourceFile(t, "tsc")
+	checker, err := NewClockSourceChecker(ClockSourceCheckerConfig{Path: path})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("expected a single running probe, got %v", reporter)
+	}
+}
+
+func writeClockSourceFile(t *testing.T, clocksource string) string {
+	path := filepath.Join(t.TempDir(), "current_clocksource")
+	if err := ioutil.WriteFile(path, []byte(clocksource+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write clocksource fixture: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gravitational/planet/lib/constants"
+
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// gceMetadataURL is the well-known address of the GCE metadata service.
+// See https://cloud.google.com/compute/docs/metadata/overview.
+const gceMetadataURL = "http://metadata.google.internal/computeMetadata/v1"
+
+// cloudMetadataCheckTimeout bounds how long the cloud checkers wait for the
+// instance metadata service to answer.
+const cloudMetadataCheckTimeout = 5 * time.Second
+
+// CloudChecksConfig configures NewCloudMetadataChecker and
+// NewCloudIAMChecker.
+type CloudChecksConfig struct {
+	// CloudProvider is the name of the cloud provider backend the cluster
+	// is using, e.g. "aws" or "gce". A checker configured with any other
+	// value (including empty, for clusters without cloud integration)
+	// reports an informational probe and does nothing else.
+	CloudProvider string
+}
+
+// CheckAndSetDefaults validates the configuration and normalizes
+// CloudProvider for comparison.
+func (r *CloudChecksConfig) CheckAndSetDefaults() error {
+	r.CloudProvider = strings.ToLower(r.CloudProvider)
+	return nil
+}
+
+// NewCloudMetadataChecker returns a checker that verifies the instance
+// metadata service of the configured cloud provider is reachable. A broken
+// or unreachable metadata service prevents the in-tree cloud provider from
+// discovering the instance and its attached IAM/service account, so this is
+// checked independently of - and ahead of - the permission checks in
+// NewCloudIAMChecker.
+func NewCloudMetadataChecker(config CloudChecksConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &cloudMetadataChecker{CloudChecksConfig: config}, nil
+}
+
+type cloudMetadataChecker struct {
+	CloudChecksConfig
+}
+
+// Name returns this checker's name.
+func (r *cloudMetadataChecker) Name() string { return CloudMetadataCheckerID }
+
+// Check queries the instance metadata service of the configured cloud
+// provider and reports a failed probe if it does not respond.
+func (r *cloudMetadataChecker) Check(ctx context.Context, reporter health.Reporter) {
+	switch r.CloudProvider {
+	case constants.CloudProviderAWS:
+		session, err := session.NewSession()
+		if err != nil {
+			reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to create AWS session", trace.Wrap(err)))
+			return
+		}
+		if !ec2metadata.New(session).Available() {
+			reporter.Add(&pb.Probe{
+				Checker:  r.Name(),
+				Status:   pb.Probe_Failed,
+				Severity: pb.Probe_Critical,
+				Error:    "EC2 instance metadata service is not responding - the in-tree AWS cloud provider cannot discover this instance",
+			})
+			return
+		}
+	case constants.CloudProviderGCE:
+		if _, err := queryGCEMetadata(ctx, "instance/id"); err != nil {
+			reporter.Add(&pb.Probe{
+				Checker:  r.Name(),
+				Status:   pb.Probe_Failed,
+				Severity: pb.Probe_Critical,
+				Error:    fmt.Sprintf("GCE instance metadata service is not responding - the in-tree GCE cloud provider cannot discover this instance: %v", err),
+			})
+			return
+		}
+	default:
+		reporter.Add(&pb.Probe{Checker: r.Name(), Status: pb.Probe_Running, Severity: pb.Probe_None, Detail: "not running with cloud integration, skipping"})
+		return
+	}
+	reporter.Add(&pb.Probe{Checker: r.Name(), Status: pb.Probe_Running})
+}
+
+// NewCloudIAMChecker returns a checker that makes a harmless read-only API
+// call requiring the same class of permission the in-tree cloud provider
+// itself needs, and reports a failed probe naming the missing permission or
+// role if it is refused.
+func NewCloudIAMChecker(config CloudChecksConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &cloudIAMChecker{CloudChecksConfig: config}, nil
+}
+
+type cloudIAMChecker struct {
+	CloudChecksConfig
+}
+
+// Name returns this checker's name.
+func (r *cloudIAMChecker) Name() string { return CloudIAMCheckerID }
+
+// Check makes a harmless read-only API call to the configured cloud
+// provider - describing the instance's own IAM role on AWS, reading the
+// instance's attached service account on GCE - and reports a failed probe
+// naming the missing role or permission if it is refused.
+func (r *cloudIAMChecker) Check(ctx context.Context, reporter health.Reporter) {
+	switch r.CloudProvider {
+	case constants.CloudProviderAWS:
+		session, err := session.NewSession()
+		if err != nil {
+			reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to create AWS session", trace.Wrap(err)))
+			return
+		}
+		if _, err := ec2metadata.New(session).IAMInfo(); err != nil {
+			reporter.Add(&pb.Probe{
+				Checker:  r.Name(),
+				Status:   pb.Probe_Failed,
+				Severity: pb.Probe_Critical,
+				Error:    fmt.Sprintf("no usable IAM instance profile is attached to this node, so the in-tree AWS cloud provider cannot manage load balancers or routes for it: %v", err),
+			})
+			return
+		}
+	case constants.CloudProviderGCE:
+		scopes, err := queryGCEMetadata(ctx, "instance/service-accounts/default/scopes")
+		if err != nil {
+			reporter.Add(&pb.Probe{
+				Checker:  r.Name(),
+				Status:   pb.Probe_Failed,
+				Severity: pb.Probe_Critical,
+				Error:    fmt.Sprintf("failed to read the instance's service account scopes, so the in-tree GCE cloud provider's access cannot be verified: %v", err),
+			})
+			return
+		}
+		if strings.TrimSpace(scopes) == "" {
+			reporter.Add(&pb.Probe{
+				Checker:  r.Name(),
+				Status:   pb.Probe_Failed,
+				Severity: pb.Probe_Critical,
+				Error:    "no service account is attached to this node, so the in-tree GCE cloud provider cannot manage instances or routes for it",
+			})
+			return
+		}
+	default:
+		reporter.Add(&pb.Probe{Checker: r.Name(), Status: pb.Probe_Running, Severity: pb.Probe_None, Detail: "not running with cloud integration, skipping"})
+		return
+	}
+	reporter.Add(&pb.Probe{Checker: r.Name(), Status: pb.Probe_Running})
+}
+
+// queryGCEMetadata fetches path from the GCE instance metadata service and
+// returns its body as a string.
+//
+// The AWS SDK is vendored into this tree and ships an EC2 metadata client
+// (used above), but no equivalent GCE client library is vendored. The GCE
+// metadata service is a plain HTTP endpoint distinguished only by a
+// required request header, so it is queried directly here rather than
+// pulling in a new dependency for it.
+func queryGCEMetadata(ctx context.Context, path string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, gceMetadataURL+"/"+path, nil)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	client := &http.Client{Timeout: cloudMetadataCheckTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", trace.BadParameter("unexpected status %v from GCE metadata service", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return string(body), nil
+}
+
+const (
+	// CloudMetadataCheckerID identifies the checker that verifies the cloud
+	// instance metadata service is reachable.
+	CloudMetadataCheckerID = "cloud-metadata"
+	// CloudIAMCheckerID identifies the checker that verifies the instance's
+	// cloud IAM/service account permissions.
+	CloudIAMCheckerID = "cloud-iam"
+)
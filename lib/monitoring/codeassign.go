@@ -0,0 +1,61 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+// NewCodeAssigningChecker wraps checker so that every failed probe it
+// reports is tagged with code, unless checker already set one itself.
+// It exists to retrofit a Code onto checkers (e.g. the vendored dns and
+// disk-space checkers) without having to fork their Check implementation.
+func NewCodeAssigningChecker(checker health.Checker, code Code) health.Checker {
+	return &codeAssigningChecker{checker: checker, code: code}
+}
+
+type codeAssigningChecker struct {
+	checker health.Checker
+	code    Code
+}
+
+// Name returns the name of the wrapped checker.
+func (r *codeAssigningChecker) Name() string { return r.checker.Name() }
+
+// Check runs the wrapped checker, tagging any failed probe it reports
+// with r.code before forwarding it to reporter.
+func (r *codeAssigningChecker) Check(ctx context.Context, reporter health.Reporter) {
+	r.checker.Check(ctx, &codeAssigningReporter{Reporter: reporter, code: r.code})
+}
+
+// codeAssigningReporter forwards probes to the underlying Reporter,
+// assigning code to any failed probe that doesn't already carry one.
+type codeAssigningReporter struct {
+	health.Reporter
+	code Code
+}
+
+// Add tags probe with r.code, when applicable, before forwarding it.
+func (r *codeAssigningReporter) Add(probe *pb.Probe) {
+	if probe.Status == pb.Probe_Failed && probe.Code == "" {
+		probe.Code = string(r.code)
+	}
+	r.Reporter.Add(probe)
+}
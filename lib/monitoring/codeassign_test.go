@@ -0,0 +1,71 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+type fakeChecker struct {
+	name   string
+	probes []*pb.Probe
+}
+
+func (f *fakeChecker) Name() string { return f.name }
+
+func (f *fakeChecker) Check(ctx context.Context, reporter health.Reporter) {
+	for _, probe := range f.probes {
+		reporter.Add(probe)
+	}
+}
+
+func TestCodeAssigningCheckerTagsFailedProbesWithoutACode(t *testing.T) {
+	checker := NewCodeAssigningChecker(&fakeChecker{
+		name: "dns",
+		probes: []*pb.Probe{
+			{Checker: "dns", Status: pb.Probe_Failed},
+		},
+	}, CodeDNSUnresolvable)
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 1 || reporter[0].Code != string(CodeDNSUnresolvable) {
+		t.Fatalf("expected the failed probe to be tagged with %v, got %v", CodeDNSUnresolvable, reporter)
+	}
+}
+
+func TestCodeAssigningCheckerLeavesExistingCodeAndSuccessProbesAlone(t *testing.T) {
+	checker := NewCodeAssigningChecker(&fakeChecker{
+		name: "disk-space",
+		probes: []*pb.Probe{
+			{Checker: "disk-space", Status: pb.Probe_Running},
+			{Checker: "disk-space", Status: pb.Probe_Failed, Code: "ALREADY_SET"},
+		},
+	}, CodeDiskFull)
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 2 || reporter[0].Code != "" || reporter[1].Code != "ALREADY_SET" {
+		t.Fatalf("expected the running probe to stay untagged and the failed probe to keep its code, got %v", reporter)
+	}
+}
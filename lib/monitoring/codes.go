@@ -0,0 +1,47 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+// Code is a stable, machine-readable reason for a failed or degraded
+// probe. Unlike Probe.Detail and Probe.Error, which are free-form text
+// meant for a human reading "planet status", a Code is meant to be
+// switched on by automation (upgrade gates, remediation tooling) without
+// having to pattern-match a message that's free to be reworded.
+//
+// A probe with no Code is not an error - plenty of checkers have nothing
+// more specific to say than their free-form Detail/Error. Code is only
+// populated for failure modes worth automating a response to.
+type Code string
+
+// Registry of codes emitted by planet's checkers. Keep this list in sync
+// with the checkers that assign them; a probe's Code must always be one
+// of these constants.
+const (
+	// CodeEtcdMembersInconsistent is set by the etcd-members checker when
+	// the cluster has duplicate client URLs or unstarted members left
+	// behind by a botched upgrade or restore.
+	CodeEtcdMembersInconsistent Code = "ETCD_MEMBERS_INCONSISTENT"
+	// CodeDiskFull is set on the disk-space checker's probe when a
+	// monitored path's usage crosses its high watermark.
+	CodeDiskFull Code = "DISK_FULL"
+	// CodeDNSUnresolvable is set on the dns checker's probe when a
+	// configured nameserver fails to answer a test query.
+	CodeDNSUnresolvable Code = "DNS_UNRESOLVABLE"
+	// CodeSystemdDegraded is set by the systemd-degraded checker when
+	// systemd reports the overall system state as degraded.
+	CodeSystemdDegraded Code = "SYSTEMD_DEGRADED"
+)
@@ -0,0 +1,176 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// ConntrackCheckerID identifies the checker that verifies conntrack table
+// utilization.
+const ConntrackCheckerID = "conntrack"
+
+// conntrackCountFile reports the number of entries currently tracked in the
+// host's connection tracking table.
+const conntrackCountFile = "/proc/sys/net/netfilter/nf_conntrack_count"
+
+// conntrackMaxFile reports the maximum size of the host's connection
+// tracking table.
+const conntrackMaxFile = "/proc/sys/net/netfilter/nf_conntrack_max"
+
+// defaultConntrackWarnThreshold is the default utilization, as a fraction of
+// nf_conntrack_max, at which ConntrackChecker reports a warning.
+const defaultConntrackWarnThreshold = 0.8
+
+// defaultConntrackCriticalThreshold is the default utilization, as a
+// fraction of nf_conntrack_max, at which ConntrackChecker reports a
+// critical failure.
+const defaultConntrackCriticalThreshold = 0.95
+
+// ConntrackCheckerConfig configures NewConntrackChecker.
+type ConntrackCheckerConfig struct {
+	// CountPath is the file to read the current conntrack entry count from.
+	// Defaults to conntrackCountFile.
+	CountPath string
+	// MaxPath is the file to read the conntrack table size limit from.
+	// Defaults to conntrackMaxFile.
+	MaxPath string
+	// WarnThreshold is the utilization, as a fraction of the table size
+	// between 0 and 1, at which the checker reports a warning. Zero uses
+	// defaultConntrackWarnThreshold.
+	WarnThreshold float64
+	// CriticalThreshold is the utilization, as a fraction of the table size
+	// between 0 and 1, at which the checker reports a critical failure.
+	// Zero uses defaultConntrackCriticalThreshold.
+	CriticalThreshold float64
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *ConntrackCheckerConfig) CheckAndSetDefaults() error {
+	if r.CountPath == "" {
+		r.CountPath = conntrackCountFile
+	}
+	if r.MaxPath == "" {
+		r.MaxPath = conntrackMaxFile
+	}
+	if r.WarnThreshold == 0 {
+		r.WarnThreshold = defaultConntrackWarnThreshold
+	}
+	if r.CriticalThreshold == 0 {
+		r.CriticalThreshold = defaultConntrackCriticalThreshold
+	}
+	if r.WarnThreshold <= 0 || r.WarnThreshold >= 1 {
+		return trace.BadParameter("conntrack warn threshold must be between 0 and 1, got %v", r.WarnThreshold)
+	}
+	if r.CriticalThreshold <= 0 || r.CriticalThreshold >= 1 {
+		return trace.BadParameter("conntrack critical threshold must be between 0 and 1, got %v", r.CriticalThreshold)
+	}
+	if r.WarnThreshold >= r.CriticalThreshold {
+		return trace.BadParameter("conntrack warn threshold (%v) must be lower than the critical threshold (%v)", r.WarnThreshold, r.CriticalThreshold)
+	}
+	return nil
+}
+
+// NewConntrackChecker returns a checker that reports when the host's
+// connection tracking table is close to full. kube-proxy relies on
+// conntrack for every Service connection - once nf_conntrack_count reaches
+// nf_conntrack_max, the kernel silently drops new connections instead of
+// rejecting them, which can look like packet loss rather than a resource
+// limit.
+func NewConntrackChecker(config ConntrackCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &conntrackChecker{ConntrackCheckerConfig: config}, nil
+}
+
+type conntrackChecker struct {
+	ConntrackCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *conntrackChecker) Name() string { return ConntrackCheckerID }
+
+// Check reads the conntrack table's current utilization and reports a probe
+// reflecting how close it is to nf_conntrack_max.
+func (r *conntrackChecker) Check(ctx context.Context, reporter health.Reporter) {
+	count, err := readConntrackValue(r.CountPath)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), fmt.Sprintf("failed to read conntrack count from %v", r.CountPath), err))
+		return
+	}
+	max, err := readConntrackValue(r.MaxPath)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), fmt.Sprintf("failed to read conntrack limit from %v", r.MaxPath), err))
+		return
+	}
+	if max == 0 {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), fmt.Sprintf("failed to read conntrack limit from %v", r.MaxPath), trace.BadParameter("limit is 0")))
+		return
+	}
+
+	utilization := float64(count) / float64(max)
+	detail := fmt.Sprintf("%v/%v conntrack entries in use (%.0f%%)", count, max, utilization*100)
+
+	switch {
+	case utilization >= r.CriticalThreshold:
+		reporter.Add(&pb.Probe{
+			Checker:  r.Name(),
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Critical,
+			Detail:   detail,
+			Error:    fmt.Sprintf("conntrack table is nearly full (%v), new connections may be silently dropped", detail),
+		})
+	case utilization >= r.WarnThreshold:
+		reporter.Add(&pb.Probe{
+			Checker:  r.Name(),
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Warning,
+			Detail:   detail,
+			Error:    fmt.Sprintf("conntrack table utilization is high (%v)", detail),
+		})
+	default:
+		reporter.Add(&pb.Probe{
+			Checker: r.Name(),
+			Status:  pb.Probe_Running,
+			Detail:  detail,
+		})
+	}
+}
+
+// readConntrackValue reads and parses a single integer value from one of
+// the nf_conntrack sysctl files.
+func readConntrackValue(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, trace.Wrap(err, "failed to parse %v", path)
+	}
+	return value, nil
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestConntrackCheckerPassesBelowWarnThreshold(t *testing.T) {
+	checker := newTestConntrackChecker(t, 100, 1000)
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("expected a single running probe, got %v", reporter)
+	}
+}
+
+func TestConntrackCheckerWarnsAboveWarnThreshold(t *testing.T) {
+	checker := newTestConntrackChecker(t, 850, 1000)
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a single failed probe, got %v", reporter)
+	}
+	if reporter[0].Severity != pb.Probe_Warning {
+		t.Fatalf("expected Warning severity, got %v", reporter[0].Severity)
+	}
+}
+
+func TestConntrackCheckerFailsAboveCriticalThreshold(t *testing.T) {
+	checker := newTestConntrackChecker(t, 960, 1000)
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a single failed probe, got %v", reporter)
+	}
+	if reporter[0].Severity != pb.Probe_Critical {
+		t.Fatalf("expected Critical severity, got %v", reporter[0].Severity)
+	}
+}
+
+func TestConntrackCheckerConfigRejectsInvalidThresholds(t *testing.T) {
+	config := ConntrackCheckerConfig{WarnThreshold: 0.9, CriticalThreshold: 0.5}
+	if err := config.CheckAndSetDefaults(); err == nil {
+		t.Fatal("expected an error when the warn threshold exceeds the critical threshold")
+	}
+}
+
+func newTestConntrackChecker(t *testing.T, count, max int) health.Checker {
+	dir := t.TempDir()
+	countPath := writeConntrackFile(t, dir, "count", count)
+	maxPath := writeConntrackFile(t, dir, "max", max)
+
+	checker, err := NewConntrackChecker(ConntrackCheckerConfig{CountPath: countPath, MaxPath: maxPath})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+	return checker
+}
+
+func writeConntrackFile(t *testing.T, dir, name string, value int) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(strconv.Itoa(value)+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write conntrack fixture: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,179 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// DockerStorageCheckerID identifies the checker that verifies docker's
+// storage driver and backing filesystem.
+const DockerStorageCheckerID = "docker-storage"
+
+// DefaultDockerStorageDriver is the storage driver DockerStorageChecker
+// expects, absent an override. Falling back to a different driver (e.g.
+// vfs, when the preferred one couldn't be used) is known to cause
+// performance and disk usage problems.
+const DefaultDockerStorageDriver = "overlay2"
+
+// dockerInfoTimeout bounds how long `docker info` may take.
+const dockerInfoTimeout = 10 * time.Second
+
+// DockerStorageCheckerConfig configures NewDockerStorageChecker.
+type DockerStorageCheckerConfig struct {
+	// ExpectedDriver is the storage driver DockerStorageChecker expects.
+	// Defaults to DefaultDockerStorageDriver ("overlay2").
+	ExpectedDriver string
+	// DockerPath is the docker CLI binary used to query `docker info`.
+	// Defaults to "docker".
+	DockerPath string
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *DockerStorageCheckerConfig) CheckAndSetDefaults() error {
+	if r.ExpectedDriver == "" {
+		r.ExpectedDriver = DefaultDockerStorageDriver
+	}
+	if r.DockerPath == "" {
+		r.DockerPath = "docker"
+	}
+	return nil
+}
+
+// NewDockerStorageChecker returns a checker that warns when docker isn't
+// using the expected storage driver, or when it's using overlay2 on a
+// backing filesystem that doesn't support the d_type feature overlay2
+// requires (a known cause of container and image corruption).
+func NewDockerStorageChecker(config DockerStorageCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &dockerStorageChecker{DockerStorageCheckerConfig: config}, nil
+}
+
+type dockerStorageChecker struct {
+	DockerStorageCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *dockerStorageChecker) Name() string { return DockerStorageCheckerID }
+
+// Check queries `docker info` and reports a warning if the storage driver
+// isn't the expected one, or if overlay2's backing filesystem doesn't
+// support d_type.
+func (r *dockerStorageChecker) Check(ctx context.Context, reporter health.Reporter) {
+	ctx, cancel := context.WithTimeout(ctx, dockerInfoTimeout)
+	defer cancel()
+
+	info, err := queryDockerInfo(ctx, r.DockerPath)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to query docker info", err))
+		return
+	}
+
+	detail := fmt.Sprintf("driver/%v", info.Driver)
+	if backingFS := info.backingFilesystem(); backingFS != "" {
+		detail = fmt.Sprintf("%v backing-fs/%v", detail, backingFS)
+	}
+
+	if info.Driver != r.ExpectedDriver {
+		reporter.Add(&pb.Probe{
+			Checker:  r.Name(),
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Warning,
+			Detail:   detail,
+			Error: fmt.Sprintf("docker is using storage driver %q instead of the expected %q, which can cause performance and disk usage issues",
+				info.Driver, r.ExpectedDriver),
+		})
+		return
+	}
+
+	if info.Driver == "overlay2" && !info.supportsDType() {
+		reporter.Add(&pb.Probe{
+			Checker:  r.Name(),
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Warning,
+			Detail:   detail,
+			Error: fmt.Sprintf("overlay2's backing filesystem (%v) does not support d_type, which overlay2 requires - expect container and image corruption",
+				info.backingFilesystem()),
+		})
+		return
+	}
+
+	reporter.Add(&pb.Probe{
+		Checker: r.Name(),
+		Status:  pb.Probe_Running,
+		Detail:  detail,
+	})
+}
+
+// dockerInfoResult is the subset of `docker info`'s JSON output this
+// checker uses.
+type dockerInfoResult struct {
+	Driver string `json:"Driver"`
+	// DriverStatus is a list of [key, value] pairs specific to the storage
+	// driver, e.g. ["Backing Filesystem", "extfs"], ["Supports d_type", "true"].
+	DriverStatus [][2]string `json:"DriverStatus"`
+}
+
+// driverStatus looks up key in DriverStatus.
+func (r dockerInfoResult) driverStatus(key string) (string, bool) {
+	for _, kv := range r.DriverStatus {
+		if kv[0] == key {
+			return kv[1], true
+		}
+	}
+	return "", false
+}
+
+// backingFilesystem returns the "Backing Filesystem" driver status value,
+// empty if the driver doesn't report one.
+func (r dockerInfoResult) backingFilesystem() string {
+	fs, _ := r.driverStatus("Backing Filesystem")
+	return fs
+}
+
+// supportsDType reports whether the driver's backing filesystem supports
+// d_type, assuming it does unless docker explicitly reports otherwise -
+// not every storage driver reports "Supports d_type" at all.
+func (r dockerInfoResult) supportsDType() bool {
+	value, ok := r.driverStatus("Supports d_type")
+	return !ok || value != "false"
+}
+
+// queryDockerInfo runs `docker info --format '{{json .}}'` and parses its
+// output.
+func queryDockerInfo(ctx context.Context, dockerPath string) (dockerInfoResult, error) {
+	out, err := exec.CommandContext(ctx, dockerPath, "info", "--format", "{{json .}}").CombinedOutput()
+	if err != nil {
+		return dockerInfoResult{}, trace.Wrap(err, "docker info: %v", string(out))
+	}
+	var result dockerInfoResult
+	if err := json.Unmarshal(out, &result); err != nil {
+		return dockerInfoResult{}, trace.Wrap(err, "failed to parse docker info output")
+	}
+	return result, nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import "testing"
+
+func TestDockerInfoResultBackingFilesystem(t *testing.T) {
+	result := dockerInfoResult{DriverStatus: [][2]string{{"Backing Filesystem", "extfs"}}}
+	if fs := result.backingFilesystem(); fs != "extfs" {
+		t.Fatalf("expected extfs, got %v", fs)
+	}
+
+	if fs := (dockerInfoResult{}).backingFilesystem(); fs != "" {
+		t.Fatalf("expected an empty string when unreported, got %v", fs)
+	}
+}
+
+func TestDockerInfoResultSupportsDType(t *testing.T) {
+	cases := []struct {
+		name   string
+		status [][2]string
+		want   bool
+	}{
+		{"explicitly supported", [][2]string{{"Supports d_type", "true"}}, true},
+		{"explicitly unsupported", [][2]string{{"Supports d_type", "false"}}, false},
+		{"unreported defaults to supported", nil, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result := dockerInfoResult{DriverStatus: c.status}
+			if got := result.supportsDType(); got != c.want {
+				t.Fatalf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestDockerStorageCheckerConfigDefaults(t *testing.T) {
+	var config DockerStorageCheckerConfig
+	if err := config.CheckAndSetDefaults(); err != nil {
+		t.Fatal(err)
+	}
+	if config.ExpectedDriver != DefaultDockerStorageDriver {
+		t.Fatalf("expected default driver %v, got %v", DefaultDockerStorageDriver, config.ExpectedDriver)
+	}
+	if config.DockerPath != "docker" {
+		t.Fatalf("expected default docker path 'docker', got %v", config.DockerPath)
+	}
+}
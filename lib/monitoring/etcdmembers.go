@@ -0,0 +1,154 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	etcdconf "github.com/gravitational/coordinate/v4/config"
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+)
+
+// EtcdMembersCheckerID identifies the checker that detects duplicate or
+// stuck etcd cluster members.
+const EtcdMembersCheckerID = "etcd-members"
+
+// etcdMembersCheckTimeout bounds how long the checker waits for etcd to
+// answer a member list request.
+const etcdMembersCheckTimeout = 10 * time.Second
+
+// EtcdMembersCheckerConfig configures NewEtcdMembersChecker.
+type EtcdMembersCheckerConfig struct {
+	// ETCDConfig is used to dial etcd, with the same TLS settings as
+	// "planet etcd status".
+	ETCDConfig etcdconf.Config
+}
+
+// CheckAndSetDefaults validates the configuration.
+func (r *EtcdMembersCheckerConfig) CheckAndSetDefaults() error {
+	if len(r.ETCDConfig.Endpoints) == 0 {
+		return trace.BadParameter("at least one etcd endpoint is required")
+	}
+	return nil
+}
+
+// NewEtcdMembersChecker returns a checker that lists the etcd cluster's
+// members and reports a failed probe if it finds members that a botched
+// upgrade or restore left behind: two or more members advertising the same
+// client URL, or a member that never finished joining the cluster (it has
+// no name until it does).
+func NewEtcdMembersChecker(config EtcdMembersCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &etcdMembersChecker{EtcdMembersCheckerConfig: config}, nil
+}
+
+type etcdMembersChecker struct {
+	EtcdMembersCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *etcdMembersChecker) Name() string { return EtcdMembersCheckerID }
+
+// Check lists the cluster's etcd members and reports a probe describing
+// any duplicate client URLs or unstarted members found, so operators know
+// exactly which member to remove with "etcdctl member remove".
+func (r *etcdMembersChecker) Check(ctx context.Context, reporter health.Reporter) {
+	client, err := r.ETCDConfig.NewClientV3()
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to create etcd client", trace.Wrap(err)))
+		return
+	}
+	defer client.Close()
+
+	listCtx, cancel := context.WithTimeout(ctx, etcdMembersCheckTimeout)
+	defer cancel()
+	resp, err := client.MemberList(listCtx)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to list etcd members", trace.Wrap(err)))
+		return
+	}
+
+	problems := findEtcdMemberProblems(resp.Members)
+	if len(problems) == 0 {
+		reporter.Add(&pb.Probe{
+			Checker: r.Name(),
+			Status:  pb.Probe_Running,
+		})
+		return
+	}
+
+	detail := strings.Join(problems, "; ")
+	reporter.Add(&pb.Probe{
+		Checker:  r.Name(),
+		Status:   pb.Probe_Failed,
+		Severity: pb.Probe_Critical,
+		Detail:   detail,
+		Error:    fmt.Sprintf("etcd cluster has members left behind by a botched upgrade or restore - remove them with etcdctl member remove: %v", detail),
+		Code:     string(CodeEtcdMembersInconsistent),
+	})
+}
+
+// findEtcdMemberProblems returns a human-readable description of each
+// duplicate client URL and unstarted (nameless) member found in members,
+// naming the offending member IDs and URLs.
+func findEtcdMemberProblems(members []*etcdserverpb.Member) []string {
+	var problems []string
+
+	byClientURL := make(map[string][]uint64)
+	for _, member := range members {
+		if member.Name == "" {
+			problems = append(problems, fmt.Sprintf("member %x has not finished joining the cluster (no name)", member.ID))
+			continue // an unstarted member has no client URLs to check for duplicates
+		}
+		for _, url := range member.ClientURLs {
+			byClientURL[url] = append(byClientURL[url], member.ID)
+		}
+	}
+
+	var duplicateURLs []string
+	for url, ids := range byClientURL {
+		if len(ids) > 1 {
+			duplicateURLs = append(duplicateURLs, url)
+		}
+	}
+	sort.Strings(duplicateURLs)
+	for _, url := range duplicateURLs {
+		problems = append(problems, fmt.Sprintf("client URL %v is advertised by multiple members: %v", url, formatMemberIDs(byClientURL[url])))
+	}
+
+	return problems
+}
+
+// formatMemberIDs hex-formats member IDs the same way "planet etcd status"
+// and etcdctl do.
+func formatMemberIDs(ids []uint64) []string {
+	formatted := make([]string, len(ids))
+	for i, id := range ids {
+		formatted[i] = fmt.Sprintf("%x", id)
+	}
+	return formatted
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"strings"
+	"testing"
+
+	"go.etcd.io/etcd/etcdserver/etcdserverpb"
+)
+
+func TestFindEtcdMemberProblemsReturnsNoneForHealthyCluster(t *testing.T) {
+	members := []*etcdserverpb.Member{
+		{ID: 1, Name: "node-1", ClientURLs: []string{"https://10.0.0.1:2379"}},
+		{ID: 2, Name: "node-2", ClientURLs: []string{"https://10.0.0.2:2379"}},
+	}
+	if problems := findEtcdMemberProblems(members); len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+}
+
+func TestFindEtcdMemberProblemsDetectsUnstartedMember(t *testing.T) {
+	members := []*etcdserverpb.Member{
+		{ID: 1, Name: "node-1", ClientURLs: []string{"https://10.0.0.1:2379"}},
+		{ID: 3},
+	}
+	problems := findEtcdMemberProblems(members)
+	if len(problems) != 1 {
+		t.Fatalf("expected one problem, got %v", problems)
+	}
+	if !strings.Contains(problems[0], "3") || !strings.Contains(problems[0], "has not finished joining") {
+		t.Fatalf("expected problem to name the unstarted member, got %q", problems[0])
+	}
+}
+
+func TestFindEtcdMemberProblemsDetectsDuplicateClientURL(t *testing.T) {
+	members := []*etcdserverpb.Member{
+		{ID: 1, Name: "node-1", ClientURLs: []string{"https://10.0.0.1:2379"}},
+		{ID: 2, Name: "node-1-stale", ClientURLs: []string{"https://10.0.0.1:2379"}},
+	}
+	problems := findEtcdMemberProblems(members)
+	if len(problems) != 1 {
+		t.Fatalf("expected one problem, got %v", problems)
+	}
+	if !strings.Contains(problems[0], "https://10.0.0.1:2379") {
+		t.Fatalf("expected problem to name the duplicated URL, got %q", problems[0])
+	}
+	if !strings.Contains(problems[0], "1") || !strings.Contains(problems[0], "2") {
+		t.Fatalf("expected problem to name both offending member IDs, got %q", problems[0])
+	}
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// EventCoalescerCheckerID identifies the checker that reports whether an
+// EventCoalescer has had to drop events because its queue was full.
+const EventCoalescerCheckerID = "event-coalescer"
+
+// eventCoalescerOverflows counts events an EventCoalescer had to drop
+// because its bounded queue was already full when the event arrived. A
+// flood of churn (e.g. a large cluster rebooting at once) shows up here
+// rather than as unbounded memory growth.
+var eventCoalescerOverflows = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "planet_event_coalescer_overflow_total",
+	Help: "Number of events dropped by an event coalescer because its bounded queue was full.",
+})
+
+func init() {
+	prometheus.MustRegister(eventCoalescerOverflows)
+}
+
+// EventCoalescerConfig configures a EventCoalescer.
+type EventCoalescerConfig struct {
+	// Window is how long to accumulate events for a given key before
+	// flushing. Events pushed for the same key inside a window collapse
+	// into a single flush of the most recently pushed one, so a burst of
+	// updates for the same key (e.g. a flapping node) costs one
+	// recomputation, not one per update.
+	Window time.Duration
+	// MaxPending bounds how many distinct keys can be queued awaiting
+	// flush at once. Once MaxPending is reached, an event for a key not
+	// already queued is dropped and counted as overflow instead of
+	// growing the queue further.
+	MaxPending int
+	// Flush is called with the most recently pushed event for a key once
+	// that key's window elapses.
+	Flush func(key string, event interface{})
+}
+
+// CheckAndSetDefaults validates the configuration.
+func (c *EventCoalescerConfig) CheckAndSetDefaults() error {
+	if c.Window <= 0 {
+		return trace.BadParameter("Window must be positive")
+	}
+	if c.MaxPending <= 0 {
+		return trace.BadParameter("MaxPending must be positive")
+	}
+	if c.Flush == nil {
+		return trace.BadParameter("Flush is required")
+	}
+	return nil
+}
+
+// NewEventCoalescer returns a new EventCoalescer.
+func NewEventCoalescer(config EventCoalescerConfig) (*EventCoalescer, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &EventCoalescer{
+		EventCoalescerConfig: config,
+		pending:              make(map[string]*pendingEvent),
+	}, nil
+}
+
+// EventCoalescer coalesces bursts of events for the same key into a single
+// flush, and bounds the number of distinct keys it will queue at once so a
+// flood of events for many different keys can't grow its memory use
+// without limit.
+type EventCoalescer struct {
+	EventCoalescerConfig
+
+	mu       sync.Mutex
+	pending  map[string]*pendingEvent
+	overflow uint64
+}
+
+type pendingEvent struct {
+	event interface{}
+	timer *time.Timer
+}
+
+// Push queues event under key. If key is already queued, event replaces
+// whatever was queued for it - only the last event pushed for a key within
+// a window is ever flushed. If key is not already queued and MaxPending
+// keys are already pending, event is dropped and counted as overflow.
+func (c *EventCoalescer) Push(key string, event interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p, ok := c.pending[key]; ok {
+		p.event = event
+		return
+	}
+	if len(c.pending) >= c.MaxPending {
+		c.overflow++
+		eventCoalescerOverflows.Inc()
+		return
+	}
+	p := &pendingEvent{event: event}
+	p.timer = time.AfterFunc(c.Window, func() { c.flush(key) })
+	c.pending[key] = p
+}
+
+// flush removes key from the pending set and calls Flush with its most
+// recently pushed event, if it is still queued - it may already have been
+// flushed and re-pushed by the time its timer fires.
+func (c *EventCoalescer) flush(key string) {
+	c.mu.Lock()
+	p, ok := c.pending[key]
+	if ok {
+		delete(c.pending, key)
+	}
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	c.Flush(key, p.event)
+}
+
+// Pending returns the number of distinct keys currently queued awaiting
+// flush.
+func (c *EventCoalescer) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+// Overflows returns the number of events dropped so far because the queue
+// was already at MaxPending when they arrived.
+func (c *EventCoalescer) Overflows() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.overflow
+}
+
+// NewEventCoalescerChecker returns a checker that reports a warning probe
+// naming how many events coalescer has had to drop because its bounded
+// queue filled up, so a checker overflowing under real churn shows up in
+// cluster status rather than only in the Prometheus counter.
+func NewEventCoalescerChecker(coalescer *EventCoalescer) health.Checker {
+	return &eventCoalescerChecker{coalescer: coalescer}
+}
+
+type eventCoalescerChecker struct {
+	coalescer *EventCoalescer
+}
+
+// Name returns this checker's name.
+func (r *eventCoalescerChecker) Name() string { return EventCoalescerCheckerID }
+
+// Check reports a warning probe if the coalescer has ever dropped an event,
+// naming how many.
+func (r *eventCoalescerChecker) Check(ctx context.Context, reporter health.Reporter) {
+	if overflows := r.coalescer.Overflows(); overflows > 0 {
+		reporter.Add(&pb.Probe{
+			Checker:  r.Name(),
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Warning,
+			Detail:   fmt.Sprintf("dropped %v events because the queue was full", overflows),
+		})
+		return
+	}
+	reporter.Add(&pb.Probe{Checker: r.Name(), Status: pb.Probe_Running})
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestEventCoalescerCoalescesBurstsWithinWindow pushes thousands of
+// synthetic membership events for a handful of keys within a single
+// window and asserts they collapse into one flush per key, carrying only
+// the last event pushed for it - simulating many nodes each reporting many
+// membership updates in a short burst, e.g. during a mass reboot.
+func TestEventCoalescerCoalescesBurstsWithinWindow(t *testing.T) {
+	const keys = 10
+	const eventsPerKey = 1000
+
+	var mu sync.Mutex
+	flushed := make(map[string]int)
+	var flushCount int
+
+	coalescer, err := NewEventCoalescer(EventCoalescerConfig{
+		Window:     20 * time.Millisecond,
+		MaxPending: keys,
+		Flush: func(key string, event interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			flushCount++
+			flushed[key] = event.(int)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewEventCoalescer: %v", err)
+	}
+
+	for i := 0; i < eventsPerKey; i++ {
+		for k := 0; k < keys; k++ {
+			coalescer.Push(fmt.Sprintf("node-%v", k), i)
+		}
+	}
+
+	if pending := coalescer.Pending(); pending != keys {
+		t.Fatalf("expected %v keys pending before the window elapses, got %v", keys, pending)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if flushCount != keys {
+		t.Fatalf("expected exactly %v flushes (one per key), got %v", keys, flushCount)
+	}
+	for k := 0; k < keys; k++ {
+		key := fmt.Sprintf("node-%v", k)
+		if flushed[key] != eventsPerKey-1 {
+			t.Errorf("expected %v's flushed event to be the last one pushed (%v), got %v", key, eventsPerKey-1, flushed[key])
+		}
+	}
+	if overflows := coalescer.Overflows(); overflows != 0 {
+		t.Errorf("expected no overflow when pushes stay within MaxPending keys, got %v", overflows)
+	}
+	if pending := coalescer.Pending(); pending != 0 {
+		t.Errorf("expected no keys left pending after their windows elapsed, got %v", pending)
+	}
+}
+
+// TestEventCoalescerBoundsQueueUnderOverflow pushes events for far more
+// distinct keys than MaxPending allows, before any of them can flush, and
+// asserts the queue never grows past MaxPending - the rest are dropped and
+// counted rather than accumulated.
+func TestEventCoalescerBoundsQueueUnderOverflow(t *testing.T) {
+	const maxPending = 5
+	const distinctKeys = 5000
+
+	coalescer, err := NewEventCoalescer(EventCoalescerConfig{
+		Window:     time.Hour, // long enough that nothing flushes during the test
+		MaxPending: maxPending,
+		Flush:      func(key string, event interface{}) {},
+	})
+	if err != nil {
+		t.Fatalf("NewEventCoalescer: %v", err)
+	}
+
+	for i := 0; i < distinctKeys; i++ {
+		coalescer.Push(fmt.Sprintf("node-%v", i), i)
+		if pending := coalescer.Pending(); pending > maxPending {
+			t.Fatalf("queue grew to %v pending keys, want at most %v", pending, maxPending)
+		}
+	}
+
+	if pending := coalescer.Pending(); pending != maxPending {
+		t.Fatalf("expected the queue to fill up to MaxPending (%v), got %v", maxPending, pending)
+	}
+	if overflows := coalescer.Overflows(); overflows != distinctKeys-maxPending {
+		t.Fatalf("expected %v dropped events, got %v", distinctKeys-maxPending, overflows)
+	}
+}
@@ -0,0 +1,291 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+)
+
+// DefaultExecCheckerDir is where planet looks for external checker
+// drop-ins by default.
+const DefaultExecCheckerDir = "/etc/planet/checks.d"
+
+// defaultExecCheckerTimeout bounds how long a single drop-in checker may
+// run before it's killed and reported as failed.
+const defaultExecCheckerTimeout = 30 * time.Second
+
+// execCheckerJSONHeader is the first line a drop-in checker's stdout must
+// contain to switch from the plain-text contract (exit code and stdout
+// only) to the JSON contract (multiple probes, settable severity).
+const execCheckerJSONHeader = "#planet:probes-json"
+
+// DiscoverExecCheckers scans dir for executable files and returns one
+// checker per file found, named after the file's name with its extension
+// stripped (e.g. "check-dns.sh" becomes "check-dns"). dir not
+// existing is not an error, since the drop-in directory is optional; it
+// simply yields no checkers.
+//
+// A drop-in may declare which subsystem its probes should be grouped
+// under (see CheckerSubsystem) by shipping a sibling file named
+// "<name>.subsystem" containing the subsystem's name, e.g. "network".
+// Omitting it leaves the checker in the default SubsystemSystem group.
+func DiscoverExecCheckers(dir string, timeout time.Duration) ([]health.Checker, error) {
+	if timeout <= 0 {
+		timeout = defaultExecCheckerTimeout
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if trace.IsNotFound(trace.ConvertSystemError(err)) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	var checkers []health.Checker
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Mode()&0111 == 0 {
+			continue
+		}
+		name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		checkers = append(checkers, NewExecChecker(ExecCheckerConfig{
+			Name:      name,
+			Path:      filepath.Join(dir, entry.Name()),
+			Timeout:   timeout,
+			Subsystem: readExecCheckerSubsystem(dir, name),
+		}))
+	}
+	return checkers, nil
+}
+
+// readExecCheckerSubsystem reads the subsystem a drop-in checker declares
+// for itself from a sibling "<name>.subsystem" file in dir, trimmed of
+// surrounding whitespace. The file is optional - a missing one simply
+// leaves Subsystem unset, and the checker falls back to SubsystemSystem
+// like any other unregistered checker.
+func readExecCheckerSubsystem(dir, name string) Subsystem {
+	contents, err := ioutil.ReadFile(filepath.Join(dir, name+".subsystem"))
+	if err != nil {
+		return ""
+	}
+	return Subsystem(strings.TrimSpace(string(contents)))
+}
+
+// ExecCheckerConfig configures NewExecChecker.
+type ExecCheckerConfig struct {
+	// Name identifies the checker, and is reported as the Checker field of
+	// every probe it produces.
+	Name string
+	// Path is the executable to run on each check.
+	Path string
+	// Timeout bounds how long Path may run before it's killed and the
+	// check reported as failed. Defaults to defaultExecCheckerTimeout.
+	Timeout time.Duration
+	// Subsystem declares which subsystem this checker's probes should be
+	// grouped under in the status rollup (see CheckerSubsystem). Empty
+	// leaves the checker unregistered, falling back to SubsystemSystem.
+	Subsystem Subsystem
+}
+
+func (r *ExecCheckerConfig) checkAndSetDefaults() {
+	if r.Timeout <= 0 {
+		r.Timeout = defaultExecCheckerTimeout
+	}
+}
+
+// NewExecChecker returns a checker that runs an external executable and
+// translates its result into one or more probes.
+//
+// In the plain-text contract, exit code 0 reports a Running probe with
+// stdout (trimmed) as the Detail, and a non-zero exit code reports a
+// Failed probe with the critical severity, stdout as Detail and stderr as
+// the error message.
+//
+// If stdout's first line is execCheckerJSONHeader, the remainder is
+// parsed as a JSON array of probe descriptions instead, each of the form
+// {"name": "...", "status": "running"|"failed", "severity":
+// "warning"|"critical", "detail": "...", "error": "..."}, letting a
+// single script report multiple probes with independent severities. Name
+// defaults to the checker's own name when omitted.
+func NewExecChecker(config ExecCheckerConfig) health.Checker {
+	config.checkAndSetDefaults()
+	if config.Subsystem != "" {
+		RegisterCheckerSubsystem(config.Name, config.Subsystem)
+	}
+	return &execChecker{ExecCheckerConfig: config}
+}
+
+type execChecker struct {
+	ExecCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *execChecker) Name() string { return r.ExecCheckerConfig.Name }
+
+// Check runs the configured executable under a timeout and reports its
+// result. A script that misbehaves - hangs, exits nonzero, prints
+// malformed JSON - only ever produces a Failed probe for this checker; it
+// never returns an error that could interrupt the checkers running
+// alongside it.
+func (r *execChecker) Check(ctx context.Context, reporter health.Reporter) {
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(r.Path)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Run the checker in its own process group so a timeout can kill it
+	// and any children it spawned in one shot. exec.CommandContext only
+	// ever kills the direct child; a script that forks a long-running
+	// grandchild before exiting would otherwise keep the output pipe open
+	// and hang Wait() until that grandchild exits on its own.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	if err := cmd.Start(); err != nil {
+		reporter.Add(&pb.Probe{
+			Checker:  r.Name(),
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Critical,
+			Error:    fmt.Sprintf("failed to start checker %v: %v", r.Path, err),
+		})
+		return
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var runErr error
+	select {
+	case runErr = <-done:
+	case <-ctx.Done():
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-done
+		reporter.Add(&pb.Probe{
+			Checker:  r.Name(),
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Critical,
+			Error:    fmt.Sprintf("checker %v timed out after %v", r.Path, r.Timeout),
+		})
+		return
+	}
+
+	if probes, ok := parseExecCheckerJSON(r.Name(), stdout.String()); ok {
+		for _, probe := range probes {
+			reporter.Add(probe)
+		}
+		return
+	}
+
+	if runErr != nil {
+		reporter.Add(&pb.Probe{
+			Checker:  r.Name(),
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Critical,
+			Detail:   strings.TrimSpace(stdout.String()),
+			Error:    fmt.Sprintf("%v: %v", runErr, strings.TrimSpace(stderr.String())),
+		})
+		return
+	}
+
+	reporter.Add(&pb.Probe{
+		Checker: r.Name(),
+		Status:  pb.Probe_Running,
+		Detail:  strings.TrimSpace(stdout.String()),
+	})
+}
+
+// execProbeResult is the JSON shape a drop-in checker prints one-per-array
+// element under the JSON output contract.
+type execProbeResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Severity string `json:"severity"`
+	Detail   string `json:"detail"`
+	Error    string `json:"error"`
+}
+
+// parseExecCheckerJSON parses output under the JSON output contract,
+// reporting ok=false when output doesn't start with execCheckerJSONHeader
+// (i.e. the script is using the plain-text contract instead). A header
+// present but followed by unparseable JSON is reported as a single failed
+// probe rather than falling back to the plain-text contract, since that
+// would silently misreport a script bug as a passing check.
+func parseExecCheckerJSON(name, output string) (probes []*pb.Probe, ok bool) {
+	line, rest := splitFirstLine(output)
+	if strings.TrimSpace(line) != execCheckerJSONHeader {
+		return nil, false
+	}
+
+	var results []execProbeResult
+	if err := json.Unmarshal([]byte(rest), &results); err != nil {
+		return []*pb.Probe{{
+			Checker:  name,
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Critical,
+			Error:    fmt.Sprintf("failed to parse JSON probe output: %v", err),
+		}}, true
+	}
+
+	for _, result := range results {
+		probes = append(probes, result.toProbe(name))
+	}
+	return probes, true
+}
+
+func (r execProbeResult) toProbe(defaultName string) *pb.Probe {
+	name := r.Name
+	if name == "" {
+		name = defaultName
+	}
+	probe := &pb.Probe{
+		Checker:  name,
+		Status:   pb.Probe_Running,
+		Severity: pb.Probe_Warning,
+		Detail:   r.Detail,
+		Error:    r.Error,
+	}
+	if strings.EqualFold(r.Status, "failed") {
+		probe.Status = pb.Probe_Failed
+	}
+	if strings.EqualFold(r.Severity, "critical") {
+		probe.Severity = pb.Probe_Critical
+	}
+	if probe.Status == pb.Probe_Running {
+		probe.Severity = pb.Probe_None
+	}
+	return probe
+}
+
+// splitFirstLine splits s into its first line and the remainder.
+func splitFirstLine(s string) (line, rest string) {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestExecCheckerReportsRunningOnSuccess(t *testing.T) {
+	path := writeExecCheckerScript(t, "#!/bin/sh\necho all good\nexit 0\n")
+	checker := NewExecChecker(ExecCheckerConfig{Name: "custom", Path: path})
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("expected a running probe, got %v", reporter)
+	}
+	if reporter[0].Detail != "all good" {
+		t.Fatalf("expected stdout as detail, got %q", reporter[0].Detail)
+	}
+}
+
+func TestExecCheckerReportsFailedOnNonZeroExit(t *testing.T) {
+	path := writeExecCheckerScript(t, "#!/bin/sh\necho broken >&2\nexit 1\n")
+	checker := NewExecChecker(ExecCheckerConfig{Name: "custom", Path: path})
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed || reporter[0].Severity != pb.Probe_Critical {
+		t.Fatalf("expected a critical failed probe, got %v", reporter)
+	}
+}
+
+func TestExecCheckerKillsHungScript(t *testing.T) {
+	path := writeExecCheckerScript(t, "#!/bin/sh\nsleep 5\n")
+	checker := NewExecChecker(ExecCheckerConfig{Name: "custom", Path: path, Timeout: 50 * time.Millisecond})
+
+	var reporter health.Probes
+	start := time.Now()
+	checker.Check(context.Background(), &reporter)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected the hung script to be killed quickly, took %v", elapsed)
+	}
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a failed probe for the timed out script, got %v", reporter)
+	}
+}
+
+func TestExecCheckerJSONContract(t *testing.T) {
+	path := writeExecCheckerScript(t, "#!/bin/sh\n"+
+		"echo '"+execCheckerJSONHeader+"'\n"+
+		`echo '[{"name":"disk","status":"running"},{"name":"license","status":"failed","severity":"warning","detail":"expires soon"}]'`+"\n")
+	checker := NewExecChecker(ExecCheckerConfig{Name: "custom", Path: path})
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 2 {
+		t.Fatalf("expected two probes, got %v", reporter)
+	}
+	if reporter[0].Checker != "disk" || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("unexpected first probe: %v", reporter[0])
+	}
+	if reporter[1].Checker != "license" || reporter[1].Status != pb.Probe_Failed || reporter[1].Severity != pb.Probe_Warning {
+		t.Fatalf("unexpected second probe: %v", reporter[1])
+	}
+}
+
+func TestExecCheckerJSONContractMalformed(t *testing.T) {
+	path := writeExecCheckerScript(t, "#!/bin/sh\necho '"+execCheckerJSONHeader+"'\necho 'not json'\n")
+	checker := NewExecChecker(ExecCheckerConfig{Name: "custom", Path: path})
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected malformed JSON output to report a single failed probe, got %v", reporter)
+	}
+}
+
+func TestDiscoverExecCheckersSkipsNonExecutableFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeExecCheckerFile(t, filepath.Join(dir, "check-one.sh"), "#!/bin/sh\nexit 0\n", 0755)
+	writeExecCheckerFile(t, filepath.Join(dir, "readme.txt"), "not executable", 0644)
+
+	checkers, err := DiscoverExecCheckers(dir, time.Second)
+	if err != nil {
+		t.Fatalf("DiscoverExecCheckers failed: %v", err)
+	}
+	if len(checkers) != 1 || checkers[0].Name() != "check-one" {
+		t.Fatalf("expected a single checker named after the executable, got %v", checkers)
+	}
+}
+
+func TestDiscoverExecCheckersReadsDeclaredSubsystem(t *testing.T) {
+	dir := t.TempDir()
+	writeExecCheckerFile(t, filepath.Join(dir, "check-dns.sh"), "#!/bin/sh\nexit 0\n", 0755)
+	writeExecCheckerFile(t, filepath.Join(dir, "check-dns.subsystem"), "network\n", 0644)
+
+	checkers, err := DiscoverExecCheckers(dir, time.Second)
+	if err != nil {
+		t.Fatalf("DiscoverExecCheckers failed: %v", err)
+	}
+	if len(checkers) != 1 {
+		t.Fatalf("expected a single checker, got %v", checkers)
+	}
+	if subsystem := CheckerSubsystem(checkers[0].Name()); subsystem != SubsystemNetwork {
+		t.Fatalf("expected the drop-in's declared subsystem to be registered, got %v", subsystem)
+	}
+}
+
+func TestDiscoverExecCheckersIgnoresMissingDir(t *testing.T) {
+	checkers, err := DiscoverExecCheckers(filepath.Join(t.TempDir(), "missing"), time.Second)
+	if err != nil {
+		t.Fatalf("expected a missing drop-in directory to not be an error, got %v", err)
+	}
+	if len(checkers) != 0 {
+		t.Fatalf("expected no checkers, got %v", checkers)
+	}
+}
+
+func writeExecCheckerScript(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "check.sh")
+	writeExecCheckerFile(t, path, script, 0755)
+	return path
+}
+
+func writeExecCheckerFile(t *testing.T, path, content string, mode os.FileMode) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), mode); err != nil {
+		t.Fatalf("failed to write %v: %v", path, err)
+	}
+}
@@ -0,0 +1,285 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// IPTablesCheckerID identifies the checker that verifies the iptables rules
+// kube-proxy and flannel depend on for service VIPs and pod connectivity
+// are in place.
+const IPTablesCheckerID = "iptables"
+
+// masqueradeComment marks the pod subnet masquerade rule planet installs,
+// so it can be told apart from rules owned by kube-proxy or the operator.
+const masqueradeComment = "planet flannel masquerade"
+
+// forwardComment marks the FORWARD accept rules planet installs.
+const forwardComment = "planet flannel forward"
+
+// IPTablesCheckerConfig configures NewIPTablesChecker.
+type IPTablesCheckerConfig struct {
+	// PodSubnet is the cluster's pod subnet CIDR.
+	PodSubnet net.IPNet
+	// ServiceSubnet is the cluster's service subnet CIDR.
+	ServiceSubnet net.IPNet
+	// Repair enables automatically recreating the masquerade and FORWARD
+	// rules planet owns when they're found missing. Rules managed by
+	// kube-proxy (the KUBE-SERVICES jumps) are only ever reported, never
+	// restored, since kube-proxy will re-add them on its own sync loop.
+	Repair bool
+}
+
+// CheckAndSetDefaults validates the configuration.
+func (r *IPTablesCheckerConfig) CheckAndSetDefaults() error {
+	if len(r.PodSubnet.IP) == 0 {
+		return trace.BadParameter("PodSubnet is required")
+	}
+	if len(r.ServiceSubnet.IP) == 0 {
+		return trace.BadParameter("ServiceSubnet is required")
+	}
+	return nil
+}
+
+// NewIPTablesChecker returns a checker that verifies the presence of the
+// iptables chains/rules kube-proxy and flannel require for service VIPs
+// and pod connectivity to work, since a manual `iptables -F` silently
+// breaks both without failing any other health check.
+func NewIPTablesChecker(config IPTablesCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &iptablesChecker{IPTablesCheckerConfig: config}, nil
+}
+
+type iptablesChecker struct {
+	IPTablesCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *iptablesChecker) Name() string { return IPTablesCheckerID }
+
+// Check dumps the local iptables rules and reports a probe per rule that's
+// expected but missing. If Repair is enabled, it also recreates the rules
+// planet owns (not the kube-proxy-managed KUBE-SERVICES jumps).
+func (r *iptablesChecker) Check(ctx context.Context, reporter health.Reporter) {
+	output, err := iptablesSave(ctx)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to dump iptables rules", trace.Wrap(err)))
+		return
+	}
+
+	rules := parseIPTablesSave(output)
+	checks := iptablesRuleChecks(r.PodSubnet, r.ServiceSubnet)
+	var missing []iptablesRuleCheck
+	for _, check := range checks {
+		if !check.present(rules) {
+			missing = append(missing, check)
+		}
+	}
+
+	if len(missing) == 0 {
+		reporter.Add(monitoring.NewSuccessProbe(r.Name()))
+		return
+	}
+
+	for _, check := range missing {
+		reporter.Add(&pb.Probe{
+			Checker: r.Name(),
+			Detail:  fmt.Sprintf("missing rule: %v", check.description),
+			Status:  pb.Probe_Failed,
+		})
+	}
+
+	if !r.Repair {
+		return
+	}
+	for _, check := range missing {
+		if len(check.repairArgs) == 0 {
+			continue
+		}
+		logger := log.WithField("rule", check.id)
+		if err := runIPTables(ctx, check.repairArgs); err != nil {
+			logger.WithError(err).Warn("Failed to restore iptables rule.")
+			continue
+		}
+		logger.Info("Restored iptables rule.")
+	}
+}
+
+// iptablesRuleCheck describes a single iptables rule this checker verifies
+// is present.
+type iptablesRuleCheck struct {
+	// id identifies this check in logs.
+	id string
+	// description is included in the probe reported when this rule is missing.
+	description string
+	// repairArgs are the `iptables` arguments that recreate this rule. Left
+	// nil for rules owned by kube-proxy, which this checker only reports on
+	// and never modifies.
+	repairArgs []string
+	// present reports whether this rule is found among rules, the output of
+	// parseIPTablesSave.
+	present func(rules []string) bool
+}
+
+// iptablesRuleChecks returns the rule checks this checker performs for the
+// given pod/service subnets.
+func iptablesRuleChecks(podSubnet, serviceSubnet net.IPNet) []iptablesRuleCheck {
+	return []iptablesRuleCheck{
+		{
+			id:          "nat-prerouting-kube-services",
+			description: "KUBE-SERVICES jump missing from nat PREROUTING chain (kube-proxy managed)",
+			present:     hasJump("PREROUTING", "KUBE-SERVICES"),
+		},
+		{
+			id:          "nat-output-kube-services",
+			description: "KUBE-SERVICES jump missing from nat OUTPUT chain (kube-proxy managed)",
+			present:     hasJump("OUTPUT", "KUBE-SERVICES"),
+		},
+		{
+			id:          "nat-postrouting-masquerade",
+			description: fmt.Sprintf("masquerade rule for pod subnet %v missing from nat POSTROUTING chain", podSubnet.String()),
+			repairArgs: []string{
+				"-t", "nat", "-A", "POSTROUTING",
+				"-s", podSubnet.String(), "!", "-o", "docker0",
+				"-m", "comment", "--comment", masqueradeComment,
+				"-j", "MASQUERADE",
+			},
+			present: hasMasquerade(podSubnet),
+		},
+		{
+			id:          "filter-forward-pods",
+			description: fmt.Sprintf("FORWARD chain does not accept traffic for pod subnet %v", podSubnet.String()),
+			repairArgs: []string{
+				"-I", "FORWARD",
+				"-s", podSubnet.String(),
+				"-m", "comment", "--comment", forwardComment,
+				"-j", "ACCEPT",
+			},
+			present: hasForwardAccept(podSubnet),
+		},
+		{
+			id:          "filter-forward-services",
+			description: fmt.Sprintf("FORWARD chain does not accept traffic for service subnet %v", serviceSubnet.String()),
+			repairArgs: []string{
+				"-I", "FORWARD",
+				"-d", serviceSubnet.String(),
+				"-m", "comment", "--comment", forwardComment,
+				"-j", "ACCEPT",
+			},
+			present: hasForwardAccept(serviceSubnet),
+		},
+	}
+}
+
+// hasJump returns a predicate that reports whether rules contains a rule
+// appending to chain that jumps to target.
+func hasJump(chain, target string) func(rules []string) bool {
+	return func(rules []string) bool {
+		prefix := fmt.Sprintf("-A %v ", chain)
+		suffix := fmt.Sprintf("-j %v", target)
+		for _, rule := range rules {
+			if strings.HasPrefix(rule, prefix) && strings.Contains(rule, suffix) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// hasMasquerade returns a predicate that reports whether rules contains a
+// MASQUERADE rule sourced from subnet.
+func hasMasquerade(subnet net.IPNet) func(rules []string) bool {
+	return func(rules []string) bool {
+		src := fmt.Sprintf("-s %v ", subnet.String())
+		for _, rule := range rules {
+			if strings.HasPrefix(rule, "-A POSTROUTING ") && strings.Contains(rule, src) && strings.Contains(rule, "-j MASQUERADE") {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// hasForwardAccept returns a predicate that reports whether the FORWARD
+// chain either defaults to ACCEPT or explicitly accepts traffic to/from
+// subnet.
+func hasForwardAccept(subnet net.IPNet) func(rules []string) bool {
+	return func(rules []string) bool {
+		for _, rule := range rules {
+			if rule == ":FORWARD ACCEPT" || strings.HasPrefix(rule, ":FORWARD ACCEPT ") {
+				return true
+			}
+		}
+		src := fmt.Sprintf("-s %v ", subnet.String())
+		dst := fmt.Sprintf("-d %v ", subnet.String())
+		for _, rule := range rules {
+			if !strings.HasPrefix(rule, "-A FORWARD ") || !strings.Contains(rule, "-j ACCEPT") {
+				continue
+			}
+			if strings.Contains(rule, src) || strings.Contains(rule, dst) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// parseIPTablesSave splits the output of `iptables-save` into the lines
+// relevant to this checker: chain policy declarations (":CHAIN POLICY ...")
+// and appended rules ("-A CHAIN ...").
+func parseIPTablesSave(output string) []string {
+	var rules []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, ":") || strings.HasPrefix(line, "-A ") {
+			rules = append(rules, line)
+		}
+	}
+	return rules
+}
+
+// iptablesSave runs `iptables-save` and returns its output.
+func iptablesSave(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "iptables-save").CombinedOutput()
+	if err != nil {
+		return "", trace.Wrap(err, "iptables-save: %v", string(out))
+	}
+	return string(out), nil
+}
+
+// runIPTables invokes `iptables` with args.
+func runIPTables(ctx context.Context, args []string) error {
+	out, err := exec.CommandContext(ctx, "iptables", args...).CombinedOutput()
+	if err != nil {
+		return trace.Wrap(err, "iptables %v: %v", strings.Join(args, " "), string(out))
+	}
+	return nil
+}
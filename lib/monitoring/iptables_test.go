@@ -0,0 +1,125 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"net"
+	"testing"
+)
+
+func mustParseCIDR(t *testing.T, s string) net.IPNet {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		t.Fatalf("failed to parse CIDR %v: %v", s, err)
+	}
+	return *ipNet
+}
+
+func TestIPTablesRuleChecksAllPresent(t *testing.T) {
+	podSubnet := mustParseCIDR(t, "10.244.0.0/16")
+	serviceSubnet := mustParseCIDR(t, "10.100.0.0/16")
+
+	rules := parseIPTablesSave(`
+*nat
+:PREROUTING ACCEPT
+:OUTPUT ACCEPT
+:POSTROUTING ACCEPT
+-A PREROUTING -m comment --comment "kubernetes service portals" -j KUBE-SERVICES
+-A OUTPUT -m comment --comment "kubernetes service portals" -j KUBE-SERVICES
+-A POSTROUTING -s 10.244.0.0/16 ! -o docker0 -m comment --comment "planet flannel masquerade" -j MASQUERADE
+COMMIT
+*filter
+:FORWARD DROP
+-A FORWARD -s 10.244.0.0/16 -m comment --comment "planet flannel forward" -j ACCEPT
+-A FORWARD -d 10.100.0.0/16 -m comment --comment "planet flannel forward" -j ACCEPT
+COMMIT
+`)
+
+	for _, check := range iptablesRuleChecks(podSubnet, serviceSubnet) {
+		if !check.present(rules) {
+			t.Errorf("expected rule %v to be reported present", check.id)
+		}
+	}
+}
+
+func TestIPTablesRuleChecksForwardAcceptPolicy(t *testing.T) {
+	podSubnet := mustParseCIDR(t, "10.244.0.0/16")
+	serviceSubnet := mustParseCIDR(t, "10.100.0.0/16")
+
+	// A FORWARD chain that defaults to ACCEPT satisfies the forward checks
+	// even without explicit accept rules for the pod/service subnets.
+	rules := parseIPTablesSave(`
+*filter
+:FORWARD ACCEPT
+COMMIT
+`)
+
+	for _, id := range []string{"filter-forward-pods", "filter-forward-services"} {
+		found := false
+		for _, check := range iptablesRuleChecks(podSubnet, serviceSubnet) {
+			if check.id != id {
+				continue
+			}
+			found = true
+			if !check.present(rules) {
+				t.Errorf("expected rule %v to be satisfied by a default-accept FORWARD policy", id)
+			}
+		}
+		if !found {
+			t.Fatalf("no rule check with id %v", id)
+		}
+	}
+}
+
+func TestIPTablesRuleChecksMissing(t *testing.T) {
+	podSubnet := mustParseCIDR(t, "10.244.0.0/16")
+	serviceSubnet := mustParseCIDR(t, "10.100.0.0/16")
+
+	// Rules flushed entirely - nothing is present.
+	rules := parseIPTablesSave(`
+*nat
+:PREROUTING ACCEPT
+:OUTPUT ACCEPT
+:POSTROUTING ACCEPT
+COMMIT
+*filter
+:FORWARD DROP
+COMMIT
+`)
+
+	var missing []string
+	for _, check := range iptablesRuleChecks(podSubnet, serviceSubnet) {
+		if !check.present(rules) {
+			missing = append(missing, check.id)
+		}
+	}
+	if len(missing) != 5 {
+		t.Fatalf("expected all 5 rule checks to be missing, got %v", missing)
+	}
+
+	// The KUBE-SERVICES jumps are kube-proxy managed and must not be
+	// repaired by planet.
+	for _, check := range iptablesRuleChecks(podSubnet, serviceSubnet) {
+		if check.id == "nat-prerouting-kube-services" || check.id == "nat-output-kube-services" {
+			if len(check.repairArgs) != 0 {
+				t.Errorf("expected %v to have no repair args, got %v", check.id, check.repairArgs)
+			}
+		} else if len(check.repairArgs) == 0 {
+			t.Errorf("expected %v to have repair args", check.id)
+		}
+	}
+}
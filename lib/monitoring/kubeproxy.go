@@ -0,0 +1,91 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// KubeProxyCheckerID identifies the checker that verifies kube-proxy's
+// healthz endpoint.
+const KubeProxyCheckerID = "kube-proxy"
+
+// DefaultKubeProxyHealthzPort is the default kube-proxy healthz endpoint port.
+const DefaultKubeProxyHealthzPort = 10256
+
+// DefaultKubeProxyHealthzAddr is the default kube-proxy healthz address on localhost.
+var DefaultKubeProxyHealthzAddr = fmt.Sprintf("http://127.0.0.1:%d/healthz", DefaultKubeProxyHealthzPort)
+
+// KubeProxyHealthzCheckerConfig configures NewKubeProxyHealthzChecker.
+type KubeProxyHealthzCheckerConfig struct {
+	// Addr is the kube-proxy healthz endpoint address. Defaults to
+	// DefaultKubeProxyHealthzAddr.
+	Addr string
+	// Disabled skips the HTTP probe and reports an informational probe
+	// instead, for deployments that intentionally run without kube-proxy
+	// (e.g. proxyless CNI setups).
+	Disabled bool
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *KubeProxyHealthzCheckerConfig) CheckAndSetDefaults() error {
+	if r.Addr == "" {
+		r.Addr = DefaultKubeProxyHealthzAddr
+	}
+	return nil
+}
+
+// NewKubeProxyHealthzChecker returns a checker that verifies kube-proxy's
+// healthz endpoint is reachable and returning success, so a wedged
+// kube-proxy is caught the same way other control plane components are. If
+// config.Disabled is set, the returned checker reports an informational
+// probe instead of probing the endpoint, rather than failing on a
+// deployment that doesn't run kube-proxy at all.
+func NewKubeProxyHealthzChecker(config KubeProxyHealthzCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	if config.Disabled {
+		return &disabledChecker{name: KubeProxyCheckerID}, nil
+	}
+	return monitoring.NewHTTPHealthzChecker(KubeProxyCheckerID, config.Addr, noopResponseChecker), nil
+}
+
+// disabledChecker reports an informational probe rather than running any
+// real check, for a checker that's been intentionally turned off by
+// configuration - so its absence shows up as a note rather than a failure.
+type disabledChecker struct {
+	name string
+}
+
+// Name returns the name of this checker.
+func (r *disabledChecker) Name() string { return r.name }
+
+// Check reports an informational probe without performing any check.
+func (r *disabledChecker) Check(ctx context.Context, reporter health.Reporter) {
+	reporter.Add(&pb.Probe{
+		Checker: r.name,
+		Status:  pb.Probe_Running,
+		Detail:  "check disabled by configuration",
+	})
+}
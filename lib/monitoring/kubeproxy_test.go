@@ -0,0 +1,52 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestKubeProxyHealthzCheckerUsesDefaultAddr(t *testing.T) {
+	checker, err := NewKubeProxyHealthzChecker(KubeProxyHealthzCheckerConfig{})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+	if checker.Name() != KubeProxyCheckerID {
+		t.Errorf("expected checker name %v, got %v", KubeProxyCheckerID, checker.Name())
+	}
+}
+
+func TestKubeProxyHealthzCheckerDisabledReportsInfoProbe(t *testing.T) {
+	checker, err := NewKubeProxyHealthzChecker(KubeProxyHealthzCheckerConfig{Disabled: true})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var probes health.Probes
+	checker.Check(context.Background(), &probes)
+
+	if len(probes) != 1 {
+		t.Fatalf("expected exactly one probe, got %v", probes)
+	}
+	if probes[0].Status != pb.Probe_Running {
+		t.Errorf("expected a disabled check to report success, got %v", probes[0])
+	}
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KubeProxyRulesCheckerID identifies the checker that verifies kube-proxy is
+// actually programming the dataplane, rather than merely reporting healthy
+// on its healthz endpoint.
+const KubeProxyRulesCheckerID = "kube-proxy-rules"
+
+// DefaultMinRuleRatio is the minimum acceptable ratio of programmed
+// dataplane service rules to services with a ClusterIP, below which the
+// checker reports the dataplane Degraded.
+const DefaultMinRuleRatio = 0.5
+
+// procNetIPVS is the kernel's live view of programmed ipvs virtual services.
+const procNetIPVS = "/proc/net/ip_vs"
+
+// KubeProxyRulesCheckerConfig configures NewKubeProxyRulesChecker.
+type KubeProxyRulesCheckerConfig struct {
+	// KubeConfig provides Kubernetes access.
+	monitoring.KubeConfig
+	// MinRuleRatio is the minimum acceptable ratio of programmed dataplane
+	// service rules to services with a ClusterIP. Defaults to DefaultMinRuleRatio.
+	MinRuleRatio float64
+	// countRules returns the number of service rules currently programmed
+	// in the dataplane (ipvs services, or iptables KUBE-SVC-* chains).
+	// Defaults to countDataplaneServiceRules; overridable in tests.
+	countRules func(ctx context.Context) (int, error)
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *KubeProxyRulesCheckerConfig) CheckAndSetDefaults() error {
+	if r.Client == nil {
+		return trace.BadParameter("Kubernetes client is required")
+	}
+	if r.MinRuleRatio <= 0 {
+		r.MinRuleRatio = DefaultMinRuleRatio
+	}
+	if r.countRules == nil {
+		r.countRules = countDataplaneServiceRules
+	}
+	return nil
+}
+
+// NewKubeProxyRulesChecker returns a checker that compares the number of
+// services that should have dataplane rules against the number actually
+// programmed (iptables KUBE-SVC-* chains, or ipvs services), reporting
+// Degraded when the count is implausibly low - which a passing kube-proxy
+// healthz check alone would not catch.
+func NewKubeProxyRulesChecker(config KubeProxyRulesCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &kubeProxyRulesChecker{KubeProxyRulesCheckerConfig: config}, nil
+}
+
+type kubeProxyRulesChecker struct {
+	KubeProxyRulesCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *kubeProxyRulesChecker) Name() string { return KubeProxyRulesCheckerID }
+
+// Check compares the number of services expecting dataplane rules against
+// the number of rules actually programmed, reporting Degraded if the ratio
+// falls below MinRuleRatio.
+func (r *kubeProxyRulesChecker) Check(ctx context.Context, reporter health.Reporter) {
+	services, err := r.Client.CoreV1().Services(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to list kubernetes services", trace.Wrap(err)))
+		return
+	}
+
+	expected := countRuledServices(services.Items)
+	if expected == 0 {
+		reporter.Add(monitoring.NewSuccessProbe(r.Name()))
+		return
+	}
+
+	actual, err := r.countRules(ctx)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to count dataplane service rules", trace.Wrap(err)))
+		return
+	}
+
+	reporter.Add(evaluateRuleRatio(actual, expected, r.MinRuleRatio))
+}
+
+// evaluateRuleRatio compares the number of dataplane rules actually
+// programmed against the number of services expecting one and returns the
+// probe this checker should report.
+func evaluateRuleRatio(actual, expected int, minRatio float64) *pb.Probe {
+	ratio := float64(actual) / float64(expected)
+	if ratio >= minRatio {
+		return monitoring.NewSuccessProbe(KubeProxyRulesCheckerID)
+	}
+	return &pb.Probe{
+		Checker:  KubeProxyRulesCheckerID,
+		Status:   pb.Probe_Failed,
+		Severity: pb.Probe_Critical,
+		Detail: fmt.Sprintf("only %v dataplane rule(s) programmed for %v service(s) (ratio %.2f below minimum %.2f) - "+
+			"kube-proxy may not be programming the dataplane", actual, expected, ratio, minRatio),
+	}
+}
+
+// countRuledServices returns the number of services kube-proxy is expected
+// to have programmed dataplane rules for - those assigned a ClusterIP.
+func countRuledServices(services []v1.Service) int {
+	var count int
+	for _, service := range services {
+		if service.Spec.ClusterIP != "" && service.Spec.ClusterIP != v1.ClusterIPNone {
+			count++
+		}
+	}
+	return count
+}
+
+// countDataplaneServiceRules counts the currently programmed dataplane
+// service rules, preferring ipvs when the kernel module is loaded and
+// falling back to counting iptables KUBE-SVC-* chains otherwise.
+func countDataplaneServiceRules(ctx context.Context) (int, error) {
+	if data, err := ioutil.ReadFile(procNetIPVS); err == nil {
+		return countIPVSServices(string(data)), nil
+	}
+
+	output, err := iptablesSave(ctx)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	return countIPTablesKubeSVCChains(output), nil
+}
+
+// kubeSVCChainRegexp matches the chain declaration line iptables-save emits
+// for each KUBE-SVC-* chain kube-proxy creates (one per service port).
+var kubeSVCChainRegexp = regexp.MustCompile(`(?m)^:KUBE-SVC-\S+ `)
+
+// countIPTablesKubeSVCChains counts the distinct KUBE-SVC-* chains declared
+// in the output of iptables-save.
+func countIPTablesKubeSVCChains(output string) int {
+	return len(kubeSVCChainRegexp.FindAllString(output, -1))
+}
+
+// countIPVSServices counts the virtual service entries in the contents of
+// /proc/net/ip_vs - lines beginning with "TCP" or "UDP" (real servers are
+// listed indented beneath their virtual service and are not counted).
+func countIPVSServices(output string) int {
+	var count int
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "TCP ") || strings.HasPrefix(line, "UDP ") {
+			count++
+		}
+	}
+	return count
+}
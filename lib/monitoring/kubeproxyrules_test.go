@@ -0,0 +1,113 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"testing"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const sampleIPTablesSaveAboveThreshold = `
+*nat
+:KUBE-SERVICES - [0:0]
+:KUBE-SVC-AAAA - [0:0]
+:KUBE-SVC-BBBB - [0:0]
+:KUBE-SVC-CCCC - [0:0]
+-A KUBE-SERVICES -j KUBE-SVC-AAAA
+COMMIT
+`
+
+const sampleIPTablesSaveBelowThreshold = `
+*nat
+:KUBE-SERVICES - [0:0]
+:KUBE-SVC-AAAA - [0:0]
+COMMIT
+`
+
+const sampleIPVSSaveAboveThreshold = `IP Virtual Server version 1.2.1 (size=4096)
+Prot LocalAddress:Port Scheduler Flags
+  -> RemoteAddress:Port           Forward Weight ActiveConn InActConn
+TCP  0A64000A:0050 rr
+  -> 0A2C0001:0050              Masq    1      0          0
+TCP  0A64000B:0050 rr
+UDP  0A64000C:0035 rr
+`
+
+const sampleIPVSSaveBelowThreshold = `IP Virtual Server version 1.2.1 (size=4096)
+Prot LocalAddress:Port Scheduler Flags
+  -> RemoteAddress:Port           Forward Weight ActiveConn InActConn
+TCP  0A64000A:0050 rr
+`
+
+func servicesWithClusterIPs(ips ...string) []v1.Service {
+	var services []v1.Service
+	for _, ip := range ips {
+		services = append(services, v1.Service{Spec: v1.ServiceSpec{ClusterIP: ip}})
+	}
+	return services
+}
+
+func TestCountIPTablesKubeSVCChains(t *testing.T) {
+	if count := countIPTablesKubeSVCChains(sampleIPTablesSaveAboveThreshold); count != 3 {
+		t.Errorf("expected 3 KUBE-SVC chains, got %v", count)
+	}
+	if count := countIPTablesKubeSVCChains(sampleIPTablesSaveBelowThreshold); count != 1 {
+		t.Errorf("expected 1 KUBE-SVC chain, got %v", count)
+	}
+}
+
+func TestCountIPVSServices(t *testing.T) {
+	if count := countIPVSServices(sampleIPVSSaveAboveThreshold); count != 3 {
+		t.Errorf("expected 3 ipvs services, got %v", count)
+	}
+	if count := countIPVSServices(sampleIPVSSaveBelowThreshold); count != 1 {
+		t.Errorf("expected 1 ipvs service, got %v", count)
+	}
+}
+
+func TestCountRuledServicesIgnoresHeadless(t *testing.T) {
+	services := append(servicesWithClusterIPs("10.100.0.1", "10.100.0.2"),
+		v1.Service{Spec: v1.ServiceSpec{ClusterIP: v1.ClusterIPNone}},
+		v1.Service{},
+	)
+	if count := countRuledServices(services); count != 2 {
+		t.Errorf("expected 2 ruled services, got %v", count)
+	}
+}
+
+func TestEvaluateRuleRatioReportsDegradedBelowThreshold(t *testing.T) {
+	expected := countRuledServices(servicesWithClusterIPs("10.100.0.1", "10.100.0.2", "10.100.0.3", "10.100.0.4"))
+	actual := countIPTablesKubeSVCChains(sampleIPTablesSaveBelowThreshold)
+
+	probe := evaluateRuleRatio(actual, expected, DefaultMinRuleRatio)
+	if probe.Status != pb.Probe_Failed {
+		t.Fatalf("expected a failed probe, got %v", probe)
+	}
+}
+
+func TestEvaluateRuleRatioReportsSuccessAboveThreshold(t *testing.T) {
+	expected := countRuledServices(servicesWithClusterIPs("10.100.0.1", "10.100.0.2", "10.100.0.3"))
+	actual := countIPTablesKubeSVCChains(sampleIPTablesSaveAboveThreshold)
+
+	probe := evaluateRuleRatio(actual, expected, DefaultMinRuleRatio)
+	if probe.Status != pb.Probe_Running {
+		t.Fatalf("expected a successful probe, got %v", probe)
+	}
+}
@@ -17,40 +17,163 @@ limitations under the License.
 package monitoring
 
 import (
+	"time"
+
 	"github.com/gravitational/satellite/agent"
 	"github.com/gravitational/satellite/monitoring"
 	"github.com/gravitational/satellite/monitoring/collector"
 	"github.com/gravitational/trace"
+	"github.com/gravitational/version"
 	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// Names of the individually selectable metrics sub-collectors AddMetrics
+// knows how to register. Listing one of these in
+// Config.DisabledMetricsCollectors skips it.
+const (
+	metricsCollectorEtcd    = "etcd"
+	metricsCollectorKube    = "kube"
+	metricsCollectorDocker  = "docker"
+	metricsCollectorSystemd = "systemd"
+	metricsCollectorSysctl  = "sysctl"
 )
 
-// AddMetrics exposes specific metrics to Prometheus
+// AddMetrics exposes specific metrics to Prometheus. Each sub-collector is
+// constructed and registered independently, so a collector that's
+// unavailable on a given node (or fails to register) is logged and skipped
+// rather than aborting the rest. Sub-collectors named in
+// config.DisabledMetricsCollectors are skipped without being attempted.
 func AddMetrics(node agent.Agent, config *Config) error {
+	disabled := make(map[string]bool, len(config.DisabledMetricsCollectors))
+	for _, name := range config.DisabledMetricsCollectors {
+		disabled[name] = true
+	}
+
 	etcdConfig := &monitoring.ETCDConfig{
 		Endpoints: config.ETCDConfig.Endpoints,
 		CAFile:    config.ETCDConfig.CAFile,
 		CertFile:  config.ETCDConfig.CertFile,
 		KeyFile:   config.ETCDConfig.KeyFile,
 	}
-	client, err := GetKubeClient()
-	if err != nil {
-		return trace.Wrap(err)
-	}
-	kubeConfig := monitoring.KubeConfig{Client: client}
-
-	var mc *collector.MetricsCollector
 
-	switch config.Role {
-	case agent.RoleMaster:
-		mc, err = collector.NewMetricsCollector(etcdConfig, kubeConfig, agent.RoleMaster)
-	case agent.RoleNode:
-		mc, err = collector.NewMetricsCollector(etcdConfig, kubeConfig, agent.RoleNode)
+	registerCollector(prometheus.DefaultRegisterer, disabled, metricsCollectorEtcd, func() (collector.Collector, error) {
+		return collector.NewEtcdCollector(etcdConfig)
+	})
+	if config.Role == agent.RoleMaster {
+		registerCollector(prometheus.DefaultRegisterer, disabled, metricsCollectorKube, func() (collector.Collector, error) {
+			client, err := GetKubeClient()
+			if err != nil {
+				return nil, trace.Wrap(err)
+			}
+			return collector.NewKubernetesCollector(monitoring.KubeConfig{Client: client})
+		})
 	}
-	if err != nil {
+	registerCollector(prometheus.DefaultRegisterer, disabled, metricsCollectorDocker, func() (collector.Collector, error) {
+		return collector.NewDockerCollector()
+	})
+	registerCollector(prometheus.DefaultRegisterer, disabled, metricsCollectorSystemd, func() (collector.Collector, error) {
+		return collector.NewSystemdCollector()
+	})
+	registerCollector(prometheus.DefaultRegisterer, disabled, metricsCollectorSysctl, func() (collector.Collector, error) {
+		return collector.NewSysctlCollector(), nil
+	})
+
+	if err := prometheus.Register(buildInfoCollector{}); err != nil {
 		return trace.Wrap(err)
 	}
-	if err = prometheus.Register(mc); err != nil {
+	if err := prometheus.Register(bootTimeCollector{slowUnitCount: defaultSlowUnitCount}); err != nil {
 		return trace.Wrap(err)
 	}
 	return nil
 }
+
+// registerCollector builds and registers a single named metrics
+// sub-collector on registerer, returning whether it ended up registered.
+// It logs and returns false without error if name is disabled, construction
+// fails, or registration fails - a problem with one collector must never
+// prevent the others from being registered.
+func registerCollector(registerer prometheus.Registerer, disabled map[string]bool, name string, newCollector func() (collector.Collector, error)) bool {
+	if disabled[name] {
+		log.Infof("%v metrics collector disabled, skipping.", name)
+		return false
+	}
+	c, err := newCollector()
+	if err != nil {
+		log.Warnf("Failed to create %v metrics collector: %v.", name, err)
+		return false
+	}
+	if err := registerer.Register(namedCollector{name: name, collector: c}); err != nil {
+		log.Warnf("Failed to register %v metrics collector: %v.", name, err)
+		return false
+	}
+	return true
+}
+
+var (
+	collectorDurationDesc = prometheus.NewDesc(
+		"planet_metrics_collector_duration_seconds",
+		"Duration of a metrics sub-collector's last scrape.",
+		[]string{"collector"}, nil,
+	)
+	collectorSuccessDesc = prometheus.NewDesc(
+		"planet_metrics_collector_success",
+		"Whether a metrics sub-collector's last scrape succeeded.",
+		[]string{"collector"}, nil,
+	)
+)
+
+// namedCollector adapts a single vendored monitoring/collector.Collector -
+// which only knows how to Collect, not Describe - into a standalone
+// prometheus.Collector so it can be registered on its own, alongside a
+// duration/success metric recording how its last scrape went.
+type namedCollector struct {
+	name      string
+	collector collector.Collector
+}
+
+// Describe implements prometheus.Collector. It describes nothing, so the
+// registry treats every namedCollector as unchecked, matching the pattern
+// already used by collector.MetricsCollector in the vendored satellite
+// package. All sub-collectors share the duration/success descs, so
+// describing them would make every registration after the first fail as a
+// duplicate.
+func (c namedCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+// Collect implements prometheus.Collector.
+func (c namedCollector) Collect(ch chan<- prometheus.Metric) {
+	begin := time.Now()
+	err := c.collector.Collect(ch)
+	duration := time.Since(begin)
+
+	success := 1.0
+	if err != nil {
+		log.Warnf("%v metrics collector failed after %v: %v.", c.name, duration, err)
+		success = 0
+	}
+	ch <- prometheus.MustNewConstMetric(collectorDurationDesc, prometheus.GaugeValue, duration.Seconds(), c.name)
+	ch <- prometheus.MustNewConstMetric(collectorSuccessDesc, prometheus.GaugeValue, success, c.name)
+}
+
+// buildInfoDesc describes the planet_build_info metric.
+var buildInfoDesc = prometheus.NewDesc(
+	"planet_build_info",
+	"Build information about the running planet binary.",
+	[]string{"version", "git_commit"}, nil,
+)
+
+// buildInfoCollector exposes the planet build version and git commit as a
+// constant gauge, following the standard Prometheus build-info pattern.
+type buildInfoCollector struct{}
+
+// Describe implements prometheus.Collector.
+func (buildInfoCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- buildInfoDesc
+}
+
+// Collect implements prometheus.Collector.
+func (buildInfoCollector) Collect(ch chan<- prometheus.Metric) {
+	info := version.Get()
+	ch <- prometheus.MustNewConstMetric(buildInfoDesc, prometheus.GaugeValue, 1, info.Version, info.GitCommit)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"testing"
+
+	"github.com/gravitational/satellite/monitoring/collector"
+	"github.com/gravitational/trace"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type fakeCollector struct {
+	err error
+}
+
+func (c fakeCollector) Collect(ch chan<- prometheus.Metric) error {
+	return c.err
+}
+
+func TestRegisterCollectorSkipsDisabledCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	disabled := map[string]bool{"etcd": true}
+	called := false
+
+	registered := registerCollector(registry, disabled, "etcd", func() (collector.Collector, error) {
+		called = true
+		return fakeCollector{}, nil
+	})
+
+	if registered {
+		t.Error("expected disabled collector not to be registered")
+	}
+	if called {
+		t.Error("expected disabled collector's constructor not to be called")
+	}
+}
+
+func TestRegisterCollectorSurvivesConstructionFailure(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	disabled := map[string]bool{}
+
+	registered := registerCollector(registry, disabled, "etcd", func() (collector.Collector, error) {
+		return nil, trace.BadParameter("no etcd endpoints configured")
+	})
+
+	if registered {
+		t.Error("expected a collector that failed to construct not to be registered")
+	}
+}
+
+func TestRegisterCollectorIsolatesFailures(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	disabled := map[string]bool{}
+
+	etcdRegistered := registerCollector(registry, disabled, "etcd", func() (collector.Collector, error) {
+		return nil, trace.BadParameter("no etcd endpoints configured")
+	})
+	dockerRegistered := registerCollector(registry, disabled, "docker", func() (collector.Collector, error) {
+		return fakeCollector{}, nil
+	})
+
+	if etcdRegistered {
+		t.Error("expected the failing etcd collector not to be registered")
+	}
+	if !dockerRegistered {
+		t.Error("expected the docker collector to register despite the etcd collector's failure")
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+	if len(families) == 0 {
+		t.Error("expected the docker collector's metrics to be gathered")
+	}
+}
+
+func TestRegisterCollectorSelectsSuccessfulCollector(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	disabled := map[string]bool{}
+
+	registered := registerCollector(registry, disabled, "sysctl", func() (collector.Collector, error) {
+		return fakeCollector{}, nil
+	})
+	if !registered {
+		t.Fatal("expected the collector to be registered")
+	}
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("failed to gather metrics: %v", err)
+	}
+
+	var found bool
+	for _, family := range families {
+		if family.GetName() == "planet_metrics_collector_success" {
+			found = true
+			if got := family.GetMetric()[0].GetGauge().GetValue(); got != 1 {
+				t.Errorf("expected planet_metrics_collector_success=1, got %v", got)
+			}
+		}
+	}
+	if !found {
+		t.Error("expected planet_metrics_collector_success to be gathered")
+	}
+}
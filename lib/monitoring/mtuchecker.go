@@ -0,0 +1,196 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/lib/membership"
+	"github.com/gravitational/satellite/lib/rpc/client"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// OverlayMTUCheckerID identifies the checker that verifies the overlay
+// network interface MTU is consistent across cluster nodes.
+const OverlayMTUCheckerID = "overlay-mtu"
+
+// defaultOverlayInterface is the name of the flannel overlay interface.
+const defaultOverlayInterface = "flannel.1"
+
+// OverlayMTUCheckerConfig configures NewOverlayMTUChecker.
+type OverlayMTUCheckerConfig struct {
+	// NodeName is the name of the local node as seen by the cluster.
+	NodeName string
+	// OverlayInterface is the name of the local overlay network interface.
+	// Defaults to "flannel.1".
+	OverlayInterface string
+	// Cluster is used to query serf cluster membership.
+	Cluster membership.Cluster
+	// DialRPC is used to create clients to other cluster nodes.
+	DialRPC client.DialRPC
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *OverlayMTUCheckerConfig) CheckAndSetDefaults() error {
+	if r.NodeName == "" {
+		return trace.BadParameter("NodeName is required")
+	}
+	if r.Cluster == nil {
+		return trace.BadParameter("Cluster is required")
+	}
+	if r.DialRPC == nil {
+		return trace.BadParameter("DialRPC is required")
+	}
+	if r.OverlayInterface == "" {
+		r.OverlayInterface = defaultOverlayInterface
+	}
+	return nil
+}
+
+// NewOverlayMTUChecker returns a checker that verifies that the local
+// overlay interface MTU matches the MTU reported by the rest of the
+// cluster, to catch overlay MTU mismatches that cause hard-to-debug
+// intermittent large-packet drops.
+func NewOverlayMTUChecker(config OverlayMTUCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &overlayMTUChecker{OverlayMTUCheckerConfig: config}, nil
+}
+
+type overlayMTUChecker struct {
+	OverlayMTUCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *overlayMTUChecker) Name() string { return OverlayMTUCheckerID }
+
+// overlayMTUStatus is the JSON payload this checker publishes in its own
+// probe detail, so peers querying this node's local status over RPC can
+// extract its overlay MTU without a dedicated RPC method.
+type overlayMTUStatus struct {
+	MTU int `json:"mtu"`
+}
+
+// Check reads the local overlay interface MTU, publishes it, and compares
+// it against the MTU reported by the rest of the cluster.
+func (r *overlayMTUChecker) Check(ctx context.Context, reporter health.Reporter) {
+	localMTU, err := overlayInterfaceMTU(r.OverlayInterface)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), fmt.Sprintf("failed to query MTU of interface %v", r.OverlayInterface), trace.Wrap(err)))
+		return
+	}
+
+	detail, err := json.Marshal(overlayMTUStatus{MTU: localMTU})
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to encode local MTU", trace.Wrap(err)))
+		return
+	}
+	reporter.Add(&pb.Probe{
+		Checker: r.Name(),
+		Status:  pb.Probe_Running,
+		Detail:  string(detail),
+	})
+
+	members, err := r.Cluster.Members()
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to query cluster members", trace.Wrap(err)))
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, member := range members {
+		if member.Status != pb.MemberStatus_Alive || member.NodeName == r.NodeName {
+			continue
+		}
+		member := member
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mtu, err := r.remoteOverlayMTU(ctx, member)
+			if err != nil {
+				log.WithError(err).WithField("node", member.NodeName).Debug("Failed to query remote overlay MTU.")
+				return
+			}
+			if probe := mtuMismatchProbe(r.Name(), localMTU, member.NodeName, mtu); probe != nil {
+				mu.Lock()
+				reporter.Add(probe)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// remoteOverlayMTU queries the specified cluster member for its overlay MTU.
+func (r *overlayMTUChecker) remoteOverlayMTU(ctx context.Context, member *pb.MemberStatus) (int, error) {
+	agentClient, err := r.DialRPC(ctx, member.Addr)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	status, err := agentClient.LocalStatus(ctx)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	for _, probe := range status.Probes {
+		if probe.Checker != r.Name() {
+			continue
+		}
+		var payload overlayMTUStatus
+		if err := json.Unmarshal([]byte(probe.Detail), &payload); err != nil {
+			continue
+		}
+		return payload.MTU, nil
+	}
+	return 0, trace.NotFound("node %v did not report an overlay MTU", member.NodeName)
+}
+
+// mtuMismatchProbe returns a failed probe reporting the mismatch between
+// the local overlay MTU and the MTU reported by the named peer node, or
+// nil if the two agree.
+func mtuMismatchProbe(checker string, localMTU int, nodeName string, nodeMTU int) *pb.Probe {
+	if localMTU == nodeMTU {
+		return nil
+	}
+	return &pb.Probe{
+		Checker:  checker,
+		Status:   pb.Probe_Failed,
+		Severity: pb.Probe_Warning,
+		Detail:   fmt.Sprintf("node/%v", nodeName),
+		Error: fmt.Sprintf("overlay MTU mismatch: local node has MTU %v, node %v has MTU %v",
+			localMTU, nodeName, nodeMTU),
+	}
+}
+
+// overlayInterfaceMTU returns the MTU of the named network interface.
+func overlayInterfaceMTU(name string) (int, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	return iface.MTU, nil
+}
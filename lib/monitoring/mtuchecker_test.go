@@ -0,0 +1,33 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import "testing"
+
+func TestMTUMismatchProbe(t *testing.T) {
+	if probe := mtuMismatchProbe(OverlayMTUCheckerID, 1450, "node-2", 1450); probe != nil {
+		t.Fatalf("expected no probe for matching MTUs, got %v", probe)
+	}
+
+	probe := mtuMismatchProbe(OverlayMTUCheckerID, 1450, "node-2", 1400)
+	if probe == nil {
+		t.Fatal("expected a probe for mismatched MTUs")
+	}
+	if probe.Detail != "node/node-2" {
+		t.Errorf("expected detail %q but got %q", "node/node-2", probe.Detail)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// NeighborTableCheckerID identifies the checker that warns when the node's
+// IPv4 neighbor (ARP) table is approaching its configured size limit.
+const NeighborTableCheckerID = "neighbor-table"
+
+// procNetARPFile lists the node's current IPv4 neighbor table entries, one
+// per line after a header row.
+const procNetARPFile = "/proc/net/arp"
+
+// neighborGCThreshSysctls are the sysctl parameters bounding the size of
+// the IPv4 neighbor table. Once the current entry count passes gc_thresh1,
+// the kernel starts garbage collecting stale entries under memory
+// pressure; past gc_thresh3 new entries are refused outright and
+// in-cluster traffic to not-yet-resolved peers starts failing.
+var neighborGCThreshSysctls = [3]string{
+	"net.ipv4.neigh.default.gc_thresh1",
+	"net.ipv4.neigh.default.gc_thresh2",
+	"net.ipv4.neigh.default.gc_thresh3",
+}
+
+// NeighborTableCheckerConfig configures NewNeighborTableChecker.
+type NeighborTableCheckerConfig struct {
+	// Path is the file to read the current neighbor table from.
+	// Defaults to procNetARPFile.
+	Path string
+	// readSysctl reads a sysctl parameter's current value. Overridable in
+	// tests.
+	readSysctl func(param string) (string, error)
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *NeighborTableCheckerConfig) CheckAndSetDefaults() error {
+	if r.Path == "" {
+		r.Path = procNetARPFile
+	}
+	if r.readSysctl == nil {
+		r.readSysctl = monitoring.Sysctl
+	}
+	return nil
+}
+
+// NewNeighborTableChecker returns a checker that warns when the number of
+// entries in the node's IPv4 neighbor table has reached the configured
+// gc_thresh1 limit. Large clusters with many pods can exhaust the neighbor
+// cache, causing packet loss to peers the kernel can no longer keep an ARP
+// entry for.
+func NewNeighborTableChecker(config NeighborTableCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &neighborTableChecker{NeighborTableCheckerConfig: config}, nil
+}
+
+type neighborTableChecker struct {
+	NeighborTableCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *neighborTableChecker) Name() string { return NeighborTableCheckerID }
+
+// Check reads the current neighbor table size and the gc_thresh sysctls,
+// and reports a warning once the table has reached gc_thresh1.
+func (r *neighborTableChecker) Check(ctx context.Context, reporter health.Reporter) {
+	data, err := ioutil.ReadFile(r.Path)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), fmt.Sprintf("failed to read neighbor table from %v", r.Path), trace.ConvertSystemError(err)))
+		return
+	}
+	count := countARPEntries(string(data))
+
+	threshs, err := r.readGCThresh()
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to read neighbor table gc_thresh sysctls", trace.Wrap(err)))
+		return
+	}
+
+	if count < threshs[0] {
+		reporter.Add(&pb.Probe{
+			Checker: r.Name(),
+			Status:  pb.Probe_Running,
+			Detail:  fmt.Sprintf("%v neighbor table entries (gc_thresh1=%v, gc_thresh2=%v, gc_thresh3=%v)", count, threshs[0], threshs[1], threshs[2]),
+		})
+		return
+	}
+
+	reporter.Add(&pb.Probe{
+		Checker:  r.Name(),
+		Status:   pb.Probe_Failed,
+		Severity: pb.Probe_Warning,
+		Detail:   fmt.Sprintf("%v neighbor table entries (gc_thresh1=%v, gc_thresh2=%v, gc_thresh3=%v)", count, threshs[0], threshs[1], threshs[2]),
+		Error: fmt.Sprintf("IPv4 neighbor table has %v entries, at or above gc_thresh1 (%v) - the kernel will start "+
+			"garbage collecting stale entries, and will refuse new ones past gc_thresh3 (%v); consider raising "+
+			"net.ipv4.neigh.default.gc_thresh1/2/3", count, threshs[0], threshs[2]),
+	})
+}
+
+// readGCThresh reads the three neighborGCThreshSysctls in order.
+func (r *neighborTableChecker) readGCThresh() (threshs [3]int, err error) {
+	for i, param := range neighborGCThreshSysctls {
+		value, err := r.readSysctl(param)
+		if err != nil {
+			return threshs, trace.Wrap(err)
+		}
+		threshs[i], err = strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return threshs, trace.Wrap(err, "unexpected value for %v: %q", param, value)
+		}
+	}
+	return threshs, nil
+}
+
+// countARPEntries counts the neighbor entries in the contents of
+// /proc/net/arp, which has a header line followed by one line per entry.
+func countARPEntries(data string) int {
+	var count int
+	for i, line := range strings.Split(data, "\n") {
+		if i == 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+		count++
+	}
+	return count
+}
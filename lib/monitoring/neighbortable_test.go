@@ -0,0 +1,84 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+const sampleARPTable = "IP address       HW type     Flags       HW address            Mask     Device\n" +
+	"10.0.0.1         0x1         0x2         52:54:00:12:34:56     *        eth0\n" +
+	"10.0.0.2         0x1         0x2         52:54:00:12:34:57     *        eth0\n"
+
+func TestCountARPEntriesReturnsNoneWhenOnlyHeaderPresent(t *testing.T) {
+	count := countARPEntries("IP address       HW type     Flags       HW address            Mask     Device\n")
+	if count != 0 {
+		t.Fatalf("expected no entries, got %v", count)
+	}
+}
+
+func TestCountARPEntriesCountsEachEntry(t *testing.T) {
+	count := countARPEntries(sampleARPTable)
+	if count != 2 {
+		t.Fatalf("expected 2 entries, got %v", count)
+	}
+}
+
+func TestNeighborTableCheckerPassesUnderThreshold(t *testing.T) {
+	checker := &neighborTableChecker{NeighborTableCheckerConfig{
+		Path:       writeARPFile(t, sampleARPTable),
+		readSysctl: func(param string) (string, error) { return "1024", nil },
+	}}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("expected a single running probe, got %v", reporter)
+	}
+}
+
+func TestNeighborTableCheckerWarnsAtThreshold(t *testing.T) {
+	checker := &neighborTableChecker{NeighborTableCheckerConfig{
+		Path:       writeARPFile(t, sampleARPTable),
+		readSysctl: func(param string) (string, error) { return "2", nil },
+	}}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a single failed probe, got %v", reporter)
+	}
+	if reporter[0].Severity != pb.Probe_Warning {
+		t.Fatalf("expected default severity to be Warning, got %v", reporter[0].Severity)
+	}
+}
+
+func writeARPFile(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "arp")
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write arp fixture: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/lib/membership"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// publicIPTag is the serf member tag that carries the node's advertised IP.
+// It mirrors the tag satellite's agent sets for each cluster member.
+const publicIPTag = "publicip"
+
+// NodeSerfStatusCheckerID identifies the checker that cross-references
+// Kubernetes node status with serf cluster membership.
+const NodeSerfStatusCheckerID = "kube-serf-node-status"
+
+// NodeSerfStatusCheckerConfig configures NewNodeSerfStatusChecker.
+type NodeSerfStatusCheckerConfig struct {
+	// KubeConfig provides Kubernetes access.
+	monitoring.KubeConfig
+	// Cluster is used to query serf cluster membership.
+	Cluster membership.Cluster
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *NodeSerfStatusCheckerConfig) CheckAndSetDefaults() error {
+	if r.Client == nil {
+		return trace.BadParameter("Kubernetes client is required")
+	}
+	if r.Cluster == nil {
+		return trace.BadParameter("Cluster is required")
+	}
+	return nil
+}
+
+// NewNodeSerfStatusChecker returns a checker that correlates Kubernetes node
+// readiness with serf cluster membership, so that a kube API server that is
+// up but reporting stale/NotReady nodes does not mask an actual outage.
+//
+// The checker reports a failed probe per mismatch it finds:
+//   - a kube node is NotReady while serf reports the corresponding member alive
+//   - a serf member is alive but has no corresponding kube node
+//   - a kube node exists with no corresponding serf member
+func NewNodeSerfStatusChecker(config NodeSerfStatusCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &nodeSerfStatusChecker{NodeSerfStatusCheckerConfig: config}, nil
+}
+
+type nodeSerfStatusChecker struct {
+	NodeSerfStatusCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *nodeSerfStatusChecker) Name() string { return NodeSerfStatusCheckerID }
+
+// Check correlates kube nodes with serf members and reports a probe per
+// discovered mismatch.
+func (r *nodeSerfStatusChecker) Check(ctx context.Context, reporter health.Reporter) {
+	nodes, err := r.Client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to query kubernetes nodes", trace.Wrap(err)))
+		return
+	}
+
+	members, err := r.Cluster.Members()
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to query serf cluster members", trace.Wrap(err)))
+		return
+	}
+
+	probes := correlateNodesAndMembers(nodes.Items, members)
+	if len(probes) == 0 {
+		reporter.Add(&pb.Probe{
+			Checker: r.Name(),
+			Status:  pb.Probe_Running,
+		})
+		return
+	}
+	for _, probe := range probes {
+		reporter.Add(probe)
+	}
+}
+
+// correlateNodesAndMembers compares the kube node list against the serf
+// member list (matched by advertised IP) and returns a probe for each
+// mismatch found.
+func correlateNodesAndMembers(nodes []v1.Node, members []*pb.MemberStatus) (probes []*pb.Probe) {
+	nodesByIP := make(map[string]v1.Node, len(nodes))
+	for _, node := range nodes {
+		if ip := nodeInternalIP(node); ip != "" {
+			nodesByIP[ip] = node
+		}
+	}
+
+	membersByIP := make(map[string]*pb.MemberStatus, len(members))
+	for _, member := range members {
+		if ip := member.Tags[publicIPTag]; ip != "" {
+			membersByIP[ip] = member
+		}
+	}
+
+	for ip, node := range nodesByIP {
+		member, memberExists := membersByIP[ip]
+		switch {
+		case !memberExists:
+			probes = append(probes, &pb.Probe{
+				Checker:  NodeSerfStatusCheckerID,
+				Status:   pb.Probe_Failed,
+				Severity: pb.Probe_Warning,
+				Detail:   fmt.Sprintf("node/%v", node.Name),
+				Error:    fmt.Sprintf("kubernetes node %v (%v) has no corresponding serf cluster member", node.Name, ip),
+			})
+		case member.Status == pb.MemberStatus_Alive && !isNodeReady(node):
+			probes = append(probes, &pb.Probe{
+				Checker:  NodeSerfStatusCheckerID,
+				Status:   pb.Probe_Failed,
+				Severity: pb.Probe_Critical,
+				Detail:   fmt.Sprintf("node/%v", node.Name),
+				Error:    fmt.Sprintf("kubernetes node %v (%v) is NotReady while serf member %v is alive", node.Name, ip, member.NodeName),
+			})
+		}
+	}
+
+	for ip, member := range membersByIP {
+		if member.Status != pb.MemberStatus_Alive {
+			continue
+		}
+		if _, nodeExists := nodesByIP[ip]; !nodeExists {
+			probes = append(probes, &pb.Probe{
+				Checker:  NodeSerfStatusCheckerID,
+				Status:   pb.Probe_Failed,
+				Severity: pb.Probe_Warning,
+				Detail:   fmt.Sprintf("member/%v", member.NodeName),
+				Error:    fmt.Sprintf("serf member %v (%v) has no corresponding kubernetes node", member.NodeName, ip),
+			})
+		}
+	}
+
+	return probes
+}
+
+// nodeInternalIP returns the node's internal IP address, or an empty string
+// if it does not have one.
+func nodeInternalIP(node v1.Node) string {
+	for _, addr := range node.Status.Addresses {
+		if addr.Type == v1.NodeInternalIP {
+			return addr.Address
+		}
+	}
+	return ""
+}
+
+// isNodeReady returns true if the node's Ready condition is true.
+func isNodeReady(node v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
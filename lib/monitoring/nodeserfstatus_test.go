@@ -0,0 +1,85 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"sort"
+	"testing"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCorrelateNodesAndMembers(t *testing.T) {
+	nodes := []v1.Node{
+		node("node-1", "10.0.0.1", true),
+		node("node-2", "10.0.0.2", false),
+		node("node-3", "10.0.0.3", true),
+	}
+	members := []*pb.MemberStatus{
+		member("node-1", "10.0.0.1", pb.MemberStatus_Alive),
+		member("node-2", "10.0.0.2", pb.MemberStatus_Alive),
+		member("node-4", "10.0.0.4", pb.MemberStatus_Alive),
+	}
+
+	probes := correlateNodesAndMembers(nodes, members)
+
+	var details []string
+	for _, probe := range probes {
+		details = append(details, probe.Detail)
+	}
+	sort.Strings(details)
+
+	expected := []string{"member/node-4", "node/node-2", "node/node-3"}
+	if len(details) != len(expected) {
+		t.Fatalf("expected probes %v, got %v", expected, details)
+	}
+	for i := range expected {
+		if details[i] != expected[i] {
+			t.Errorf("expected probes %v, got %v", expected, details)
+			break
+		}
+	}
+}
+
+func node(name, ip string, ready bool) v1.Node {
+	status := v1.ConditionFalse
+	if ready {
+		status = v1.ConditionTrue
+	}
+	return v1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{
+				{Type: v1.NodeInternalIP, Address: ip},
+			},
+			Conditions: []v1.NodeCondition{
+				{Type: v1.NodeReady, Status: status},
+			},
+		},
+	}
+}
+
+func member(nodeName, ip string, status pb.MemberStatus_Type) *pb.MemberStatus {
+	return &pb.MemberStatus{
+		NodeName: nodeName,
+		Status:   status,
+		Tags:     map[string]string{publicIPTag: ip},
+	}
+}
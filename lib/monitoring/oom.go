@@ -0,0 +1,159 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// OOMCheckerID identifies the checker that reports container-level
+// out-of-memory kill events.
+const OOMCheckerID = "oom"
+
+// defaultOOMEventsPaths lists the cgroup files that expose a cumulative
+// OOM-kill counter, tried in order: cgroup v2's memory.events first, then
+// the cgroup v1 memory controller's memory.oom_control.
+var defaultOOMEventsPaths = []string{
+	"/sys/fs/cgroup/memory.events",
+	"/sys/fs/cgroup/memory/memory.oom_control",
+}
+
+// OOMCheckerConfig configures NewOOMChecker.
+type OOMCheckerConfig struct {
+	// Paths lists the cgroup files to read the cumulative OOM-kill count
+	// from, tried in order until one exists. Defaults to
+	// defaultOOMEventsPaths.
+	Paths []string
+}
+
+func (r *OOMCheckerConfig) checkAndSetDefaults() {
+	if len(r.Paths) == 0 {
+		r.Paths = defaultOOMEventsPaths
+	}
+}
+
+// NewOOMChecker returns a checker that reports a warning probe when new
+// OOM-kill events have occurred since the previous check, tracking the
+// last seen cumulative count so it reports deltas rather than a running
+// total. The first check after the checker is created never reports a
+// delta, since there's nothing to compare the initial count against.
+func NewOOMChecker(config OOMCheckerConfig) health.Checker {
+	config.checkAndSetDefaults()
+	return &oomChecker{OOMCheckerConfig: config}
+}
+
+type oomChecker struct {
+	OOMCheckerConfig
+
+	mu        sync.Mutex
+	lastCount int64
+	checked   bool
+}
+
+// Name returns the name of this checker.
+func (r *oomChecker) Name() string { return OOMCheckerID }
+
+// Check reads the current cumulative OOM-kill count and reports a warning
+// probe with the number of new kills observed since the previous check.
+func (r *oomChecker) Check(ctx context.Context, reporter health.Reporter) {
+	count, path, err := readOOMKillCount(r.Paths)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to read OOM kill count", trace.Wrap(err)))
+		return
+	}
+
+	delta := r.recordAndDiff(count)
+	if delta <= 0 {
+		reporter.Add(&pb.Probe{
+			Checker: r.Name(),
+			Status:  pb.Probe_Running,
+		})
+		return
+	}
+
+	reporter.Add(&pb.Probe{
+		Checker:  r.Name(),
+		Status:   pb.Probe_Failed,
+		Severity: pb.Probe_Warning,
+		Detail:   fmt.Sprintf("oom-kills/%v", delta),
+		Error: fmt.Sprintf("%v new OOM kill event(s) detected since the last check (source: %v, cumulative count: %v)",
+			delta, path, count),
+	})
+}
+
+// recordAndDiff updates the last seen count and returns how much it grew
+// by since the previous call, or 0 on the very first call.
+func (r *oomChecker) recordAndDiff(count int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var delta int64
+	if r.checked {
+		delta = count - r.lastCount
+	}
+	r.lastCount = count
+	r.checked = true
+	return delta
+}
+
+// readOOMKillCount returns the cumulative OOM-kill count from the first of
+// paths that exists and contains a parseable oom_kill entry.
+func readOOMKillCount(paths []string) (count int64, path string, err error) {
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			if trace.IsNotFound(trace.ConvertSystemError(err)) {
+				continue
+			}
+			return 0, "", trace.ConvertSystemError(err)
+		}
+		if count, ok := parseOOMKillCount(string(data)); ok {
+			return count, path, nil
+		}
+	}
+	return 0, "", trace.NotFound("no oom_kill counter found in %v", paths)
+}
+
+// parseOOMKillCount extracts the value of the "oom_kill" key from a cgroup
+// memory.events- or memory.oom_control-formatted file (whitespace-separated
+// "key value" lines).
+func parseOOMKillCount(data string) (int64, bool) {
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "oom_kill" {
+			continue
+		}
+		count, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		return count, true
+	}
+	return 0, false
+}
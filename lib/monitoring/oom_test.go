@@ -0,0 +1,91 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestParseOOMKillCount(t *testing.T) {
+	if count, ok := parseOOMKillCount("low 0\nhigh 0\noom 0\noom_kill 3\n"); !ok || count != 3 {
+		t.Fatalf("expected (3, true), got (%v, %v)", count, ok)
+	}
+	if _, ok := parseOOMKillCount("under_oom 0\noom_kill_disable 0\n"); ok {
+		t.Fatal("expected missing oom_kill entry to report false")
+	}
+	if _, ok := parseOOMKillCount("oom_kill not-a-number\n"); ok {
+		t.Fatal("expected malformed oom_kill value to report false")
+	}
+}
+
+func TestOOMCheckerReportsDeltaSinceLastCheck(t *testing.T) {
+	path := writeOOMEventsFile(t, 2)
+	checker := NewOOMChecker(OOMCheckerConfig{Paths: []string{path}})
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("expected the first check to report Running (nothing to diff against yet), got %v", reporter)
+	}
+
+	writeOOMEventsFileAt(t, path, 5)
+	reporter = nil
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed || reporter[0].Severity != pb.Probe_Warning {
+		t.Fatalf("expected a warning probe after new kills, got %v", reporter)
+	}
+	if reporter[0].Detail != "oom-kills/3" {
+		t.Fatalf("expected a delta of 3 new kills, got detail %q", reporter[0].Detail)
+	}
+
+	reporter = nil
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("expected no new kills on the third check to report Running, got %v", reporter)
+	}
+}
+
+func TestOOMCheckerFailsWhenNoSourceExists(t *testing.T) {
+	checker := NewOOMChecker(OOMCheckerConfig{Paths: []string{filepath.Join(t.TempDir(), "missing")}})
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a failed probe when no source file exists, got %v", reporter)
+	}
+}
+
+func writeOOMEventsFile(t *testing.T, count int) string {
+	path := filepath.Join(t.TempDir(), "memory.events")
+	writeOOMEventsFileAt(t, path, count)
+	return path
+}
+
+func writeOOMEventsFileAt(t *testing.T, path string, count int) {
+	t.Helper()
+	data := []byte("low 0\nhigh 0\nmax 0\noom 0\noom_kill " + strconv.Itoa(count) + "\n")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("failed to write oom events fixture: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"sort"
+
+	"github.com/gravitational/satellite/agent/health"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// PKITrustChainCheckerID identifies the checker that verifies every
+// component certificate chains to the cluster CA, so a CA mismatch between
+// etcd, the API server and kubelet surfaces as a named failed probe instead
+// of a confusing TLS handshake error deep in one of those components.
+const PKITrustChainCheckerID = "pki-trust-chain"
+
+// PKITrustChainCheckerConfig is configuration for NewPKITrustChainChecker.
+type PKITrustChainCheckerConfig struct {
+	// CAFile is the path to the cluster CA certificate every component
+	// certificate is expected to chain to.
+	CAFile string
+	// ComponentCerts maps a component name (e.g. "etcd", "apiserver") to the
+	// path of its certificate.
+	ComponentCerts map[string]string
+}
+
+// CheckAndSetDefaults validates the configuration.
+func (c *PKITrustChainCheckerConfig) CheckAndSetDefaults() error {
+	if c.CAFile == "" {
+		return trace.BadParameter("CAFile is required")
+	}
+	if len(c.ComponentCerts) == 0 {
+		return trace.BadParameter("at least one component certificate is required")
+	}
+	return nil
+}
+
+// NewPKITrustChainChecker returns a checker that verifies each of
+// config.ComponentCerts chains to config.CAFile.
+func NewPKITrustChainChecker(config PKITrustChainCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &pkiTrustChainChecker{PKITrustChainCheckerConfig: config}, nil
+}
+
+type pkiTrustChainChecker struct {
+	PKITrustChainCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *pkiTrustChainChecker) Name() string { return PKITrustChainCheckerID }
+
+// Check verifies each component certificate chains to the CA, reporting a
+// failed probe naming the component whose certificate doesn't validate.
+func (r *pkiTrustChainChecker) Check(ctx context.Context, reporter health.Reporter) {
+	roots, err := newCertPool([]string{r.CAFile})
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to load cluster CA", err))
+		return
+	}
+
+	names := make([]string, 0, len(r.ComponentCerts))
+	for name := range r.ComponentCerts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var failed bool
+	for _, name := range names {
+		cert, err := loadCertificate(r.ComponentCerts[name])
+		if err != nil {
+			failed = true
+			reporter.Add(monitoring.NewProbeFromErr(r.Name(), fmt.Sprintf("failed to load %v certificate", name), err))
+			continue
+		}
+		if _, err := cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}); err != nil {
+			failed = true
+			reporter.Add(monitoring.NewProbeFromErr(r.Name(),
+				fmt.Sprintf("%v certificate does not chain to the configured CA", name), err))
+		}
+	}
+	if !failed {
+		reporter.Add(monitoring.NewSuccessProbe(r.Name()))
+	}
+}
+
+// loadCertificate reads and parses the first certificate found in the PEM
+// file at path.
+func loadCertificate(path string) (*x509.Certificate, error) {
+	pemBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, trace.BadParameter("%v does not contain a PEM-encoded certificate", path)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return cert, nil
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestPKITrustChainCheckerReportsRunningWhenCertsChainToCA(t *testing.T) {
+	dir := t.TempDir()
+	caCert, caKey := writeTestCA(t, filepath.Join(dir, "ca.cert"))
+	writeTestLeafCert(t, filepath.Join(dir, "etcd.cert"), "etcd", caCert, caKey)
+
+	checker, err := NewPKITrustChainChecker(PKITrustChainCheckerConfig{
+		CAFile:         filepath.Join(dir, "ca.cert"),
+		ComponentCerts: map[string]string{"etcd": filepath.Join(dir, "etcd.cert")},
+	})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("expected a single running probe, got %v", reporter)
+	}
+}
+
+func TestPKITrustChainCheckerReportsFailedForUntrustedComponentCert(t *testing.T) {
+	dir := t.TempDir()
+	caCert, caKey := writeTestCA(t, filepath.Join(dir, "ca.cert"))
+	writeTestLeafCert(t, filepath.Join(dir, "etcd.cert"), "etcd", caCert, caKey)
+	writeSelfSignedCert(t, filepath.Join(dir, "rogue.cert"), "rogue")
+
+	checker, err := NewPKITrustChainChecker(PKITrustChainCheckerConfig{
+		CAFile: filepath.Join(dir, "ca.cert"),
+		ComponentCerts: map[string]string{
+			"etcd":      filepath.Join(dir, "etcd.cert"),
+			"apiserver": filepath.Join(dir, "rogue.cert"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a single failed probe naming apiserver, got %v", reporter)
+	}
+}
+
+func writeTestCA(t *testing.T, path string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	writeCertPEM(t, path, der)
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %v", err)
+	}
+	return cert, key
+}
+
+func writeTestLeafCert(t *testing.T, path, commonName string, caCert *x509.Certificate, caKey *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate %v key: %v", commonName, err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create %v certificate: %v", commonName, err)
+	}
+	writeCertPEM(t, path, der)
+}
+
+func writeSelfSignedCert(t *testing.T, path, commonName string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate %v key: %v", commonName, err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create %v certificate: %v", commonName, err)
+	}
+	writeCertPEM(t, path, der)
+}
+
+func writeCertPEM(t *testing.T, path string, der []byte) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create %v: %v", path, err)
+	}
+	defer f.Close()
+	if err := pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: der}); err != nil {
+		t.Fatalf("failed to write %v: %v", path, err)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"sort"
+
+	"github.com/gravitational/satellite/agent"
+	"github.com/gravitational/satellite/agent/health"
+)
+
+// Registry decorates an agent.Agent, indexing every checker added through
+// it by name. The satellite agent.Agent interface only allows checkers to
+// be added, not listed or looked up, so a checker can otherwise only ever
+// be run as part of the agent's own scheduled cycle. Wrapping the agent in
+// a Registry before calling AddCheckers makes it possible to run a single
+// checker again on demand, outside that cycle, and to drop individual
+// checkers by name before they ever reach the wrapped agent.
+type Registry struct {
+	agent.Agent
+	checkers map[string]health.Checker
+	disabled map[string]bool
+}
+
+// NewRegistry returns a Registry wrapping node. Checkers subsequently
+// added under one of the disabled names are dropped instead of being
+// forwarded to node.
+func NewRegistry(node agent.Agent, disabled ...string) *Registry {
+	registry := &Registry{
+		Agent:    node,
+		checkers: make(map[string]health.Checker),
+		disabled: make(map[string]bool, len(disabled)),
+	}
+	for _, name := range disabled {
+		registry.disabled[name] = true
+	}
+	return registry
+}
+
+// AddChecker registers checker with the wrapped agent and records it under
+// its name for later lookup via Checker, unless its name was disabled when
+// the registry was created.
+func (r *Registry) AddChecker(checker health.Checker) {
+	if r.disabled[checker.Name()] {
+		return
+	}
+	r.Agent.AddChecker(checker)
+	r.checkers[checker.Name()] = checker
+}
+
+// Checker returns the checker previously registered under name, and
+// whether one was found.
+func (r *Registry) Checker(name string) (health.Checker, bool) {
+	checker, ok := r.checkers[name]
+	return checker, ok
+}
+
+// CheckerNames returns the names of all registered checkers, sorted, for
+// reporting when a requested checker isn't found.
+func (r *Registry) CheckerNames() []string {
+	names := make([]string, 0, len(r.checkers))
+	for name := range r.checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// RegistryContentCheckerID identifies the checker that verifies the private
+// docker registry actually serves the images planet depends on, as opposed
+// to dockerRegistryHealth which only hits /v2/ and reports healthy even when
+// the registry has lost its backing data.
+const RegistryContentCheckerID = "docker-registry-content"
+
+// defaultCatalogPageSize bounds how many repositories NewRegistryContentChecker
+// asks the registry to list when checking that its catalog isn't empty.
+const defaultCatalogPageSize = 100
+
+// RegistryContentCheckerConfig is configuration for NewRegistryContentChecker.
+type RegistryContentCheckerConfig struct {
+	// Addr is the address of the private docker registry, e.g. https://host:5000
+	Addr string
+	// Client is the HTTP client used to talk to the registry.
+	Client *http.Client
+	// CriticalImages lists the full image references (host:port/repo:tag)
+	// that must have at least one reachable tag and manifest.
+	CriticalImages []string
+	// CatalogPageSize bounds the number of repositories requested from the
+	// catalog listing. Defaults to defaultCatalogPageSize.
+	CatalogPageSize int
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *RegistryContentCheckerConfig) CheckAndSetDefaults() error {
+	if r.Addr == "" {
+		return trace.BadParameter("Addr is required")
+	}
+	if r.Client == nil {
+		return trace.BadParameter("Client is required")
+	}
+	if r.CatalogPageSize <= 0 {
+		r.CatalogPageSize = defaultCatalogPageSize
+	}
+	return nil
+}
+
+// NewRegistryContentChecker returns a checker that verifies the private
+// docker registry is not just answering /v2/ but actually serves content:
+// the catalog is non-empty and each of CriticalImages has a tag whose
+// manifest is reachable.
+func NewRegistryContentChecker(config RegistryContentCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &registryContentChecker{RegistryContentCheckerConfig: config}, nil
+}
+
+type registryContentChecker struct {
+	RegistryContentCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *registryContentChecker) Name() string { return RegistryContentCheckerID }
+
+// Check verifies the registry catalog is non-empty and that each of
+// CriticalImages has at least one tag with a reachable manifest, reporting
+// a failed probe that distinguishes an unreachable registry, an empty
+// catalog and a missing manifest/blob.
+func (r *registryContentChecker) Check(ctx context.Context, reporter health.Reporter) {
+	repositories, err := r.catalog(ctx)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "registry unreachable", err))
+		return
+	}
+	if len(repositories) == 0 {
+		reporter.Add(&pb.Probe{
+			Checker: r.Name(),
+			Detail:  "registry catalog is empty",
+			Status:  pb.Probe_Failed,
+		})
+		return
+	}
+
+	var failed bool
+	for _, image := range r.CriticalImages {
+		repo, tag, err := parseImageRef(image)
+		if err != nil {
+			failed = true
+			reporter.Add(monitoring.NewProbeFromErr(r.Name(), fmt.Sprintf("critical image %v", image), err))
+			continue
+		}
+		if err := r.checkManifestReachable(ctx, repo, tag); err != nil {
+			failed = true
+			reporter.Add(monitoring.NewProbeFromErr(r.Name(),
+				fmt.Sprintf("manifest/blob missing for critical repository %v", repo), err))
+		}
+	}
+	if !failed {
+		reporter.Add(monitoring.NewSuccessProbe(r.Name()))
+	}
+}
+
+// catalog returns the list of repositories the registry reports, bounded to
+// CatalogPageSize entries.
+func (r *registryContentChecker) catalog(ctx context.Context) ([]string, error) {
+	var result struct {
+		Repositories []string `json:"repositories"`
+	}
+	url := fmt.Sprintf("%v/v2/_catalog?n=%v", r.Addr, r.CatalogPageSize)
+	if err := r.getJSON(ctx, url, &result); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return result.Repositories, nil
+}
+
+// checkManifestReachable verifies repo has at least one tag (using tag, if
+// given) and that its manifest responds to a HEAD request.
+func (r *registryContentChecker) checkManifestReachable(ctx context.Context, repo, tag string) error {
+	if tag == "" {
+		var tags struct {
+			Tags []string `json:"tags"`
+		}
+		url := fmt.Sprintf("%v/v2/%v/tags/list", r.Addr, repo)
+		if err := r.getJSON(ctx, url, &tags); err != nil {
+			return trace.Wrap(err)
+		}
+		if len(tags.Tags) == 0 {
+			return trace.NotFound("repository %v has no tags", repo)
+		}
+		tag = tags.Tags[0]
+	}
+
+	url := fmt.Sprintf("%v/v2/%v/manifests/%v", r.Addr, repo, tag)
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/vnd.docker.distribution.manifest.v2+json")
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return trace.NotFound("manifest for %v:%v responded with status %v", repo, tag, resp.StatusCode)
+	}
+	return nil
+}
+
+func (r *registryContentChecker) getJSON(ctx context.Context, url string, result interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	req = req.WithContext(ctx)
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return trace.BadParameter("%v responded with status %v", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// criticalRegistryImages returns the non-empty critical image references to
+// verify in the registry content checker (the pause and nettest images).
+func criticalRegistryImages(config *Config) []string {
+	var images []string
+	for _, image := range []string{config.PauseContainerImage, config.NettestContainerImage} {
+		if image != "" {
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
+// parseImageRef splits a docker image reference of the form
+// host[:port]/path/to/repo[:tag] into the registry path (path/to/repo) and
+// tag, stripping the leading registry host component.
+func parseImageRef(ref string) (repo, tag string, err error) {
+	idx := strings.Index(ref, "/")
+	if idx < 0 {
+		return "", "", trace.BadParameter("%v is not a host/repo[:tag] image reference", ref)
+	}
+	rest := ref[idx+1:]
+	if colon := strings.LastIndex(rest, ":"); colon >= 0 && !strings.Contains(rest[colon:], "/") {
+		return rest[:colon], rest[colon+1:], nil
+	}
+	return rest, "", nil
+}
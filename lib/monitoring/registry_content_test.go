@@ -0,0 +1,134 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestRegistryContentCheckerReportsRunningWhenCatalogAndManifestsPresent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/_catalog":
+			w.Write([]byte(`{"repositories":["gcr.io/google_containers/pause"]}`))
+		case r.URL.Path == "/v2/gcr.io/google_containers/pause/manifests/3.2" && r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	checker, err := NewRegistryContentChecker(RegistryContentCheckerConfig{
+		Addr:           server.URL,
+		Client:         server.Client(),
+		CriticalImages: []string{"registry:5000/gcr.io/google_containers/pause:3.2"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("expected a single running probe, got %v", reporter)
+	}
+}
+
+func TestRegistryContentCheckerReportsFailedWhenUnreachable(t *testing.T) {
+	checker, err := NewRegistryContentChecker(RegistryContentCheckerConfig{
+		Addr:   "http://127.0.0.1:0",
+		Client: http.DefaultClient,
+	})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a failed probe for an unreachable registry, got %v", reporter)
+	}
+}
+
+func TestRegistryContentCheckerReportsFailedWhenCatalogEmpty(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"repositories":[]}`))
+	}))
+	defer server.Close()
+
+	checker, err := NewRegistryContentChecker(RegistryContentCheckerConfig{
+		Addr:   server.URL,
+		Client: server.Client(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed || reporter[0].Detail != "registry catalog is empty" {
+		t.Fatalf("expected a single failed empty-catalog probe, got %v", reporter)
+	}
+}
+
+func TestRegistryContentCheckerReportsFailedWhenManifestMissing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/_catalog":
+			w.Write([]byte(`{"repositories":["gcr.io/google_containers/pause"]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	checker, err := NewRegistryContentChecker(RegistryContentCheckerConfig{
+		Addr:           server.URL,
+		Client:         server.Client(),
+		CriticalImages: []string{"registry:5000/gcr.io/google_containers/pause:3.2"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a single failed probe for a missing manifest, got %v", reporter)
+	}
+}
+
+func TestParseImageRef(t *testing.T) {
+	repo, tag, err := parseImageRef("registry:5000/gcr.io/google_containers/pause:3.2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if repo != "gcr.io/google_containers/pause" || tag != "3.2" {
+		t.Fatalf("expected repo=gcr.io/google_containers/pause tag=3.2, got repo=%v tag=%v", repo, tag)
+	}
+
+	if _, _, err := parseImageRef("no-slash-in-ref"); err == nil {
+		t.Fatalf("expected an error for a reference without a registry host")
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/satellite/agent"
+	"github.com/gravitational/satellite/agent/health"
+)
+
+// fakeAgent implements agent.Agent, recording every checker added through
+// it. All other methods are inherited (unimplemented) from the embedded nil
+// interface and are not exercised by these tests.
+type fakeAgent struct {
+	agent.Agent
+	added []health.Checker
+}
+
+func (f *fakeAgent) AddChecker(checker health.Checker) {
+	f.added = append(f.added, checker)
+}
+
+type namedChecker string
+
+func (c namedChecker) Name() string                           { return string(c) }
+func (c namedChecker) Check(context.Context, health.Reporter) {}
+
+func TestRegistryLooksUpCheckerByName(t *testing.T) {
+	inner := &fakeAgent{}
+	registry := NewRegistry(inner)
+
+	checker := namedChecker("test-checker")
+	registry.AddChecker(checker)
+
+	if len(inner.added) != 1 || inner.added[0] != checker {
+		t.Fatalf("expected checker to be added to the wrapped agent, got %v", inner.added)
+	}
+
+	found, ok := registry.Checker("test-checker")
+	if !ok || found != checker {
+		t.Fatalf("expected to find registered checker, got %v, %v", found, ok)
+	}
+
+	if _, ok := registry.Checker("unknown"); ok {
+		t.Fatal("expected lookup of unregistered checker to fail")
+	}
+}
+
+func TestRegistryCheckerNamesSorted(t *testing.T) {
+	registry := NewRegistry(&fakeAgent{})
+	registry.AddChecker(namedChecker("zebra"))
+	registry.AddChecker(namedChecker("apple"))
+
+	names := registry.CheckerNames()
+	if len(names) != 2 || names[0] != "apple" || names[1] != "zebra" {
+		t.Fatalf("expected sorted [apple zebra], got %v", names)
+	}
+}
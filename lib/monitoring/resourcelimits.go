@@ -0,0 +1,143 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/planet/lib/constants"
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// ResourceLimitsCheckerID identifies the checker that warns when a node's
+// configured container memory limit leaves it little headroom against the
+// host's actual memory.
+const ResourceLimitsCheckerID = "resource-limits"
+
+// resourceOverCommitThreshold is how much of the host's total memory the
+// container's memory limit may claim before this checker considers the
+// node over-committed and reports a warning.
+const resourceOverCommitThreshold = 0.9
+
+// resourceLimitsStateFile is where "planet start" records the resource
+// limits the container was started with (see tool/planet/resources.go).
+// It is read directly rather than over RPC since, like the checker's own
+// probe, it is specific to the node it runs on.
+var resourceLimitsStateFile = filepath.Join(constants.SecretsMountDir, "resource-limits.json")
+
+// resourceLimitsState mirrors the JSON tool/planet's "planet start" writes
+// to resourceLimitsStateFile. It is duplicated here rather than imported
+// since tool/planet is a main package and cannot be depended on.
+type resourceLimitsState struct {
+	MemoryLimit int64 `json:"memoryLimit,omitempty"`
+}
+
+// NewResourceLimitsChecker returns a checker that warns if the node's
+// configured container memory limit leaves little headroom against the
+// host's actual total memory, making an over-committed node visible in
+// cluster status rather than only discoverable by comparing flags to
+// `free -m` by hand.
+func NewResourceLimitsChecker() health.Checker {
+	return &resourceLimitsChecker{}
+}
+
+type resourceLimitsChecker struct{}
+
+// Name returns this checker's name.
+func (r *resourceLimitsChecker) Name() string { return ResourceLimitsCheckerID }
+
+// Check reports a warning probe if the node's configured memory limit
+// claims more than resourceOverCommitThreshold of the host's total memory.
+// A node with no memory limit configured, or one that has not recorded its
+// limits yet, is reported as running - this checker only flags limits that
+// have actually been set too high, not the absence of a limit.
+func (r *resourceLimitsChecker) Check(ctx context.Context, reporter health.Reporter) {
+	state, err := readResourceLimitsState()
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to read configured resource limits", trace.Wrap(err)))
+		return
+	}
+	if state == nil || state.MemoryLimit == 0 {
+		reporter.Add(&pb.Probe{Checker: r.Name(), Status: pb.Probe_Running})
+		return
+	}
+
+	hostMemory, err := hostMemoryBytes()
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to determine host memory", trace.Wrap(err)))
+		return
+	}
+
+	if fraction := float64(state.MemoryLimit) / float64(hostMemory); fraction > resourceOverCommitThreshold {
+		reporter.Add(&pb.Probe{
+			Checker:  r.Name(),
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Warning,
+			Detail: fmt.Sprintf("configured memory-limit is %.0f%% of this node's total memory, leaving little headroom for the host",
+				fraction*100),
+		})
+		return
+	}
+	reporter.Add(&pb.Probe{Checker: r.Name(), Status: pb.Probe_Running})
+}
+
+// readResourceLimitsState loads the resource limits most recently recorded
+// by "planet start". It returns nil, nil if none have ever been recorded.
+func readResourceLimitsState() (*resourceLimitsState, error) {
+	data, err := ioutil.ReadFile(resourceLimitsStateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	var state resourceLimitsState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &state, nil
+}
+
+// hostMemoryBytes returns the total physical memory of the host, read
+// directly from /proc/meminfo.
+func hostMemoryBytes() (uint64, error) {
+	data, err := ioutil.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, trace.Wrap(err)
+			}
+			return kb * 1024, nil
+		}
+	}
+	return 0, trace.NotFound("MemTotal not found in /proc/meminfo")
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gravitational/planet/lib/check"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// RootFSCheckerID identifies the checker that reports filesystem feature
+// problems (missing d_type support, noexec/nodev/nosuid mounts, low
+// inodes) under the rootfs and state directories.
+const RootFSCheckerID = "rootfs"
+
+// RootFSCheckerConfig configures NewRootFSChecker.
+type RootFSCheckerConfig struct {
+	// Paths lists the directories to check, as visible from inside the
+	// planet container (e.g. "/" for the rootfs, DefaultSecretsMountDir
+	// for the state directory).
+	Paths []string
+}
+
+// NewRootFSChecker returns a checker that reports the filesystem feature
+// checks performed by planet's start-time preflight (see
+// lib/check.CheckRootFS), so a failure that was overridden with
+// --ignore-checks still shows up in the status report support bundles
+// capture. The check is expensive (it creates a probe file) and its
+// result can't change while the filesystem stays mounted the same way,
+// so it only ever runs once, on the first Check call, and the same
+// result is reported on every subsequent cycle.
+func NewRootFSChecker(config RootFSCheckerConfig) health.Checker {
+	return &rootfsChecker{RootFSCheckerConfig: config}
+}
+
+type rootfsChecker struct {
+	RootFSCheckerConfig
+
+	mu      sync.Mutex
+	checked bool
+	probes  []*pb.Probe
+}
+
+// Name returns the name of this checker.
+func (r *rootfsChecker) Name() string { return RootFSCheckerID }
+
+// Check reports the cached result of checking r.Paths, computing it on
+// the first call.
+func (r *rootfsChecker) Check(ctx context.Context, reporter health.Reporter) {
+	for _, probe := range r.results() {
+		reporter.Add(probe)
+	}
+}
+
+func (r *rootfsChecker) results() []*pb.Probe {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.checked {
+		r.probes = checkRootFSPaths(r.Paths)
+		r.checked = true
+	}
+	return r.probes
+}
+
+// checkRootFSPaths runs check.CheckRootFS against every path and
+// translates the result into one probe per path.
+func checkRootFSPaths(paths []string) []*pb.Probe {
+	probes := make([]*pb.Probe, 0, len(paths))
+	for _, path := range paths {
+		result, err := check.CheckRootFS(path)
+		if err != nil {
+			probes = append(probes, monitoring.NewProbeFromErr(RootFSCheckerID, fmt.Sprintf("failed to check filesystem features of %v", path), trace.Wrap(err)))
+			continue
+		}
+		failures := result.Failures()
+		if len(failures) == 0 {
+			probes = append(probes, &pb.Probe{
+				Checker: RootFSCheckerID,
+				Status:  pb.Probe_Running,
+				Detail:  fmt.Sprintf("%v (%v)", path, result.FSType),
+			})
+			continue
+		}
+		probes = append(probes, &pb.Probe{
+			Checker:  RootFSCheckerID,
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Critical,
+			Detail:   fmt.Sprintf("%v (%v)", path, result.FSType),
+			Error:    strings.Join(failures, "; "),
+		})
+	}
+	return probes
+}
@@ -0,0 +1,40 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import "strconv"
+
+// RPCPortTag is the serf/member tag that carries the port the agent's RPC
+// listener actually bound to, set by the agent on startup so peers can
+// still reach it after a non-default --rpc-addr. Members that don't
+// advertise it (e.g. older agents) are expected to be dialed on the
+// default RPC port instead.
+const RPCPortTag = "rpc-port"
+
+// ParseRPCPortTag extracts and parses the RPCPortTag from tags, returning
+// false if it's absent or isn't a valid port number.
+func ParseRPCPortTag(tags map[string]string) (int, bool) {
+	raw, ok := tags[RPCPortTag]
+	if !ok {
+		return 0, false
+	}
+	port, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, false
+	}
+	return port, true
+}
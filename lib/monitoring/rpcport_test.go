@@ -0,0 +1,31 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import "testing"
+
+func TestParseRPCPortTag(t *testing.T) {
+	if port, ok := ParseRPCPortTag(map[string]string{RPCPortTag: "8888"}); !ok || port != 8888 {
+		t.Fatalf("expected (8888, true), got (%v, %v)", port, ok)
+	}
+	if _, ok := ParseRPCPortTag(map[string]string{"role": "master"}); ok {
+		t.Fatal("expected missing tag to report false")
+	}
+	if _, ok := ParseRPCPortTag(map[string]string{RPCPortTag: "not-a-port"}); ok {
+		t.Fatal("expected malformed tag to report false")
+	}
+}
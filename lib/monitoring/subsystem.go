@@ -0,0 +1,128 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import "github.com/gravitational/satellite/monitoring"
+
+// Subsystem groups related checkers so a long flat probe list can be
+// rolled up into a handful of sections an operator can scan at a glance
+// (see tool/planet's status rendering), instead of having to read every
+// probe to work out whether a problem is in etcd, networking, kubernetes,
+// storage, or the host system in general.
+type Subsystem string
+
+const (
+	// SubsystemEtcd covers etcd health and cluster membership.
+	SubsystemEtcd Subsystem = "etcd"
+	// SubsystemNetwork covers inter-pod/inter-node networking, DNS,
+	// iptables, MTU and neighbor table health.
+	SubsystemNetwork Subsystem = "network"
+	// SubsystemKubernetes covers the Kubernetes control plane and node
+	// components (apiserver, scheduler, controller-manager, kubelet,
+	// kube-proxy) as well as workload health.
+	SubsystemKubernetes Subsystem = "kubernetes"
+	// SubsystemStorage covers disk space, the docker storage driver and
+	// other on-disk health.
+	SubsystemStorage Subsystem = "storage"
+	// SubsystemSystem is the catch-all for host-level checkers (kernel,
+	// systemd, time, boot, swap, cgroups) and anything with no more
+	// specific subsystem registered for it.
+	SubsystemSystem Subsystem = "system"
+)
+
+// checkerSubsystems maps a checker's Name() to the Subsystem it reports
+// into. It only lists the checkers this package registers itself, plus the
+// handful of vendored satellite checkers (github.com/gravitational/satellite/monitoring)
+// wired up in checkers.go whose Name() is a well-known constant string;
+// satellite doesn't export a subsystem concept of its own, and keeping an
+// exhaustive, hand-maintained list of every vendored checker's internal
+// name in sync isn't worth it for what's ultimately a cosmetic grouping -
+// an unlisted checker simply falls back to SubsystemSystem via
+// CheckerSubsystem, which is a reasonable default for host/infra checkers.
+var checkerSubsystems = map[string]Subsystem{
+	// etcd
+	EtcdMembersCheckerID: SubsystemEtcd,
+	"etcd-healthz":       SubsystemEtcd,
+
+	// network
+	IPTablesCheckerID:      SubsystemNetwork,
+	OverlayMTUCheckerID:    SubsystemNetwork,
+	NeighborTableCheckerID: SubsystemNetwork,
+	"dns":                  SubsystemNetwork,
+	"networking":           SubsystemNetwork,
+	"nethealth-checker":    SubsystemNetwork,
+	"time-drift":           SubsystemNetwork,
+	"ip-forward":           SubsystemNetwork,
+	"br-netfilter":         SubsystemNetwork,
+	ConntrackCheckerID:     SubsystemNetwork,
+
+	// kubernetes
+	KubeProxyCheckerID:      SubsystemKubernetes,
+	KubeProxyRulesCheckerID: SubsystemKubernetes,
+	NodeSerfStatusCheckerID: SubsystemKubernetes,
+	VersionSkewCheckerID:    SubsystemKubernetes,
+	"kube-components":       SubsystemKubernetes,
+	"kubelet":               SubsystemKubernetes,
+	"node-status":           SubsystemKubernetes,
+	"nodes-status":          SubsystemKubernetes,
+	"system-pods-checker":   SubsystemKubernetes,
+
+	// storage
+	DockerStorageCheckerID:        SubsystemStorage,
+	RegistryContentCheckerID:      SubsystemStorage,
+	RootFSCheckerID:               SubsystemStorage,
+	monitoring.DiskSpaceCheckerID: SubsystemStorage,
+
+	// system (host/infra checkers without a more specific home)
+	RequiredBinariesCheckerID: SubsystemSystem,
+	BootTimeCheckerID:         SubsystemSystem,
+	ClockSourceCheckerID:      SubsystemSystem,
+	OOMCheckerID:              SubsystemSystem,
+	SwapCheckerID:             SubsystemSystem,
+	SystemdDegradedCheckerID:  SubsystemSystem,
+	CloudMetadataCheckerID:    SubsystemSystem,
+	CloudIAMCheckerID:         SubsystemSystem,
+	ResourceLimitsCheckerID:   SubsystemSystem,
+	PKITrustChainCheckerID:    SubsystemSystem,
+	EventCoalescerCheckerID:   SubsystemSystem,
+	"versions":                SubsystemSystem,
+	"docker":                  SubsystemSystem,
+	"systemd":                 SubsystemSystem,
+	"aws":                     SubsystemSystem,
+	"kernel-check":            SubsystemSystem,
+	"cgroup-mounts":           SubsystemSystem,
+	"may-detach-mounts":       SubsystemSystem,
+	"file-nr":                 SubsystemSystem,
+}
+
+// CheckerSubsystem returns the Subsystem registered for the checker named
+// name, falling back to SubsystemSystem if none was registered.
+func CheckerSubsystem(name string) Subsystem {
+	if subsystem, ok := checkerSubsystems[name]; ok {
+		return subsystem
+	}
+	return SubsystemSystem
+}
+
+// RegisterCheckerSubsystem declares that the checker named name belongs to
+// subsystem, overriding any default. It is exported so code outside this
+// package can extend the registry; within this package, NewExecChecker
+// uses it for external exec checkers, whose names are not known at
+// compile time (see DiscoverExecCheckers).
+func RegisterCheckerSubsystem(name string, subsystem Subsystem) {
+	checkerSubsystems[name] = subsystem
+}
@@ -0,0 +1,35 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import "testing"
+
+func TestCheckerSubsystemKnownAndFallback(t *testing.T) {
+	if subsystem := CheckerSubsystem(EtcdMembersCheckerID); subsystem != SubsystemEtcd {
+		t.Fatalf("expected %v to be in %v, got %v", EtcdMembersCheckerID, SubsystemEtcd, subsystem)
+	}
+	if subsystem := CheckerSubsystem("some-checker-nobody-registered"); subsystem != SubsystemSystem {
+		t.Fatalf("expected an unregistered checker to fall back to %v, got %v", SubsystemSystem, subsystem)
+	}
+}
+
+func TestRegisterCheckerSubsystemOverridesDefault(t *testing.T) {
+	RegisterCheckerSubsystem("test-custom-checker", SubsystemStorage)
+	if subsystem := CheckerSubsystem("test-custom-checker"); subsystem != SubsystemStorage {
+		t.Fatalf("expected the registered subsystem to take effect, got %v", subsystem)
+	}
+}
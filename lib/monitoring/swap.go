@@ -0,0 +1,130 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// SwapCheckerID identifies the checker that verifies swap is disabled.
+const SwapCheckerID = "swap"
+
+// procSwapsFile lists the swap devices currently active on the host, one
+// per line after a header row.
+const procSwapsFile = "/proc/swaps"
+
+// SwapCheckerConfig configures NewSwapChecker.
+type SwapCheckerConfig struct {
+	// Path is the file to read active swap devices from.
+	// Defaults to procSwapsFile.
+	Path string
+	// Severity is the severity to report when swap is found enabled.
+	// Defaults to pb.Probe_Warning.
+	Severity pb.Probe_Severity
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *SwapCheckerConfig) CheckAndSetDefaults() error {
+	if r.Path == "" {
+		r.Path = procSwapsFile
+	}
+	if r.Severity == pb.Probe_None {
+		r.Severity = pb.Probe_Warning
+	}
+	return nil
+}
+
+// NewSwapChecker returns a checker that reports when swap is enabled on the
+// host. Kubernetes expects swap to be disabled - an active swap device can
+// mask memory pressure from the kubelet's eviction manager.
+func NewSwapChecker(config SwapCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &swapChecker{SwapCheckerConfig: config}, nil
+}
+
+type swapChecker struct {
+	SwapCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *swapChecker) Name() string { return SwapCheckerID }
+
+// Check reads the active swap devices and reports a probe if any are found.
+func (r *swapChecker) Check(ctx context.Context, reporter health.Reporter) {
+	data, err := ioutil.ReadFile(r.Path)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), fmt.Sprintf("failed to read swap devices from %v", r.Path), trace.ConvertSystemError(err)))
+		return
+	}
+
+	devices := parseSwapDevices(string(data))
+	if len(devices) == 0 {
+		reporter.Add(&pb.Probe{
+			Checker: r.Name(),
+			Status:  pb.Probe_Running,
+		})
+		return
+	}
+
+	reporter.Add(&pb.Probe{
+		Checker:  r.Name(),
+		Status:   pb.Probe_Failed,
+		Severity: r.Severity,
+		Detail:   strings.Join(devices, ", "),
+		Error:    fmt.Sprintf("swap is enabled (%v) but Kubernetes expects it disabled - an active swap device can mask memory pressure from the kubelet's eviction manager", strings.Join(devices, ", ")),
+	})
+}
+
+// swapDevice describes a single active swap device as reported by
+// /proc/swaps.
+type swapDevice struct {
+	name    string
+	sizeKiB string
+}
+
+// String formats the swap device as "name (size)".
+func (d swapDevice) String() string {
+	return fmt.Sprintf("%v (%vkB)", d.name, d.sizeKiB)
+}
+
+// parseSwapDevices parses the contents of /proc/swaps and returns the name
+// and size of every active swap device. /proc/swaps has a header line
+// followed by one line per device: "Filename Type Size Used Priority".
+func parseSwapDevices(data string) []string {
+	var devices []string
+	for i, line := range strings.Split(data, "\n") {
+		if i == 0 || strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		devices = append(devices, swapDevice{name: fields[0], sizeKiB: fields[2]}.String())
+	}
+	return devices
+}
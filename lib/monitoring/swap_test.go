@@ -0,0 +1,92 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestParseSwapDevicesReturnsNoneWhenOnlyHeaderPresent(t *testing.T) {
+	devices := parseSwapDevices("Filename\t\t\t\tType\t\tSize\tUsed\tPriority\n")
+	if len(devices) != 0 {
+		t.Fatalf("expected no swap devices, got %v", devices)
+	}
+}
+
+func TestParseSwapDevicesReturnsActiveDevices(t *testing.T) {
+	data := "Filename\t\t\t\tType\t\tSize\tUsed\tPriority\n" +
+		"/dev/sda2                               partition\t2097148\t0\t-2\n"
+	devices := parseSwapDevices(data)
+	if len(devices) != 1 {
+		t.Fatalf("expected one swap device, got %v", devices)
+	}
+	if devices[0] != "/dev/sda2 (2097148kB)" {
+		t.Fatalf("unexpected device description: %v", devices[0])
+	}
+}
+
+func TestSwapCheckerPassesWhenNoSwapActive(t *testing.T) {
+	path := writeSwapsFile(t, "Filename\t\t\t\tType\t\tSize\tUsed\tPriority\n")
+	checker, err := NewSwapChecker(SwapCheckerConfig{Path: path})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Running {
+		t.Fatalf("expected a single running probe, got %v", reporter)
+	}
+}
+
+func TestSwapCheckerFailsWhenSwapActive(t *testing.T) {
+	data := "Filename\t\t\t\tType\t\tSize\tUsed\tPriority\n" +
+		"/dev/sda2                               partition\t2097148\t0\t-2\n"
+	path := writeSwapsFile(t, data)
+	checker, err := NewSwapChecker(SwapCheckerConfig{Path: path})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(reporter) != 1 || reporter[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected a single failed probe, got %v", reporter)
+	}
+	if reporter[0].Severity != pb.Probe_Warning {
+		t.Fatalf("expected default severity to be Warning, got %v", reporter[0].Severity)
+	}
+	if reporter[0].Detail != "/dev/sda2 (2097148kB)" {
+		t.Fatalf("expected probe detail to name the offending device, got %v", reporter[0].Detail)
+	}
+}
+
+func writeSwapsFile(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "swaps")
+	if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatalf("failed to write swaps fixture: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+
+	"github.com/gravitational/satellite/agent/health"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// RemediatingSysctlCheckerConfig configures NewRemediatingSysctlChecker.
+type RemediatingSysctlCheckerConfig struct {
+	// Checker is the underlying sysctl checker to wrap.
+	Checker *monitoring.SysctlChecker
+	// Module, if set, is loaded via modprobe before the sysctl is set, for
+	// parameters that are only exposed once a kernel module is loaded (e.g.
+	// net.bridge.bridge-nf-call-iptables requires br_netfilter).
+	Module string
+	// readSysctl reads a sysctl parameter's current value. Overridable in
+	// tests.
+	readSysctl func(param string) (string, error)
+	// setSysctl sets a sysctl parameter to value. Overridable in tests.
+	setSysctl func(ctx context.Context, param, value string) error
+	// loadModule loads a kernel module by name. Overridable in tests.
+	loadModule func(ctx context.Context, module string) error
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *RemediatingSysctlCheckerConfig) CheckAndSetDefaults() error {
+	if r.Checker == nil {
+		return trace.BadParameter("Checker is required")
+	}
+	if r.readSysctl == nil {
+		r.readSysctl = monitoring.Sysctl
+	}
+	if r.setSysctl == nil {
+		r.setSysctl = runSysctl
+	}
+	if r.loadModule == nil {
+		r.loadModule = runModprobe
+	}
+	return nil
+}
+
+// NewRemediatingSysctlChecker returns a checker that wraps a sysctl
+// checker: when the wrapped checker's parameter is missing or has an
+// unexpected value, it attempts to fix it (loading Module if set, then
+// setting the parameter to its expected value) before re-running the
+// wrapped check, so well-understood misconfigurations don't require manual
+// intervention.
+func NewRemediatingSysctlChecker(config RemediatingSysctlCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &remediatingSysctlChecker{RemediatingSysctlCheckerConfig: config}, nil
+}
+
+type remediatingSysctlChecker struct {
+	RemediatingSysctlCheckerConfig
+}
+
+// Name returns the name of the wrapped checker.
+func (r *remediatingSysctlChecker) Name() string { return r.Checker.Name() }
+
+// Check verifies the wrapped sysctl parameter, attempting remediation and a
+// re-check if it's found missing or misconfigured.
+func (r *remediatingSysctlChecker) Check(ctx context.Context, reporter health.Reporter) {
+	value, err := r.readSysctl(r.Checker.Param)
+	if err == nil && value == r.Checker.Expected {
+		r.Checker.Check(ctx, reporter)
+		return
+	}
+
+	logger := log.WithField("param", r.Checker.Param)
+	if err := r.remediate(ctx); err != nil {
+		logger.WithError(err).Warn("Failed to remediate sysctl parameter.")
+	} else {
+		logger.Info("Remediated sysctl parameter.")
+	}
+	r.Checker.Check(ctx, reporter)
+}
+
+// remediate loads Module (if set) and sets the wrapped checker's parameter
+// to its expected value.
+func (r *remediatingSysctlChecker) remediate(ctx context.Context) error {
+	if r.Module != "" {
+		if err := r.loadModule(ctx, r.Module); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return trace.Wrap(r.setSysctl(ctx, r.Checker.Param, r.Checker.Expected))
+}
+
+// runSysctl sets a sysctl parameter via the sysctl binary.
+func runSysctl(ctx context.Context, param, value string) error {
+	out, err := exec.CommandContext(ctx, "sysctl", "-w", fmt.Sprintf("%v=%v", param, value)).CombinedOutput()
+	if err != nil {
+		return trace.Wrap(err, "sysctl -w %v=%v: %v", param, value, string(out))
+	}
+	return nil
+}
+
+// runModprobe loads a kernel module via the modprobe binary.
+func runModprobe(ctx context.Context, module string) error {
+	out, err := exec.CommandContext(ctx, "modprobe", module).CombinedOutput()
+	if err != nil {
+		return trace.Wrap(err, "modprobe %v: %v", module, string(out))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gravitational/satellite/agent/health"
+	"github.com/gravitational/satellite/monitoring"
+)
+
+func TestRemediatingSysctlCheckerAttemptsRemediation(t *testing.T) {
+	var modprobeCalls []string
+	var sysctlCalls [][2]string
+
+	checker, err := NewRemediatingSysctlChecker(RemediatingSysctlCheckerConfig{
+		Checker: &monitoring.SysctlChecker{
+			CheckerName: "test-sysctl",
+			// A parameter that doesn't exist on the test host, so the
+			// checker always finds it missing and attempts remediation.
+			Param:     "test.made.up.param",
+			Expected:  "1",
+			OnMissing: "parameter is missing",
+		},
+		Module: "test-module",
+		setSysctl: func(ctx context.Context, param, value string) error {
+			sysctlCalls = append(sysctlCalls, [2]string{param, value})
+			return nil
+		},
+		loadModule: func(ctx context.Context, module string) error {
+			modprobeCalls = append(modprobeCalls, module)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if len(modprobeCalls) != 1 || modprobeCalls[0] != "test-module" {
+		t.Errorf("expected modprobe to be called once with test-module, got %v", modprobeCalls)
+	}
+	if len(sysctlCalls) != 1 || sysctlCalls[0] != [2]string{"test.made.up.param", "1"} {
+		t.Errorf("expected sysctl -w test.made.up.param=1 to be attempted once, got %v", sysctlCalls)
+	}
+	// The re-check runs after remediation and still reports a probe - the
+	// parameter genuinely doesn't exist on the test host so it can't
+	// actually be fixed, but remediation must still have been attempted.
+	if reporter.NumProbes() != 1 {
+		t.Fatalf("expected exactly one probe from the re-check, got %v", reporter.NumProbes())
+	}
+}
+
+func TestRemediatingSysctlCheckerSkipsRemediationWhenAlreadyCorrect(t *testing.T) {
+	remediated := false
+
+	checker, err := NewRemediatingSysctlChecker(RemediatingSysctlCheckerConfig{
+		Checker: &monitoring.SysctlChecker{
+			CheckerName: "test-sysctl",
+			Param:       "test.made.up.param",
+			Expected:    "1",
+		},
+		readSysctl: func(param string) (string, error) {
+			return "1", nil
+		},
+		setSysctl: func(ctx context.Context, param, value string) error {
+			remediated = true
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+
+	var reporter health.Probes
+	checker.Check(context.Background(), &reporter)
+
+	if remediated {
+		t.Errorf("expected no remediation attempt when the parameter already matches")
+	}
+	if reporter.NumProbes() != 1 {
+		t.Fatalf("expected exactly one probe, got %v", reporter.NumProbes())
+	}
+}
+
+func TestRemediatingSysctlCheckerName(t *testing.T) {
+	checker, err := NewRemediatingSysctlChecker(RemediatingSysctlCheckerConfig{
+		Checker: &monitoring.SysctlChecker{CheckerName: "test-sysctl", Param: "test.param", Expected: "1"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create checker: %v", err)
+	}
+	if checker.Name() != "test-sysctl" {
+		t.Errorf("expected name test-sysctl, got %v", checker.Name())
+	}
+}
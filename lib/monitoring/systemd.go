@@ -0,0 +1,146 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+)
+
+// SystemdDegradedCheckerID identifies the checker that verifies systemd
+// isn't reporting a degraded system state because of accumulated failed
+// units that individually don't fail any other health check.
+const SystemdDegradedCheckerID = "systemd-degraded"
+
+// SystemdDegradedCheckerConfig configures NewSystemdDegradedChecker.
+type SystemdDegradedCheckerConfig struct {
+	// CriticalUnits are the systemd units whose presence in the failed set
+	// fails this checker. Any other failed unit is only reported as a warning.
+	CriticalUnits []string
+}
+
+// CheckAndSetDefaults validates the configuration.
+func (r *SystemdDegradedCheckerConfig) CheckAndSetDefaults() error {
+	if len(r.CriticalUnits) == 0 {
+		return trace.BadParameter("CriticalUnits is required")
+	}
+	return nil
+}
+
+// NewSystemdDegradedChecker returns a checker that runs
+// `systemctl is-system-running` and, when systemd reports the system as
+// degraded, enumerates the failed units via `systemctl --failed` and
+// includes them in the probe detail. This complements SystemdHealth, which
+// only checks the units planet itself depends on and can pass even while
+// unrelated failed units accumulate and degrade the overall system state.
+func NewSystemdDegradedChecker(config SystemdDegradedCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &systemdDegradedChecker{SystemdDegradedCheckerConfig: config}, nil
+}
+
+type systemdDegradedChecker struct {
+	SystemdDegradedCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *systemdDegradedChecker) Name() string { return SystemdDegradedCheckerID }
+
+// Check verifies systemd does not consider the system degraded.
+func (r *systemdDegradedChecker) Check(ctx context.Context, reporter health.Reporter) {
+	state, err := systemctlIsSystemRunning(ctx)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to query systemd system state", trace.Wrap(err)))
+		return
+	}
+
+	if state != "degraded" {
+		reporter.Add(monitoring.NewSuccessProbe(r.Name()))
+		return
+	}
+
+	failedUnits, err := systemctlFailedUnits(ctx)
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "system is degraded, failed to enumerate failed units", trace.Wrap(err)))
+		return
+	}
+
+	severity := pb.Probe_Warning
+	if isCriticalUnitFailed(r.CriticalUnits, failedUnits) {
+		severity = pb.Probe_Critical
+	}
+	reporter.Add(&pb.Probe{
+		Checker:  r.Name(),
+		Detail:   fmt.Sprintf("system is degraded, failed units: %v", strings.Join(failedUnits, ", ")),
+		Status:   pb.Probe_Failed,
+		Severity: severity,
+		Code:     string(CodeSystemdDegraded),
+	})
+}
+
+// isCriticalUnitFailed reports whether any of criticalUnits is present in failedUnits.
+func isCriticalUnitFailed(criticalUnits, failedUnits []string) bool {
+	for _, failed := range failedUnits {
+		for _, critical := range criticalUnits {
+			if failed == critical {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// systemctlIsSystemRunning runs `systemctl is-system-running` and returns
+// its state (e.g. "running", "degraded"). Note that the command exits
+// non-zero for any state other than "running", so its output is used
+// regardless of the exit code.
+func systemctlIsSystemRunning(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, "systemctl", "is-system-running").CombinedOutput()
+	state := strings.TrimSpace(string(out))
+	if state == "" {
+		return "", trace.Wrap(err, "systemctl is-system-running: %v", string(out))
+	}
+	return state, nil
+}
+
+// systemctlFailedUnits runs `systemctl --failed` and returns the names of
+// the failed units.
+func systemctlFailedUnits(ctx context.Context) ([]string, error) {
+	out, err := exec.CommandContext(ctx, "systemctl", "--failed", "--no-legend", "--plain").CombinedOutput()
+	if err != nil {
+		return nil, trace.Wrap(err, "systemctl --failed: %v", string(out))
+	}
+	var units []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		// each line starts with the unit name, e.g.:
+		// docker.service loaded failed failed Docker Application Container Engine
+		units = append(units, strings.Fields(line)[0])
+	}
+	return units, nil
+}
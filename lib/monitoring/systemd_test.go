@@ -0,0 +1,41 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import "testing"
+
+func TestIsCriticalUnitFailedMatch(t *testing.T) {
+	criticalUnits := []string{"etcd.service", "kube-kubelet.service"}
+	failedUnits := []string{"cron.service", "kube-kubelet.service"}
+	if !isCriticalUnitFailed(criticalUnits, failedUnits) {
+		t.Errorf("expected a critical unit to be reported failed")
+	}
+}
+
+func TestIsCriticalUnitFailedNoMatch(t *testing.T) {
+	criticalUnits := []string{"etcd.service", "kube-kubelet.service"}
+	failedUnits := []string{"cron.service", "logrotate.service"}
+	if isCriticalUnitFailed(criticalUnits, failedUnits) {
+		t.Errorf("expected no critical unit to be reported failed")
+	}
+}
+
+func TestIsCriticalUnitFailedEmpty(t *testing.T) {
+	if isCriticalUnitFailed([]string{"etcd.service"}, nil) {
+		t.Errorf("expected no critical unit to be reported failed when nothing has failed")
+	}
+}
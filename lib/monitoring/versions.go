@@ -18,12 +18,19 @@ package monitoring
 
 import (
 	"context"
+	"encoding/json"
 	"os/exec"
+	"regexp"
 
 	"github.com/gravitational/satellite/agent/health"
 	pb "github.com/gravitational/satellite/agent/proto/agentpb"
 )
 
+// VersionTag is the serf member tag that carries the planet build version,
+// set by the agent on startup so it rides along with MemberStatus and can
+// be cross-referenced by versionSkewChecker without an extra RPC.
+const VersionTag = "version"
+
 // NewVersionCollector returns new instance of version collector probe
 func NewVersionCollector() *VersionCollector {
 	return &VersionCollector{}
@@ -45,12 +52,62 @@ func (r *VersionCollector) Check(ctx context.Context, reporter health.Reporter)
 		if err != nil {
 			out += err.Error()
 		}
-		reporter.Add(&pb.Probe{
+		probe := &pb.Probe{
 			Checker: checker.component,
-			Detail:  string(output),
+			Detail:  out,
 			Status:  pb.Probe_Running,
-		})
+		}
+		if version := parseComponentVersion(out); version != "" {
+			data, err := json.Marshal(componentVersion{Version: version})
+			if err == nil {
+				probe.CheckerData = data
+			}
+		}
+		reporter.Add(probe)
+	}
+}
+
+// componentVersion is the structured data stored in a version probe's
+// CheckerData, so downstream tooling can compare versions without having to
+// parse the raw command output kept in Detail.
+type componentVersion struct {
+	// Version is the normalized "vX.Y.Z[-pre]" version extracted from the
+	// component's raw output. Empty if none could be found. For "uname -a"
+	// this is the kernel version (e.g. "v5.4.0-generic").
+	Version string `json:"version,omitempty"`
+}
+
+// semverPattern matches a semantic-version-like substring (optionally
+// v-prefixed, with an optional pre-release/build suffix) anywhere in a
+// string. It is deliberately permissive rather than component-specific,
+// since each binary formats its version output differently (see
+// versions_test.go for the range this needs to handle) and a single
+// pattern that finds the version wherever it appears is easier to keep
+// correct than one parser per binary.
+var semverPattern = regexp.MustCompile(`v?(\d+\.\d+\.\d+(?:[-+][0-9A-Za-z.+-]*)?)`)
+
+// parseComponentVersion extracts a normalized "vX.Y.Z" version from a
+// component's raw version/info output, or returns "" if none is found.
+func parseComponentVersion(output string) string {
+	match := semverPattern.FindStringSubmatch(output)
+	if match == nil {
+		return ""
+	}
+	return "v" + match[1]
+}
+
+// InfoCheckerCommands returns the commands NewVersionCollector runs to
+// determine component versions, keyed by component name. It is exposed for
+// callers that need to run the same commands in a different execution
+// context than the collector's own Check - e.g. "planet debug diag" runs on
+// the host but must execute these commands inside the container where the
+// binaries actually live.
+func InfoCheckerCommands() map[string][]string {
+	commands := make(map[string][]string, len(infoCheckers))
+	for _, checker := range infoCheckers {
+		commands[checker.component] = checker.command
 	}
+	return commands
 }
 
 type infoChecker struct {
@@ -0,0 +1,83 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import "testing"
+
+func TestParseComponentVersion(t *testing.T) {
+	testCases := []struct {
+		comment string
+		output  string
+		version string
+	}{
+		{
+			comment: "kubelet",
+			output:  "Kubernetes v1.17.9\n",
+			version: "v1.17.9",
+		},
+		{
+			comment: "etcd",
+			output:  "etcd Version: 3.3.15\nGit SHA: 1e35b40\nGo Version: go1.12.17\nGo OS/Arch: linux/amd64\n",
+			version: "v3.3.15",
+		},
+		{
+			comment: "docker info",
+			output:  "Client:\n Debug Mode: false\nServer:\n Containers: 12\n Server Version: 19.03.13\n Storage Driver: overlay2\n",
+			version: "v19.03.13",
+		},
+		{
+			comment: "coredns",
+			output:  "CoreDNS-1.6.7\nlinux/amd64, go1.13.4, 8b3a0da\n",
+			version: "v1.6.7",
+		},
+		{
+			comment: "flanneld",
+			output:  "0.11.0\n",
+			version: "v0.11.0",
+		},
+		{
+			comment: "registry",
+			output:  "docker-registry github.com/docker/distribution v2.7.1\n",
+			version: "v2.7.1",
+		},
+		{
+			comment: "node-problem-detector",
+			output:  "node-problem-detector version: v0.8.0\n",
+			version: "v0.8.0",
+		},
+		{
+			comment: "uname reports a kernel version rather than a component version",
+			output:  "Linux planet 5.4.0-generic #1 SMP x86_64 GNU/Linux\n",
+			version: "v5.4.0-generic",
+		},
+		{
+			comment: "no semver-like version present",
+			output:  "D-Bus Message Bus Daemon 1.12\n",
+			version: "",
+		},
+		{
+			comment: "command failed and produced no output",
+			output:  "exec: \"/usr/bin/kubelet\": stat /usr/bin/kubelet: no such file or directory",
+			version: "",
+		},
+	}
+	for _, tc := range testCases {
+		if version := parseComponentVersion(tc.output); version != tc.version {
+			t.Errorf("%v: expected version %q, got %q", tc.comment, tc.version, version)
+		}
+	}
+}
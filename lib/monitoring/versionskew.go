@@ -0,0 +1,122 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/lib/membership"
+	"github.com/gravitational/satellite/monitoring"
+	"github.com/gravitational/trace"
+	"github.com/gravitational/version"
+)
+
+// VersionSkewCheckerID identifies the checker that verifies the planet
+// build version is consistent across cluster nodes.
+const VersionSkewCheckerID = "version-skew"
+
+// VersionSkewCheckerConfig configures NewVersionSkewChecker.
+type VersionSkewCheckerConfig struct {
+	// NodeName is the name of the local node as seen by the cluster.
+	NodeName string
+	// LocalVersion is the local planet build version. Defaults to the
+	// version this binary was built with.
+	LocalVersion string
+	// Cluster is used to query serf cluster membership.
+	Cluster membership.Cluster
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *VersionSkewCheckerConfig) CheckAndSetDefaults() error {
+	if r.NodeName == "" {
+		return trace.BadParameter("NodeName is required")
+	}
+	if r.Cluster == nil {
+		return trace.BadParameter("Cluster is required")
+	}
+	if r.LocalVersion == "" {
+		r.LocalVersion = version.Get().Version
+	}
+	return nil
+}
+
+// NewVersionSkewChecker returns a checker that warns when cluster nodes are
+// running mismatched planet build versions, which is expected transiently
+// during a rolling upgrade but otherwise indicates a stuck upgrade.
+func NewVersionSkewChecker(config VersionSkewCheckerConfig) (health.Checker, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &versionSkewChecker{VersionSkewCheckerConfig: config}, nil
+}
+
+type versionSkewChecker struct {
+	VersionSkewCheckerConfig
+}
+
+// Name returns the name of this checker.
+func (r *versionSkewChecker) Name() string { return VersionSkewCheckerID }
+
+// Check compares the local planet version against the version reported by
+// the rest of the cluster via serf member tags.
+func (r *versionSkewChecker) Check(ctx context.Context, reporter health.Reporter) {
+	members, err := r.Cluster.Members()
+	if err != nil {
+		reporter.Add(monitoring.NewProbeFromErr(r.Name(), "failed to query cluster members", trace.Wrap(err)))
+		return
+	}
+
+	if probe := versionSkewProbe(r.Name(), r.NodeName, r.LocalVersion, members); probe != nil {
+		reporter.Add(probe)
+		return
+	}
+	reporter.Add(&pb.Probe{
+		Checker: r.Name(),
+		Status:  pb.Probe_Running,
+	})
+}
+
+// versionSkewProbe returns a failed probe listing the nodes whose reported
+// version tag differs from localVersion, or nil if the cluster agrees.
+func versionSkewProbe(checker, localNode, localVersion string, members []*pb.MemberStatus) *pb.Probe {
+	var mismatched []string
+	for _, member := range members {
+		if member.Status != pb.MemberStatus_Alive || member.NodeName == localNode {
+			continue
+		}
+		nodeVersion, ok := member.Tags[VersionTag]
+		if !ok || nodeVersion == localVersion {
+			continue
+		}
+		mismatched = append(mismatched, fmt.Sprintf("%v(%v)", member.NodeName, nodeVersion))
+	}
+	if len(mismatched) == 0 {
+		return nil
+	}
+	sort.Strings(mismatched)
+	return &pb.Probe{
+		Checker:  checker,
+		Status:   pb.Probe_Failed,
+		Severity: pb.Probe_Warning,
+		Detail:   fmt.Sprintf("local node is running %v", localVersion),
+		Error:    fmt.Sprintf("version mismatch across cluster: %v", mismatched),
+	}
+}
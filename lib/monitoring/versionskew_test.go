@@ -0,0 +1,62 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package monitoring
+
+import (
+	"testing"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestVersionSkewProbe(t *testing.T) {
+	members := []*pb.MemberStatus{
+		{NodeName: "node-1", Status: pb.MemberStatus_Alive, Tags: map[string]string{VersionTag: "1.2.3"}},
+		{NodeName: "node-2", Status: pb.MemberStatus_Alive, Tags: map[string]string{VersionTag: "1.2.3"}},
+	}
+	if probe := versionSkewProbe(VersionSkewCheckerID, "node-0", "1.2.3", members); probe != nil {
+		t.Fatalf("expected no probe when all versions match, got %v", probe)
+	}
+
+	// A node with no version tag (e.g. an older release) should be ignored
+	// rather than reported as mismatched.
+	membersMissingTag := []*pb.MemberStatus{
+		{NodeName: "node-1", Status: pb.MemberStatus_Alive, Tags: map[string]string{}},
+	}
+	if probe := versionSkewProbe(VersionSkewCheckerID, "node-0", "1.2.3", membersMissingTag); probe != nil {
+		t.Fatalf("expected no probe for a node with no version tag, got %v", probe)
+	}
+
+	// A dead member with a different version should not trigger a probe.
+	membersDead := []*pb.MemberStatus{
+		{NodeName: "node-1", Status: pb.MemberStatus_Left, Tags: map[string]string{VersionTag: "1.0.0"}},
+	}
+	if probe := versionSkewProbe(VersionSkewCheckerID, "node-0", "1.2.3", membersDead); probe != nil {
+		t.Fatalf("expected no probe for a non-alive member, got %v", probe)
+	}
+
+	mismatched := []*pb.MemberStatus{
+		{NodeName: "node-1", Status: pb.MemberStatus_Alive, Tags: map[string]string{VersionTag: "1.2.3"}},
+		{NodeName: "node-2", Status: pb.MemberStatus_Alive, Tags: map[string]string{VersionTag: "1.0.0"}},
+	}
+	probe := versionSkewProbe(VersionSkewCheckerID, "node-0", "1.2.3", mismatched)
+	if probe == nil {
+		t.Fatal("expected a probe for mismatched versions")
+	}
+	if probe.Status != pb.Probe_Failed {
+		t.Errorf("expected status %v but got %v", pb.Probe_Failed, probe.Status)
+	}
+}
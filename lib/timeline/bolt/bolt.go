@@ -0,0 +1,304 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bolt provides a history.Timeline implementation backed by a
+// pure-Go bbolt database, for deployments that want to avoid the CGO
+// dependency of the upstream sqlite timeline.
+package bolt
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/utils"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// defaultTimelineRetention defines the default duration to store timeline events.
+const defaultTimelineRetention = time.Hour * 24 * 7
+
+// evictionFrequency is the time between eviction loops.
+const evictionFrequency = time.Hour
+
+// eventsBucket holds serialized events keyed by timestamp||sequence, so a
+// bucket scan naturally returns events in chronological order.
+var eventsBucket = []byte("events")
+
+// indexBucket holds one entry per distinct event tuple (timestamp, type,
+// node, probe, oldState, newState), used to detect and skip duplicates.
+var indexBucket = []byte("index")
+
+// Config defines Timeline configuration.
+type Config struct {
+	// DBPath specifies the database location.
+	DBPath string
+	// RetentionDuration specifies the duration to store events.
+	RetentionDuration time.Duration
+	// Clock will be used to record event timestamps.
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults validates this configuration object.
+// Config values that were not specified will be set to their default values if
+// available.
+func (c *Config) CheckAndSetDefaults() error {
+	var errors []error
+
+	if c.DBPath == "" {
+		errors = append(errors, trace.BadParameter("bolt database path must be provided"))
+	}
+
+	if c.Clock == nil {
+		c.Clock = clockwork.NewRealClock()
+	}
+
+	if c.RetentionDuration == time.Duration(0) {
+		c.RetentionDuration = defaultTimelineRetention
+	}
+
+	return trace.NewAggregate(errors...)
+}
+
+// Timeline represents a timeline of status events.
+// Timeline events are stored in a local bbolt database.
+// The timeline will retain events for a specified duration and then deleted.
+//
+// Implements history.Timeline
+type Timeline struct {
+	// config contains timeline configuration.
+	config Config
+	// database points to the underlying bbolt database.
+	database *bolt.DB
+}
+
+// NewTimeline initializes and returns a new Timeline with the
+// specified configuration.
+func NewTimeline(ctx context.Context, config Config) (*Timeline, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	timeline := &Timeline{config: config}
+
+	if err := timeline.initBolt(); err != nil {
+		return nil, trace.Wrap(err, "failed to initialize bolt database")
+	}
+
+	go timeline.eventEvictionLoop(context.TODO())
+
+	return timeline, nil
+}
+
+// initBolt opens the database and creates the buckets used to store events.
+func (t *Timeline) initBolt() error {
+	dir := filepath.Dir(t.config.DBPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+
+	database, err := bolt.Open(t.config.DBPath, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return trace.Wrap(err, "failed to open bolt database at %s", t.config.DBPath)
+	}
+
+	err = database.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(eventsBucket); err != nil {
+			return trace.Wrap(err)
+		}
+		if _, err := tx.CreateBucketIfNotExists(indexBucket); err != nil {
+			return trace.Wrap(err)
+		}
+		return nil
+	})
+	if err != nil {
+		database.Close()
+		return trace.Wrap(err, "failed to create bolt buckets")
+	}
+
+	t.database = database
+	return nil
+}
+
+// Close releases the underlying database handle.
+func (t *Timeline) Close() error {
+	return trace.Wrap(t.database.Close())
+}
+
+// eventEvictionLoop periodically evicts old events to free up storage.
+func (t *Timeline) eventEvictionLoop(ctx context.Context) {
+	ticker := t.config.Clock.NewTicker(evictionFrequency)
+	defer ticker.Stop()
+	for range ticker.Chan() {
+		if utils.IsContextDone(ctx) {
+			log.Info("Eviction loop is stopping.")
+			return
+		}
+		if err := t.evictEvents(t.getRetentionCutOff()); err != nil {
+			log.WithError(err).Warn("Error evicting expired events.")
+		}
+	}
+}
+
+// getRetentionCutOff returns the retention cut off time for the timeline. All
+// events before this time is expired and should be removed from the timeline.
+func (t *Timeline) getRetentionCutOff() time.Time {
+	return t.config.Clock.Now().Add(-(t.config.RetentionDuration))
+}
+
+// evictEvents deletes events that have outlived the timeline retention duration.
+func (t *Timeline) evictEvents(retentionCutOff time.Time) error {
+	cutoff := encodeTimestamp(retentionCutOff)
+	return trace.Wrap(t.database.Update(func(tx *bolt.Tx) error {
+		events := tx.Bucket(eventsBucket)
+		index := tx.Bucket(indexBucket)
+
+		var expired [][]byte
+		c := events.Cursor()
+		for key, value := c.First(); key != nil && bytesLess(key[:8], cutoff); key, value = c.Next() {
+			var row boltEvent
+			if err := json.Unmarshal(value, &row); err != nil {
+				log.WithError(err).Warn("Failed to unmarshal expired event, dropping.")
+			} else if err := index.Delete(dedupKey(decodeTimestamp(key), row)); err != nil {
+				return trace.Wrap(err)
+			}
+			expired = append(expired, append([]byte{}, key...))
+		}
+		for _, key := range expired {
+			if err := events.Delete(key); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	}))
+}
+
+// RecordEvents records the provided events into the timeline.
+// Duplicate events will be ignored.
+func (t *Timeline) RecordEvents(ctx context.Context, events []*pb.TimelineEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	cutoff := t.getRetentionCutOff()
+	return trace.Wrap(t.database.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(eventsBucket)
+		index := tx.Bucket(indexBucket)
+		for _, event := range events {
+			if event.GetTimestamp().ToTime().Before(cutoff) {
+				log.WithField("filtered-event", event).Debug("Event filtered.")
+				continue
+			}
+			if err := insertEvent(bucket, index, event); err != nil {
+				return trace.Wrap(err)
+			}
+		}
+		return nil
+	}))
+}
+
+// insertEvent inserts event into bucket, skipping it if an identical event
+// (matching timestamp, type, node, probe, oldState and newState) already exists.
+func insertEvent(bucket, index *bolt.Bucket, event *pb.TimelineEvent) error {
+	row, err := newBoltEvent(event)
+	if err != nil {
+		log.WithError(err).Warn("Attempting to insert unknown event.")
+		return nil
+	}
+
+	ts := event.GetTimestamp().ToTime()
+	dedup := dedupKey(ts, row)
+	if index.Get(dedup) != nil {
+		log.WithField("event", event).Debug("Attempting to insert duplicate event.")
+		return nil
+	}
+
+	seq, err := bucket.NextSequence()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	value, err := json.Marshal(row)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := bucket.Put(eventKey(ts, seq), value); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(index.Put(dedup, []byte{1}))
+}
+
+// GetEvents returns a filtered list of events based on the provided params.
+// Events will be returned in sorted order by timestamp.
+// The filter uses "AND" logic with the params.
+func (t *Timeline) GetEvents(ctx context.Context, params map[string]string) (events []*pb.TimelineEvent, err error) {
+	filtered := filterParams(params)
+	err = t.database.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(eventsBucket).ForEach(func(key, value []byte) error {
+			var row boltEvent
+			if err := json.Unmarshal(value, &row); err != nil {
+				return trace.Wrap(err)
+			}
+			if !row.matches(filtered) {
+				return nil
+			}
+			events = append(events, row.ProtoBuf(decodeTimestamp(key)))
+			return nil
+		})
+	})
+	return events, trace.Wrap(err)
+}
+
+// filterParams returns the subset of params recognized as event fields.
+func filterParams(params map[string]string) map[string]string {
+	filtered := make(map[string]string)
+	for _, key := range []string{"type", "node", "probe", "oldState", "newState"} {
+		if val, ok := params[key]; ok {
+			filtered[key] = val
+		}
+	}
+	return filtered
+}
+
+// eventKey builds the bucket key for an event, so bucket iteration returns
+// events sorted by timestamp with ties broken by insertion order.
+func eventKey(ts time.Time, seq uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[:8], uint64(ts.UnixNano()))
+	binary.BigEndian.PutUint64(key[8:], seq)
+	return key
+}
+
+func encodeTimestamp(ts time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(ts.UnixNano()))
+	return key
+}
+
+func decodeTimestamp(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key[:8]))).UTC()
+}
+
+func bytesLess(a, b []byte) bool {
+	return string(a) < string(b)
+}
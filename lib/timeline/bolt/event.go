@@ -0,0 +1,117 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bolt
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/lib/history"
+
+	"github.com/gravitational/trace"
+)
+
+// boltEvent is the JSON representation of a timeline event stored in bolt.
+// The event timestamp is not included - it is encoded in the bucket key.
+type boltEvent struct {
+	// Type specifies the event type.
+	Type string `json:"type"`
+	// Node specifies the name of the node, if applicable.
+	Node string `json:"node,omitempty"`
+	// Probe specifies the name of the probe, if applicable.
+	Probe string `json:"probe,omitempty"`
+	// Old specifies the previous probe state, if applicable.
+	Old string `json:"oldState,omitempty"`
+	// New specifies the new probe state, if applicable.
+	New string `json:"newState,omitempty"`
+}
+
+// newBoltEvent converts event into its bolt storage representation.
+func newBoltEvent(event *pb.TimelineEvent) (row boltEvent, err error) {
+	switch data := event.GetData().(type) {
+	case *pb.TimelineEvent_ClusterDegraded:
+		return boltEvent{Type: string(history.ClusterDegraded)}, nil
+	case *pb.TimelineEvent_ClusterHealthy:
+		return boltEvent{Type: string(history.ClusterHealthy)}, nil
+	case *pb.TimelineEvent_NodeAdded:
+		return boltEvent{Type: string(history.NodeAdded), Node: data.NodeAdded.GetNode()}, nil
+	case *pb.TimelineEvent_NodeRemoved:
+		return boltEvent{Type: string(history.NodeRemoved), Node: data.NodeRemoved.GetNode()}, nil
+	case *pb.TimelineEvent_NodeHealthy:
+		return boltEvent{Type: string(history.NodeHealthy), Node: data.NodeHealthy.GetNode()}, nil
+	case *pb.TimelineEvent_NodeDegraded:
+		return boltEvent{Type: string(history.NodeDegraded), Node: data.NodeDegraded.GetNode()}, nil
+	case *pb.TimelineEvent_ProbeSucceeded:
+		return boltEvent{Type: string(history.ProbeSucceeded), Node: data.ProbeSucceeded.GetNode(), Probe: data.ProbeSucceeded.GetProbe()}, nil
+	case *pb.TimelineEvent_ProbeFailed:
+		return boltEvent{Type: string(history.ProbeFailed), Node: data.ProbeFailed.GetNode(), Probe: data.ProbeFailed.GetProbe()}, nil
+	case *pb.TimelineEvent_LeaderElected:
+		return boltEvent{Type: string(history.LeaderElected), Old: data.LeaderElected.GetPrev(), New: data.LeaderElected.GetNew()}, nil
+	default:
+		return row, trace.BadParameter("unknown event type %T", data)
+	}
+}
+
+// ProtoBuf returns row as a protobuf message, using ts as the event timestamp.
+func (r boltEvent) ProtoBuf(ts time.Time) *pb.TimelineEvent {
+	switch history.EventType(r.Type) {
+	case history.ClusterDegraded:
+		return pb.NewClusterDegraded(ts)
+	case history.ClusterHealthy:
+		return pb.NewClusterHealthy(ts)
+	case history.NodeAdded:
+		return pb.NewNodeAdded(ts, r.Node)
+	case history.NodeRemoved:
+		return pb.NewNodeRemoved(ts, r.Node)
+	case history.NodeDegraded:
+		return pb.NewNodeDegraded(ts, r.Node)
+	case history.NodeHealthy:
+		return pb.NewNodeHealthy(ts, r.Node)
+	case history.ProbeFailed:
+		return pb.NewProbeFailed(ts, r.Node, r.Probe)
+	case history.ProbeSucceeded:
+		return pb.NewProbeSucceeded(ts, r.Node, r.Probe)
+	case history.LeaderElected:
+		return pb.NewLeaderElected(ts, r.Old, r.New)
+	default:
+		return pb.NewUnknownEvent(ts)
+	}
+}
+
+// matches reports whether row satisfies all of the given field filters.
+func (r boltEvent) matches(params map[string]string) bool {
+	fields := map[string]string{
+		"type":     r.Type,
+		"node":     r.Node,
+		"probe":    r.Probe,
+		"oldState": r.Old,
+		"newState": r.New,
+	}
+	for key, want := range params {
+		if fields[key] != want {
+			return false
+		}
+	}
+	return true
+}
+
+// dedupKey returns the index bucket key identifying the unique tuple of
+// (timestamp, type, node, probe, oldState, newState) that row represents.
+func dedupKey(ts time.Time, row boltEvent) []byte {
+	return []byte(fmt.Sprintf("%d|%s|%s|%s|%s|%s", ts.UnixNano(), row.Type, row.Node, row.Probe, row.Old, row.New))
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance holds a shared test suite run against every
+// history.Timeline implementation, so backends stay behaviorally identical.
+package conformance
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/lib/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run exercises timeline against the behavior every history.Timeline
+// implementation is expected to provide. newTimeline is called once per
+// sub-test and should return a fresh, empty Timeline.
+func Run(t *testing.T, newTimeline func(t *testing.T) history.Timeline) {
+	t.Run("RecordAndGetEvents", func(t *testing.T) {
+		testRecordAndGetEvents(t, newTimeline(t))
+	})
+	t.Run("DuplicateEventsAreIgnored", func(t *testing.T) {
+		testDuplicateEventsAreIgnored(t, newTimeline(t))
+	})
+	t.Run("FilterByParams", func(t *testing.T) {
+		testFilterByParams(t, newTimeline(t))
+	})
+}
+
+func testRecordAndGetEvents(t *testing.T, timeline history.Timeline) {
+	ctx := context.Background()
+	t0 := time.Now().Add(-time.Hour).UTC()
+
+	events := []*pb.TimelineEvent{
+		pb.NewNodeAdded(t0, "node-1"),
+		pb.NewProbeFailed(t0.Add(time.Minute), "node-1", "disk-space"),
+		pb.NewProbeSucceeded(t0.Add(2*time.Minute), "node-1", "disk-space"),
+	}
+	require.NoError(t, timeline.RecordEvents(ctx, events))
+
+	got, err := timeline.GetEvents(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, got, len(events))
+	for i, event := range got {
+		assert.True(t, event.GetTimestamp().ToTime().Equal(events[i].GetTimestamp().ToTime()),
+			"expected events to be returned sorted by timestamp")
+	}
+}
+
+func testDuplicateEventsAreIgnored(t *testing.T, timeline history.Timeline) {
+	ctx := context.Background()
+	ts := time.Now().Add(-time.Hour).UTC()
+	event := pb.NewProbeFailed(ts, "node-1", "disk-space")
+
+	require.NoError(t, timeline.RecordEvents(ctx, []*pb.TimelineEvent{event}))
+	require.NoError(t, timeline.RecordEvents(ctx, []*pb.TimelineEvent{event}))
+
+	got, err := timeline.GetEvents(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, got, 1, "duplicate event should have been ignored")
+}
+
+func testFilterByParams(t *testing.T, timeline history.Timeline) {
+	ctx := context.Background()
+	t0 := time.Now().Add(-time.Hour).UTC()
+
+	require.NoError(t, timeline.RecordEvents(ctx, []*pb.TimelineEvent{
+		pb.NewProbeFailed(t0, "node-1", "disk-space"),
+		pb.NewProbeFailed(t0.Add(time.Minute), "node-2", "disk-space"),
+		pb.NewProbeSucceeded(t0.Add(2*time.Minute), "node-1", "disk-space"),
+	}))
+
+	got, err := timeline.GetEvents(ctx, map[string]string{"node": "node-1"})
+	require.NoError(t, err)
+	assert.Len(t, got, 2, "expected only node-1 events")
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/lib/history"
+)
+
+// fields extracts the (type, node, probe, oldState, newState) tuple used for
+// both duplicate detection and GetEvents filtering.
+func fields(e *pb.TimelineEvent) (eventType history.EventType, node, probe, oldState, newState string) {
+	switch data := e.GetData().(type) {
+	case *pb.TimelineEvent_ClusterDegraded:
+		return history.ClusterDegraded, "", "", "", ""
+	case *pb.TimelineEvent_ClusterHealthy:
+		return history.ClusterHealthy, "", "", "", ""
+	case *pb.TimelineEvent_NodeAdded:
+		return history.NodeAdded, data.NodeAdded.GetNode(), "", "", ""
+	case *pb.TimelineEvent_NodeRemoved:
+		return history.NodeRemoved, data.NodeRemoved.GetNode(), "", "", ""
+	case *pb.TimelineEvent_NodeHealthy:
+		return history.NodeHealthy, data.NodeHealthy.GetNode(), "", "", ""
+	case *pb.TimelineEvent_NodeDegraded:
+		return history.NodeDegraded, data.NodeDegraded.GetNode(), "", "", ""
+	case *pb.TimelineEvent_ProbeSucceeded:
+		return history.ProbeSucceeded, data.ProbeSucceeded.GetNode(), data.ProbeSucceeded.GetProbe(), "", ""
+	case *pb.TimelineEvent_ProbeFailed:
+		return history.ProbeFailed, data.ProbeFailed.GetNode(), data.ProbeFailed.GetProbe(), "", ""
+	case *pb.TimelineEvent_LeaderElected:
+		return history.LeaderElected, "", "", data.LeaderElected.GetPrev(), data.LeaderElected.GetNew()
+	default:
+		return history.UnknownEvent, "", "", "", ""
+	}
+}
+
+// dedupKey returns a key identifying the unique tuple of (timestamp, type,
+// node, probe, oldState, newState) that e represents.
+func dedupKey(ts time.Time, e *pb.TimelineEvent) string {
+	eventType, node, probe, oldState, newState := fields(e)
+	return fmt.Sprintf("%d|%s|%s|%s|%s|%s", ts.UnixNano(), eventType, node, probe, oldState, newState)
+}
+
+// matches reports whether e satisfies all of the given field filters.
+func matches(e *pb.TimelineEvent, params map[string]string) bool {
+	eventType, node, probe, oldState, newState := fields(e)
+	values := map[string]string{
+		"type":     string(eventType),
+		"node":     node,
+		"probe":    probe,
+		"oldState": oldState,
+		"newState": newState,
+	}
+	for key, want := range params {
+		if values[key] != want {
+			return false
+		}
+	}
+	return true
+}
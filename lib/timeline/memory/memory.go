@@ -0,0 +1,148 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package memory provides a history.Timeline implementation that keeps
+// events in memory. It does not persist across agent restarts and is
+// intended for development, testing, or deployments that don't need the
+// local timeline to survive a restart.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+
+	"github.com/gravitational/trace"
+	"github.com/jonboulle/clockwork"
+)
+
+// defaultTimelineRetention defines the default duration to store timeline events.
+const defaultTimelineRetention = time.Hour * 24 * 7
+
+// Config defines Timeline configuration.
+type Config struct {
+	// RetentionDuration specifies the duration to store events.
+	RetentionDuration time.Duration
+	// Clock will be used to record event timestamps and evict expired events.
+	Clock clockwork.Clock
+}
+
+// CheckAndSetDefaults validates this configuration object.
+// Config values that were not specified will be set to their default values if
+// available.
+func (c *Config) CheckAndSetDefaults() error {
+	if c.Clock == nil {
+		c.Clock = clockwork.NewRealClock()
+	}
+	if c.RetentionDuration == time.Duration(0) {
+		c.RetentionDuration = defaultTimelineRetention
+	}
+	return nil
+}
+
+// event is a single recorded timeline event, kept alongside its timestamp
+// so expired events can be filtered out on read without decoding data.
+type event struct {
+	timestamp time.Time
+	data      *pb.TimelineEvent
+}
+
+// Timeline represents a timeline of status events, kept in memory.
+// The timeline will retain events for a specified duration and then
+// they become invisible to GetEvents; they are not actively evicted.
+//
+// Implements history.Timeline
+type Timeline struct {
+	config Config
+
+	mu     sync.Mutex
+	events []event
+}
+
+// NewTimeline initializes and returns a new Timeline with the
+// specified configuration.
+func NewTimeline(config Config) (*Timeline, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &Timeline{config: config}, nil
+}
+
+// RecordEvents records the provided events into the timeline.
+// Duplicate events will be ignored.
+func (t *Timeline) RecordEvents(ctx context.Context, events []*pb.TimelineEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	cutoff := t.getRetentionCutOff()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seen := make(map[string]struct{}, len(t.events))
+	for _, e := range t.events {
+		seen[dedupKey(e.timestamp, e.data)] = struct{}{}
+	}
+
+	for _, e := range events {
+		ts := e.GetTimestamp().ToTime()
+		if ts.Before(cutoff) {
+			continue
+		}
+		key := dedupKey(ts, e)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		t.events = append(t.events, event{timestamp: ts, data: e})
+	}
+
+	return nil
+}
+
+// GetEvents returns a filtered list of events based on the provided params.
+// Events will be returned in sorted order by timestamp.
+// The filter uses "AND" logic with the params.
+func (t *Timeline) GetEvents(ctx context.Context, params map[string]string) ([]*pb.TimelineEvent, error) {
+	cutoff := t.getRetentionCutOff()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	events := make([]*pb.TimelineEvent, 0, len(t.events))
+	for _, e := range t.events {
+		if e.timestamp.Before(cutoff) {
+			continue
+		}
+		if matches(e.data, params) {
+			events = append(events, e.data)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].GetTimestamp().ToTime().Before(events[j].GetTimestamp().ToTime())
+	})
+	return events, nil
+}
+
+// getRetentionCutOff returns the retention cut off time for the timeline. All
+// events before this time are expired and excluded from GetEvents.
+func (t *Timeline) getRetentionCutOff() time.Time {
+	return t.config.Clock.Now().Add(-(t.config.RetentionDuration))
+}
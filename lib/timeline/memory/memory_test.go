@@ -0,0 +1,35 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package memory
+
+import (
+	"testing"
+
+	"github.com/gravitational/planet/lib/timeline/conformance"
+
+	"github.com/gravitational/satellite/lib/history"
+)
+
+func TestTimelineConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) history.Timeline {
+		timeline, err := NewTimeline(Config{})
+		if err != nil {
+			t.Fatalf("NewTimeline failed: %v", err)
+		}
+		return timeline
+	})
+}
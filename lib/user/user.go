@@ -135,6 +135,17 @@ func (r *passwdFile) Upsert(u User) {
 	r.users = append(r.users, u)
 }
 
+// FindByUID returns the user with the specified uid, if any.
+func (r *passwdFile) FindByUID(uid int) (*User, bool) {
+	for _, usr := range r.users {
+		if usr.Uid == uid {
+			usr := usr
+			return &usr, true
+		}
+	}
+	return nil, false
+}
+
 // Save stores the contents of this passwdFile into w.
 func (r *passwdFile) WriteTo(w io.Writer) (n int64, err error) {
 	b := newBuffer(w)
@@ -232,6 +243,17 @@ func (r *groupFile) Upsert(g Group) {
 	r.groups = append(r.groups, g)
 }
 
+// FindByGID returns the group with the specified gid, if any.
+func (r *groupFile) FindByGID(gid int) (*Group, bool) {
+	for _, group := range r.groups {
+		if group.Gid == gid {
+			group := group
+			return &group, true
+		}
+	}
+	return nil, false
+}
+
 // Save stores the contents of this groupFile into w.
 func (r *groupFile) WriteTo(w io.Writer) (n int64, err error) {
 	b := newBuffer(w)
@@ -102,6 +102,46 @@ func TestReplacesUser(t *testing.T) {
 	}
 }
 
+func TestFindsUserByUID(t *testing.T) {
+	rdr := strings.NewReader(passwd)
+	r, err := NewPasswd(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, found := r.FindByUID(1)
+	if !found {
+		t.Fatal("expected to find a user with uid 1")
+	}
+	if u.Name != "daemon" {
+		t.Errorf("expected daemon but got %v", u.Name)
+	}
+	if _, found := r.FindByUID(1005); found {
+		t.Error("did not expect to find a user with uid 1005")
+	}
+}
+
+const group = `root:x:0:
+daemon:x:1:
+bin:x:2:`
+
+func TestFindsGroupByGID(t *testing.T) {
+	rdr := strings.NewReader(group)
+	r, err := NewGroup(rdr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, found := r.FindByGID(1)
+	if !found {
+		t.Fatal("expected to find a group with gid 1")
+	}
+	if g.Name != "daemon" {
+		t.Errorf("expected daemon but got %v", g.Name)
+	}
+	if _, found := r.FindByGID(1005); found {
+		t.Error("did not expect to find a group with gid 1005")
+	}
+}
+
 func newUser(uid, gid int) User {
 	return User{
 		Name:  "planet-agent",
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/gravitational/trace"
+)
+
+// EnvFileFormat identifies the syntax WriteEnvFile uses for each variable
+type EnvFileFormat string
+
+const (
+	// EnvFileFormatPlain writes each variable as KEY=value, one per line,
+	// suitable for consumption as a systemd EnvironmentFile
+	EnvFileFormatPlain EnvFileFormat = "plain"
+	// EnvFileFormatExport writes each variable as export KEY="value", one
+	// per line, so the file can be sourced by a shell and have the
+	// variables inherited by child processes
+	EnvFileFormatExport EnvFileFormat = "export"
+	// EnvFileFormatQuoted writes each variable as KEY="value", one per
+	// line, suitable for a systemd EnvironmentFile value containing
+	// spaces
+	EnvFileFormatQuoted EnvFileFormat = "quoted"
+)
+
+// WriteEnvFile writes env to path, one KEY=value pair per line in the
+// requested format. Keys are sorted for deterministic output.
+func WriteEnvFile(path string, env map[string]string, format EnvFileFormat, perm os.FileMode) error {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		switch format {
+		case EnvFileFormatExport:
+			fmt.Fprintf(&buf, "export %v=%q\n", name, env[name])
+		case EnvFileFormatQuoted:
+			fmt.Fprintf(&buf, "%v=%q\n", name, env[name])
+		default:
+			fmt.Fprintf(&buf, "%v=%v\n", name, env[name])
+		}
+	}
+
+	return trace.Wrap(SafeWriteFile(path, buf.Bytes(), perm))
+}
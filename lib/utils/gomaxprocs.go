@@ -0,0 +1,85 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"io/ioutil"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// cfsQuotaFile and cfsPeriodFile are the cgroup v1 cpu controller files that
+// describe the CPU quota assigned to the current process' cgroup.
+const (
+	cfsQuotaFile  = "/sys/fs/cgroup/cpu/cpu.cfs_quota_us"
+	cfsPeriodFile = "/sys/fs/cgroup/cpu/cpu.cfs_period_us"
+)
+
+// SetGOMAXPROCS sets GOMAXPROCS to the number of CPUs available to the
+// current process' cgroup, so the runtime does not spin up more threads
+// than the container is actually allowed to schedule. It falls back to
+// runtime.NumCPU() if no CPU quota is configured (or it can't be read).
+func SetGOMAXPROCS() {
+	procs, ok := cgroupCPUQuota()
+	if !ok {
+		return
+	}
+	logrus.Infof("Setting GOMAXPROCS to %v based on cgroup CPU quota.", procs)
+	runtime.GOMAXPROCS(procs)
+}
+
+// cgroupCPUQuota reads the cgroup cpu.cfs_quota_us/cpu.cfs_period_us files
+// and computes the number of CPUs available to the process. The second
+// return value is false if no quota is configured (cfs_quota_us is unset
+// or -1) or the cgroup files could not be read.
+func cgroupCPUQuota() (procs int, ok bool) {
+	quota, err := readCgroupInt(cfsQuotaFile)
+	if err != nil || quota <= 0 {
+		return 0, false
+	}
+	period, err := readCgroupInt(cfsPeriodFile)
+	if err != nil || period <= 0 {
+		return 0, false
+	}
+	return cpuQuotaToGOMAXPROCS(quota, period, runtime.NumCPU()), true
+}
+
+// cpuQuotaToGOMAXPROCS translates a cgroup CPU quota/period pair into a
+// GOMAXPROCS value, rounded up to the nearest whole CPU and capped at
+// numCPU (the number of CPUs actually available on the host).
+func cpuQuotaToGOMAXPROCS(quota, period int64, numCPU int) int {
+	procs := int((quota + period - 1) / period) // round up
+	if procs < 1 {
+		procs = 1
+	}
+	if procs > numCPU {
+		procs = numCPU
+	}
+	return procs
+}
+
+// readCgroupInt reads a single integer value from a cgroup control file.
+func readCgroupInt(path string) (int64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+}
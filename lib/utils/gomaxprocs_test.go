@@ -0,0 +1,65 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import "testing"
+
+func TestCPUQuotaToGOMAXPROCS(t *testing.T) {
+	tests := []struct {
+		comment  string
+		quota    int64
+		period   int64
+		numCPU   int
+		expected int
+	}{
+		{
+			comment:  "quota equal to a single period gives one CPU",
+			quota:    100000,
+			period:   100000,
+			numCPU:   8,
+			expected: 1,
+		},
+		{
+			comment:  "fractional quota rounds up",
+			quota:    150000,
+			period:   100000,
+			numCPU:   8,
+			expected: 2,
+		},
+		{
+			comment:  "quota exceeding host CPUs is capped",
+			quota:    1600000,
+			period:   100000,
+			numCPU:   8,
+			expected: 8,
+		},
+		{
+			comment:  "quota smaller than a period still rounds up to one CPU",
+			quota:    20000,
+			period:   100000,
+			numCPU:   8,
+			expected: 1,
+		},
+	}
+	for _, tt := range tests {
+		got := cpuQuotaToGOMAXPROCS(tt.quota, tt.period, tt.numCPU)
+		if got != tt.expected {
+			t.Errorf("%v: cpuQuotaToGOMAXPROCS(%v, %v, %v) = %v, expected %v",
+				tt.comment, tt.quota, tt.period, tt.numCPU, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+
+	"github.com/gravitational/planet/lib/constants"
+
+	"github.com/gravitational/trace"
+)
+
+// hostsBeginMarker and hostsEndMarker delimit the block of entries
+// UpsertHostsFile manages within a hosts file. Lines outside the markers
+// are left untouched, so entries added by hand survive a sync.
+const (
+	hostsBeginMarker = "# BEGIN PLANET MANAGED HOSTS - DO NOT EDIT"
+	hostsEndMarker   = "# END PLANET MANAGED HOSTS"
+)
+
+// UpsertHostsFile rewrites the managed block of the hosts file at path with
+// one line per entry, replacing whatever managed block was there before.
+// Content outside the block - including the entire file, if it predates
+// the markers or doesn't exist yet - is preserved verbatim, so entries
+// added to the file by hand are not clobbered.
+func UpsertHostsFile(path string, entries []HostEntry) error {
+	existing, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return trace.ConvertSystemError(err)
+	}
+
+	before, after := splitManagedHostsBlock(existing)
+
+	var buf bytes.Buffer
+	buf.Write(before)
+	buf.WriteString(hostsBeginMarker + "\n")
+	if err := WriteHosts(&buf, entries); err != nil {
+		return trace.Wrap(err)
+	}
+	buf.WriteString(hostsEndMarker + "\n")
+	buf.Write(after)
+
+	return trace.Wrap(SafeWriteFile(path, buf.Bytes(), constants.SharedReadMask))
+}
+
+// splitManagedHostsBlock splits data into the parts before and after the
+// UpsertHostsFile managed block, excluding the markers themselves. If no
+// managed block is present, before is all of data and after is empty.
+func splitManagedHostsBlock(data []byte) (before, after []byte) {
+	var pre, post bytes.Buffer
+	inBlock, pastBlock := false, false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case !inBlock && !pastBlock && line == hostsBeginMarker:
+			inBlock = true
+		case inBlock && line == hostsEndMarker:
+			inBlock, pastBlock = false, true
+		case inBlock:
+			// Drop the previous managed entries.
+		case pastBlock:
+			post.WriteString(line + "\n")
+		default:
+			pre.WriteString(line + "\n")
+		}
+	}
+	return pre.Bytes(), post.Bytes()
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUpsertHostsFilePreservesManualEntries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hosts")
+	manual := "127.0.0.1 localhost\n10.0.0.99 manually-added\n"
+	if err := ioutil.WriteFile(path, []byte(manual), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = UpsertHostsFile(path, []HostEntry{
+		{IP: "10.0.0.1", Hostnames: "node-1 node-1.cluster"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := manual + hostsBeginMarker + "\n10.0.0.1 node-1 node-1.cluster\n" + hostsEndMarker + "\n"
+	if string(contents) != expected {
+		t.Errorf("expected %q, got %q", expected, string(contents))
+	}
+}
+
+func TestUpsertHostsFileReplacesPreviousManagedBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hosts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hosts")
+
+	err = UpsertHostsFile(path, []HostEntry{{IP: "10.0.0.1", Hostnames: "node-1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = UpsertHostsFile(path, []HostEntry{{IP: "10.0.0.2", Hostnames: "node-2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := hostsBeginMarker + "\n10.0.0.2 node-2\n" + hostsEndMarker + "\n"
+	if string(contents) != expected {
+		t.Errorf("expected %q, got %q", expected, string(contents))
+	}
+}
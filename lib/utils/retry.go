@@ -25,43 +25,75 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// RetryStats describes how many attempts (and how much time) a successful
+// retry helper call needed, so callers can log/metric how flaky an
+// operation turned out to be.
+type RetryStats struct {
+	// Attempts is the number of times fn was invoked, including the
+	// successful attempt.
+	Attempts int
+	// TotalDuration is the wall-clock time spent across all attempts,
+	// including the time spent waiting between them.
+	TotalDuration time.Duration
+}
+
 // Retry retries 'times' attempts with retry period 'period' calling function fn
 // until it returns nil, or until the context gets cancelled or the retries
 // get exceeded the times number of attempts
 func Retry(ctx context.Context, times int, period time.Duration, fn func() error) error {
+	_, err := RetryE(ctx, times, period, fn)
+	return err
+}
+
+// RetryE behaves like Retry but also returns RetryStats describing how many
+// attempts the call needed.
+func RetryE(ctx context.Context, times int, period time.Duration, fn func() error) (RetryStats, error) {
+	start := time.Now()
 	var err error
 	for i := 0; i < times; i += 1 {
 		err = fn()
+		stats := RetryStats{Attempts: i + 1, TotalDuration: time.Since(start)}
 		if err == nil {
-			return nil
+			return stats, nil
 		}
 		log.Debugf("Attempt %v, result: %v, retry in %v", i+1, err, period)
 		select {
 		case <-ctx.Done():
 			log.Debug("Context is closing, return.")
-			return err
+			return stats, err
 		case <-time.After(period):
 		}
 	}
-	return trace.Wrap(err)
+	return RetryStats{Attempts: times, TotalDuration: time.Since(start)}, trace.Wrap(err)
 }
 
 // RetryWithInterval retries the specified operation fn using the specified backoff interval.
 // fn should return backoff.PermanentError if the error should not be retried and returned directly.
 // Returns nil on success or the last received error upon exhausting the interval.
 func RetryWithInterval(ctx context.Context, interval backoff.BackOff, fn func() error) error {
+	_, err := RetryWithIntervalE(ctx, interval, fn)
+	return err
+}
+
+// RetryWithIntervalE behaves like RetryWithInterval but also returns
+// RetryStats describing how many attempts the call needed.
+func RetryWithIntervalE(ctx context.Context, interval backoff.BackOff, fn func() error) (RetryStats, error) {
+	start := time.Now()
+	var attempts int
 	b := backoff.WithContext(interval, ctx)
 	err := backoff.RetryNotify(func() (err error) {
+		attempts++
 		err = fn()
 		return err
 	}, b, func(err error, d time.Duration) {
 		log.Debugf("Retrying: %v (time %v).", trace.UserMessage(err), d)
 	})
+	stats := RetryStats{Attempts: attempts, TotalDuration: time.Since(start)}
 	if err != nil {
 		log.WithError(err).Warn("All attempts failed.")
-		return trace.Wrap(err)
+		return stats, trace.Wrap(err)
 	}
-	return nil
+	return stats, nil
 }
 
 // NewUnlimitedExponentialBackOff returns a backoff interval without time restriction
@@ -0,0 +1,73 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+func TestRetryEStatsOnSuccess(t *testing.T) {
+	var calls int
+	stats, err := RetryE(context.Background(), 5, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if stats.Attempts != 3 {
+		t.Errorf("expected 3 attempts, got %v", stats.Attempts)
+	}
+}
+
+func TestRetryEStatsOnExhaustion(t *testing.T) {
+	stats, err := RetryE(context.Background(), 3, time.Millisecond, func() error {
+		return errors.New("always fails")
+	})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if stats.Attempts != 3 {
+		t.Errorf("expected 3 attempts, got %v", stats.Attempts)
+	}
+}
+
+func TestRetryWithIntervalEStatsOnSuccess(t *testing.T) {
+	var calls int
+	b := backoff.WithMaxRetries(backoff.NewConstantBackOff(time.Millisecond), 5)
+	stats, err := RetryWithIntervalE(context.Background(), b, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if stats.Attempts != 3 {
+		t.Errorf("expected 3 attempts, got %v", stats.Attempts)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/gravitational/planet/lib/box"
 	"github.com/gravitational/planet/lib/constants"
 	"github.com/gravitational/planet/lib/monitoring"
 	"github.com/gravitational/planet/lib/utils"
@@ -38,10 +39,12 @@ import (
 	"github.com/gravitational/satellite/agent"
 	pb "github.com/gravitational/satellite/agent/proto/agentpb"
 	"github.com/gravitational/satellite/cmd"
+	"github.com/gravitational/satellite/lib/membership"
 	k8smembership "github.com/gravitational/satellite/lib/membership/kubernetes"
 	"github.com/gravitational/satellite/lib/rpc/client"
 	agentutils "github.com/gravitational/satellite/utils"
 	"github.com/gravitational/trace"
+	"github.com/gravitational/version"
 	log "github.com/sirupsen/logrus"
 	etcd "go.etcd.io/etcd/client"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -304,6 +307,35 @@ func stopUnits(ctx context.Context) error {
 	return trace.NewAggregate(errors...)
 }
 
+// peerDialTimeout bounds how long logReachablePeer waits on each peer
+// before moving on to the next one.
+const peerDialTimeout = 2 * time.Second
+
+// peerAgentPort is the RPC port planet agents listen on, used to probe
+// --initial-cluster peers for reachability at startup.
+const peerAgentPort = "7575"
+
+// logReachablePeer dials each of peers in turn (on the agent RPC port) and
+// logs the first one found reachable. Cluster membership itself is
+// established independently via Kubernetes/etcd rather than a peer join, so
+// this is a best-effort startup diagnostic, not a precondition: an
+// unreachable peer list produces a warning, never a startup failure.
+func logReachablePeer(peers []string) {
+	for _, peer := range peers {
+		addr := net.JoinHostPort(peer, peerAgentPort)
+		conn, err := net.DialTimeout("tcp", addr, peerDialTimeout)
+		if err != nil {
+			continue
+		}
+		conn.Close()
+		log.WithField("peer", peer).Info("Reached seed peer from --initial-cluster.")
+		return
+	}
+	if len(peers) > 0 {
+		log.WithField("peers", peers).Warn("None of the configured --initial-cluster seed peers were reachable at startup.")
+	}
+}
+
 // getKubeClientFromPath returns a Kubernetes clientset using the given
 // kubeconfig file path.
 func getKubeClientFromPath(kubeconfigPath string) (*kubernetes.Clientset, error) {
@@ -326,6 +358,25 @@ type agentConfig struct {
 	leader      *LeaderConfig
 	peers       []string
 	serviceCIDR net.IPNet
+	// noCluster skips setting up Kubernetes-informer-based cluster
+	// membership and reports only the local node instead. Intended for
+	// single-node dev clusters where the overhead (and the API server
+	// dependency) of tracking cluster membership isn't warranted.
+	noCluster bool
+	// dnsSetupTimeout bounds how long setupResolver retries waiting for the
+	// DNS services to become available, in case they never do. Zero means
+	// retry indefinitely.
+	dnsSetupTimeout time.Duration
+	// hostsSync configures the /etc/hosts synchronization loop.
+	hostsSync hostsSyncConfig
+	// certRotation configures the automatic certificate rotation loop.
+	certRotation certRotationConfig
+	// disabledCheckers lists the names of checkers (built-in or exec
+	// drop-ins) to drop instead of registering.
+	disabledCheckers []string
+	// disableNodeEvents turns off publishing node lifecycle changes
+	// (join/leave/failed/role change) as Kubernetes Events.
+	disableNodeEvents bool
 }
 
 // runAgent starts the master election / health check loops in background and
@@ -334,6 +385,8 @@ func runAgent(config agentConfig) error {
 	ctx, cancelCtx := context.WithCancel(context.Background())
 	defer cancelCtx()
 
+	logReachablePeer(config.peers)
+
 	err := config.monitoring.CheckAndSetDefaults()
 	if err != nil {
 		return trace.Wrap(err)
@@ -342,24 +395,43 @@ func runAgent(config agentConfig) error {
 		config.agent.Tags = make(map[string]string)
 	}
 	config.agent.Tags["role"] = string(config.monitoring.Role)
-
-	clientset, err := getKubeClientFromPath(constants.KubeletConfigPath)
-	if err != nil {
-		return trace.Wrap(err, "failed to get Kubernetes clientset")
+	config.agent.Tags[monitoring.VersionTag] = version.Get().Version
+	config.agent.Tags[selfHealthHeartbeatTag] = time.Now().Format(time.RFC3339)
+	if port, ok := rpcListenPort(config.agent.RPCAddrs); ok {
+		config.agent.Tags[monitoring.RPCPortTag] = strconv.Itoa(port)
 	}
 
-	informer := informers.NewSharedInformerFactory(clientset, 0).Core().V1().Nodes().Informer()
-	stop := make(chan struct{})
-	defer close(stop)
-	go informer.Run(stop)
+	var cluster membership.Cluster
+	if config.noCluster {
+		cluster = newLocalCluster(config.agent.Name, config.monitoring.AdvertiseIP, config.monitoring.Role)
+	} else {
+		clientset, err := getKubeClientFromPath(constants.KubeletConfigPath)
+		if err != nil {
+			return trace.Wrap(err, "failed to get Kubernetes clientset")
+		}
+
+		informer := informers.NewSharedInformerFactory(clientset, 0).Core().V1().Nodes().Informer()
 
-	cluster, err := k8smembership.NewCluster(&k8smembership.Config{
-		Informer: informer,
-	})
-	if err != nil {
-		return trace.Wrap(err, "failed to initialize cluster membership")
+		if config.monitoring.Role == agent.RoleMaster && !config.disableNodeEvents {
+			recorder := newNodeEventRecorder(clientset.CoreV1().Events(metav1.NamespaceSystem))
+			registerNodeEventHandlers(informer, recorder)
+		}
+
+		stop := make(chan struct{})
+		defer close(stop)
+		go informer.Run(stop)
+
+		cluster, err = k8smembership.NewCluster(&k8smembership.Config{
+			Informer: informer,
+		})
+		if err != nil {
+			return trace.Wrap(err, "failed to initialize cluster membership")
+		}
 	}
+	logIfAlreadyMember(cluster, config.agent.Name)
+
 	config.agent.Cluster = cluster
+	config.agent.DialRPC = newTaggedDialRPC(cluster, defaultRPCPort, config.agent.CAFile, config.agent.CertFile, config.agent.KeyFile)
 
 	monitoringAgent, err := agent.New(config.agent)
 	if err != nil {
@@ -371,14 +443,19 @@ func runAgent(config agentConfig) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = monitoring.AddCheckers(monitoringAgent, config.monitoring)
+	checkerRegistry := monitoring.NewRegistry(monitoringAgent, config.disabledCheckers...)
+	err = monitoring.AddCheckers(checkerRegistry, config.monitoring)
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	selfHealth := &selfHealthState{}
+	checkerRegistry.AddChecker(newSelfHealthChecker(selfHealth))
+	registerCheckerHandler(checkerRegistry)
 	err = monitoringAgent.Start()
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	go runSelfHealthWatchdog(ctx, monitoringAgent, selfHealth)
 
 	errorC := make(chan error, 10)
 	client, err := startLeaderClient(config, monitoringAgent, errorC)
@@ -392,9 +469,14 @@ func runAgent(config agentConfig) error {
 		if err != nil {
 			return trace.Wrap(err)
 		}
+		go func() {
+			if err := runCertRotation(ctx, config.certRotation); err != nil {
+				log.WithError(err).Warn("Certificate rotation loop exited.")
+			}
+		}()
 	}
 
-	err = setupResolver(ctx, config.monitoring.Role, config.serviceCIDR)
+	err = setupResolver(ctx, config.monitoring.Role, config.serviceCIDR, config.dnsSetupTimeout)
 	if err != nil {
 		return trace.Wrap(err)
 	}
@@ -408,6 +490,11 @@ func runAgent(config agentConfig) error {
 	}
 
 	go runSystemdCgroupCleaner(ctx)
+	go func() {
+		if err := runHostsSync(ctx, monitoringAgent, config.hostsSync); err != nil {
+			log.WithError(err).Warn("Hosts file synchronization loop exited.")
+		}
+	}()
 
 	signalc := make(chan os.Signal, 2)
 	signal.Notify(signalc, os.Interrupt, syscall.SIGTERM)
@@ -421,6 +508,27 @@ func runAgent(config agentConfig) error {
 	return nil
 }
 
+// logIfAlreadyMember logs when name is already a known, alive member of
+// cluster. Unlike a serf-style agent, this agent has no discrete join
+// handshake to skip - cluster membership is derived automatically from
+// Kubernetes Node objects (or, with --no-cluster, a single-node
+// localCluster) - so there's no redundant work to avoid. This only makes a
+// restart's log output easier to read: an operator scanning startup logs
+// after a planet restart can tell at a glance that the node rejoined an
+// established cluster rather than bootstrapping a new one.
+func logIfAlreadyMember(cluster membership.Cluster, name string) {
+	member, err := cluster.Member(name)
+	if err != nil {
+		if !trace.IsNotFound(err) {
+			log.WithError(err).Warn("Failed to query existing cluster membership.")
+		}
+		return
+	}
+	if member.Status == pb.MemberStatus_Alive {
+		log.Infof("Node %v is already a member of an established cluster.", name)
+	}
+}
+
 func leaderPause(publicIP, electionKey string, etcd *etcdconf.Config) error {
 	log.Infof("disable election participation for %v", publicIP)
 	return enableElection(publicIP, electionKey, false, etcd)
@@ -465,14 +573,42 @@ func getEtcdClient(conf *etcdconf.Config) (etcd.KeysAPI, error) {
 	return etcdapi, nil
 }
 
+// statusFormat identifies how the status command renders its result.
+type statusFormat string
+
+const (
+	// statusFormatText renders the status as pretty-printed JSON.
+	statusFormatText statusFormat = "text"
+	// statusFormatJSON renders the status as compact JSON.
+	statusFormatJSON statusFormat = "json"
+	// statusFormatNagios renders the status as an NRPE-compatible plugin
+	// result: a single summary line and a Nagios exit code.
+	statusFormatNagios statusFormat = "nagios"
+	// statusFormatOpenMetrics renders the status as OpenMetrics text, a
+	// pull-based alternative to registering the full metrics collector
+	// subsystem (lib/monitoring/metrics.go). See renderOpenMetricsStatus.
+	statusFormatOpenMetrics statusFormat = "openmetrics"
+)
+
 type statusConfig struct {
 	rpcPort        int
 	local          bool
-	prettyPrint    bool
+	format         statusFormat
 	timeout        time.Duration
 	caFile         string
 	clientCertFile string
 	clientKeyFile  string
+	// memberFailureGrace, if positive, suppresses the cluster status
+	// flipping to Degraded on a Failed member until that member has been
+	// failed for at least this long. See applyMemberFailureGracePeriod.
+	memberFailureGrace time.Duration
+	// pretty renders the status as subsystem sections instead of JSON,
+	// only in effect together with format == statusFormatText. See
+	// renderPrettyStatus.
+	pretty bool
+	// verbose, with pretty, shows every probe rather than only the
+	// failing ones.
+	verbose bool
 }
 
 // status obtains either the status of the planet cluster or that of
@@ -492,6 +628,19 @@ func status(c statusConfig) (ok bool, err error) {
 	if err != nil {
 		return false, trace.Wrap(err)
 	}
+
+	maint, err := readMaintenance()
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+
+	resourceLimits, err := readResourceLimits()
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+
+	var nagiosSummaryLine string
+	var nagiosCode int
 	var statusJson []byte
 	var statusBlob interface{}
 	if c.local {
@@ -503,7 +652,13 @@ func status(c statusConfig) (ok bool, err error) {
 			return false, trace.Wrap(err)
 		}
 		ok = status.Status == pb.NodeStatus_Running
-		statusBlob = status
+		statusBlob = nodeStatusReport{
+			NodeStatus:     status,
+			Maintenance:    activeMaintenance(maint),
+			ResourceLimits: resourceLimits,
+			Subsystems:     groupProbesBySubsystem(status.Probes),
+		}
+		nagiosSummaryLine, nagiosCode = nagiosNodeStatus(status)
 	} else {
 		status, err := client.Status(ctx)
 		if err != nil {
@@ -512,10 +667,45 @@ func status(c statusConfig) (ok bool, err error) {
 			}
 			return false, trace.Wrap(err)
 		}
+		annotateUnreachableNodes(status)
+		if err := applyMemberFailureGracePeriod(status, c.memberFailureGrace); err != nil {
+			return false, trace.Wrap(err)
+		}
 		ok = status.Status == pb.SystemStatus_Running
-		statusBlob = status
+		subsystems := make(map[string][]subsystemReport, len(status.Nodes))
+		for _, node := range status.Nodes {
+			subsystems[node.NodeName] = groupProbesBySubsystem(node.Probes)
+		}
+		statusBlob = clusterStatusReport{SystemStatus: status, Maintenance: activeMaintenance(maint), Subsystems: subsystems}
+		nagiosSummaryLine, nagiosCode = nagiosClusterStatus(status)
+	}
+
+	if maint.active() {
+		ok = true
+		nagiosCode = nagiosOK
+		nagiosSummaryLine = fmt.Sprintf("PLANET OK - maintenance mode active since %v, alerts suppressed (underlying: %v)",
+			maint.Since.Format(time.RFC3339), nagiosSummaryLine)
 	}
-	if c.prettyPrint {
+
+	if c.format == statusFormatNagios {
+		fmt.Println(nagiosSummaryLine)
+		if nagiosCode != nagiosOK {
+			return ok, trace.Wrap(&box.ExitError{Code: nagiosCode})
+		}
+		return ok, nil
+	}
+
+	if c.format == statusFormatText && c.pretty {
+		renderPrettyStatus(os.Stdout, statusBlob, c.verbose)
+		return ok, nil
+	}
+
+	if c.format == statusFormatOpenMetrics {
+		renderOpenMetricsStatus(os.Stdout, statusBlob)
+		return ok, nil
+	}
+
+	if c.format == statusFormatText {
 		statusJson, err = json.MarshalIndent(statusBlob, "", "   ")
 	} else {
 		statusJson, err = json.Marshal(statusBlob)
@@ -584,6 +774,23 @@ func rpcAddr(port int) string {
 	return fmt.Sprintf("127.0.0.1:%d", port)
 }
 
+// rpcListenPort returns the port of the first address in addrs, so it can
+// be advertised via monitoring.RPCPortTag for peers to dial this agent on.
+func rpcListenPort(addrs []string) (port int, ok bool) {
+	if len(addrs) == 0 {
+		return 0, false
+	}
+	_, rawPort, err := net.SplitHostPort(addrs[0])
+	if err != nil {
+		return 0, false
+	}
+	port, err = strconv.Atoi(rawPort)
+	if err != nil {
+		return 0, false
+	}
+	return port, true
+}
+
 func newAgentUnavailableError() error {
 	return trace.LimitExceeded("agent could not be contacted. Make sure that the planet-agent service is running and try again")
 }
@@ -0,0 +1,60 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+)
+
+// fakeCluster is a minimal membership.Cluster stand-in for tests, since this
+// agent has no serf client to fake against - membership is queried through
+// membership.Cluster regardless of what backs it (Kubernetes, serf, or a
+// single-node localCluster).
+type fakeCluster struct {
+	members map[string]*pb.MemberStatus
+}
+
+func (f *fakeCluster) Members() ([]*pb.MemberStatus, error) {
+	members := make([]*pb.MemberStatus, 0, len(f.members))
+	for _, member := range f.members {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (f *fakeCluster) Member(name string) (*pb.MemberStatus, error) {
+	member, ok := f.members[name]
+	if !ok {
+		return nil, trace.NotFound("member %v not found", name)
+	}
+	return member, nil
+}
+
+func TestLogIfAlreadyMemberDoesNotPanicWhenAlreadyPresent(t *testing.T) {
+	cluster := &fakeCluster{members: map[string]*pb.MemberStatus{
+		"node-1": {Name: "node-1", Status: pb.MemberStatus_Alive},
+	}}
+
+	// logIfAlreadyMember only logs - exercise both the already-a-member and
+	// not-yet-a-member paths and confirm neither panics for a nil/missing
+	// lookup.
+	logIfAlreadyMember(cluster, "node-1")
+	logIfAlreadyMember(cluster, "node-2")
+}
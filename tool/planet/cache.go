@@ -0,0 +1,77 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/gravitational/planet/lib/agentcache"
+	"github.com/gravitational/planet/lib/timeline/bolt"
+	"github.com/gravitational/planet/lib/timeline/memory"
+
+	"github.com/gravitational/satellite/agent/backend/inmemory"
+	"github.com/gravitational/satellite/agent/cache"
+	"github.com/gravitational/satellite/lib/history"
+	"github.com/gravitational/satellite/lib/history/sqlite"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// localTimelineFile is the database file the agent's local timeline is
+// stored in, relative to the timeline directory, for the persistent (sqlite
+// and bolt) backends. It matches the file name the satellite agent itself
+// uses when it opens its own local timeline.
+const localTimelineFile = "local.db"
+
+// timelineBackends lists the values accepted by the agent's --cache flag.
+var timelineBackends = []string{"sqlite", "bolt", "memory"}
+
+// newAgentCache returns the cache the agent's status collector reports
+// into. It is rehydrated from the most recent event in the local timeline
+// (if any) so a freshly restarted agent doesn't report the cluster as
+// unknown for the first collection interval.
+//
+// backend selects the local timeline implementation ("sqlite", "bolt" or
+// "memory") - see timelineBackends. "sqlite" pairs with the in-memory status
+// cache by default; "bolt" is a pure-Go alternative for builds that need to
+// avoid sqlite's CGO dependency, and "memory" keeps no history across
+// restarts.
+func newAgentCache(ctx context.Context, backend, timelineDir string) cache.Cache {
+	inner := inmemory.New()
+	timeline, err := newLocalTimeline(ctx, backend, timelineDir)
+	if err != nil {
+		log.WithError(err).Warn("Failed to open local timeline for cache rehydration, starting with an empty cache.")
+		return inner
+	}
+	return agentcache.NewRehydrating(ctx, inner, timeline)
+}
+
+// newLocalTimeline opens the local timeline using the specified backend.
+func newLocalTimeline(ctx context.Context, backend, timelineDir string) (history.Timeline, error) {
+	dbPath := filepath.Join(timelineDir, localTimelineFile)
+	switch backend {
+	case "", "sqlite":
+		return sqlite.NewTimeline(ctx, sqlite.Config{DBPath: dbPath})
+	case "bolt":
+		return bolt.NewTimeline(ctx, bolt.Config{DBPath: dbPath})
+	case "memory":
+		return memory.NewTimeline(memory.Config{})
+	default:
+		return nil, trace.BadParameter("unsupported cache backend %q, must be one of %v", backend, timelineBackends)
+	}
+}
@@ -18,8 +18,11 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -43,6 +46,11 @@ type Config struct {
 	Rootfs string
 	// PublicIP is the public IP address of this node
 	PublicIP string
+	// PublicIPs lists additional public addresses of this node beyond
+	// PublicIP, e.g. a secondary address of the other IP family on a
+	// dual-stack host. Empty unless the node was started with more than
+	// one --public-ip address or --bind-interface resolved more than one.
+	PublicIPs []string
 	// MasterIP is the IP addess of the leader
 	MasterIP string
 	// CloudProvider specifies the name of the cloud provider. Optional
@@ -120,6 +128,12 @@ type Config struct {
 	KubeletOptions string
 	// APIServerOptions defines additional parameters for API server
 	APIServerOptions string
+	// KubeletArgs lists additional "key=value" command line arguments to
+	// append to kubelet's command line, on top of KubeletOptions
+	KubeletArgs []string
+	// APIServerArgs lists additional "key=value" command line arguments to
+	// append to the API server's command line, on top of APIServerOptions
+	APIServerArgs []string
 	// ServiceUser defines the user context for container's service user
 	ServiceUser serviceUser
 	// DNS is the local DNS configuration
@@ -134,10 +148,18 @@ type Config struct {
 	KubeletConfig string
 	// CloudConfig specifies the cloud configuration as JSON-encoded payload
 	CloudConfig string
+	// CloudConfigPath specifies the path to a cloud provider configuration
+	// file on the host to use in place of CloudConfig. Takes precedence over
+	// CloudConfig if both are set.
+	CloudConfigPath string
 	// AllowPrivileged controls whether privileged containers are allowed.
 	AllowPrivileged bool
 	// SELinux turns on SELinux support
 	SELinux bool
+	// ReadonlyRootfs mounts the container's rootfs read-only, with writable
+	// tmpfs mounts added for the paths systemd/etcd need. See
+	// box.readonlyRootfsTmpfsPaths for the exact list.
+	ReadonlyRootfs bool
 	// HighAvailability enables kubernetes high availability mode. If enabled,
 	// control plane components will be enabled on all master nodes.
 	HighAvailability bool
@@ -148,6 +170,23 @@ type Config struct {
 	EncryptionProvider string
 	// AWSEncryptionConfig specifies configuration for aws encryption provider.
 	AWSEncryptionConfig AWSEncryptionConfig
+	// CapAdd is a list of capabilities to add to the default set the
+	// container is started with.
+	CapAdd []string
+	// CapDrop is a list of capabilities to remove from the default set the
+	// container is started with.
+	CapDrop []string
+	// MemoryLimit caps the container's total memory usage, e.g. "4G".
+	// Optional - unset means no limit.
+	MemoryLimit string
+	// MemoryReserveHost guarantees this much memory, e.g. "2G", stays free
+	// on the host by capping the container's memory usage at the host's
+	// total memory minus this amount. Ignored if MemoryLimit is also set
+	// and leaves at least this much free on its own. Optional.
+	MemoryReserveHost string
+	// CPUQuota caps the container's CPU usage as a percentage of a single
+	// CPU, e.g. "200%" for two full CPUs. Optional - unset means no quota.
+	CPUQuota string
 }
 
 // DNS describes DNS server configuration
@@ -160,9 +199,24 @@ type DNS struct {
 	ListenAddrs []string
 	// Port specifies the DNS port
 	Port int
+	// Ndots overrides the number of dots that must appear in a name
+	// before an absolute lookup is tried first, in the generated
+	// container resolv.conf. 0 uses DefaultDNSNdots.
+	Ndots int
+	// Timeout overrides how long, in seconds, the resolver waits for a
+	// response before retrying with a different name server. 0 uses
+	// DefaultDNSTimeout.
+	Timeout int
+	// Attempts overrides how many times the resolver retries a lost query
+	// before giving up on a name server. 0 uses DefaultDNSAttempts.
+	Attempts int
 }
 
 func (cfg *Config) checkAndSetDefaults() (err error) {
+	if err := cfg.resolveServiceUser(); err != nil {
+		return trace.Wrap(err)
+	}
+
 	cfg.ServiceUser.User, err = user.LookupID(cfg.ServiceUser.UID)
 	if err != nil {
 		return trace.Wrap(err)
@@ -175,6 +229,37 @@ func (cfg *Config) checkAndSetDefaults() (err error) {
 	if cfg.VxlanPort <= 0 {
 		cfg.VxlanPort = DefaultVxlanPort
 	}
+
+	if cfg.DNS.Ndots == 0 {
+		cfg.DNS.Ndots = DefaultDNSNdots
+	}
+	if cfg.DNS.Timeout == 0 {
+		cfg.DNS.Timeout = DefaultDNSTimeout
+	}
+	if cfg.DNS.Attempts == 0 {
+		cfg.DNS.Attempts = DefaultDNSAttempts
+	}
+	if err := verifyDNSOptions(cfg.DNS); err != nil {
+		return trace.Wrap(err)
+	}
+
+	return nil
+}
+
+// verifyDNSOptions validates the resolv.conf options overrides against the
+// ranges resolv.conf(5) itself treats as meaningful, so a typo in
+// --dns-ndots/--dns-timeout/--dns-attempts fails fast at startup instead of
+// silently producing a resolv.conf the resolver library clamps or ignores.
+func verifyDNSOptions(dns DNS) error {
+	if dns.Ndots < 0 || dns.Ndots > 15 {
+		return trace.BadParameter("dns ndots must be between 0 and 15").AddField("dns-ndots", dns.Ndots)
+	}
+	if dns.Timeout < 1 || dns.Timeout > 30 {
+		return trace.BadParameter("dns timeout must be between 1 and 30 seconds").AddField("dns-timeout", dns.Timeout)
+	}
+	if dns.Attempts < 1 || dns.Attempts > 5 {
+		return trace.BadParameter("dns attempts must be between 1 and 5").AddField("dns-attempts", dns.Attempts)
+	}
 	return nil
 }
 
@@ -211,6 +296,68 @@ type serviceUser struct {
 	GID string
 }
 
+// persistedServiceUser is the on-disk representation of a previously
+// selected service user/group ID.
+type persistedServiceUser struct {
+	UID string `json:"uid"`
+	GID string `json:"gid"`
+}
+
+// resolveServiceUser fills in cfg.ServiceUser.UID/GID from the persisted
+// state directory when the corresponding flags were not specified, and
+// otherwise persists the selection so a later restart without explicit
+// --service-uid/--service-gid flags reuses the same values.
+func (cfg *Config) resolveServiceUser() error {
+	statePath := cfg.inRootfs(StateDir, ServiceUserStateFile)
+
+	if cfg.ServiceUser.UID == "" && cfg.ServiceUser.GID == "" {
+		persisted, err := readPersistedServiceUser(statePath)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		if persisted != nil {
+			cfg.ServiceUser.UID = persisted.UID
+			cfg.ServiceUser.GID = persisted.GID
+			return nil
+		}
+	}
+
+	return trace.Wrap(writePersistedServiceUser(statePath, persistedServiceUser{
+		UID: cfg.ServiceUser.UID,
+		GID: cfg.ServiceUser.GID,
+	}))
+}
+
+// readPersistedServiceUser reads the previously persisted service user
+// selection, returning nil if none has been persisted yet.
+func readPersistedServiceUser(path string) (*persistedServiceUser, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	var persisted persistedServiceUser
+	if err := json.Unmarshal(data, &persisted); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &persisted, nil
+}
+
+// writePersistedServiceUser persists the service user selection so it can
+// be reused on a subsequent restart.
+func writePersistedServiceUser(path string, persisted persistedServiceUser) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	data, err := json.Marshal(persisted)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.ConvertSystemError(ioutil.WriteFile(path, data, 0644))
+}
+
 // APIServerIP returns the IP of the "kubernetes" service which is the first IP
 // of the configured service subnet
 func (cfg *Config) APIServerIP() net.IP {
@@ -253,14 +400,43 @@ func (l *list) IsCumulative() bool {
 	return true
 }
 
-// Set sets the value for this flag from command line
+// Set sets the value for this flag from command line.
+// If val has the form "@/path/to/file", the values are read one per line
+// from the referenced file instead, with blank lines and lines starting
+// with "#" ignored. This allows long lists (e.g. insecure registries or
+// roles) to be kept out of systemd unit files.
 func (l *list) Set(val string) error {
+	if strings.HasPrefix(val, "@") {
+		values, err := readListFile(val[1:])
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		*l = append(*l, values...)
+		return nil
+	}
 	for _, r := range cstrings.SplitComma(val) {
 		*l = append(*l, r)
 	}
 	return nil
 }
 
+// readListFile reads a list of values from the file at path, one per line.
+// Blank lines and lines starting with "#" are ignored.
+func readListFile(path string) (values []string, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		values = append(values, line)
+	}
+	return values, nil
+}
+
 // String returns a textual representation of the flag
 func (l *list) String() string {
 	return fmt.Sprintf("%v", []string(*l))
@@ -302,9 +478,13 @@ func toKeyValueList(kv kv.KeyVal) string {
 // boolFlag defines a boolean command line flag.
 // The behavioral difference to the kingpin's built-in Bool() modifier
 // is that it supports the long form:
-// 	--flag=true|false
+//
+//	--flag=true|false
+//
 // as opposed to built-in's only short form:
+//
 //	--flag	(true, if specified, false - otherwise)
+//
 // The long form is required when populating the flag from the environment.
 type boolFlag bool
 
@@ -18,6 +18,9 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sort"
 
 	kv "github.com/gravitational/configure"
@@ -58,6 +61,43 @@ func (r *CommandFlagSuite) TestConvertsToEtcdPeer(c *check.C) {
 	c.Assert(addrs, OneOfEquals, []string{expected, expectedReverse})
 }
 
+func (r *CommandFlagSuite) TestListReadsValuesFromFile(c *check.C) {
+	dir, err := ioutil.TempDir("", "planet-list-test")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "registries.list")
+	contents := "registry-1.example.com\n# a comment\n\nregistry-2.example.com\n"
+	c.Assert(ioutil.WriteFile(path, []byte(contents), 0644), check.IsNil)
+
+	var l list
+	c.Assert(l.Set("@"+path), check.IsNil)
+
+	expected := []string{"registry-1.example.com", "registry-2.example.com"}
+	c.Assert([]string(l), check.DeepEquals, expected)
+}
+
+func (r *CommandFlagSuite) TestListReadsCommaSeparatedValues(c *check.C) {
+	var l list
+	c.Assert(l.Set("registry-1.example.com,registry-2.example.com"), check.IsNil)
+
+	expected := []string{"registry-1.example.com", "registry-2.example.com"}
+	c.Assert([]string(l), check.DeepEquals, expected)
+}
+
+func (r *CommandFlagSuite) TestVerifyDNSOptionsAcceptsDefaults(c *check.C) {
+	c.Assert(verifyDNSOptions(DNS{Ndots: DefaultDNSNdots, Timeout: DefaultDNSTimeout, Attempts: DefaultDNSAttempts}), check.IsNil)
+}
+
+func (r *CommandFlagSuite) TestVerifyDNSOptionsRejectsOutOfRangeValues(c *check.C) {
+	c.Assert(verifyDNSOptions(DNS{Ndots: -1, Timeout: 1, Attempts: 1}), check.NotNil)
+	c.Assert(verifyDNSOptions(DNS{Ndots: 16, Timeout: 1, Attempts: 1}), check.NotNil)
+	c.Assert(verifyDNSOptions(DNS{Ndots: 0, Timeout: 0, Attempts: 1}), check.NotNil)
+	c.Assert(verifyDNSOptions(DNS{Ndots: 0, Timeout: 31, Attempts: 1}), check.NotNil)
+	c.Assert(verifyDNSOptions(DNS{Ndots: 0, Timeout: 1, Attempts: 0}), check.NotNil)
+	c.Assert(verifyDNSOptions(DNS{Ndots: 0, Timeout: 1, Attempts: 6}), check.NotNil)
+}
+
 // oneOfChecker implements a gocheck.Checker that asserts that the actual value
 // matches one of the values from the expected list.
 type oneOfChecker struct {
@@ -83,4 +123,3 @@ func (r *oneOfChecker) Check(params []interface{}, names []string) (result bool,
 	}
 	return true, ""
 }
-
@@ -23,12 +23,14 @@ Notes:
 - The cgroup customization is within planet cgroup namespace only
 - Systems with less than 5 cores, will not reserve resources in kubernetes
   - Relative prioritization will still be applied
+
 - User tasks will be capped at a maximum CPU usage
   - 500 millicores on systems with less than 5 cores
   - 10% of system resources (0.6/6, 1/10, 2/20, 4/40 cores etc) on 6 cores or more
   - User tasks run with high scheduling priority
-	- The idea is, an administrator should always be able to troubleshoot a system
-	- However, because CPU usage is capped at 10%, an administrator shouldn't interfere with system services
+  - The idea is, an administrator should always be able to troubleshoot a system
+  - However, because CPU usage is capped at 10%, an administrator shouldn't interfere with system services
+
 - Planet services and user tasks take scheduling priority over kubernetes pods
   - System and User tasks always have priority over pods
   - kubernetes remains responsible for setting pod cgroup settings, and relative priority between pods
@@ -36,7 +38,6 @@ Notes:
 package main
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io/ioutil"
@@ -45,7 +46,6 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
-	"text/template"
 	"time"
 
 	systemdDbus "github.com/coreos/go-systemd/v22/dbus"
@@ -303,20 +303,9 @@ func writeKubeReservedEnvironment(config *CgroupConfig) error {
 		env["KUBE_SYSTEM_RESERVED"] = fmt.Sprintf("cpu=%vm", config.KubeSystemCPUMillicores)
 	}
 
-	var b bytes.Buffer
-	err := kubeReservedEnv.Execute(&b, &env)
-	if err != nil {
-		return trace.Wrap(err)
-	}
-
-	return trace.Wrap(utils.SafeWriteFile("/run/kubernetes-reserved.env", b.Bytes(), constants.SharedReadMask))
+	return trace.Wrap(utils.WriteEnvFile("/run/kubernetes-reserved.env", env, utils.EnvFileFormatQuoted, constants.SharedReadMask))
 }
 
-var kubeReservedEnv = template.Must(
-	template.New("kube-reserved-env").Parse(`{{ range $key, $value := . }}{{ $key }}="{{ $value }}"
-{{ end }}
-`))
-
 func u64(n uint64) *uint64 {
 	return &n
 }
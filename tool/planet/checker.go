@@ -0,0 +1,105 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gravitational/planet/lib/monitoring"
+
+	"github.com/gravitational/satellite/agent/health"
+	"github.com/gravitational/trace"
+)
+
+// checkerRunTimeout bounds how long an on-demand checker run may take.
+const checkerRunTimeout = 30 * time.Second
+
+// checkerPath is the path of the on-demand checker handler on the debug
+// endpoint (see --httpprofile).
+const checkerPath = "/debug/checker"
+
+// registerCheckerHandler wires the on-demand checker handler into the
+// default HTTP mux, alongside the pprof and log level handlers already
+// registered there.
+func registerCheckerHandler(registry *monitoring.Registry) {
+	http.HandleFunc(checkerPath, checkerHandler(registry))
+}
+
+// checkerHandler runs the checker named by the "name" query parameter
+// outside the agent's normal cycle and writes its raw probes as JSON. It
+// never touches the status cache or backends, so an on-demand run can't
+// mask or duplicate the scheduled results. An unknown name reports the
+// list of registered checkers instead.
+func checkerHandler(registry *monitoring.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := strings.TrimSpace(r.URL.Query().Get("name"))
+		checker, ok := registry.Checker(name)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(registry.CheckerNames())
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), checkerRunTimeout)
+		defer cancel()
+
+		var reporter health.Probes
+		checker.Check(ctx, &reporter)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reporter.GetProbes())
+	}
+}
+
+// runChecker runs the named checker on the planet process behind endpoint
+// (see --httpprofile) and returns its raw probes as JSON. If name isn't a
+// registered checker, the returned error lists the checkers that are.
+func runChecker(endpoint, name string) ([]byte, error) {
+	client := &http.Client{Timeout: checkerRunTimeout + 10*time.Second}
+	requestURL := fmt.Sprintf("http://%v%v?name=%v", endpoint, checkerPath, url.QueryEscape(name))
+	resp, err := client.Get(requestURL)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusNotFound:
+		return nil, trace.NotFound("unknown checker %q, registered checkers: %s", name, strings.TrimSpace(string(body)))
+	default:
+		return nil, trace.BadParameter("unexpected status %v: %v", resp.Status, strings.TrimSpace(string(body)))
+	}
+}
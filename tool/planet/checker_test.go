@@ -0,0 +1,70 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gravitational/planet/lib/monitoring"
+
+	"github.com/gravitational/satellite/agent"
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAgent struct {
+	agent.Agent
+}
+
+func (fakeAgent) AddChecker(health.Checker) {}
+
+type fakeChecker struct{ name string }
+
+func (c fakeChecker) Name() string { return c.name }
+
+func (c fakeChecker) Check(_ context.Context, reporter health.Reporter) {
+	reporter.Add(&pb.Probe{Checker: c.name, Status: pb.Probe_Running})
+}
+
+func TestRunCheckerReturnsProbesForRegisteredChecker(t *testing.T) {
+	registry := monitoring.NewRegistry(fakeAgent{})
+	registry.AddChecker(fakeChecker{name: "test-checker"})
+
+	server := httptest.NewServer(checkerHandler(registry))
+	defer server.Close()
+
+	body, err := runChecker(strings.TrimPrefix(server.URL, "http://"), "test-checker")
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"checker":"test-checker"`)
+}
+
+func TestRunCheckerReportsUnknownChecker(t *testing.T) {
+	registry := monitoring.NewRegistry(fakeAgent{})
+	registry.AddChecker(fakeChecker{name: "test-checker"})
+
+	server := httptest.NewServer(checkerHandler(registry))
+	defer server.Close()
+
+	_, err := runChecker(strings.TrimPrefix(server.URL, "http://"), "missing-checker")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test-checker")
+}
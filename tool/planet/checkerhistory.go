@@ -0,0 +1,121 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"time"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	agentutils "github.com/gravitational/satellite/utils"
+
+	"github.com/gravitational/satellite/lib/rpc/client"
+	"github.com/gravitational/trace"
+)
+
+type checkerHistoryConfig struct {
+	rpcPort        int
+	checker        string
+	timeout        time.Duration
+	prettyPrint    bool
+	caFile         string
+	clientCertFile string
+	clientKeyFile  string
+}
+
+// checkerHistoryEvent is a single probe result for the requested checker,
+// reduced from a pb.TimelineEvent to the fields relevant to that checker.
+type checkerHistoryEvent struct {
+	Timestamp time.Time `json:"timestamp"`
+	Node      string    `json:"node"`
+	Succeeded bool      `json:"succeeded"`
+}
+
+// checkerHistory queries the cluster status timeline and prints the
+// aggregated cross-cluster history of probe results for the named checker,
+// letting an operator see whether it flaps on some nodes but not others.
+func checkerHistory(c checkerHistoryConfig) error {
+	ctx, cancel := context.WithTimeout(context.TODO(), c.timeout)
+	defer cancel()
+
+	config := client.Config{
+		Address:  rpcAddr(c.rpcPort),
+		CAFile:   c.caFile,
+		CertFile: c.clientCertFile,
+		KeyFile:  c.clientKeyFile,
+	}
+	rpcClient, err := client.NewClient(ctx, config)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer rpcClient.Close()
+
+	resp, err := rpcClient.Timeline(ctx, &pb.TimelineRequest{})
+	if err != nil {
+		if agentutils.IsUnavailableError(err) {
+			return newAgentUnavailableError()
+		}
+		return trace.Wrap(err)
+	}
+
+	events := filterCheckerEvents(resp.GetEvents(), c.checker)
+
+	var data []byte
+	if c.prettyPrint {
+		data, err = json.MarshalIndent(events, "", "   ")
+	} else {
+		data, err = json.Marshal(events)
+	}
+	if err != nil {
+		return trace.Wrap(err, "failed to marshal checker history")
+	}
+	if _, err := os.Stdout.Write(data); err != nil {
+		return trace.Wrap(err, "failed to output checker history")
+	}
+	return nil
+}
+
+// filterCheckerEvents extracts the probe successes/failures for checker from
+// events, in chronological order, across every node that reported one.
+func filterCheckerEvents(events []*pb.TimelineEvent, checker string) []checkerHistoryEvent {
+	var result []checkerHistoryEvent
+	for _, event := range events {
+		switch data := event.GetData().(type) {
+		case *pb.TimelineEvent_ProbeSucceeded:
+			if data.ProbeSucceeded.GetProbe() != checker {
+				continue
+			}
+			result = append(result, checkerHistoryEvent{
+				Timestamp: event.GetTimestamp().ToTime(),
+				Node:      data.ProbeSucceeded.GetNode(),
+				Succeeded: true,
+			})
+		case *pb.TimelineEvent_ProbeFailed:
+			if data.ProbeFailed.GetProbe() != checker {
+				continue
+			}
+			result = append(result, checkerHistoryEvent{
+				Timestamp: event.GetTimestamp().ToTime(),
+				Node:      data.ProbeFailed.GetNode(),
+				Succeeded: false,
+			})
+		}
+	}
+	return result
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/stretchr/testify/assert"
+)
+
+func probeSucceededEvent(ts time.Time, node, probe string) *pb.TimelineEvent {
+	return &pb.TimelineEvent{
+		Timestamp: pb.NewTimeToProto(ts),
+		Data: &pb.TimelineEvent_ProbeSucceeded{
+			ProbeSucceeded: &pb.ProbeSucceeded{Node: node, Probe: probe},
+		},
+	}
+}
+
+func probeFailedEvent(ts time.Time, node, probe string) *pb.TimelineEvent {
+	return &pb.TimelineEvent{
+		Timestamp: pb.NewTimeToProto(ts),
+		Data: &pb.TimelineEvent_ProbeFailed{
+			ProbeFailed: &pb.ProbeFailed{Node: node, Probe: probe},
+		},
+	}
+}
+
+func TestFilterCheckerEventsAggregatesAcrossNodes(t *testing.T) {
+	t0 := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	events := []*pb.TimelineEvent{
+		probeSucceededEvent(t0, "node-1", "kube-proxy-rules"),
+		probeFailedEvent(t0.Add(time.Minute), "node-2", "kube-proxy-rules"),
+		probeSucceededEvent(t0.Add(2*time.Minute), "node-2", "other-checker"),
+		{Data: &pb.TimelineEvent_NodeAdded{NodeAdded: &pb.NodeAdded{Node: "node-3"}}, Timestamp: pb.NewTimeToProto(t0)},
+	}
+
+	result := filterCheckerEvents(events, "kube-proxy-rules")
+
+	assert.Equal(t, []checkerHistoryEvent{
+		{Timestamp: t0, Node: "node-1", Succeeded: true},
+		{Timestamp: t0.Add(time.Minute), Node: "node-2", Succeeded: false},
+	}, result)
+}
+
+func TestFilterCheckerEventsNoMatches(t *testing.T) {
+	events := []*pb.TimelineEvent{
+		probeSucceededEvent(time.Now(), "node-1", "other-checker"),
+	}
+	assert.Empty(t, filterCheckerEvents(events, "kube-proxy-rules"))
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// testNamespace is the namespace used by the inter-pod communication
+	// checker for its functional tests.
+	testNamespace = "planet-test"
+	// nettestServicePrefix is the prefix of services/pods created by the
+	// inter-pod communication checker for a single test run.
+	nettestServicePrefix = "nettest-"
+)
+
+// cleanup removes leftover resources from aborted inter-pod communication
+// checks: the test namespace (and everything in it) plus any orphaned
+// nettest-* pods/services left behind cluster-wide.
+func cleanup(client kubernetes.Interface) error {
+	var errors []error
+	if err := cleanupTestNamespace(client); err != nil {
+		errors = append(errors, err)
+	}
+	if err := cleanupOrphanedNettestResources(client); err != nil {
+		errors = append(errors, err)
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// cleanupTestNamespace removes the planet-test namespace if it exists.
+func cleanupTestNamespace(client kubernetes.Interface) error {
+	err := client.CoreV1().Namespaces().Delete(context.TODO(), testNamespace, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return trace.Wrap(err, "failed to remove namespace %v", testNamespace)
+	}
+	log.Infof("removed test namespace %v.", testNamespace)
+	return nil
+}
+
+// cleanupOrphanedNettestResources removes nettest-* pods and services left
+// behind in namespaces other than the test namespace by aborted checks.
+func cleanupOrphanedNettestResources(client kubernetes.Interface) error {
+	var errors []error
+
+	namespaces, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return trace.Wrap(err, "failed to list namespaces")
+	}
+
+	for _, namespace := range namespaces.Items {
+		if namespace.Name == testNamespace {
+			// Already handled wholesale above.
+			continue
+		}
+
+		pods, err := client.CoreV1().Pods(namespace.Name).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to list pods in %v", namespace.Name))
+			continue
+		}
+		for _, pod := range pods.Items {
+			if !strings.HasPrefix(pod.Name, nettestServicePrefix) {
+				continue
+			}
+			if err := client.CoreV1().Pods(namespace.Name).Delete(context.TODO(), pod.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				errors = append(errors, trace.Wrap(err, "failed to remove pod %v/%v", namespace.Name, pod.Name))
+			}
+		}
+
+		services, err := client.CoreV1().Services(namespace.Name).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to list services in %v", namespace.Name))
+			continue
+		}
+		for _, svc := range services.Items {
+			if !strings.HasPrefix(svc.Name, nettestServicePrefix) {
+				continue
+			}
+			if err := client.CoreV1().Services(namespace.Name).Delete(context.TODO(), svc.Name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				errors = append(errors, trace.Wrap(err, "failed to remove service %v/%v", namespace.Name, svc.Name))
+			}
+		}
+	}
+
+	return trace.NewAggregate(errors...)
+}
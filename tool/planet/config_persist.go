@@ -0,0 +1,237 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/gravitational/trace"
+
+	kv "github.com/gravitational/configure"
+	log "github.com/sirupsen/logrus"
+)
+
+// configStateFile is the name of the file under the state directory that
+// holds the effective start Config, persisted on every successful start.
+const configStateFile = "start-config.json"
+
+// configFormatVersion is bumped whenever a change to Config would otherwise
+// be ambiguous to a reader (e.g. a field is repurposed rather than simply
+// added or removed). It's currently unused by loadStartConfig beyond being
+// recorded, since added/removed fields are already handled by JSON's own
+// tolerance for missing/extra keys.
+const configFormatVersion = "1"
+
+// persistedConfigFile is the on-disk representation written to
+// configStateFile.
+type persistedConfigFile struct {
+	Version string          `json:"version"`
+	Config  persistedConfig `json:"config"`
+}
+
+// persistedConfig mirrors Config for JSON persistence. It's needed because
+// kv.CIDR (used for Config.ServiceCIDR/PodCIDR) stores its data in
+// unexported fields and so encodes to an empty object on its own; shadowing
+// the two fields here with a string form (using kv.CIDR's own String/Set)
+// lets the rest of Config round-trip through the embedded promotion as-is.
+type persistedConfig struct {
+	Config
+	ServiceCIDR string `json:"ServiceCIDR"`
+	PodCIDR     string `json:"PodCIDR"`
+}
+
+func newPersistedConfig(cfg Config) persistedConfig {
+	return persistedConfig{
+		Config:      cfg,
+		ServiceCIDR: cfg.ServiceCIDR.String(),
+		PodCIDR:     cfg.PodCIDR.String(),
+	}
+}
+
+// toConfig reconstructs a Config from p, parsing the string form of the
+// CIDR fields back into kv.CIDR.
+func (p persistedConfig) toConfig() (Config, error) {
+	cfg := p.Config
+	if p.ServiceCIDR != "" {
+		cidr, err := kv.ParseCIDR(p.ServiceCIDR)
+		if err != nil {
+			return Config{}, trace.Wrap(err, "invalid persisted ServiceCIDR %q", p.ServiceCIDR)
+		}
+		cfg.ServiceCIDR = *cidr
+	}
+	if p.PodCIDR != "" {
+		cidr, err := kv.ParseCIDR(p.PodCIDR)
+		if err != nil {
+			return Config{}, trace.Wrap(err, "invalid persisted PodCIDR %q", p.PodCIDR)
+		}
+		cfg.PodCIDR = *cidr
+	}
+	return cfg, nil
+}
+
+// configStatePath returns the path cfg's effective configuration is
+// persisted to/loaded from.
+func configStatePath(rootfs string) string {
+	return filepath.Join(rootfs, StateDir, configStateFile)
+}
+
+// persistStartConfig writes cfg's effective configuration to the state
+// directory, so a later `planet config show` or `planet start --from-config`
+// can reproduce it without scraping the original command line.
+func persistStartConfig(cfg *Config) error {
+	path := configStatePath(cfg.Rootfs)
+	data, err := json.MarshalIndent(persistedConfigFile{
+		Version: configFormatVersion,
+		Config:  newPersistedConfig(*cfg),
+	}, "", "  ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(ioutil.WriteFile(path, data, 0600))
+}
+
+// loadStartConfig reads a Config previously written by persistStartConfig.
+// Fields present in the file but no longer known to this binary (e.g. the
+// file was written by a newer planet) are logged as a warning rather than
+// treated as an error.
+func loadStartConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+
+	if unknown, err := unknownConfigFields(data); err != nil {
+		log.WithError(err).Warn("Failed to check for unknown fields in --from-config file.")
+	} else if len(unknown) != 0 {
+		log.Warnf("Ignoring unknown fields in --from-config file %v: %v.", path, unknown)
+	}
+
+	var file persistedConfigFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, trace.Wrap(err, "failed to parse %v", path)
+	}
+	cfg, err := file.Config.toConfig()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &cfg, nil
+}
+
+// unknownConfigFields reports the top-level keys of the "config" object in
+// data that don't correspond to a field persistedConfig knows about.
+func unknownConfigFields(data []byte) ([]string, error) {
+	var file struct {
+		Config map[string]json.RawMessage `json:"config"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	known, err := knownConfigFields()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var unknown []string
+	for key := range file.Config {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown, nil
+}
+
+// knownConfigFields returns the set of JSON keys persistedConfig marshals
+// to, derived from its zero value rather than duplicated by hand so it never
+// drifts from the actual struct definition.
+func knownConfigFields() (map[string]bool, error) {
+	data, err := json.Marshal(persistedConfig{})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	known := make(map[string]bool, len(fields))
+	for key := range fields {
+		known[key] = true
+	}
+	return known, nil
+}
+
+// mergeFromConfig fills any field of cfg still at its zero value with the
+// corresponding field of base, so a flag actually given on the command line
+// always wins over --from-config, while an omitted flag falls back to the
+// persisted configuration. The one limitation this implies: there's no way
+// to use a flag to explicitly reset a field to its zero value once
+// --from-config has set it to something else.
+func mergeFromConfig(cfg, base Config) Config {
+	cv := reflect.ValueOf(&cfg).Elem()
+	bv := reflect.ValueOf(base)
+	for i := 0; i < cv.NumField(); i++ {
+		field := cv.Field(i)
+		if field.IsZero() {
+			field.Set(bv.Field(i))
+		}
+	}
+	return cfg
+}
+
+// redactedConfigFields lists the Config fields that may carry sensitive
+// material (cloud provider credentials embedded in a JSON/YAML payload) and
+// so are blanked out by `planet config show`.
+var redactedConfigFields = map[string]bool{
+	"CloudConfig":   true,
+	"KubeletConfig": true,
+}
+
+const redactedPlaceholder = "<redacted>"
+
+// redactConfig returns a copy of cfg with the fields in redactedConfigFields
+// blanked out, for safe display.
+func redactConfig(cfg Config) Config {
+	if cfg.CloudConfig != "" {
+		cfg.CloudConfig = redactedPlaceholder
+	}
+	if cfg.KubeletConfig != "" {
+		cfg.KubeletConfig = redactedPlaceholder
+	}
+	return cfg
+}
+
+// showConfig prints the configuration persisted under rootfs's state
+// directory, with secrets redacted.
+func showConfig(rootfs string) error {
+	cfg, err := loadStartConfig(configStatePath(rootfs))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	data, err := json.MarshalIndent(newPersistedConfig(redactConfig(*cfg)), "", "  ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	_, err = os.Stdout.Write(append(data, '\n'))
+	return trace.ConvertSystemError(err)
+}
@@ -64,6 +64,11 @@ const (
 	// EnvPublicIP names the environment variable that specifies
 	// the public IP address of the node
 	EnvPublicIP = "PLANET_PUBLIC_IP"
+	// EnvPublicIPs names the environment variable that specifies the
+	// node's additional public addresses beyond EnvPublicIP, as a
+	// comma-separated list, for dual-stack advertisement. Empty when the
+	// node only has a single public address.
+	EnvPublicIPs = "PLANET_PUBLIC_IPS"
 	// EnvAPIServerName names the environment variable that specifies
 	// the address of the API server
 	EnvAPIServerName = "KUBE_APISERVER"
@@ -253,6 +258,10 @@ const (
 	// This is external configuration for the container
 	EnvPlanetCloudConfig = "PLANET_CLOUD_CONFIG"
 
+	// EnvPlanetCloudConfigFile specifies the path to a cloud provider
+	// configuration file on the host to use in place of EnvPlanetCloudConfig.
+	EnvPlanetCloudConfigFile = "PLANET_CLOUD_CONFIG_FILE"
+
 	// EnvPlanetAllowPrivileged is an environment variable that indicates whether
 	// privileged containers are allowed.
 	EnvPlanetAllowPrivileged = "PLANET_ALLOW_PRIVILEGED"
@@ -347,13 +356,23 @@ const (
 	// This is kept for backwards-compatibility
 	LegacyAPIServerDNSName = "apiserver"
 
-	// DNSNdots defines the threshold for amount of dots that must appear in a name
-	// before an initial absolute query will be made
-	// See resolv.conf(5) on a Linux machine
-	DNSNdots = 2
-	// DNSTimeout is the amount time resolver will wait for response before retrying
-	// the query with a different name server. Measured in seconds
-	DNSTimeout = 1
+	// DefaultDNSNdots defines the default threshold for amount of dots that
+	// must appear in a name before an initial absolute query will be made,
+	// in the container's generated resolv.conf. See resolv.conf(5) on a
+	// Linux machine. Defaults to 5, matching what kubelet generates for
+	// each pod's own resolv.conf - Kubernetes service names like
+	// "foo.default.svc.cluster.local" need that many dots to resolve
+	// without an extra, failed absolute-query round trip first.
+	// Overridable with --dns-ndots.
+	DefaultDNSNdots = 5
+	// DefaultDNSTimeout is the default amount of time the resolver will
+	// wait for a response before retrying the query with a different name
+	// server, measured in seconds. Overridable with --dns-timeout.
+	DefaultDNSTimeout = 1
+	// DefaultDNSAttempts is the default number of times the resolver
+	// retries a lost query before giving up on a name server. Overridable
+	// with --dns-attempts.
+	DefaultDNSAttempts = 2
 
 	// ETCDServiceName names the service unit for etcd
 	ETCDServiceName = "etcd.service"
@@ -499,6 +518,11 @@ const (
 	// StateDir is a location within the planet container that can hold persistent state
 	StateDir = "/ext/state"
 
+	// ServiceUserStateFile stores the service user/group ID selected on a
+	// previous start so restarts without explicit --service-uid/--service-gid
+	// flags reuse the same values.
+	ServiceUserStateFile = "planet-service-user.json"
+
 	// KubernetesServiceName defines the name of the kubernetes service
 	// Unfortunately, it is an implementation detail in v1.17.9
 	// See https://github.com/kubernetes/kubernetes/blob/v1.17.9/pkg/master/controller.go#L44
@@ -0,0 +1,201 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/planet/lib/defaults"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// EnvPlanetDaemonChild marks a re-executed "planet start" process as the
+// already-daemonized child, so it runs the container in the foreground of
+// its own detached session instead of forking again.
+const EnvPlanetDaemonChild = "PLANET_DAEMON_CHILD"
+
+// DefaultDaemonLogPath is where a daemonized planet process' stdout/stderr
+// are redirected, since it no longer has a controlling terminal to inherit
+// them from.
+const DefaultDaemonLogPath = "/var/log/planet.log"
+
+// DefaultLogMaxSizeMB is the default maximum size, in megabytes, the
+// daemon log file is allowed to grow to before it's rotated.
+const DefaultLogMaxSizeMB = 100
+
+// DefaultLogMaxBackups is the default number of rotated daemon log files
+// retained alongside the active one.
+const DefaultLogMaxBackups = 5
+
+// daemonPidWait bounds how long daemonize waits for the forked child to
+// record its pidfile before giving up and reporting an error.
+const daemonPidWait = 10 * time.Second
+
+// runcDataDir is the directory the pidfile is stored in. It's a var (rather
+// than using defaults.RuncDataDir directly) so tests can point it at a
+// temporary directory.
+var runcDataDir = defaults.RuncDataDir
+
+// pidFilePath returns the location of the pidfile used to track the
+// running planet daemon, shared by start/stop/status regardless of which
+// CLI invocation created it.
+func pidFilePath() string {
+	return filepath.Join(runcDataDir, "planet.pid")
+}
+
+// daemonize re-executes the current command with EnvPlanetDaemonChild set,
+// detached from the controlling terminal in a new session, with stdout and
+// stderr redirected to DefaultDaemonLogPath, rotating it once it exceeds
+// logMaxSizeMB (a non-positive value disables rotation) and retaining up to
+// logMaxBackups rotated copies. It returns once the child has recorded its
+// pidfile, or after daemonPidWait elapses.
+func daemonize(args []string, logMaxSizeMB, logMaxBackups int) error {
+	if err := checkPidFile(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	if err := os.MkdirAll(runcDataDir, 0755); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+
+	logFile, err := newRotatingFile(DefaultDaemonLogPath, int64(logMaxSizeMB)*1024*1024, logMaxBackups)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer logFile.Close()
+
+	self, err := os.Executable()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	cmd := exec.Command(self, args...)
+	cmd.Env = append(os.Environ(), EnvPlanetDaemonChild+"=1")
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.WithField("pid", cmd.Process.Pid).Info("Started planet daemon, logs at " + DefaultDaemonLogPath)
+	return trace.Wrap(waitForPidFile(cmd.Process.Pid, daemonPidWait))
+}
+
+// waitForPidFile polls the pidfile until it records pid or timeout elapses.
+func waitForPidFile(pid int, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		recorded, err := readPidFile()
+		if err == nil && recorded == pid {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return trace.LimitExceeded("timed out waiting for planet daemon (pid %v) to start", pid)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// writePidFile records pid as the currently running planet daemon.
+func writePidFile(pid int) error {
+	if err := os.MkdirAll(filepath.Dir(pidFilePath()), 0755); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(ioutil.WriteFile(pidFilePath(), []byte(strconv.Itoa(pid)), 0644))
+}
+
+// readPidFile returns the pid recorded by writePidFile, or a trace.NotFound
+// error if no pidfile exists.
+func readPidFile() (int, error) {
+	data, err := ioutil.ReadFile(pidFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, trace.NotFound("no planet pidfile at %v", pidFilePath())
+		}
+		return 0, trace.ConvertSystemError(err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, trace.BadParameter("invalid pidfile %v: %v", pidFilePath(), err)
+	}
+	return pid, nil
+}
+
+// removePidFile removes the pidfile, ignoring a missing file.
+func removePidFile() error {
+	err := os.Remove(pidFilePath())
+	if err != nil && !os.IsNotExist(err) {
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
+
+// processAlive reports whether pid identifies a currently running process.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// checkPidFile verifies that no planet daemon is already running. A stale
+// pidfile - one whose process no longer exists - is removed rather than
+// blocking a new start.
+func checkPidFile() error {
+	pid, err := readPidFile()
+	if trace.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if processAlive(pid) {
+		return trace.AlreadyExists("planet is already running with pid %v (%v)", pid, pidFilePath())
+	}
+	log.WithField("pid", pid).Warn("Removing stale planet pidfile.")
+	return trace.Wrap(removePidFile())
+}
+
+// runForeground writes the pidfile for the current process, starts and
+// waits for the container, and removes the pidfile on exit. This is the
+// code path used both for --foreground and for the re-exec'd daemon child.
+func runForeground(config *Config) error {
+	if err := checkPidFile(); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := writePidFile(os.Getpid()); err != nil {
+		return trace.Wrap(err)
+	}
+	defer func() {
+		if err := removePidFile(); err != nil {
+			log.WithError(err).Warn("Failed to remove pidfile.")
+		}
+	}()
+	return trace.Wrap(startAndWait(config))
+}
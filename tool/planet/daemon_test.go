@@ -0,0 +1,86 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withRuncDataDir points runcDataDir (and hence pidFilePath) at a temporary
+// directory for the duration of the test.
+func withRuncDataDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "planet-daemon-test")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	original := runcDataDir
+	runcDataDir = dir
+	t.Cleanup(func() { runcDataDir = original })
+	return dir
+}
+
+func TestPidFileRoundTrip(t *testing.T) {
+	withRuncDataDir(t)
+
+	_, err := readPidFile()
+	assert.True(t, trace.IsNotFound(err))
+
+	require.NoError(t, writePidFile(os.Getpid()))
+
+	pid, err := readPidFile()
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), pid)
+
+	require.NoError(t, removePidFile())
+	_, err = readPidFile()
+	assert.True(t, trace.IsNotFound(err))
+
+	// removing an already-absent pidfile is not an error
+	require.NoError(t, removePidFile())
+}
+
+func TestCheckPidFileDetectsStaleEntry(t *testing.T) {
+	dir := withRuncDataDir(t)
+
+	// A pid that's very unlikely to be in use.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "planet.pid"), []byte("999999"), 0644))
+
+	require.NoError(t, checkPidFile())
+	_, err := readPidFile()
+	assert.True(t, trace.IsNotFound(err), "stale pidfile should have been removed")
+}
+
+func TestCheckPidFileBlocksLiveProcess(t *testing.T) {
+	withRuncDataDir(t)
+
+	require.NoError(t, writePidFile(os.Getpid()))
+
+	err := checkPidFile()
+	assert.True(t, trace.IsAlreadyExists(err))
+}
+
+func TestProcessAlive(t *testing.T) {
+	assert.True(t, processAlive(os.Getpid()))
+	assert.False(t, processAlive(999999))
+}
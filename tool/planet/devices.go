@@ -17,31 +17,107 @@ limitations under the License.
 package main
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"syscall"
+	"time"
 
-	"github.com/gravitational/trace"
+	"github.com/gravitational/planet/lib/utils"
 
+	"github.com/containerd/cgroups"
+	"github.com/gravitational/trace"
 	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/devices"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	log "github.com/sirupsen/logrus"
+)
+
+// cgroupDevicePermissions is the cgroup access format (rwm) used for
+// devices hot-plugged into the container by the udev listener
+const cgroupDevicePermissions = "rwm"
+
+const (
+	// deviceRemoveAttempts is the number of times to retry unlinking a
+	// device node that's still busy (open by another process) before
+	// giving up
+	deviceRemoveAttempts = 5
+	// deviceRemoveRetryPeriod is the delay between device node unlink
+	// attempts
+	deviceRemoveRetryPeriod = 500 * time.Millisecond
 )
 
-// createDevice creates a node for the specified device in the container
+// deviceResult is the structured result a device subcommand prints to
+// stdout on completion, so the host-side udev listener can log success or
+// failure precisely instead of scraping unstructured log output
+type deviceResult struct {
+	// Success indicates whether the operation completed
+	Success bool `json:"success"`
+	// Error is a human-readable description of the failure, empty on success
+	Error string `json:"error,omitempty"`
+}
+
+// createDevice creates a node for the specified device in the container,
+// adds it to the container's devices cgroup allow list and applies the
+// device's ownership and permissions. The operation is idempotent - it is
+// not an error if the node or the allow list entry already exist
 func createDevice(device *configs.Device) error {
+	return reportDeviceResult(doCreateDevice(device))
+}
+
+func doCreateDevice(device *configs.Device) error {
 	oldMask := syscall.Umask(0000)
-	if err := createDeviceNode(device); err != nil {
-		syscall.Umask(oldMask)
+	err := createDeviceNode(device)
+	syscall.Umask(oldMask)
+	if err != nil {
 		return trace.Wrap(err)
 	}
-	syscall.Umask(oldMask)
-	return nil
+	return trace.Wrap(updateDeviceCgroup(device, true))
 }
 
-// removeDevice removes the device specified with node path
-func removeDevice(node string) (err error) {
-	if err = os.Remove(node); err != nil && os.IsNotExist(err) {
-		// Ignore `file not found` errors
+// removeDevice denies the device at node in the container's devices cgroup
+// and unlinks the device node. If the node is still busy (open by another
+// process), the unlink is retried every deviceRemoveRetryPeriod, up to
+// deviceRemoveAttempts times. The operation is idempotent
+// - it is not an error if the node or the allow list entry are already gone
+func removeDevice(node string) error {
+	return reportDeviceResult(doRemoveDevice(node))
+}
+
+func doRemoveDevice(node string) error {
+	device, err := devices.DeviceFromPath(node, cgroupDevicePermissions)
+	if err != nil && !os.IsNotExist(err) {
+		return trace.Wrap(err)
+	}
+	if device != nil {
+		if err := updateDeviceCgroup(device, false); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	err = utils.Retry(context.TODO(), deviceRemoveAttempts, deviceRemoveRetryPeriod, func() error {
+		err := os.Remove(node)
+		if err != nil && !os.IsNotExist(err) {
+			return trace.ConvertSystemError(err)
+		}
 		return nil
+	})
+	return trace.Wrap(err)
+}
+
+// reportDeviceResult prints the structured result of a device operation to
+// stdout for the host-side udev listener to parse, and returns err unchanged
+// so the command still exits with a non-zero status on failure
+func reportDeviceResult(err error) error {
+	result := deviceResult{Success: err == nil}
+	if err != nil {
+		result.Error = trace.UserMessage(err)
+	}
+	if marshalErr := json.NewEncoder(os.Stdout).Encode(result); marshalErr != nil {
+		log.WithError(marshalErr).Warn("Failed to encode device command result.")
 	}
 	return trace.Wrap(err)
 }
@@ -75,3 +151,63 @@ func mknodDevice(dest string, node *configs.Device) error {
 	}
 	return syscall.Chown(dest, int(node.Uid), int(node.Gid))
 }
+
+// updateDeviceCgroup adds (allow=true) or removes (allow=false) device from
+// the devices cgroup allow list of the container this process is running in
+func updateDeviceCgroup(device *configs.Device, allow bool) error {
+	cgroupPath, err := selfCgroupPath("devices")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	control, err := cgroups.Load(cgroups.V1, cgroups.StaticPath(cgroupPath))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	major, minor := device.Major, device.Minor
+	resources := &specs.LinuxResources{
+		Devices: []specs.LinuxDeviceCgroup{
+			{
+				Allow:  allow,
+				Type:   string(device.Type),
+				Major:  &major,
+				Minor:  &minor,
+				Access: cgroupDevicePermissions,
+			},
+		},
+	}
+	return trace.Wrap(control.Update(resources))
+}
+
+// selfCgroupPath returns the cgroup path of this process for the specified
+// controller (e.g. "devices"), as read from /proc/self/cgroup. It must only
+// be called from inside the container's own namespaces - runDeviceCmd
+// re-execs through enter() for exactly this reason - since outside them
+// /proc/self/cgroup resolves to the caller's own cgroup instead (the class
+// of bug fixed for updateresources.go in 4b240b9).
+func selfCgroupPath(controller string) (path string, err error) {
+	f, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// each line has the form: hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		for _, c := range strings.Split(fields[1], ",") {
+			if c == controller {
+				return fields[2], nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	return "", trace.NotFound("%v cgroup controller not found for this process", controller)
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gravitational/planet/lib/monitoring"
+
+	etcdconf "github.com/gravitational/coordinate/v4/config"
+	"github.com/gravitational/satellite/lib/rpc/client"
+	agentutils "github.com/gravitational/satellite/utils"
+	"github.com/gravitational/trace"
+	"github.com/gravitational/version"
+)
+
+// diagCollectTimeout bounds how long a single diagnostic section may take
+// to collect, so one wedged section doesn't hang the whole snapshot.
+const diagCollectTimeout = 30 * time.Second
+
+// diagJournalLines is the number of most recent journal lines captured in
+// the journal.txt section.
+const diagJournalLines = "1000"
+
+// diagMaxSectionSize caps the size of a single collected section, so a
+// runaway command (or a node with years of accumulated journal logs)
+// doesn't blow up the size of the resulting tarball.
+const diagMaxSectionSize = 4 * 1024 * 1024
+
+// secretEnvVarPattern matches environment variable names that commonly
+// carry secrets, so their values can be redacted from environment.txt.
+var secretEnvVarPattern = regexp.MustCompile(`(?i)key|secret|token|password|credential`)
+
+// keyMaterialPattern matches PEM-encoded private keys, so they can be
+// redacted from any collected section that happens to embed one.
+var keyMaterialPattern = regexp.MustCompile(`(?s)-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----.*?-----END [A-Z0-9 ]*PRIVATE KEY-----`)
+
+// diagConfig configures "planet debug diag".
+type diagConfig struct {
+	// output is the path the diagnostic tarball is written to.
+	output string
+	// rpcPort is the local agent's RPC port, used to collect cluster status.
+	rpcPort int
+	// seLinux enables SELinux support on commands run inside the container.
+	seLinux bool
+}
+
+// diagSection is a single named entry of a diagnostic archive, e.g.
+// "status.json" or "mounts.txt".
+type diagSection struct {
+	name string
+	data []byte
+}
+
+// diag collects a diagnostic snapshot of the local planet node - cluster
+// and node status, versions, the effective environment, mounts, network
+// namespace info, iptables rules, cgroup stats, journal excerpts, the DNS
+// resolver configuration and etcd status - into a single gzipped tarball at
+// config.output. A section that fails to collect is still included, with
+// its error recorded as its content, so a single unavailable source (e.g.
+// etcd on a non-master node) doesn't prevent the rest of the snapshot from
+// being captured. Every section is size-capped and scrubbed of anything
+// that looks like private key material before being written out, since the
+// resulting tarball is meant to be handed to support.
+func diag(config diagConfig) error {
+	out, err := os.Create(config.output)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer out.Close()
+
+	sections := collectDiagSections(config)
+	return trace.Wrap(writeDiagArchive(out, sections))
+}
+
+// collectDiagSections gathers every diagnostic section, substituting a
+// section's collection error for its content rather than aborting.
+func collectDiagSections(config diagConfig) []diagSection {
+	collectors := []struct {
+		name    string
+		collect func() ([]byte, error)
+	}{
+		{"status.json", func() ([]byte, error) { return collectDiagStatus(config.rpcPort) }},
+		{"version.json", collectDiagVersion},
+		{"component-versions.json", func() ([]byte, error) { return collectDiagComponentVersions(config.seLinux) }},
+		{"etcd-status.json", collectDiagEtcdStatus},
+		{"environment.txt", func() ([]byte, error) { return collectDiagEnvironment(config.seLinux) }},
+		{"mounts.txt", func() ([]byte, error) { return collectDiagCommand(config.seLinux, "/bin/cat", "/proc/mounts") }},
+		{"netns.json", func() ([]byte, error) {
+			return collectDiagCommand(config.seLinux, "/usr/bin/planet", "--debug", "netns", "collect")
+		}},
+		{"iptables.txt", func() ([]byte, error) { return collectDiagCommand(config.seLinux, "iptables-save") }},
+		{"cgroup.txt", func() ([]byte, error) {
+			return collectDiagCommand(config.seLinux, "/bin/systemd-cgtop", "-b", "-n", "1")
+		}},
+		{"journal.txt", func() ([]byte, error) {
+			return collectDiagCommand(config.seLinux, journalctlPath, "--no-pager", "-n", diagJournalLines)
+		}},
+		{"dns.env", func() ([]byte, error) { return collectDiagCommand(config.seLinux, "/bin/cat", DNSEnvFile) }},
+		{"coredns.conf", func() ([]byte, error) { return collectDiagCommand(config.seLinux, "/bin/cat", CoreDNSConf) }},
+	}
+
+	sections := make([]diagSection, 0, len(collectors))
+	for _, c := range collectors {
+		data, err := c.collect()
+		if err != nil {
+			data = []byte("failed to collect " + c.name + ": " + err.Error() + "\n")
+		}
+		sections = append(sections, diagSection{name: c.name, data: redactAndCap(data)})
+	}
+	return sections
+}
+
+// redactAndCap strips anything that looks like private key material from
+// data and truncates it to diagMaxSectionSize.
+func redactAndCap(data []byte) []byte {
+	data = keyMaterialPattern.ReplaceAll(data, []byte("[REDACTED PRIVATE KEY]"))
+	if len(data) <= diagMaxSectionSize {
+		return data
+	}
+	truncated := data[:diagMaxSectionSize]
+	return append(truncated, []byte(fmt.Sprintf("\n... truncated, section exceeded %d bytes\n", diagMaxSectionSize))...)
+}
+
+// collectDiagStatus queries the local planet agent for cluster status.
+func collectDiagStatus(rpcPort int) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), diagCollectTimeout)
+	defer cancel()
+
+	agentClient, err := client.NewClient(ctx, client.Config{Address: rpcAddr(rpcPort)})
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer agentClient.Close()
+
+	status, err := agentClient.Status(ctx)
+	if err != nil {
+		if agentutils.IsUnavailableError(err) {
+			return nil, newAgentUnavailableError()
+		}
+		return nil, trace.Wrap(err)
+	}
+	annotateUnreachableNodes(status)
+	return json.MarshalIndent(status, "", "   ")
+}
+
+// collectDiagVersion returns the planet binary's version information.
+func collectDiagVersion() ([]byte, error) {
+	return json.MarshalIndent(version.Get(), "", "   ")
+}
+
+// collectDiagEtcdStatus queries etcd cluster status using the same TLS
+// settings as "planet etcd status".
+func collectDiagEtcdStatus() ([]byte, error) {
+	conf := etcdconf.Config{
+		Endpoints: []string{DefaultEtcdEndpoints},
+		KeyFile:   DefaultEtcdctlKeyFile,
+		CertFile:  DefaultEtcdctlCertFile,
+		CAFile:    DefaultEtcdctlCAFile,
+	}
+	etcdClient, err := conf.NewClientV3()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer etcdClient.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), diagCollectTimeout)
+	defer cancel()
+
+	status, err := collectEtcdStatus(ctx, etcdClient, conf.Endpoints)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return json.MarshalIndent(status, "", "   ")
+}
+
+// collectDiagComponentVersions runs the same commands NewVersionCollector
+// uses to determine component versions, inside the container.
+func collectDiagComponentVersions(seLinux bool) ([]byte, error) {
+	versions := make(map[string]string)
+	for component, command := range monitoring.InfoCheckerCommands() {
+		out, err := collectDiagCommand(seLinux, command[0], command[1:]...)
+		if err != nil {
+			versions[component] = "failed to collect: " + err.Error()
+			continue
+		}
+		versions[component] = strings.TrimSpace(string(out))
+	}
+	return json.MarshalIndent(versions, "", "   ")
+}
+
+// collectDiagEnvironment returns the effective configuration planet was
+// started with, as read from the environment of the container's init
+// process - planet threads its configuration into the container as
+// environment variables (see box.EnvVars). Values whose name suggests a
+// secret are redacted.
+func collectDiagEnvironment(seLinux bool) ([]byte, error) {
+	out, err := collectDiagCommand(seLinux, "/bin/cat", "/proc/1/environ")
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []byte(formatEnviron(out)), nil
+}
+
+// formatEnviron turns a NUL-separated "/proc/<pid>/environ" dump into one
+// "NAME=VALUE" line per variable, redacting values whose name suggests a
+// secret.
+func formatEnviron(data []byte) string {
+	var lines []string
+	for _, entry := range bytes.Split(data, []byte{0}) {
+		if len(entry) == 0 {
+			continue
+		}
+		name, value := splitEnvEntry(string(entry))
+		if secretEnvVarPattern.MatchString(name) {
+			value = "[REDACTED]"
+		}
+		lines = append(lines, name+"="+value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// splitEnvEntry splits a single "NAME=VALUE" environment entry.
+func splitEnvEntry(entry string) (name, value string) {
+	if i := strings.Index(entry, "="); i >= 0 {
+		return entry[:i], entry[i+1:]
+	}
+	return entry, ""
+}
+
+// collectDiagCommand runs cmd inside the container's namespaces and
+// returns its combined output.
+func collectDiagCommand(seLinux bool, cmd string, args ...string) ([]byte, error) {
+	out, err := runInContainer(seLinux, cmd, args...)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return []byte(out), nil
+}
+
+// writeDiagArchive writes sections to w as a gzipped tar archive.
+func writeDiagArchive(w io.Writer, sections []diagSection) error {
+	gzw := gzip.NewWriter(w)
+	defer gzw.Close()
+
+	tw := tar.NewWriter(gzw)
+	defer tw.Close()
+
+	for _, section := range sections {
+		header := &tar.Header{
+			Name: section.name,
+			Mode: 0644,
+			Size: int64(len(section.data)),
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return trace.Wrap(err)
+		}
+		if _, err := tw.Write(section.data); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/gravitational/planet/lib/monitoring"
+
+	"github.com/gravitational/satellite/lib/membership"
+	"github.com/gravitational/satellite/lib/rpc"
+	"github.com/gravitational/satellite/lib/rpc/client"
+	"github.com/gravitational/trace"
+)
+
+// newTaggedDialRPC returns a DialRPC that resolves the port to dial for a
+// peer from its RPCPortTag, so agents started with a non-default
+// --rpc-addr remain reachable. Peers with no tag - e.g. older agents, or
+// members reported by a Cluster implementation that doesn't propagate
+// tags - are dialed on defaultPort for backward compatibility.
+//
+// Like satellite's own default DialRPC (client.ClientCache.DefaultDialRPC),
+// the returned function caches one Client per resolved address rather than
+// dialing a fresh TLS+gRPC connection on every call - callers never close
+// the Client they're handed back.
+func newTaggedDialRPC(cluster membership.Cluster, defaultPort int, caFile, certFile, keyFile string) client.DialRPC {
+	cache := &taggedClientCache{}
+	return func(ctx context.Context, addr string) (client.Client, error) {
+		port := rpcPortForAddr(cluster, addr, defaultPort)
+		address := fmt.Sprintf("%v:%v", addr, port)
+		return cache.getOrDial(address, caFile, certFile, keyFile)
+	}
+}
+
+// taggedClientCache caches RPC clients by resolved address for
+// newTaggedDialRPC, mirroring client.ClientCache's caching behavior.
+type taggedClientCache struct {
+	mu      sync.Mutex
+	clients map[string]client.Client
+}
+
+// getOrDial returns the cached client for address, dialing and caching a
+// new one if none exists yet.
+func (c *taggedClientCache) getOrDial(address, caFile, certFile, keyFile string) (client.Client, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if cached, ok := c.clients[address]; ok {
+		return cached, nil
+	}
+
+	config := client.Config{
+		Address:  address,
+		CAFile:   caFile,
+		CertFile: certFile,
+		KeyFile:  keyFile,
+	}
+	// Dial with a background context, as satellite's own DefaultDialRPC
+	// does: the connection is cached and reused across calls, so its
+	// lifetime shouldn't be tied to a single RPC's context.
+	agentClient, err := client.NewClient(context.Background(), config)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	if c.clients == nil {
+		c.clients = make(map[string]client.Client)
+	}
+	c.clients[address] = agentClient
+	return agentClient, nil
+}
+
+// rpcPortForAddr looks up the member whose address is addr and returns the
+// RPC port advertised in its RPCPortTag, or defaultPort if the member
+// can't be found or doesn't advertise one.
+func rpcPortForAddr(cluster membership.Cluster, addr string, defaultPort int) int {
+	members, err := cluster.Members()
+	if err != nil {
+		return defaultPort
+	}
+	for _, member := range members {
+		if member.Addr != addr {
+			continue
+		}
+		if port, ok := monitoring.ParseRPCPortTag(member.Tags); ok {
+			return port
+		}
+		break
+	}
+	return defaultPort
+}
+
+// defaultRPCPort is the RPC port used for peers that don't advertise an
+// RPCPortTag of their own.
+const defaultRPCPort = rpc.Port
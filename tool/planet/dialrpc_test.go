@@ -0,0 +1,85 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gravitational/planet/lib/monitoring"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+// fakeMemberCluster is a fixed list of members, used to exercise
+// rpcPortForAddr without a real Cluster implementation.
+type fakeMemberCluster []*pb.MemberStatus
+
+func (c fakeMemberCluster) Members() ([]*pb.MemberStatus, error) { return c, nil }
+
+func (c fakeMemberCluster) Member(name string) (*pb.MemberStatus, error) {
+	for _, member := range c {
+		if member.Name == name {
+			return member, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestRPCPortForAddrUsesTagWhenPresent(t *testing.T) {
+	cluster := fakeMemberCluster{
+		{Name: "new-agent", Addr: "10.0.0.1", Tags: map[string]string{monitoring.RPCPortTag: "8888"}},
+	}
+
+	port := rpcPortForAddr(cluster, "10.0.0.1", defaultRPCPort)
+	if port != 8888 {
+		t.Fatalf("expected tagged port 8888, got %v", port)
+	}
+}
+
+func TestRPCPortForAddrFallsBackWhenTagMissing(t *testing.T) {
+	cluster := fakeMemberCluster{
+		{Name: "old-agent", Addr: "10.0.0.2", Tags: map[string]string{"role": "master"}},
+	}
+
+	port := rpcPortForAddr(cluster, "10.0.0.2", defaultRPCPort)
+	if port != defaultRPCPort {
+		t.Fatalf("expected fallback to default port %v, got %v", defaultRPCPort, port)
+	}
+}
+
+func TestRPCPortForAddrFallsBackWhenMemberUnknown(t *testing.T) {
+	cluster := fakeMemberCluster{}
+
+	port := rpcPortForAddr(cluster, "10.0.0.3", defaultRPCPort)
+	if port != defaultRPCPort {
+		t.Fatalf("expected fallback to default port %v, got %v", defaultRPCPort, port)
+	}
+}
+
+func TestRPCPortForAddrMixedVersionCluster(t *testing.T) {
+	cluster := fakeMemberCluster{
+		{Name: "new-agent", Addr: "10.0.0.1", Tags: map[string]string{monitoring.RPCPortTag: "9999"}},
+		{Name: "old-agent", Addr: "10.0.0.2", Tags: map[string]string{"role": "node"}},
+	}
+
+	if port := rpcPortForAddr(cluster, "10.0.0.1", defaultRPCPort); port != 9999 {
+		t.Fatalf("expected tagged member to use its advertised port, got %v", port)
+	}
+	if port := rpcPortForAddr(cluster, "10.0.0.2", defaultRPCPort); port != defaultRPCPort {
+		t.Fatalf("expected untagged member to fall back to the default port, got %v", port)
+	}
+}
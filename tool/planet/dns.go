@@ -21,9 +21,12 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/gravitational/planet/lib/box"
 	"github.com/gravitational/planet/lib/constants"
 	"github.com/gravitational/planet/lib/ipallocator"
 	"github.com/gravitational/planet/lib/utils"
@@ -38,18 +41,107 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/kubernetes"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
-// setupResolver finds the kube-dns service address, and writes an environment file accordingly
-func setupResolver(ctx context.Context, role agent.Role, serviceCIDR net.IPNet) error {
+// dnsZoneServerDialTimeout bounds how long validateDNSZones waits for a
+// single forwarder to answer before reporting it unreachable. Kept short
+// since an unreachable forwarder is only ever a warning - start must not
+// be slowed down noticeably waiting on it.
+const dnsZoneServerDialTimeout = 2 * time.Second
+
+// validateDNSZones checks the domain/nameserver overrides CoreDNS was
+// configured to forward per-zone queries to (config.DNS.Zones, set via
+// "planet start --dns-zones domain/ip[,domain/ip...]"), returning one
+// warning string per problem found. Nothing here is fatal: a typo'd
+// domain or a forwarder that's temporarily unreachable at boot shouldn't
+// block the node from starting, so the caller is expected to log these
+// rather than fail on them.
+func validateDNSZones(zones map[string][]string) []string {
+	var warnings []string
+	for _, domain := range sortedKeys(zones) {
+		if errs := validation.IsDNS1123Subdomain(strings.ToLower(domain)); len(errs) != 0 {
+			warnings = append(warnings, fmt.Sprintf("DNS zone %q is not a valid domain name: %v", domain, strings.Join(errs, "; ")))
+		}
+		for _, server := range zones[domain] {
+			if net.ParseIP(server) == nil {
+				warnings = append(warnings, fmt.Sprintf("DNS zone %q forwarder %q is not a valid IP address", domain, server))
+				continue
+			}
+			if !dnsServerReachable(server) {
+				warnings = append(warnings, fmt.Sprintf("DNS zone %q forwarder %v is not reachable on port 53 - queries for this zone may fail until it is", domain, server))
+			}
+		}
+	}
+	return warnings
+}
+
+// dnsServerReachable reports whether a DNS server looks reachable on port
+// 53, trying both protocols concurrently since either is enough to
+// consider the forwarder reachable. This is a best-effort network-layer
+// check only: a successful UDP dial just means the route/port didn't
+// immediately reject the connection, not that a DNS daemon actually
+// answered - it's enough to catch a host that's unreachable outright
+// (wrong IP, no route, firewalled), which is the common case for a typo'd
+// forwarder.
+func dnsServerReachable(server string) bool {
+	addr := net.JoinHostPort(server, "53")
+	var wg sync.WaitGroup
+	reachable := make(chan bool, 2)
+	for _, network := range []string{"tcp", "udp"} {
+		wg.Add(1)
+		go func(network string) {
+			defer wg.Done()
+			conn, err := net.DialTimeout(network, addr, dnsZoneServerDialTimeout)
+			if err != nil {
+				reachable <- false
+				return
+			}
+			conn.Close()
+			reachable <- true
+		}(network)
+	}
+	go func() {
+		wg.Wait()
+		close(reachable)
+	}()
+	for ok := range reachable {
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of m in sorted order, so callers that report
+// one message per key (e.g. validateDNSZones) do so deterministically.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setupResolver finds the kube-dns service address, and writes an environment file accordingly.
+// If timeout is non-zero, retries are bounded by it - required on single-node clusters, where
+// the kube-dns-worker service legitimately never appears and would otherwise retry forever.
+func setupResolver(ctx context.Context, role agent.Role, serviceCIDR net.IPNet, timeout time.Duration) error {
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 	client, err := cmd.GetKubeClientFromPath(constants.KubeletConfigPath)
 	if err != nil {
 		return trace.Wrap(err)
 	}
-	err = utils.RetryWithInterval(ctx, newUnlimitedExponentialBackoff(5*time.Second), func() error {
-		err = updateEnvDNSAddresses(ctx, client, role, serviceCIDR)
+	var degraded bool
+	stats, err := utils.RetryWithIntervalE(ctx, newUnlimitedExponentialBackoff(5*time.Second), func() error {
+		degraded, err = updateEnvDNSAddresses(ctx, client, role, serviceCIDR)
 		if err != nil {
 			log.Warn("Error updating DNS env: ", err)
 			return trace.Wrap(err)
@@ -57,54 +149,155 @@ func setupResolver(ctx context.Context, role agent.Role, serviceCIDR net.IPNet)
 		return nil
 
 	})
-	return trace.Wrap(err)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	log.WithFields(log.Fields{
+		"attempts": stats.Attempts,
+		"duration": stats.TotalDuration,
+	}).Info("DNS resolver configuration updated.")
+	if degraded {
+		log.Warn("DNS resolver configured with the master service only - kube-dns-worker was not found.")
+	}
+	return nil
 }
 
 func writeEnvDNSAddresses(addr []string, overwrite bool) error {
-	env := fmt.Sprintf(`%v="%v"`, EnvDNSAddresses, strings.Join(addr, ","))
-	env = fmt.Sprintln(env)
-
 	if _, err := os.Stat(DNSEnvFile); !os.IsNotExist(err) && !overwrite {
 		return nil
 	}
 
-	err := utils.SafeWriteFile(DNSEnvFile, []byte(env), constants.SharedReadMask)
+	env := map[string]string{EnvDNSAddresses: strings.Join(addr, ",")}
+	err := utils.WriteEnvFile(DNSEnvFile, env, utils.EnvFileFormatPlain, constants.SharedReadMask)
 	return trace.Wrap(err)
 }
 
-func updateEnvDNSAddresses(ctx context.Context, client *kubernetes.Clientset, role agent.Role, serviceCIDR net.IPNet) error {
+// updateEnvDNSAddresses writes the resolver addresses computed for role to
+// DNSEnvFile, reporting whether they were computed in degraded mode (see
+// computeDNSAddresses).
+func updateEnvDNSAddresses(ctx context.Context, client *kubernetes.Clientset, role agent.Role, serviceCIDR net.IPNet) (degraded bool, err error) {
+	addr, degraded, err := computeDNSAddresses(ctx, client, role, serviceCIDR)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	return degraded, trace.Wrap(writeEnvDNSAddresses(addr, true))
+}
+
+// computeDNSAddresses queries the kube-dns/kube-dns-worker services and
+// returns the resolver addresses that updateEnvDNSAddresses would write for
+// role, without touching DNSEnvFile.
+//
+// The kube-dns-worker service does not exist on single-node clusters. Since
+// selectDNSAddresses never uses it for a master resolver, its absence is
+// only ever reported as degraded - and only for non-master roles, where it
+// causes a fallback to the master-only address.
+func computeDNSAddresses(ctx context.Context, client *kubernetes.Clientset, role agent.Role, serviceCIDR net.IPNet) (addrs []string, degraded bool, err error) {
 	// locate the cluster IP of the kube-dns service
 	masterServices, err := client.CoreV1().Services(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{
 		LabelSelector: dnsServiceSelector.String(),
 	})
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, false, trace.Wrap(err)
 	}
 	svcMaster, err := getDNSService(masterServices.Items, serviceCIDR)
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, false, trace.Wrap(err)
 	}
 
 	workerServices, err := client.CoreV1().Services(metav1.NamespaceSystem).List(ctx, metav1.ListOptions{
 		LabelSelector: dnsWorkerServiceSelector.String(),
 	})
 	if err != nil {
-		return trace.Wrap(err)
+		return nil, false, trace.Wrap(err)
 	}
 	svcWorker, err := getDNSService(workerServices.Items, serviceCIDR)
 	if err != nil {
-		return trace.Wrap(err)
+		if !trace.IsNotFound(err) {
+			return nil, false, trace.Wrap(err)
+		}
+		svcWorker = nil
 	}
 
-	// If we're a master server, only use the master servers as a resolver.
-	// This is because, we don't know if the second worker service will have any pods after future scaling operations
-	//
-	// If we're a worker, query the workers coredns first, and master second
-	// This guaranteess any retries will not be handled by the same node
+	addrs, degraded = selectDNSAddresses(role, svcMaster, svcWorker)
+	return addrs, degraded, nil
+}
+
+// selectDNSAddresses picks the resolver address(es) to use for role given
+// the current kube-dns and kube-dns-worker services, and whether that
+// choice was a degraded fallback caused by a missing kube-dns-worker.
+//
+// If we're a master server, only use the master servers as a resolver.
+// This is because, we don't know if the second worker service will have any pods after future scaling operations
+//
+// If we're a worker, query the workers coredns first, and master second
+// This guaranteess any retries will not be handled by the same node
+//
+// svcWorker may be nil - e.g. on a single-node cluster where kube-dns-worker
+// was never created - in which case a non-master role falls back to the
+// master address alone, reported as degraded.
+func selectDNSAddresses(role agent.Role, svcMaster, svcWorker *v1.Service) (addrs []string, degraded bool) {
 	if role == agent.RoleMaster {
-		return trace.Wrap(writeEnvDNSAddresses([]string{svcMaster.Spec.ClusterIP}, true))
+		return []string{svcMaster.Spec.ClusterIP}, false
+	}
+	if svcWorker == nil {
+		return []string{svcMaster.Spec.ClusterIP}, true
+	}
+	return []string{svcWorker.Spec.ClusterIP, svcMaster.Spec.ClusterIP}, false
+}
+
+// readEnvDNSAddresses returns the resolver addresses currently recorded in
+// DNSEnvFile, or nil if the file doesn't exist yet.
+func readEnvDNSAddresses() ([]string, error) {
+	env, err := box.ReadEnvironment(DNSEnvFile)
+	if err != nil {
+		if os.IsNotExist(trace.Unwrap(err)) {
+			return nil, nil
+		}
+		return nil, trace.Wrap(err)
+	}
+	value := env.Get(EnvDNSAddresses)
+	if value == "" {
+		return nil, nil
+	}
+	return strings.Split(value, ","), nil
+}
+
+// diffDNSAddresses compares the resolver addresses recorded in DNSEnvFile
+// against what updateEnvDNSAddresses would compute now, and prints the
+// result without writing anything.
+func diffDNSAddresses(ctx context.Context, client *kubernetes.Clientset, role agent.Role, serviceCIDR net.IPNet) error {
+	current, err := readEnvDNSAddresses()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	computed, degraded, err := computeDNSAddresses(ctx, client, role, serviceCIDR)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if degraded {
+		fmt.Println("Note: kube-dns-worker was not found - computed addresses fall back to the master service only.")
+	}
+	if dnsAddressesEqual(current, computed) {
+		fmt.Printf("DNS addresses are up to date: %v\n", strings.Join(current, ","))
+		return nil
+	}
+	fmt.Printf("DNS addresses have drifted:\n  current (%v):  %v\n  computed:      %v\nA DNS reload is needed to pick up the change.\n",
+		DNSEnvFile, strings.Join(current, ","), strings.Join(computed, ","))
+	return nil
+}
+
+// dnsAddressesEqual reports whether a and b list the same addresses in the
+// same order.
+func dnsAddressesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
 	}
-	return trace.Wrap(writeEnvDNSAddresses([]string{svcWorker.Spec.ClusterIP, svcMaster.Spec.ClusterIP}, true))
+	return true
 }
 
 func ensureDNSServices(ctx context.Context, serviceCIDR net.IPNet) error {
@@ -19,7 +19,9 @@ package main
 import (
 	"testing"
 
+	"github.com/gravitational/satellite/agent"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -62,3 +64,36 @@ func TestCreateLabelSelector(t *testing.T) {
 		assert.Equal(t, expectedSelector, actualSelector)
 	}
 }
+
+func TestSelectDNSAddresses(t *testing.T) {
+	svcMaster := &v1.Service{Spec: v1.ServiceSpec{ClusterIP: "10.100.0.10"}}
+	svcWorker := &v1.Service{Spec: v1.ServiceSpec{ClusterIP: "10.100.0.11"}}
+
+	addrs, degraded := selectDNSAddresses(agent.RoleMaster, svcMaster, svcWorker)
+	assert.Equal(t, []string{"10.100.0.10"}, addrs)
+	assert.False(t, degraded)
+
+	addrs, degraded = selectDNSAddresses(agent.RoleNode, svcMaster, svcWorker)
+	assert.Equal(t, []string{"10.100.0.11", "10.100.0.10"}, addrs)
+	assert.False(t, degraded)
+}
+
+func TestSelectDNSAddressesFallsBackWhenWorkerAbsent(t *testing.T) {
+	svcMaster := &v1.Service{Spec: v1.ServiceSpec{ClusterIP: "10.100.0.10"}}
+
+	addrs, degraded := selectDNSAddresses(agent.RoleMaster, svcMaster, nil)
+	assert.Equal(t, []string{"10.100.0.10"}, addrs)
+	assert.False(t, degraded, "master role never uses the worker service, so its absence is not degraded")
+
+	addrs, degraded = selectDNSAddresses(agent.RoleNode, svcMaster, nil)
+	assert.Equal(t, []string{"10.100.0.10"}, addrs)
+	assert.True(t, degraded, "a node role falling back to the master service alone is degraded")
+}
+
+func TestDNSAddressesEqual(t *testing.T) {
+	assert.True(t, dnsAddressesEqual(nil, nil))
+	assert.True(t, dnsAddressesEqual([]string{"10.100.0.10"}, []string{"10.100.0.10"}))
+	assert.False(t, dnsAddressesEqual([]string{"10.100.0.10"}, []string{"10.100.0.11"}))
+	assert.False(t, dnsAddressesEqual([]string{"10.100.0.10"}, []string{"10.100.0.10", "10.100.0.11"}))
+	assert.False(t, dnsAddressesEqual([]string{"10.100.0.10", "10.100.0.11"}, []string{"10.100.0.11", "10.100.0.10"}))
+}
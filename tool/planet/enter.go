@@ -17,7 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/gravitational/planet/lib/box"
 	"github.com/gravitational/planet/lib/constants"
@@ -26,6 +29,13 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// minUserID and maxUserID bound the numeric uid/gid form of --user, mirroring
+// the range libcontainer itself enforces when resolving the process user.
+const (
+	minUserID = 0
+	maxUserID = 1<<31 - 1
+)
+
 type enterConfig struct {
 	cmd     string
 	user    string
@@ -33,23 +43,64 @@ type enterConfig struct {
 	stdin   bool
 	args    []string
 	seLinux bool
+	// unit, if set, names a systemd unit whose main process' cgroup and
+	// environment the entered process should join, for debugging a
+	// misbehaving service from within its own resource constraints.
+	unit string
+	// listUnits, if set, prints the container's systemd units instead of
+	// entering the container.
+	listUnits bool
+	// readonly, if set, remounts the entered process' own view of the
+	// rootfs read-only, for handing untrusted diagnostic sessions to a
+	// customer without risking changes to the node.
+	readonly bool
+	// capAdd and capDrop adjust the Linux capabilities of the entered
+	// process relative to the container's own capability set.
+	capAdd  []string
+	capDrop []string
+	// noNewPrivileges, if set, prevents the entered process (and any child
+	// it spawns) from gaining privileges beyond what it starts with.
+	noNewPrivileges bool
+	// outputBufferSize, if non-zero, bounds the entered process' stdout to
+	// this many bytes of in-memory buffering (see box.OutputBufferConfig),
+	// dropping the oldest output once exceeded instead of blocking the
+	// process on a slow destination. Zero preserves today's unbounded
+	// backpressure.
+	outputBufferSize int
 }
 
 func enterConsole(config enterConfig) error {
+	if config.listUnits {
+		return trace.Wrap(listSystemdUnits(config.seLinux))
+	}
+
+	if err := validateUserSpec(config.user); err != nil {
+		return trace.Wrap(err)
+	}
+
 	cfg := box.EnterConfig{
 		Process: box.ProcessConfig{
 			Out:  os.Stdout,
 			Args: append([]string{config.cmd}, config.args...),
+			User: config.user,
 			Env: box.EnvVars{
 				box.EnvPair{
 					Name: EnvPath,
 					Val:  DefaultEnvPath,
 				},
 			},
+			ReadonlyRootfs:  config.readonly,
+			CapAdd:          config.capAdd,
+			CapDrop:         config.capDrop,
+			NoNewPrivileges: config.noNewPrivileges,
 		},
 		SELinux: config.seLinux,
 	}
 
+	if config.outputBufferSize > 0 {
+		cfg.Process.OutputBuffering = &box.OutputBufferConfig{MaxBytes: config.outputBufferSize}
+	}
+
 	// tty allocation implies stdin
 	if config.stdin || config.tty {
 		cfg.Process.In = os.Stdin
@@ -63,6 +114,12 @@ func enterConsole(config enterConfig) error {
 		cfg.Process.TTY = &box.TTY{H: int(s.Height), W: int(s.Width)}
 	}
 
+	if config.unit != "" {
+		if err := unitEnterConfig(config.unit, config.seLinux, &cfg); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
 	return trace.Wrap(enter(cfg))
 }
 
@@ -78,9 +135,56 @@ func enter(cfg box.EnterConfig) error {
 	return trace.Wrap(box.Enter(cfg))
 }
 
-// stop interacts with systemctl's halt feature
-func stop(seLinux bool) error {
+// validateUserSpec checks the numeric parts of a "user[:group]" --user
+// specification (e.g. "1000:1000") are within the valid uid/gid range.
+// Non-numeric parts are left for libcontainer to resolve against the
+// container's passwd/group databases.
+func validateUserSpec(spec string) error {
+	if spec == "" {
+		return nil
+	}
+	user, group := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		user, group = spec[:i], spec[i+1:]
+	}
+	if err := validateNumericID("uid", user); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := validateNumericID("gid", group); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// validateNumericID verifies that value, if purely numeric, falls within
+// the uid/gid range accepted by libcontainer. Non-numeric values (resolved
+// by name later) and empty values are ignored.
+func validateNumericID(kind, value string) error {
+	if value == "" {
+		return nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil {
+		// Not numeric - resolved by name against the container's
+		// passwd/group databases.
+		return nil
+	}
+	if id < minUserID || id > maxUserID {
+		return trace.BadParameter("%v %v is out of range %v-%v", kind, id, minUserID, maxUserID)
+	}
+	return nil
+}
+
+// stop interacts with systemctl's halt feature. If shutdownOrder is
+// non-empty, its units are stopped in sequence - each bounded by its own
+// timeout - before etcd leadership is transferred and the container halted,
+// so that (for example) kubelet can finish draining before etcd goes down.
+func stop(seLinux bool, shutdownOrder []ShutdownUnit) error {
 	log.Info("Stop container.")
+	if len(shutdownOrder) > 0 {
+		stopUnitsInOrder(context.Background(), shutdownOrder, stopSystemdUnit)
+	}
+	transferEtcdLeadershipBeforeStop()
 	cfg := box.EnterConfig{
 		Process: box.ProcessConfig{
 			User:         "root",
@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestValidateUserSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{spec: ""},
+		{spec: "root"},
+		{spec: "planet-agent:planet-agent"},
+		{spec: "1000"},
+		{spec: "1000:1000"},
+		{spec: "0:0"},
+		{spec: "-1", wantErr: true},
+		{spec: "1000:-1", wantErr: true},
+		{spec: "4294967296", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := validateUserSpec(tt.spec)
+		if tt.wantErr && err == nil {
+			t.Errorf("validateUserSpec(%q): expected an error, got nil", tt.spec)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("validateUserSpec(%q): expected no error, got %v", tt.spec, err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gravitational/planet/lib/box"
+
+	"github.com/gravitational/trace"
+)
+
+// listSystemdUnits prints the name and current state of every unit loaded
+// by the container's systemd, as valid --unit targets for "planet enter".
+func listSystemdUnits(seLinux bool) error {
+	out, err := runInContainer(seLinux, "/bin/systemctl", "list-units", "--all", "--type=service", "--no-legend", "--plain")
+	if err != nil {
+		return trace.Wrap(err, "failed to list units")
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// unitEnterConfig resolves the main process of the named systemd unit
+// inside the container and augments cfg so the entered process joins that
+// process' cgroup and inherits its environment (read from
+// /proc/<pid>/environ), while still using the container's namespaces.
+func unitEnterConfig(unit string, seLinux bool, cfg *box.EnterConfig) error {
+	pid, err := unitMainPID(unit, seLinux)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	cgroupOut, err := runInContainer(seLinux, "/bin/cat", fmt.Sprintf("/proc/%v/cgroup", pid))
+	if err != nil {
+		return trace.Wrap(err, "failed to read cgroup of unit %v (pid %v)", unit, pid)
+	}
+	unitCgroupPath, err := parseCPUCgroupPath(cgroupOut)
+	if err != nil {
+		return trace.Wrap(err, "failed to determine cgroup of unit %v (pid %v)", unit, pid)
+	}
+	cfg.Process.UnitCgroupPath = unitCgroupPath
+
+	environOut, err := runInContainer(seLinux, "/bin/cat", fmt.Sprintf("/proc/%v/environ", pid))
+	if err != nil {
+		return trace.Wrap(err, "failed to read environment of unit %v (pid %v)", unit, pid)
+	}
+	for _, pair := range parseNullSeparatedEnviron(environOut) {
+		if cfg.Process.Env.Get(pair.Name) == "" {
+			cfg.Process.Env.Upsert(pair.Name, pair.Val)
+		}
+	}
+	return nil
+}
+
+// unitMainPID resolves the main PID of the named unit, as seen inside the
+// container's pid namespace. It returns an error naming the unit's current
+// state if the unit isn't active.
+func unitMainPID(unit string, seLinux bool) (string, error) {
+	out, err := runInContainer(seLinux, "/bin/systemctl", "show", unit, "--property=MainPID,ActiveState")
+	if err != nil {
+		return "", trace.Wrap(err, "failed to look up unit %v", unit)
+	}
+	props := parseSystemctlProperties(out)
+	if state := props["ActiveState"]; state != "active" {
+		return "", trace.BadParameter("unit %v is not active (state: %v)", unit, state)
+	}
+	pid := props["MainPID"]
+	if pid == "" || pid == "0" {
+		return "", trace.BadParameter("unit %v has no main process", unit)
+	}
+	return pid, nil
+}
+
+// runInContainer executes cmd/args as root inside the running container's
+// namespaces and returns its combined output.
+func runInContainer(seLinux bool, cmd string, args ...string) (string, error) {
+	out, err := box.CombinedOutput(box.EnterConfig{
+		Process: box.ProcessConfig{
+			Args: append([]string{cmd}, args...),
+			User: "root",
+		},
+		SELinux: seLinux,
+	})
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	return string(out), nil
+}
+
+// parseSystemctlProperties parses the "Key=Value" lines emitted by
+// "systemctl show --property=...".
+func parseSystemctlProperties(out string) map[string]string {
+	props := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if i := strings.Index(line, "="); i >= 0 {
+			props[line[:i]] = line[i+1:]
+		}
+	}
+	return props
+}
+
+// parseCPUCgroupPath extracts the cpu controller's cgroup path from the
+// contents of a /proc/<pid>/cgroup file, relative to the reading process'
+// own cgroup namespace - the same frame of reference planet's own service
+// cgroups (e.g. "system.slice") are addressed in.
+func parseCPUCgroupPath(procCgroup string) (string, error) {
+	for _, line := range strings.Split(strings.TrimSpace(procCgroup), "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller == "cpu" {
+				return strings.TrimPrefix(fields[2], "/"), nil
+			}
+		}
+	}
+	return "", trace.NotFound("cpu cgroup controller not found in %q", procCgroup)
+}
+
+// parseNullSeparatedEnviron parses the contents of a /proc/<pid>/environ
+// file (NUL-separated "NAME=VALUE" entries) into EnvVars.
+func parseNullSeparatedEnviron(environ string) box.EnvVars {
+	var env box.EnvVars
+	for _, pair := range strings.Split(environ, "\x00") {
+		if pair == "" {
+			continue
+		}
+		if i := strings.Index(pair, "="); i >= 0 {
+			env = append(env, box.EnvPair{Name: pair[:i], Val: pair[i+1:]})
+		}
+	}
+	return env
+}
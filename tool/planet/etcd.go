@@ -39,6 +39,7 @@ import (
 
 	"github.com/coreos/go-systemd/v22/dbus"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/gravitational/configure/cstrings"
 	etcdconf "github.com/gravitational/coordinate/v4/config"
 	backup "github.com/gravitational/etcd-backup/lib/etcd"
 	"github.com/gravitational/trace"
@@ -278,19 +279,24 @@ func etcdInitJoinImpl(ctx context.Context, initMaster string, env box.EnvVars) e
 	}
 
 	advertisePeerURL := fmt.Sprintf("https://%v:2380", publicIP)
+	advertisePeerURLs := []string{advertisePeerURL}
+	for _, secondaryIP := range cstrings.SplitComma(env.Get(EnvPublicIPs)) {
+		advertisePeerURLs = append(advertisePeerURLs, fmt.Sprintf("https://%v:2380", secondaryIP))
+	}
+
 	isMember, peerURLs, err := etcdMemberPeerList(ctx, client, advertisePeerURL)
 	if err != nil {
 		return trace.Wrap(err)
 	}
 
 	if !isMember {
-		_, err = client.MemberAdd(ctx, []string{advertisePeerURL})
+		_, err = client.MemberAdd(ctx, advertisePeerURLs)
 		if err != nil {
 			return trace.Wrap(err)
 		}
 
 		// Add ourselves to the peer list <name>=https://<addr>:<port>
-		peerURLs = append(peerURLs, fmt.Sprintf("%v=%v", env.Get(EnvEtcdMemberName), advertisePeerURL))
+		peerURLs = append(peerURLs, fmt.Sprintf("%v=%v", env.Get(EnvEtcdMemberName), strings.Join(advertisePeerURLs, ",")))
 	}
 
 	// etcd is fairly strict about the initial cluster state matching the state of the cluster the node is joining
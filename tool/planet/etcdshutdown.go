@@ -0,0 +1,116 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"time"
+
+	etcdconf "github.com/gravitational/coordinate/v4/config"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	etcdv3 "go.etcd.io/etcd/clientv3"
+	pb "go.etcd.io/etcd/etcdserver/etcdserverpb"
+)
+
+// etcdLeadershipTransferTimeout bounds how long transferEtcdLeadership waits
+// for etcd to respond.
+const etcdLeadershipTransferTimeout = 10 * time.Second
+
+// etcdMaintenanceClient is the subset of the etcd client used to move
+// leadership away from this member before it halts. Satisfied by
+// *etcdv3.Client; overridable in tests with a mock.
+type etcdMaintenanceClient interface {
+	Status(ctx context.Context, endpoint string) (*etcdv3.StatusResponse, error)
+	MemberList(ctx context.Context) (*etcdv3.MemberListResponse, error)
+	MoveLeader(ctx context.Context, transfereeID uint64) (*etcdv3.MoveLeaderResponse, error)
+}
+
+// transferEtcdLeadershipBeforeStop moves etcd leadership away from this
+// member if it is the current leader, so that "planet stop" halting etcd
+// abruptly does not itself trigger a leader election. It is a best-effort
+// step: failures are logged but do not prevent the container from stopping.
+func transferEtcdLeadershipBeforeStop() {
+	conf := etcdconf.Config{
+		Endpoints: []string{DefaultEtcdEndpoints},
+		KeyFile:   DefaultEtcdctlKeyFile,
+		CertFile:  DefaultEtcdctlCertFile,
+		CAFile:    DefaultEtcdctlCAFile,
+	}
+	client, err := conf.NewClientV3()
+	if err != nil {
+		log.WithError(err).Warn("Failed to create etcd client, skipping leadership transfer.")
+		return
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdLeadershipTransferTimeout)
+	defer cancel()
+
+	if err := transferEtcdLeadership(ctx, client, conf.Endpoints[0]); err != nil {
+		log.WithError(err).Warn("Failed to transfer etcd leadership.")
+	}
+}
+
+// transferEtcdLeadership moves etcd leadership to another cluster member,
+// if and only if the member reachable at endpoint is the current leader and
+// at least one other member is available to take over. It is a no-op
+// (returning nil) when this member isn't the leader or has no peers to
+// transfer to.
+func transferEtcdLeadership(ctx context.Context, client etcdMaintenanceClient, endpoint string) error {
+	status, err := client.Status(ctx, endpoint)
+	if err != nil {
+		return trace.Wrap(err, "failed to query etcd status")
+	}
+
+	members, err := client.MemberList(ctx)
+	if err != nil {
+		return trace.Wrap(err, "failed to list etcd members")
+	}
+
+	if status.Leader != status.Header.MemberId {
+		log.Debug("Not the etcd leader, nothing to transfer.")
+		return nil
+	}
+
+	transferee, ok := chooseTransferee(members.Members, status.Header.MemberId)
+	if !ok {
+		log.Warn("No other etcd member available to transfer leadership to, quorum would be lost.")
+		return nil
+	}
+
+	log.WithField("transferee", transferee).Info("Transferring etcd leadership before stopping.")
+	_, err = client.MoveLeader(ctx, transferee)
+	return trace.Wrap(err)
+}
+
+// chooseTransferee returns the ID of an etcd member other than selfID to
+// transfer leadership to, and whether one was found.
+//
+// members.Members is typed []*pb.Member rather than []*etcdv3.Member:
+// etcdv3.MemberListResponse is a defined type over
+// etcdserverpb.MemberListResponse, and a defined type does not change the
+// types of the fields it inherits, so the Members slice keeps its original
+// etcdserverpb element type.
+func chooseTransferee(members []*pb.Member, selfID uint64) (uint64, bool) {
+	for _, member := range members {
+		if member.ID != selfID {
+			return member.ID, true
+		}
+	}
+	return 0, false
+}
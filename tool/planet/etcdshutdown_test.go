@@ -0,0 +1,92 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	etcdv3 "go.etcd.io/etcd/clientv3"
+	pb "go.etcd.io/etcd/etcdserver/etcdserverpb"
+)
+
+// mockEtcdMaintenanceClient is a mock etcdMaintenanceClient for testing
+// leadership transfer without a real etcd cluster.
+type mockEtcdMaintenanceClient struct {
+	leaderID       uint64
+	members        []*pb.Member
+	moveLeaderErr  error
+	moveLeaderCall *uint64
+}
+
+func (m *mockEtcdMaintenanceClient) Status(ctx context.Context, endpoint string) (*etcdv3.StatusResponse, error) {
+	return &etcdv3.StatusResponse{
+		Header: &pb.ResponseHeader{MemberId: 1},
+		Leader: m.leaderID,
+	}, nil
+}
+
+func (m *mockEtcdMaintenanceClient) MemberList(ctx context.Context) (*etcdv3.MemberListResponse, error) {
+	return &etcdv3.MemberListResponse{Members: m.members}, nil
+}
+
+func (m *mockEtcdMaintenanceClient) MoveLeader(ctx context.Context, transfereeID uint64) (*etcdv3.MoveLeaderResponse, error) {
+	m.moveLeaderCall = &transfereeID
+	return &etcdv3.MoveLeaderResponse{}, m.moveLeaderErr
+}
+
+func TestTransferEtcdLeadershipWhenLeader(t *testing.T) {
+	client := &mockEtcdMaintenanceClient{
+		leaderID: 1,
+		members: []*pb.Member{
+			{ID: 1, Name: "node-1"},
+			{ID: 2, Name: "node-2"},
+		},
+	}
+
+	require.NoError(t, transferEtcdLeadership(context.Background(), client, "127.0.0.1:2379"))
+
+	require.NotNil(t, client.moveLeaderCall, "expected leadership transfer to be attempted")
+	assert.Equal(t, uint64(2), *client.moveLeaderCall)
+}
+
+func TestTransferEtcdLeadershipWhenNotLeader(t *testing.T) {
+	client := &mockEtcdMaintenanceClient{
+		leaderID: 2,
+		members: []*pb.Member{
+			{ID: 1, Name: "node-1"},
+			{ID: 2, Name: "node-2"},
+		},
+	}
+
+	require.NoError(t, transferEtcdLeadership(context.Background(), client, "127.0.0.1:2379"))
+	assert.Nil(t, client.moveLeaderCall, "expected no leadership transfer when not the leader")
+}
+
+func TestTransferEtcdLeadershipNoOtherMembers(t *testing.T) {
+	client := &mockEtcdMaintenanceClient{
+		leaderID: 1,
+		members: []*pb.Member{
+			{ID: 1, Name: "node-1"},
+		},
+	}
+
+	require.NoError(t, transferEtcdLeadership(context.Background(), client, "127.0.0.1:2379"))
+	assert.Nil(t, client.moveLeaderCall, "expected no leadership transfer with no other members")
+}
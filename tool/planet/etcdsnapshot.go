@@ -0,0 +1,109 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	etcdconf "github.com/gravitational/coordinate/v4/config"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+	etcdv3 "go.etcd.io/etcd/clientv3"
+)
+
+// etcdSnapshotTimeout bounds how long etcdSnapshot waits for the snapshot
+// stream to complete. Snapshotting a large datastore can take a while, so
+// this is considerably more generous than etcdStatusTimeout.
+const etcdSnapshotTimeout = 5 * time.Minute
+
+// etcdSnapshot connects to etcd using the configured planet TLS settings
+// and writes a consistent point-in-time snapshot of the backend database
+// to output, the same file format etcdctl's "snapshot save" produces and
+// test/etcd-upgrade's restore flow consumes.
+func etcdSnapshot(output string) error {
+	conf := etcdconf.Config{
+		Endpoints: []string{DefaultEtcdEndpoints},
+		KeyFile:   DefaultEtcdctlKeyFile,
+		CertFile:  DefaultEtcdctlCertFile,
+		CAFile:    DefaultEtcdctlCAFile,
+	}
+	client, err := conf.NewClientV3()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdSnapshotTimeout)
+	defer cancel()
+
+	revision, err := currentRevision(ctx, client, conf.Endpoints)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	size, err := writeSnapshot(ctx, client, output)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.WithFields(log.Fields{
+		"file":     output,
+		"size":     size,
+		"revision": revision,
+	}).Info("Wrote etcd snapshot.")
+	return nil
+}
+
+// currentRevision returns the key-value store revision reported by the
+// first endpoint that answers a status query.
+func currentRevision(ctx context.Context, client *etcdv3.Client, endpoints []string) (int64, error) {
+	var lastErr error
+	for _, endpoint := range endpoints {
+		status, err := client.Status(ctx, endpoint)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return status.Header.Revision, nil
+	}
+	return 0, trace.Wrap(lastErr, "failed to query etcd status")
+}
+
+// writeSnapshot streams a consistent snapshot of the backend database to
+// a file at output, returning its size in bytes.
+func writeSnapshot(ctx context.Context, client *etcdv3.Client, output string) (int64, error) {
+	stream, err := client.Snapshot(ctx)
+	if err != nil {
+		return 0, trace.Wrap(err, "failed to open etcd snapshot stream")
+	}
+	defer stream.Close()
+
+	f, err := os.Create(output)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	defer f.Close()
+
+	size, err := io.Copy(f, stream)
+	if err != nil {
+		return 0, trace.Wrap(err, "failed to write etcd snapshot to %v", output)
+	}
+	return size, trace.Wrap(f.Sync())
+}
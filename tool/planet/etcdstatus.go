@@ -0,0 +1,163 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	etcdconf "github.com/gravitational/coordinate/v4/config"
+	"github.com/gravitational/trace"
+	etcdv3 "go.etcd.io/etcd/clientv3"
+)
+
+// etcdStatusTimeout bounds how long etcdStatus waits for etcd to respond.
+const etcdStatusTimeout = 10 * time.Second
+
+// EtcdStatus describes the state of the etcd cluster, as reported by
+// "planet etcd status".
+type EtcdStatus struct {
+	// Members lists the cluster's etcd members.
+	Members []EtcdMember `json:"members"`
+	// Leader is the name of the current leader, if known.
+	Leader string `json:"leader"`
+	// DBSize is the size in bytes of the backend database, as reported by
+	// the endpoint that answered the status query.
+	DBSize int64 `json:"db_size_bytes"`
+	// Alarms lists any active cluster alarms, e.g. "NOSPACE".
+	Alarms []string `json:"alarms,omitempty"`
+}
+
+// EtcdMember describes a single etcd cluster member.
+type EtcdMember struct {
+	// ID is the member's hex-encoded cluster ID.
+	ID string `json:"id"`
+	// Name is the member's configured name.
+	Name string `json:"name"`
+	// PeerURLs lists the URLs other members use to reach this one.
+	PeerURLs []string `json:"peer_urls"`
+	// ClientURLs lists the URLs clients use to reach this member.
+	ClientURLs []string `json:"client_urls"`
+	// IsLeader is true if this member is the current cluster leader.
+	IsLeader bool `json:"is_leader"`
+}
+
+// etcdStatus connects to etcd using the configured planet TLS settings,
+// queries cluster status and prints it in the requested output format.
+func etcdStatus(output string) error {
+	conf := etcdconf.Config{
+		Endpoints: []string{DefaultEtcdEndpoints},
+		KeyFile:   DefaultEtcdctlKeyFile,
+		CertFile:  DefaultEtcdctlCertFile,
+		CAFile:    DefaultEtcdctlCAFile,
+	}
+	client, err := conf.NewClientV3()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), etcdStatusTimeout)
+	defer cancel()
+
+	status, err := collectEtcdStatus(ctx, client, conf.Endpoints)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(printEtcdStatus(status, output))
+}
+
+// collectEtcdStatus gathers member list, leader, DB size and alarms from
+// the etcd cluster reachable through client.
+func collectEtcdStatus(ctx context.Context, client *etcdv3.Client, endpoints []string) (*EtcdStatus, error) {
+	memberResp, err := client.MemberList(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to list etcd members")
+	}
+
+	var leaderID uint64
+	var dbSize int64
+	var statusErr error
+	for _, endpoint := range endpoints {
+		statusResp, err := client.Status(ctx, endpoint)
+		if err != nil {
+			statusErr = err
+			continue
+		}
+		leaderID, dbSize, statusErr = statusResp.Leader, statusResp.DbSize, nil
+		break
+	}
+	if statusErr != nil {
+		return nil, trace.Wrap(statusErr, "failed to query etcd status")
+	}
+
+	alarmResp, err := client.AlarmList(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to list etcd alarms")
+	}
+	alarms := make([]string, 0, len(alarmResp.Alarms))
+	for _, alarm := range alarmResp.Alarms {
+		alarms = append(alarms, fmt.Sprintf("member %x: %v", alarm.MemberID, alarm.Alarm))
+	}
+
+	status := &EtcdStatus{DBSize: dbSize, Alarms: alarms}
+	for _, member := range memberResp.Members {
+		isLeader := member.ID == leaderID
+		if isLeader {
+			status.Leader = member.Name
+		}
+		status.Members = append(status.Members, EtcdMember{
+			ID:         fmt.Sprintf("%x", member.ID),
+			Name:       member.Name,
+			PeerURLs:   member.PeerURLs,
+			ClientURLs: member.ClientURLs,
+			IsLeader:   isLeader,
+		})
+	}
+	return status, nil
+}
+
+// printEtcdStatus prints status in the requested output format, either
+// "text" (a human-readable summary) or "json" (the full EtcdStatus payload).
+func printEtcdStatus(status *EtcdStatus, output string) error {
+	if output == "json" {
+		payload, err := json.Marshal(status)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Fprintln(os.Stdout, string(payload))
+		return nil
+	}
+	for _, member := range status.Members {
+		marker := ""
+		if member.IsLeader {
+			marker = " (leader)"
+		}
+		fmt.Fprintf(os.Stdout, "%v %v%v: peers=%v clients=%v\n",
+			member.ID, member.Name, marker, member.PeerURLs, member.ClientURLs)
+	}
+	fmt.Fprintf(os.Stdout, "db size: %v bytes\n", status.DBSize)
+	if len(status.Alarms) == 0 {
+		fmt.Fprintln(os.Stdout, "alarms: none")
+	} else {
+		fmt.Fprintf(os.Stdout, "alarms: %v\n", status.Alarms)
+	}
+	return nil
+}
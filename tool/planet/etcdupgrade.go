@@ -0,0 +1,299 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	etcdconf "github.com/gravitational/coordinate/v4/config"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// etcdUpgradePhase names one step of the orchestrated sequence in
+// etcdUpgradeTo. Phases run in the order they're declared below;
+// etcdUpgradeProgress records the last one to complete so a retry after a
+// crash resumes after it instead of repeating already-applied work.
+type etcdUpgradePhase string
+
+const (
+	etcdUpgradePhaseBackup  etcdUpgradePhase = "backup"
+	etcdUpgradePhaseStop    etcdUpgradePhase = "stop"
+	etcdUpgradePhaseRestore etcdUpgradePhase = "restore"
+	etcdUpgradePhaseStart   etcdUpgradePhase = "start"
+	etcdUpgradePhaseVerify  etcdUpgradePhase = "verify"
+)
+
+// etcdUpgradePhaseOrder is the sequence etcdUpgradeTo runs phases in.
+var etcdUpgradePhaseOrder = []etcdUpgradePhase{
+	etcdUpgradePhaseBackup,
+	etcdUpgradePhaseStop,
+	etcdUpgradePhaseRestore,
+	etcdUpgradePhaseStart,
+	etcdUpgradePhaseVerify,
+}
+
+// etcdUpgradeStateFile is the name of the file under the state directory
+// that records etcdUpgradeTo's progress.
+const etcdUpgradeStateFile = "etcd-upgrade-progress.json"
+
+// etcdUpgradeProgress is the on-disk record of an in-progress or
+// interrupted "planet etcd upgrade", read back on every invocation so a
+// retry can skip whatever already completed rather than repeat it - in
+// particular, the restore phase isn't safe to re-run against a partially
+// restored data directory.
+type etcdUpgradeProgress struct {
+	ToVersion   string           `json:"to_version"`
+	BackupFile  string           `json:"backup_file"`
+	PreRevision int64            `json:"pre_revision"`
+	Phase       etcdUpgradePhase `json:"phase"`
+}
+
+func etcdUpgradeProgressPath() string {
+	return filepath.Join(StateDir, etcdUpgradeStateFile)
+}
+
+// loadEtcdUpgradeProgress returns nil, nil if no upgrade is in progress.
+func loadEtcdUpgradeProgress() (*etcdUpgradeProgress, error) {
+	data, err := ioutil.ReadFile(etcdUpgradeProgressPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	var progress etcdUpgradeProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, trace.Wrap(err, "failed to parse %v", etcdUpgradeProgressPath())
+	}
+	return &progress, nil
+}
+
+func saveEtcdUpgradeProgress(progress etcdUpgradeProgress) error {
+	data, err := json.MarshalIndent(progress, "", "  ")
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(etcdUpgradeProgressPath()), 0755); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	return trace.ConvertSystemError(ioutil.WriteFile(etcdUpgradeProgressPath(), data, 0600))
+}
+
+func clearEtcdUpgradeProgress() error {
+	err := os.Remove(etcdUpgradeProgressPath())
+	if err != nil && !os.IsNotExist(err) {
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
+
+// completed reports whether progress has already finished phase, so
+// etcdUpgradeTo can skip a phase it applied on an earlier, interrupted run.
+func (p *etcdUpgradeProgress) completed(phase etcdUpgradePhase) bool {
+	if p == nil {
+		return false
+	}
+	seen := false
+	for _, ph := range etcdUpgradePhaseOrder {
+		if ph == phase {
+			seen = true
+		}
+		if ph == p.Phase {
+			return seen
+		}
+	}
+	return false
+}
+
+// etcdUpgradeTo orchestrates the sequence test/etcd-upgrade exercises
+// against the real in-container etcd, the way production upgrades have so
+// far had to script externally: back up the running cluster, stop it,
+// initialize toVersion's data directory, run the offline+online restore
+// (which migrates the kubernetes registry prefix to the v3 backend as part
+// of that flow, see etcdRestore), rewrite the etcd environment to
+// toVersion, start it back up and verify it's healthy and reports the
+// revision the backup was taken at.
+//
+// Progress is recorded to the state directory after every phase, so a crash
+// partway through resumes after whatever already succeeded on retry. Pass
+// the same toVersion again to resume; see etcdUpgradeRollback to undo an
+// upgrade instead.
+func etcdUpgradeTo(toVersion string) error {
+	progress, err := loadEtcdUpgradeProgress()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if progress != nil && progress.ToVersion != toVersion {
+		return trace.BadParameter(
+			"an upgrade to %v is already in progress (last completed phase: %v) - finish it, or run --rollback, before upgrading to %v",
+			progress.ToVersion, progress.Phase, toVersion)
+	}
+	if progress == nil {
+		progress = &etcdUpgradeProgress{ToVersion: toVersion}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), EtcdUpgradeTimeout)
+	defer cancel()
+
+	if !progress.completed(etcdUpgradePhaseBackup) {
+		log.Info("Backing up etcd before upgrading to ", toVersion)
+		revision, err := etcdCurrentRevision(ctx)
+		if err != nil {
+			return trace.Wrap(err, "failed to read pre-upgrade revision")
+		}
+
+		progress.BackupFile = filepath.Join(StateDir, fmt.Sprintf("etcd-upgrade-%v.backup", toVersion))
+		if err := etcdBackup(progress.BackupFile, []string{ETCDBackupPrefix}); err != nil {
+			return trace.Wrap(err, "failed to back up etcd before upgrading")
+		}
+		progress.PreRevision = revision
+		progress.Phase = etcdUpgradePhaseBackup
+		if err := saveEtcdUpgradeProgress(*progress); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	if !progress.completed(etcdUpgradePhaseStop) {
+		log.Info("Stopping etcd and switching to the new data directory")
+		if err := etcdDisable(etcdService, stopApiserverTrue); err != nil {
+			return trace.Wrap(err, "failed to stop etcd")
+		}
+		if err := etcdUpgrade(false); err != nil {
+			return trace.Wrap(err, "failed to switch the target etcd version")
+		}
+		progress.Phase = etcdUpgradePhaseStop
+		if err := saveEtcdUpgradeProgress(*progress); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	if !progress.completed(etcdUpgradePhaseRestore) {
+		log.Info("Restoring the backup into the new data directory")
+		if err := etcdRestore(progress.BackupFile); err != nil {
+			return trace.Wrap(err, "failed to restore etcd")
+		}
+		progress.Phase = etcdUpgradePhaseRestore
+		if err := saveEtcdUpgradeProgress(*progress); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	if !progress.completed(etcdUpgradePhaseStart) {
+		log.Info("Starting etcd on the new version")
+		if err := etcdEnable(etcdService, ""); err != nil {
+			return trace.Wrap(err, "failed to start etcd")
+		}
+		progress.Phase = etcdUpgradePhaseStart
+		if err := saveEtcdUpgradeProgress(*progress); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+
+	log.Info("Verifying upgraded etcd is healthy")
+	if err := verifyEtcdUpgradeRevision(ctx, progress.PreRevision); err != nil {
+		return trace.Wrap(err, "post-upgrade verification failed")
+	}
+	progress.Phase = etcdUpgradePhaseVerify
+	if err := saveEtcdUpgradeProgress(*progress); err != nil {
+		return trace.Wrap(err)
+	}
+
+	log.Info("etcd upgrade to ", toVersion, " complete")
+	return trace.Wrap(clearEtcdUpgradeProgress())
+}
+
+// etcdUpgradeRollback undoes an etcd upgrade by pointing the etcd unit back
+// at the previous version's data directory, which etcdUpgradeTo leaves in
+// place untouched throughout the upgrade. It does not restore from a
+// backup - the previous data directory is the rollback target, not the
+// backup file recorded in progress.
+func etcdUpgradeRollback() error {
+	log.Info("Rolling back etcd to the previous version")
+	if err := etcdDisable(etcdService, stopApiserverTrue); err != nil {
+		return trace.Wrap(err, "failed to stop etcd")
+	}
+	if err := etcdUpgrade(true); err != nil {
+		return trace.Wrap(err, "failed to switch back to the previous etcd version")
+	}
+	if err := etcdEnable(etcdService, ""); err != nil {
+		return trace.Wrap(err, "failed to start etcd")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), EtcdUpgradeTimeout)
+	defer cancel()
+	if err := verifyEtcdUpgradeRevision(ctx, 0); err != nil {
+		return trace.Wrap(err, "post-rollback verification failed")
+	}
+
+	log.Info("etcd rollback complete")
+	return trace.Wrap(clearEtcdUpgradeProgress())
+}
+
+// etcdCurrentRevision returns the key-value store revision of the
+// currently running etcd cluster.
+func etcdCurrentRevision(ctx context.Context) (int64, error) {
+	conf := etcdconf.Config{
+		Endpoints: []string{DefaultEtcdEndpoints},
+		KeyFile:   DefaultEtcdctlKeyFile,
+		CertFile:  DefaultEtcdctlCertFile,
+		CAFile:    DefaultEtcdctlCAFile,
+	}
+	client, err := conf.NewClientV3()
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	defer client.Close()
+	return currentRevision(ctx, client, conf.Endpoints)
+}
+
+// verifyEtcdUpgradeRevision waits for the upgraded/rolled-back etcd to
+// become healthy and, if minRevision is positive, confirms it reports a
+// revision at least as recent as the pre-upgrade one recorded in progress -
+// guarding against a restore that silently lost writes.
+func verifyEtcdUpgradeRevision(ctx context.Context, minRevision int64) error {
+	conf := etcdconf.Config{
+		Endpoints: []string{DefaultEtcdEndpoints},
+		KeyFile:   DefaultEtcdctlKeyFile,
+		CertFile:  DefaultEtcdctlCertFile,
+		CAFile:    DefaultEtcdctlCAFile,
+	}
+	client, err := conf.NewClient()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := waitEtcdHealthyTimeout(ctx, EtcdUpgradeTimeout, client); err != nil {
+		return trace.Wrap(err)
+	}
+
+	if minRevision <= 0 {
+		return nil
+	}
+	revision, err := etcdCurrentRevision(ctx)
+	if err != nil {
+		return trace.Wrap(err, "failed to read post-upgrade revision")
+	}
+	if revision < minRevision {
+		return trace.BadParameter("post-upgrade revision %v is older than the pre-upgrade revision %v", revision, minRevision)
+	}
+	return nil
+}
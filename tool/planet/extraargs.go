@@ -0,0 +1,130 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"github.com/gravitational/trace"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// managedComponentFlags lists, for each unit that accepts extra arguments
+// via --kubelet-arg/--apiserver-arg, the long flag names planet itself
+// already sets on the unit's command line, either directly in the systemd
+// unit file or dynamically in addComponentOptions. It's used to warn an
+// operator that an extra argument may conflict with one planet manages,
+// rather than silently letting whichever value the component picks win.
+var managedComponentFlags = map[string]map[string]bool{
+	KubeletServiceName: {
+		"--root-dir":                     true,
+		"--hostname-override":            true,
+		"--logtostderr":                  true,
+		"--pod-infra-container-image":    true,
+		"--kubeconfig":                   true,
+		"--register-with-taints":         true,
+		"--node-labels":                  true,
+		"--network-plugin":               true,
+		"--kube-reserved":                true,
+		"--system-reserved":              true,
+		"--cgroup-root":                  true,
+		"--config":                       true,
+		"--image-pull-progress-deadline": true,
+		"--tls-min-version":              true,
+	},
+	APIServerServiceName: {
+		"--service-account-key-file":           true,
+		"--service-account-lookup":             true,
+		"--service-account-signing-key-file":   true,
+		"--service-account-issuer":             true,
+		"--enable-admission-plugins":           true,
+		"--admission-control-config-file":      true,
+		"--authorization-mode":                 true,
+		"--runtime-config":                     true,
+		"--allow-privileged":                   true,
+		"--tls-cert-file":                      true,
+		"--tls-private-key-file":               true,
+		"--tls-cipher-suites":                  true,
+		"--tls-min-version":                    true,
+		"--kubelet-certificate-authority":      true,
+		"--kubelet-client-certificate":         true,
+		"--kubelet-client-key":                 true,
+		"--proxy-client-cert-file":             true,
+		"--proxy-client-key-file":              true,
+		"--requestheader-allowed-names":        true,
+		"--requestheader-client-ca-file":       true,
+		"--requestheader-extra-headers-prefix": true,
+		"--requestheader-group-headers":        true,
+		"--requestheader-username-headers":     true,
+		"--secure-port":                        true,
+		"--service-cluster-ip-range":           true,
+		"--etcd-servers":                       true,
+		"--etcd-cafile":                        true,
+		"--etcd-certfile":                      true,
+		"--etcd-keyfile":                       true,
+		"--storage-backend":                    true,
+		"--event-ttl":                          true,
+		"--bind-address":                       true,
+		"--advertise-address":                  true,
+		"--logtostderr":                        true,
+		"--client-ca-file":                     true,
+		"--anonymous-auth":                     true,
+		"--profiling":                          true,
+		"--audit-policy-file":                  true,
+		"--audit-log-path":                     true,
+		"--audit-log-maxage":                   true,
+		"--audit-log-maxbackup":                true,
+		"--audit-log-maxsize":                  true,
+		"--max-requests-inflight":              true,
+		"--max-mutating-requests-inflight":     true,
+		"--service-node-port-range":            true,
+		"--endpoint-reconciler-type":           true,
+		"--apiserver-count":                    true,
+		"--encryption-provider-config":         true,
+	},
+}
+
+// validateExtraArg checks that arg is of the form "--key=value" and, if it
+// collides with a flag planet already manages for unit, logs a warning
+// instead of failing, since --kubelet-arg/--apiserver-arg are meant as an
+// escape hatch that shouldn't require planet to enumerate every legitimate
+// use case up front.
+func validateExtraArg(unit, arg string) (string, error) {
+	key, _, ok := splitKeyValue(arg)
+	if !ok {
+		return "", trace.BadParameter(
+			"invalid extra argument %q for %v, expected key=value", arg, unit)
+	}
+	if !strings.HasPrefix(key, "--") {
+		return "", trace.BadParameter(
+			"invalid extra argument %q for %v, key must start with --", arg, unit)
+	}
+	if managedComponentFlags[unit][key] {
+		log.Warnf("Extra argument %v overrides a flag planet manages for %v.", key, unit)
+	}
+	return arg, nil
+}
+
+// splitKeyValue splits arg into a key and value around the first "=".
+func splitKeyValue(arg string) (key, value string, ok bool) {
+	parts := strings.SplitN(arg, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gravitational/planet/lib/utils"
+
+	"github.com/gravitational/satellite/agent"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultHostsSyncInterval is how often runHostsSync reconciles the hosts
+// file against cluster membership when no interval has been configured.
+const defaultHostsSyncInterval = 30 * time.Second
+
+// hostsSyncConfig configures runHostsSync.
+type hostsSyncConfig struct {
+	// Disabled skips the sync loop entirely, for deployments that manage
+	// the hosts file externally.
+	Disabled bool
+	// Interval is how often the hosts file is reconciled against cluster
+	// membership. Defaults to defaultHostsSyncInterval.
+	Interval time.Duration
+	// Path is the hosts file to reconcile. Defaults to HostsFile.
+	Path string
+	// ClusterID is appended to each member's name (as name.ClusterID) to
+	// form a second, cluster-qualified hostname.
+	ClusterID string
+}
+
+// CheckAndSetDefaults validates the configuration and applies defaults.
+func (r *hostsSyncConfig) CheckAndSetDefaults() error {
+	if r.Interval <= 0 {
+		r.Interval = defaultHostsSyncInterval
+	}
+	if r.Path == "" {
+		r.Path = HostsFile
+	}
+	return nil
+}
+
+// runHostsSync periodically reconciles the container's hosts file with the
+// agent's view of cluster membership, so pods and host processes can
+// resolve other cluster members by name even when upstream DNS is broken -
+// which tends to be exactly when an operator needs it. It blocks until ctx
+// is cancelled.
+func runHostsSync(ctx context.Context, monitoringAgent agent.Agent, config hostsSyncConfig) error {
+	if config.Disabled {
+		log.Info("Hosts file synchronization is disabled.")
+		return nil
+	}
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return trace.Wrap(err)
+	}
+
+	syncHosts(monitoringAgent, config)
+
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			syncHosts(monitoringAgent, config)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// syncHosts reconciles the hosts file at config.Path with the members
+// currently reported by monitoringAgent, logging and continuing on failure
+// since a stale hosts file shouldn't take down the agent.
+func syncHosts(monitoringAgent agent.Agent, config hostsSyncConfig) {
+	status, err := monitoringAgent.Status()
+	if err != nil {
+		log.WithError(err).Warn("Failed to query cluster status for hosts file synchronization.")
+		return
+	}
+
+	entries := hostEntries(status.Nodes, config.ClusterID)
+	if err := utils.UpsertHostsFile(config.Path, entries); err != nil {
+		log.WithError(err).Warn("Failed to update hosts file with cluster members.")
+	}
+}
+
+// hostEntries builds one hosts entry per alive cluster member, addressable
+// both by its bare name and - if clusterID is set - by name.clusterID.
+// Members that have left or failed are omitted, so their entries are
+// removed on the next sync.
+func hostEntries(nodes []*pb.NodeStatus, clusterID string) (entries []utils.HostEntry) {
+	for _, node := range nodes {
+		member := node.MemberStatus
+		if member == nil || member.Status != pb.MemberStatus_Alive {
+			continue
+		}
+		name := member.NodeName
+		if name == "" {
+			name = member.Name
+		}
+		if name == "" {
+			continue
+		}
+		ip, _, err := net.SplitHostPort(member.Addr)
+		if err != nil {
+			ip = member.Addr
+		}
+		if ip == "" {
+			continue
+		}
+		hostnames := name
+		if clusterID != "" {
+			hostnames = fmt.Sprintf("%v %v.%v", name, name, clusterID)
+		}
+		entries = append(entries, utils.HostEntry{IP: ip, Hostnames: hostnames})
+	}
+	return entries
+}
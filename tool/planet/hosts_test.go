@@ -0,0 +1,50 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gravitational/planet/lib/utils"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostEntriesSkipsDepartedMembers(t *testing.T) {
+	nodes := []*pb.NodeStatus{
+		{MemberStatus: &pb.MemberStatus{NodeName: "node-1", Addr: "10.0.0.1:7575", Status: pb.MemberStatus_Alive}},
+		{MemberStatus: &pb.MemberStatus{NodeName: "node-2", Addr: "10.0.0.2:7575", Status: pb.MemberStatus_Left}},
+		{MemberStatus: &pb.MemberStatus{Name: "node-3", Addr: "10.0.0.3:7575", Status: pb.MemberStatus_Alive}},
+	}
+
+	entries := hostEntries(nodes, "test-cluster")
+
+	assert.Equal(t, []utils.HostEntry{
+		{IP: "10.0.0.1", Hostnames: "node-1 node-1.test-cluster"},
+		{IP: "10.0.0.3", Hostnames: "node-3 node-3.test-cluster"},
+	}, entries)
+}
+
+func TestHostEntriesWithoutClusterID(t *testing.T) {
+	nodes := []*pb.NodeStatus{
+		{MemberStatus: &pb.MemberStatus{NodeName: "node-1", Addr: "10.0.0.1:7575", Status: pb.MemberStatus_Alive}},
+	}
+
+	entries := hostEntries(nodes, "")
+
+	assert.Equal(t, []utils.HostEntry{{IP: "10.0.0.1", Hostnames: "node-1"}}, entries)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"net"
+
+	"github.com/gravitational/trace"
+)
+
+// resolveInterfaceIP returns the primary address of the named network
+// interface, so --bind-interface can be given instead of a literal
+// --public-ip on hosts where interface names are stable but addresses are
+// not. It prefers the interface's first non-link-local IPv4 address,
+// falling back to the first non-link-local IPv6 address if it has none.
+func resolveInterfaceIP(name string) (net.IP, error) {
+	primary, _, err := resolveInterfaceIPs(name)
+	return primary, trace.Wrap(err)
+}
+
+// resolveInterfaceIPs returns the primary and, on a dual-stack interface,
+// secondary address of the named network interface. The primary address
+// is the interface's first non-link-local IPv4 address, or its first
+// non-link-local IPv6 address if it has no IPv4 address. secondary is the
+// interface's first non-link-local IPv6 address and is nil unless the
+// interface has both an IPv4 and an IPv6 address.
+func resolveInterfaceIPs(name string) (primary, secondary net.IP, err error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, nil, trace.NotFound("interface %q not found: %v", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, nil, trace.Wrap(err, "failed to query addresses of interface %q", name)
+	}
+
+	var ipv4, ipv6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ip := ipNet.IP.To4(); ip != nil {
+			if ipv4 == nil {
+				ipv4 = ip
+			}
+			continue
+		}
+		if ipv6 == nil {
+			ipv6 = ipNet.IP
+		}
+	}
+	switch {
+	case ipv4 != nil:
+		return ipv4, ipv6, nil
+	case ipv6 != nil:
+		return ipv6, nil, nil
+	default:
+		return nil, nil, trace.NotFound("interface %q has no suitable IPv4 or IPv6 address", name)
+	}
+}
+
+// sameIPFamily reports whether a and b are both IPv4 or both IPv6.
+func sameIPFamily(a, b net.IP) bool {
+	return (a.To4() != nil) == (b.To4() != nil)
+}
+
+// validateLocalAddr returns an error unless ip is assigned to one of this
+// host's network interfaces, so a mistyped or unreachable --public-ip
+// fails preflight instead of surfacing later as an inscrutable etcd or
+// kubelet dial failure.
+func validateLocalAddr(ip net.IP) error {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return trace.Wrap(err, "failed to query local network addresses")
+	}
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && ipNet.IP.Equal(ip) {
+			return nil
+		}
+	}
+	return trace.BadParameter("address %v is not assigned to any local network interface", ip)
+}
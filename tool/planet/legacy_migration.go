@@ -0,0 +1,105 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gravitational/planet/lib/box"
+	"github.com/gravitational/planet/lib/constants"
+	"github.com/gravitational/planet/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// legacyCubeStateDir is the libcontainer state directory the predecessor
+// cube binary used, analogous to defaults.RuncDataDir for planet. Its mere
+// presence on a host means planet is starting on a box that was previously
+// bootstrapped with cube and hasn't been migrated yet. It's a var (rather
+// than a const) so tests can point it at a temporary directory.
+var legacyCubeStateDir = "/var/run/cube"
+
+// legacyCubeEnvFile is the environment file cube wrote inside its state
+// directory, in the plain KEY=value format box.ReadEnvironment already
+// tolerates.
+var legacyCubeEnvFile = legacyCubeStateDir + "/container-environment"
+
+// setLegacyCubePaths overrides legacyCubeStateDir/legacyCubeEnvFile for
+// tests.
+func setLegacyCubePaths(stateDir, envFile string) {
+	legacyCubeStateDir = stateDir
+	legacyCubeEnvFile = envFile
+}
+
+// migrateLegacyCubeState detects container state left behind by the
+// predecessor cube binary and migrates it to the layout planet expects:
+// the old environment file is converted to planet's EnvFile format and the
+// stale libcontainer state directory is removed so cgroups can be mounted
+// cleanly by box.MountCgroups, the same helper planet always uses. It is a
+// no-op on hosts that were never bootstrapped with cube.
+func migrateLegacyCubeState(config *Config) error {
+	logger := log.WithField(trace.Component, "cube-migration")
+
+	info, err := os.Stat(legacyCubeStateDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return trace.ConvertSystemError(err)
+	}
+	if !info.IsDir() {
+		return nil
+	}
+
+	logger.WithField("dir", legacyCubeStateDir).Info("Detected leftover cube state, migrating to planet.")
+
+	if err := migrateLegacyCubeEnvFile(config, logger); err != nil {
+		return trace.Wrap(err)
+	}
+
+	logger.WithField("dir", legacyCubeStateDir).Info("Removing stale cube container record.")
+	if err := os.RemoveAll(legacyCubeStateDir); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+
+	logger.Info("Finished migrating cube state to planet.")
+	return nil
+}
+
+// migrateLegacyCubeEnvFile rewrites the cube environment file, if any, as
+// planet's own ContainerEnvironmentFile in EnvFileFormatExport. It is a
+// no-op if cube never wrote an environment file.
+func migrateLegacyCubeEnvFile(config *Config, logger log.FieldLogger) error {
+	env, err := box.ReadEnvironment(legacyCubeEnvFile)
+	if err != nil {
+		if os.IsNotExist(trace.Unwrap(err)) {
+			return nil
+		}
+		return trace.Wrap(err)
+	}
+
+	logger.WithField("vars", len(env)).Info("Converting cube environment file to the planet EnvFile format.")
+	converted := make(map[string]string, len(env))
+	for _, pair := range env {
+		converted[pair.Name] = pair.Val
+	}
+
+	path := filepath.Join(config.Rootfs, ContainerEnvironmentFile)
+	return trace.Wrap(utils.WriteEnvFile(path, converted, utils.EnvFileFormatExport, constants.SharedReadMask))
+}
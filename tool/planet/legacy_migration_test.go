@@ -0,0 +1,66 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrateLegacyCubeStateNoOpOnCleanHost(t *testing.T) {
+	withLegacyCubeStateDir(t, filepath.Join(t.TempDir(), "no-such-dir"), func() {
+		err := migrateLegacyCubeState(&Config{Rootfs: t.TempDir()})
+		require.NoError(t, err)
+	})
+}
+
+func TestMigrateLegacyCubeStateRemovesStaleRecordAndConvertsEnvFile(t *testing.T) {
+	legacyDir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(legacyDir, "container-environment"),
+		[]byte("KUBE_MASTER_IP=10.0.0.1\nKUBE_APISERVER_PORT=6443\n"), 0644)
+	require.NoError(t, err)
+
+	rootfs := t.TempDir()
+
+	withLegacyCubeStateDir(t, legacyDir, func() {
+		err := migrateLegacyCubeState(&Config{Rootfs: rootfs})
+		require.NoError(t, err)
+	})
+
+	_, err = os.Stat(legacyDir)
+	assert.True(t, os.IsNotExist(err), "expected the stale cube state directory to be removed")
+
+	converted, err := ioutil.ReadFile(filepath.Join(rootfs, ContainerEnvironmentFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(converted), `export KUBE_MASTER_IP="10.0.0.1"`)
+	assert.Contains(t, string(converted), `export KUBE_APISERVER_PORT="6443"`)
+}
+
+// withLegacyCubeStateDir points legacyCubeStateDir/legacyCubeEnvFile at dir
+// for the duration of fn, restoring the original values afterwards.
+func withLegacyCubeStateDir(t *testing.T, dir string, fn func()) {
+	t.Helper()
+	origDir, origEnvFile := legacyCubeStateDir, legacyCubeEnvFile
+	setLegacyCubePaths(dir, filepath.Join(dir, "container-environment"))
+	defer setLegacyCubePaths(origDir, origEnvFile)
+	fn()
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"github.com/gravitational/satellite/agent"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+)
+
+// localCluster is a membership.Cluster that reports only the local node as
+// a cluster member. It backs --no-cluster, which skips setting up
+// Kubernetes-informer-based cluster membership for single-node dev
+// clusters, while keeping the agent's cluster-wide status computation -
+// which looks for a master among the reported members - working correctly
+// for the single local node.
+type localCluster struct {
+	member *pb.MemberStatus
+}
+
+// newLocalCluster returns a localCluster whose only member is the local
+// node identified by name, advertiseIP and role.
+func newLocalCluster(name, advertiseIP string, role agent.Role) *localCluster {
+	return &localCluster{
+		member: pb.NewMemberStatus(name, advertiseIP, map[string]string{
+			"role":     string(role),
+			"publicip": advertiseIP,
+		}),
+	}
+}
+
+// Members returns the local node as the cluster's only member.
+// Implements membership.Cluster.
+func (r *localCluster) Members() ([]*pb.MemberStatus, error) {
+	return []*pb.MemberStatus{r.member}, nil
+}
+
+// Member returns the local member if name matches it, or NotFound otherwise.
+// Implements membership.Cluster.
+func (r *localCluster) Member(name string) (*pb.MemberStatus, error) {
+	if name == r.member.Name {
+		return r.member, nil
+	}
+	return nil, trace.NotFound("%v is not a member of the cluster", name)
+}
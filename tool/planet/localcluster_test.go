@@ -0,0 +1,54 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	"github.com/gravitational/satellite/agent"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalClusterMembers(t *testing.T) {
+	cluster := newLocalCluster("node-1", "10.0.0.1", agent.RoleMaster)
+
+	members, err := cluster.Members()
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+
+	member := members[0]
+	assert.Equal(t, "node-1", member.Name)
+	assert.Equal(t, pb.MemberStatus_Alive, member.Status)
+	// A single-node cluster running as master must report the "master" role
+	// tag, or the agent's system status computation would incorrectly
+	// report the cluster as having no master.
+	assert.Equal(t, "master", member.Tags["role"])
+}
+
+func TestLocalClusterMember(t *testing.T) {
+	cluster := newLocalCluster("node-1", "10.0.0.1", agent.RoleNode)
+
+	member, err := cluster.Member("node-1")
+	require.NoError(t, err)
+	assert.Equal(t, "node-1", member.Name)
+
+	_, err = cluster.Member("node-2")
+	assert.True(t, trace.IsNotFound(err))
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// logLevelPath is the path of the log level handler on the debug endpoint
+// (see --httpprofile).
+const logLevelPath = "/debug/loglevel"
+
+// registerLogLevelHandler wires the log level get/set handler into the
+// default HTTP mux, alongside the pprof handlers already registered there.
+func registerLogLevelHandler() {
+	http.HandleFunc(logLevelPath, logLevelHandler)
+}
+
+// logLevelHandler reports the current logrus level on GET and updates it on
+// POST, allowing the level of a running planet process to be changed
+// without a restart.
+func logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		fmt.Fprintln(w, log.GetLevel().String())
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		level, err := log.ParseLevel(strings.TrimSpace(string(body)))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.SetLevel(level)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// getLogLevel queries the log level of the running planet process behind
+// endpoint (see --httpprofile).
+func getLogLevel(endpoint string) (string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://%v%v", endpoint, logLevelPath))
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", trace.Wrap(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", trace.BadParameter("unexpected status %v: %v", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// setLogLevel updates the log level of the running planet process behind
+// endpoint (see --httpprofile) to level.
+func setLogLevel(endpoint, level string) error {
+	if _, err := log.ParseLevel(level); err != nil {
+		return trace.Wrap(err)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(fmt.Sprintf("http://%v%v", endpoint, logLevelPath), "text/plain", strings.NewReader(level))
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(resp.Body)
+		return trace.BadParameter("unexpected status %v: %v", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
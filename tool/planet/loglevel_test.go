@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSetLogLevel(t *testing.T) {
+	defer log.SetLevel(log.GetLevel())
+
+	log.SetLevel(log.WarnLevel)
+	server := httptest.NewServer(http.HandlerFunc(logLevelHandler))
+	defer server.Close()
+
+	endpoint := server.Listener.Addr().String()
+
+	level, err := getLogLevel(endpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "warning", level)
+
+	require.NoError(t, setLogLevel(endpoint, "debug"))
+	assert.Equal(t, log.DebugLevel, log.GetLevel())
+
+	level, err = getLogLevel(endpoint)
+	require.NoError(t, err)
+	assert.Equal(t, "debug", level)
+}
+
+func TestSetLogLevelInvalid(t *testing.T) {
+	defer log.SetLevel(log.GetLevel())
+
+	server := httptest.NewServer(http.HandlerFunc(logLevelHandler))
+	defer server.Close()
+
+	err := setLogLevel(server.Listener.Addr().String(), "not-a-level")
+	assert.Error(t, err)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gravitational/trace"
+)
+
+// rotatingFile is an io.WriteCloser that appends to a file on disk,
+// rotating it out to a numbered backup (path.1, path.2, ...) once it grows
+// past maxSize, and pruning backups beyond maxBackups. It exists so a
+// daemonized planet process' log output doesn't grow without bound on
+// small root volumes.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	file *os.File
+	size int64
+}
+
+// newRotatingFile opens path for appending (creating it if necessary) and
+// returns a rotatingFile that rotates it once it exceeds maxSize bytes,
+// retaining at most maxBackups rotated copies. A non-positive maxSize
+// disables rotation.
+func newRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, trace.ConvertSystemError(err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, trace.ConvertSystemError(err)
+	}
+	return &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		file:       file,
+		size:       info.Size(),
+	}, nil
+}
+
+// Write appends p to the file, rotating first if p wouldn't fit within
+// maxSize. Implements io.Writer.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	if r.maxSize > 0 && r.size+int64(len(p)) > r.maxSize && r.size > 0 {
+		if err := r.rotate(); err != nil {
+			return 0, trace.Wrap(err)
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, trace.ConvertSystemError(err)
+}
+
+// Close closes the underlying file. Implements io.Closer.
+func (r *rotatingFile) Close() error {
+	return trace.ConvertSystemError(r.file.Close())
+}
+
+// rotate closes the current file, shifts existing backups up by one
+// (dropping the oldest beyond maxBackups), moves the current file to
+// path.1, and reopens path as a fresh, empty file.
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+
+	for i := r.maxBackups; i >= 1; i-- {
+		src := r.backupPath(i)
+		if i == r.maxBackups {
+			if err := os.Remove(src); err != nil && !os.IsNotExist(err) {
+				return trace.ConvertSystemError(err)
+			}
+			continue
+		}
+		dst := r.backupPath(i + 1)
+		if err := os.Rename(src, dst); err != nil && !os.IsNotExist(err) {
+			return trace.ConvertSystemError(err)
+		}
+	}
+	if r.maxBackups > 0 {
+		if err := os.Rename(r.path, r.backupPath(1)); err != nil && !os.IsNotExist(err) {
+			return trace.ConvertSystemError(err)
+		}
+	}
+
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	r.file = file
+	r.size = 0
+	return nil
+}
+
+// backupPath returns the path of the n'th rotated backup, e.g. n=1 for
+// path.1.
+func (r *rotatingFile) backupPath(n int) string {
+	return fmt.Sprintf("%v.%v", r.path, n)
+}
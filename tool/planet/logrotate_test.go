@@ -0,0 +1,104 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingFileRotatesPastMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "planet.log")
+
+	f, err := newRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+	defer f.Close()
+
+	// Each write is 10 bytes, exactly maxSize - the second write should
+	// trigger a rotation since it wouldn't fit alongside the first.
+	_, err = f.Write([]byte("0123456789"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("abcdefghij"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("ABCDEFGHIJ"))
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "ABCDEFGHIJ", string(data))
+
+	backup1, err := ioutil.ReadFile(path + ".1")
+	require.NoError(t, err)
+	require.Equal(t, "abcdefghij", string(backup1))
+
+	backup2, err := ioutil.ReadFile(path + ".2")
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(backup2))
+}
+
+func TestRotatingFilePrunesBeyondMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "planet.log")
+
+	f, err := newRotatingFile(path, 5, 1)
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Write([]byte("aaaaa"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("bbbbb"))
+	require.NoError(t, err)
+	_, err = f.Write([]byte("ccccc"))
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "ccccc", string(data))
+
+	backup1, err := ioutil.ReadFile(path + ".1")
+	require.NoError(t, err)
+	require.Equal(t, "bbbbb", string(backup1))
+
+	_, err = os.Stat(path + ".2")
+	require.True(t, os.IsNotExist(err), "expected no second backup to be retained")
+}
+
+func TestRotatingFileDisabledWithNonPositiveMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "planet.log")
+
+	f, err := newRotatingFile(path, 0, 5)
+	require.NoError(t, err)
+	defer f.Close()
+
+	for i := 0; i < 10; i++ {
+		_, err = f.Write([]byte("0123456789"))
+		require.NoError(t, err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.Len(t, data, 100)
+
+	_, err = os.Stat(path + ".1")
+	require.True(t, os.IsNotExist(err), "expected no rotation when maxSize is disabled")
+}
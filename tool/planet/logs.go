@@ -0,0 +1,71 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strconv"
+
+	"github.com/gravitational/trace"
+)
+
+// journalctlPath is the path to journalctl inside the container.
+const journalctlPath = "/bin/journalctl"
+
+// logsConfig configures the "planet logs" command.
+type logsConfig struct {
+	// unit optionally restricts output to a single systemd unit, e.g. etcd
+	// or kubelet.
+	unit string
+	// since limits output to entries at or after the given time, in any
+	// format accepted by journalctl's --since flag.
+	since string
+	// lines is the number of most recent journal lines to show before
+	// following.
+	lines int
+	// seLinux enables SELinux support on the entered process.
+	seLinux bool
+}
+
+// journalctlArgs builds the journalctl argument list for streaming logs of
+// the specified unit (or all units, if empty) starting from since, showing
+// lines most recent entries before following.
+func journalctlArgs(config logsConfig) []string {
+	args := []string{"-f"}
+	if config.lines > 0 {
+		args = append(args, "-n", strconv.Itoa(config.lines))
+	}
+	if config.since != "" {
+		args = append(args, "--since", config.since)
+	}
+	if config.unit != "" {
+		args = append(args, "-u", config.unit)
+	}
+	return args
+}
+
+// logs streams container logs via journalctl over the existing enter
+// mechanism, optionally filtered to a single systemd unit.
+func logs(config logsConfig) error {
+	return trace.Wrap(enterConsole(enterConfig{
+		cmd:     journalctlPath,
+		user:    "root",
+		tty:     true,
+		stdin:   true,
+		args:    journalctlArgs(config),
+		seLinux: config.seLinux,
+	}))
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJournalctlArgs(t *testing.T) {
+	testCases := []struct {
+		comment string
+		config  logsConfig
+		want    []string
+	}{
+		{
+			comment: "no unit or since, default lines",
+			config:  logsConfig{lines: 100},
+			want:    []string{"-f", "-n", "100"},
+		},
+		{
+			comment: "unit and since specified",
+			config:  logsConfig{unit: "etcd", since: "1 hour ago", lines: 50},
+			want:    []string{"-f", "-n", "50", "--since", "1 hour ago", "-u", "etcd"},
+		},
+		{
+			comment: "no lines limit",
+			config:  logsConfig{unit: "kubelet"},
+			want:    []string{"-f", "-u", "kubelet"},
+		},
+	}
+	for _, testCase := range testCases {
+		got := journalctlArgs(testCase.config)
+		if !reflect.DeepEqual(got, testCase.want) {
+			t.Errorf("%v: journalctlArgs(%+v) = %v, want %v", testCase.comment, testCase.config, got, testCase.want)
+		}
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -31,10 +32,13 @@ import (
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
+	"github.com/gravitational/planet/lib/agentcache"
 	"github.com/gravitational/planet/lib/box"
 	"github.com/gravitational/planet/lib/constants"
 	"github.com/gravitational/planet/lib/monitoring"
+	"github.com/gravitational/planet/lib/utils"
 	"github.com/gravitational/planet/test/e2e"
 
 	"github.com/fatih/color"
@@ -42,15 +46,15 @@ import (
 	"github.com/gravitational/configure/cstrings"
 	etcdconf "github.com/gravitational/coordinate/v4/config"
 	"github.com/gravitational/satellite/agent"
-	"github.com/gravitational/satellite/agent/backend/inmemory"
+	satellitecmd "github.com/gravitational/satellite/cmd"
 	"github.com/gravitational/satellite/lib/history/sqlite"
 	"github.com/gravitational/trace"
-	"github.com/gravitational/version"
 	"github.com/opencontainers/runc/libcontainer/configs"
 	"github.com/opencontainers/selinux/go-selinux"
 	log "github.com/sirupsen/logrus"
 	logsyslog "github.com/sirupsen/logrus/hooks/syslog"
 	"gopkg.in/alecthomas/kingpin.v2"
+	"k8s.io/client-go/kubernetes"
 )
 
 func main() {
@@ -66,17 +70,25 @@ func main() {
 	if errExit, ok := trace.Unwrap(err).(*box.ExitError); ok {
 		os.Exit(errExit.Code)
 	}
+	switch trace.Unwrap(err).(type) {
+	case *errWaitTimeout:
+		dieWithCode(err, waitExitCodeTimeout)
+	case *errWaitDegraded:
+		dieWithCode(err, waitExitCodeDegraded)
+	}
 	die(err)
 }
 
 func run() error {
 	var (
-		app             = kingpin.New("planet", "Planet is a Kubernetes delivered as RunC container")
-		debug           = app.Flag("debug", "Enable debug mode").Bool()
-		profileEndpoint = app.Flag("httpprofile", "enable profiling endpoint on specified host/port i.e. localhost:7070").Hidden().String()
+		app                   = kingpin.New("planet", "Planet is a Kubernetes delivered as RunC container")
+		debug                 = app.Flag("debug", "Enable debug mode").Bool()
+		profileEndpoint       = app.Flag("httpprofile", "enable profiling endpoint bound to the given loopback host/port, e.g. 127.0.0.1:6060").Hidden().String()
+		upgradeHandoverSocket = app.Flag("upgrade-handover-socket", "Offer the profiling endpoint listener for handover to a newer planet binary over this unix socket, once, in the background. Requires --httpprofile. Empty disables the feature").Hidden().OverrideDefaultFromEnvar("PLANET_UPGRADE_HANDOVER_SOCKET").String()
 
 		// commands
-		cversion = app.Command("version", "Print version information")
+		cversion       = app.Command("version", "Print version information")
+		cversionOutput = cversion.Flag("output", "Output format, text or json").Default("text").Enum("text", "json")
 
 		// internal init command used by libcontainer
 		cinit = app.Command("init", "Internal init command").Hidden()
@@ -84,21 +96,27 @@ func run() error {
 		// start the container with planet
 		cstart = app.Command("start", "Start Planet container")
 
-		cstartPublicIP       = cstart.Flag("public-ip", "IP accessible by other nodes for inter-host communication").OverrideDefaultFromEnvar("PLANET_PUBLIC_IP").IP()
-		cstartMasterIP       = cstart.Flag("master-ip", "IP of the master Pod (defaults to public-ip)").OverrideDefaultFromEnvar("PLANET_MASTER_IP").IP()
-		cstartCloudProvider  = cstart.Flag("cloud-provider", "cloud provider name, e.g. 'aws' or 'gce'").OverrideDefaultFromEnvar("PLANET_CLOUD_PROVIDER").String()
-		cstartClusterID      = cstart.Flag("cluster-id", "ID of the cluster").OverrideDefaultFromEnvar("PLANET_CLUSTER_ID").String()
-		cstartGCENodeTags    = cstart.Flag("gce-node-tags", "Node tag to set in the cloud configuration file on GCE as comma-separated values").OverrideDefaultFromEnvar(EnvGCENodeTags).String()
-		cstartIgnoreChecks   = cstart.Flag("ignore-checks", "Force start ignoring some failed host checks (e.g. kernel version)").OverrideDefaultFromEnvar("PLANET_FORCE").Bool()
-		cstartEnv            = EnvVars(cstart.Flag("env", "Set environment variable as comma-separated list of name=value pairs").OverrideDefaultFromEnvar("PLANET_ENV"))
-		cstartMounts         = Mounts(cstart.Flag("volume", "External volume to mount, as a src:dst[:options] tuple").OverrideDefaultFromEnvar("PLANET_VOLUME"))
-		cstartDevices        = Devices(cstart.Flag("device", "Device to create inside container").OverrideDefaultFromEnvar("PLANET_DEVICE"))
-		cstartRoles          = List(cstart.Flag("role", "Roles such as 'master' or 'node'").OverrideDefaultFromEnvar("PLANET_ROLE"))
-		cstartSecretsDir     = cstart.Flag("secrets-dir", "Directory with master secrets - certificate authority and certificates").OverrideDefaultFromEnvar("PLANET_SECRETS_DIR").ExistingDir()
-		cstartServiceCIDR    = kv.CIDRFlag(cstart.Flag("service-subnet", "IP range from which to assign service cluster IPs. This must not overlap with any IP ranges assigned to nodes for pods.").Default(DefaultServiceSubnet).Envar(EnvPlanetServiceSubnet))
-		cstartPodCIDR        = kv.CIDRFlag(cstart.Flag("pod-subnet", "subnet dedicated to the pods in the cluster").Default(DefaultPodSubnet).OverrideDefaultFromEnvar("PLANET_POD_SUBNET"))
-		cstartPodSubnetSize  = cstart.Flag("pod-subnet-size", "Size of the subnet allocated to each host").Default(strconv.Itoa(DefaultPodSubnetSize)).OverrideDefaultFromEnvar("PLANET_POD_SUBNET_SIZE").Int()
-		cstartProxyPortRange = cstart.Flag("proxy-portrange", "Range of host ports (beginPort-endPort, single port or beginPort+offset, inclusive) that may be consumed in order to proxy service traffic. If (unspecified, 0, or 0-0) then ports will be randomly chosen.").
+		cstartPublicIP          = cstart.Flag("public-ip", "IP accessible by other nodes for inter-host communication").OverrideDefaultFromEnvar("PLANET_PUBLIC_IP").IP()
+		cstartPublicIPSecondary = List(cstart.Flag("public-ip-secondary", "Additional public address(es) of this node for dual-stack advertisement, as a comma-separated list. Must be the other IP family from --public-ip and assigned to a local interface. Ignored if --bind-interface resolves a secondary address itself").OverrideDefaultFromEnvar(EnvPublicIPs))
+		cstartBindInterface     = cstart.Flag("bind-interface", "Name of the network interface whose address(es) to use for inter-host communication, instead of specifying --public-ip directly. On a dual-stack interface its IPv4 and IPv6 addresses are used as the primary and secondary address. Overrides --public-ip and --public-ip-secondary if given").OverrideDefaultFromEnvar("PLANET_BIND_INTERFACE").String()
+		cstartMasterIP          = cstart.Flag("master-ip", "IP of the master Pod (defaults to public-ip). Ignored if --master-discovery is set and succeeds").OverrideDefaultFromEnvar("PLANET_MASTER_IP").IP()
+		cstartMasterDiscovery   = cstart.Flag("master-discovery", "URL of an HTTP endpoint returning the current master IP(s), fetched with retry at startup. Falls back to --master-ip (or --public-ip) if unset or if discovery fails").OverrideDefaultFromEnvar("PLANET_MASTER_DISCOVERY").String()
+		cstartCloudProvider     = cstart.Flag("cloud-provider", "cloud provider name, e.g. 'aws' or 'gce'").OverrideDefaultFromEnvar("PLANET_CLOUD_PROVIDER").String()
+		cstartClusterID         = cstart.Flag("cluster-id", "ID of the cluster").OverrideDefaultFromEnvar("PLANET_CLUSTER_ID").String()
+		cstartGCENodeTags       = cstart.Flag("gce-node-tags", "Node tag to set in the cloud configuration file on GCE as comma-separated values").OverrideDefaultFromEnvar(EnvGCENodeTags).String()
+		cstartIgnoreChecks      = cstart.Flag("ignore-checks", "Force start ignoring some failed host checks (e.g. kernel version)").OverrideDefaultFromEnvar("PLANET_FORCE").Bool()
+		cstartForeground        = cstart.Flag("foreground", "Run in the foreground instead of daemonizing (use with systemd Type=simple units)").Bool()
+		cstartLogMaxSizeMB      = cstart.Flag("log-max-size-mb", "Maximum size in megabytes of the daemon log file before it is rotated").Default(strconv.Itoa(DefaultLogMaxSizeMB)).OverrideDefaultFromEnvar("PLANET_LOG_MAX_SIZE_MB").Int()
+		cstartLogMaxBackups     = cstart.Flag("log-max-backups", "Number of rotated daemon log files to retain").Default(strconv.Itoa(DefaultLogMaxBackups)).OverrideDefaultFromEnvar("PLANET_LOG_MAX_BACKUPS").Int()
+		cstartEnv               = EnvVars(cstart.Flag("env", "Set environment variable as comma-separated list of name=value pairs").OverrideDefaultFromEnvar("PLANET_ENV"))
+		cstartMounts            = Mounts(cstart.Flag("volume", "External volume to mount, as a src:dst[:options] tuple").OverrideDefaultFromEnvar("PLANET_VOLUME"))
+		cstartDevices           = Devices(cstart.Flag("device", "Device to create inside container").OverrideDefaultFromEnvar("PLANET_DEVICE"))
+		cstartRoles             = List(cstart.Flag("role", "Roles such as 'master' or 'node'").OverrideDefaultFromEnvar("PLANET_ROLE"))
+		cstartSecretsDir        = cstart.Flag("secrets-dir", "Directory with master secrets - certificate authority and certificates").OverrideDefaultFromEnvar("PLANET_SECRETS_DIR").ExistingDir()
+		cstartServiceCIDR       = kv.CIDRFlag(cstart.Flag("service-subnet", "IP range from which to assign service cluster IPs. This must not overlap with any IP ranges assigned to nodes for pods.").Default(DefaultServiceSubnet).Envar(EnvPlanetServiceSubnet))
+		cstartPodCIDR           = kv.CIDRFlag(cstart.Flag("pod-subnet", "subnet dedicated to the pods in the cluster").Default(DefaultPodSubnet).OverrideDefaultFromEnvar("PLANET_POD_SUBNET"))
+		cstartPodSubnetSize     = cstart.Flag("pod-subnet-size", "Size of the subnet allocated to each host").Default(strconv.Itoa(DefaultPodSubnetSize)).OverrideDefaultFromEnvar("PLANET_POD_SUBNET_SIZE").Int()
+		cstartProxyPortRange    = cstart.Flag("proxy-portrange", "Range of host ports (beginPort-endPort, single port or beginPort+offset, inclusive) that may be consumed in order to proxy service traffic. If (unspecified, 0, or 0-0) then ports will be randomly chosen.").
 					OverrideDefaultFromEnvar(EnvPlanetProxyPortRange).String()
 		cstartServiceNodePortRange = cstart.Flag("service-node-portrange", "A port range to reserve for services with NodePort visibility. Example: '30000-32767'. Inclusive at both ends of the range.").
 						Default(DefaultServiceNodePortRange).
@@ -129,21 +147,38 @@ func run() error {
 					OverrideDefaultFromEnvar(EnvPlanetKubeletOptions).String()
 		cstartAPIServerOptions = cstart.Flag("apiserver-options", "Additional command line options to pass to API server").
 					OverrideDefaultFromEnvar(EnvPlanetAPIServerOptions).String()
+		cstartKubeletArgs        = List(cstart.Flag("kubelet-arg", "Extra key=value argument to append to kubelet's command line, on top of --kubelet-options. Can be specified multiple times").OverrideDefaultFromEnvar("PLANET_KUBELET_ARG"))
+		cstartAPIServerArgs      = List(cstart.Flag("apiserver-arg", "Extra key=value argument to append to the API server's command line, on top of --apiserver-options. Can be specified multiple times").OverrideDefaultFromEnvar("PLANET_APISERVER_ARG"))
 		cstartDNSListenAddrs     = List(cstart.Flag("dns-listen-addr", "Comma-separated list of addresses for CoreDNS to listen on").OverrideDefaultFromEnvar(EnvPlanetDNSListenAddr).Default(DefaultDNSListenAddr))
 		cstartDNSPort            = cstart.Flag("dns-port", "DNS port for CoreDNS").OverrideDefaultFromEnvar(EnvPlanetDNSPort).Default(strconv.Itoa(DNSPort)).Int()
+		cstartDNSNdots           = cstart.Flag("dns-ndots", "Number of dots that must appear in a name before the container's resolv.conf tries an absolute lookup first. 0-15, defaults to the Kubernetes-appropriate value of 5").OverrideDefaultFromEnvar("PLANET_DNS_NDOTS").Default(strconv.Itoa(DefaultDNSNdots)).Int()
+		cstartDNSTimeout         = cstart.Flag("dns-timeout", "Seconds the resolver waits for a response before retrying with a different name server, in the container's resolv.conf").OverrideDefaultFromEnvar("PLANET_DNS_TIMEOUT").Default(strconv.Itoa(DefaultDNSTimeout)).Int()
+		cstartDNSAttempts        = cstart.Flag("dns-attempts", "Number of times the resolver retries a lost query before giving up on a name server, in the container's resolv.conf").OverrideDefaultFromEnvar("PLANET_DNS_ATTEMPTS").Default(strconv.Itoa(DefaultDNSAttempts)).Int()
 		cstartTaints             = List(cstart.Flag("taint", "Kubernetes taints to apply to the node during creation").OverrideDefaultFromEnvar(EnvPlanetTaints))
 		cstartNodeLabels         = List(cstart.Flag("node-label", "Kubernetes node label to apply upon node registration").OverrideDefaultFromEnvar(EnvPlanetNodeLabels))
 		cstartDisableFlannel     = cstart.Flag("disable-flannel", "Disable flannel within the planet container").OverrideDefaultFromEnvar(EnvDisableFlannel).Bool()
 		cstartKubeletConfig      = cstart.Flag("kubelet-config", "Kubelet configuration as base64-encoded JSON payload").OverrideDefaultFromEnvar(EnvPlanetKubeletConfig).String()
 		cstartCloudConfig        = cstart.Flag("cloud-config", "Cloud configuration as base64-encoded payload").OverrideDefaultFromEnvar(EnvPlanetCloudConfig).String()
+		cstartCloudConfigFile    = cstart.Flag("cloud-config-file", "Path to a cloud provider configuration file to use in place of --cloud-config").OverrideDefaultFromEnvar(EnvPlanetCloudConfigFile).ExistingFile()
 		cstartAllowPrivileged    = cstart.Flag("allow-privileged", "Allow privileged containers").OverrideDefaultFromEnvar(EnvPlanetAllowPrivileged).Bool()
 		cstartSELinux            = cstart.Flag("selinux", "Run with SELinux support").Envar(EnvPlanetSELinux).Bool()
+		cstartReadonlyRootfs     = cstart.Flag("readonly-rootfs", "Mount the container's rootfs read-only, with writable tmpfs mounts added for the paths systemd/etcd need").OverrideDefaultFromEnvar("PLANET_READONLY_ROOTFS").Bool()
+		cstartCapAdd             = List(cstart.Flag("cap-add", "Linux capability (e.g. CAP_SYS_ADMIN) to add to the container's default capability set. Can be specified multiple times").OverrideDefaultFromEnvar("PLANET_CAP_ADD"))
+		cstartCapDrop            = List(cstart.Flag("cap-drop", "Linux capability (e.g. CAP_SYS_MODULE) to remove from the container's default capability set. Can be specified multiple times").OverrideDefaultFromEnvar("PLANET_CAP_DROP"))
+		cstartMemoryLimit        = cstart.Flag("memory-limit", "Cap the container's total memory usage, e.g. \"4G\". Unset means no limit").OverrideDefaultFromEnvar("PLANET_MEMORY_LIMIT").String()
+		cstartMemoryReserveHost  = cstart.Flag("memory-reserve-host", "Guarantee this much memory, e.g. \"2G\", stays free on the host by capping the container to the host's total memory minus this amount").OverrideDefaultFromEnvar("PLANET_MEMORY_RESERVE_HOST").String()
+		cstartCPUQuota           = cstart.Flag("cpu-quota", "Cap the container's CPU usage as a percentage of a single CPU, e.g. \"200%\" for two full CPUs. Unset means no quota").OverrideDefaultFromEnvar("PLANET_CPU_QUOTA").String()
 		cstartHighAvailability   = cstart.Flag("high-availability", "Boolean flag to enable/disable kubernetes high availability mode.").OverrideDefaultFromEnvar(EnvHighAvailability).Bool()
 		cstartFlannelBackend     = cstart.Flag("flannel-backend", "Flannel backend: 'aws-vpc', 'gce', or 'vxlan'").Envar(EnvFlannelBackend).String()
 		cstartEncryptionProvider = cstart.Flag("encryption-provider", "Kubernetes encryption provder: 'aws'").Envar(EnvEncryptionProvider).String()
 		cstartAWSAccountID       = cstart.Flag("aws-account-id", "AWS account ID").Envar(EnvAWSAccountID).String()
 		cstartAWSKeyID           = cstart.Flag("aws-key-id", "AWS KMS key ID").Envar(EnvAWSKeyID).String()
 		cstartAWSKeyRegion       = cstart.Flag("aws-key-region", "AWS KMS key region").Envar(EnvAWSKeyRegion).String()
+		cstartFromConfig         = cstart.Flag("from-config", "Load the effective configuration persisted by a previous start from this path, filling in any flag not given explicitly on this command line").ExistingFile()
+
+		// display the persisted start configuration
+		cconfig     = app.Command("config", "Inspect the persisted start configuration")
+		cconfigShow = cconfig.Command("show", "Print the configuration persisted by the last successful start, with secrets redacted")
 
 		// start the planet agent
 		cagent                 = app.Command("agent", "Start Planet Agent")
@@ -160,65 +195,202 @@ func run() error {
 		cagentInitialCluster   = KeyValueList(cagent.Flag("initial-cluster", "Initial planet cluster configuration as a comma-separated list of peers").OverrideDefaultFromEnvar(EnvInitialCluster))
 		cagentRegistryAddr     = cagent.Flag("docker-registry-addr",
 			"Address of the private docker registry.  Will default to apiserver-dns:5000").String()
-		cagentEtcdEndpoints          = List(cagent.Flag("etcd-endpoints", "List of comma-separated etcd endpoints").Default(DefaultEtcdEndpoints))
-		cagentEtcdCAFile             = cagent.Flag("etcd-cafile", "Certificate Authority file used to secure etcd communication").String()
-		cagentEtcdCertFile           = cagent.Flag("etcd-certfile", "TLS certificate file used to secure etcd communication").String()
-		cagentEtcdKeyFile            = cagent.Flag("etcd-keyfile", "TLS key file used to secure etcd communication").String()
-		cagentElectionEnabled        = Bool(cagent.Flag("election-enabled", "Boolean flag to control if the agent initially starts with election participation on").OverrideDefaultFromEnvar(EnvElectionEnabled))
-		cagentDNSUpstreamNameservers = List(cagent.Flag("nameservers", "List of additional upstream nameservers to add to DNS configuration as a comma-separated list of IPs").OverrideDefaultFromEnvar(EnvDNSUpstreamNameservers))
-		cagentDNSLocalNameservers    = List(cagent.Flag("local-nameservers", "List of node-local nameserver addresses").OverrideDefaultFromEnvar(EnvDNSLocalNameservers).Default(DefaultDNSAddress))
-		cagentDNSZones               = DNSOverrides(cagent.Flag("dns-zones", "Comma-separated list of DNS zone to nameserver IP mappings as 'zone/nameserver' pairs").OverrideDefaultFromEnvar(EnvDNSZones))
-		cagentCloudProvider          = cagent.Flag("cloud-provider", "Which cloud provider backend the cluster is using").OverrideDefaultFromEnvar(EnvCloudProvider).String()
-		cagentLowWatermark           = cagent.Flag("low-watermark", "Low disk usage percentage of monitored directories").Default(strconv.Itoa(LowWatermark)).OverrideDefaultFromEnvar("PLANET_LOW_WATERMARK").Uint64()
-		cagentHighWatermark          = cagent.Flag("high-watermark", "High disk usage percentage of monitored directories").Default(strconv.Itoa(HighWatermark)).OverrideDefaultFromEnvar("PLANET_HIGH_WATERMARK").Uint64()
-		cagentHTTPTimeout            = cagent.Flag("http-timeout", "Timeout for HTTP requests, formatted as Go duration.").OverrideDefaultFromEnvar(EnvPlanetAgentHTTPTimeout).Default(constants.HTTPTimeout.String()).Duration()
-		cagentServiceUID             = cagent.Flag("service-uid", "UID of the service user (planet)").OverrideDefaultFromEnvar(EnvServiceUID).String()
-		cagentServiceGID             = cagent.Flag("service-gid", "GID of the service user (planet)").OverrideDefaultFromEnvar(EnvServiceGID).String()
-		cagentTimelineDir            = cagent.Flag("timeline-dir", "Directory to be used for timeline storage").Default("/tmp/timeline").String()
-		cagentRetention              = cagent.Flag("retention", "Window to retain timeline as a Go duration").Duration()
-		cagentServiceCIDR            = cidrFlag(cagent.Flag("service-subnet", "IP range from which to assign service cluster IPs. This must not overlap with any IP ranges assigned to nodes for pods.").Default(DefaultServiceSubnet).Envar(EnvServiceSubnet))
-		cagentCriticalNamespaces     = List(cagent.Flag("critical-namespaces", "List of Kubernetes namespaces to search for critical system pods").Default(DefaultCriticalNamespaces).OverrideDefaultFromEnvar(EnvCriticalNamespaces))
-		cagentHighAvailability       = cagent.Flag("high-availability", "Boolean flag to enable/disable kubernetes high availability mode.").OverrideDefaultFromEnvar(EnvHighAvailability).Bool()
+		cagentEtcdEndpoints             = List(cagent.Flag("etcd-endpoints", "List of comma-separated etcd endpoints").Default(DefaultEtcdEndpoints))
+		cagentEtcdCAFile                = cagent.Flag("etcd-cafile", "Certificate Authority file used to secure etcd communication").String()
+		cagentEtcdCertFile              = cagent.Flag("etcd-certfile", "TLS certificate file used to secure etcd communication").String()
+		cagentEtcdKeyFile               = cagent.Flag("etcd-keyfile", "TLS key file used to secure etcd communication").String()
+		cagentElectionEnabled           = Bool(cagent.Flag("election-enabled", "Boolean flag to control if the agent initially starts with election participation on").OverrideDefaultFromEnvar(EnvElectionEnabled))
+		cagentDNSUpstreamNameservers    = List(cagent.Flag("nameservers", "List of additional upstream nameservers to add to DNS configuration as a comma-separated list of IPs").OverrideDefaultFromEnvar(EnvDNSUpstreamNameservers))
+		cagentDNSLocalNameservers       = List(cagent.Flag("local-nameservers", "List of node-local nameserver addresses").OverrideDefaultFromEnvar(EnvDNSLocalNameservers).Default(DefaultDNSAddress))
+		cagentDNSZones                  = DNSOverrides(cagent.Flag("dns-zones", "Comma-separated list of DNS zone to nameserver IP mappings as 'zone/nameserver' pairs").OverrideDefaultFromEnvar(EnvDNSZones))
+		cagentCloudProvider             = cagent.Flag("cloud-provider", "Which cloud provider backend the cluster is using").OverrideDefaultFromEnvar(EnvCloudProvider).String()
+		cagentLowWatermark              = cagent.Flag("low-watermark", "Low disk usage percentage of monitored directories").Default(strconv.Itoa(LowWatermark)).OverrideDefaultFromEnvar("PLANET_LOW_WATERMARK").Uint64()
+		cagentHighWatermark             = cagent.Flag("high-watermark", "High disk usage percentage of monitored directories").Default(strconv.Itoa(HighWatermark)).OverrideDefaultFromEnvar("PLANET_HIGH_WATERMARK").Uint64()
+		cagentHTTPTimeout               = cagent.Flag("http-timeout", "Timeout for HTTP requests, formatted as Go duration.").OverrideDefaultFromEnvar(EnvPlanetAgentHTTPTimeout).Default(constants.HTTPTimeout.String()).Duration()
+		cagentServiceUID                = cagent.Flag("service-uid", "UID of the service user (planet)").OverrideDefaultFromEnvar(EnvServiceUID).String()
+		cagentServiceGID                = cagent.Flag("service-gid", "GID of the service user (planet)").OverrideDefaultFromEnvar(EnvServiceGID).String()
+		cagentTimelineDir               = cagent.Flag("timeline-dir", "Directory to be used for timeline storage").Default("/tmp/timeline").String()
+		cagentCache                     = cagent.Flag("cache", "Local timeline backend to use, one of sqlite, bolt or memory").Default("sqlite").Enum(timelineBackends...)
+		cagentStatusWebhook             = cagent.Flag("status-webhook", "URL to POST the aggregated status to whenever it transitions between running and degraded").OverrideDefaultFromEnvar("PLANET_STATUS_WEBHOOK").String()
+		cagentStatusWebhookTimeout      = cagent.Flag("status-webhook-timeout", "Maximum time to spend posting a status update to the status webhook, including retries, formatted as a Go duration").Default("30s").OverrideDefaultFromEnvar("PLANET_STATUS_WEBHOOK_TIMEOUT").Duration()
+		cagentRetention                 = cagent.Flag("retention", "Window to retain timeline as a Go duration").Duration()
+		cagentServiceCIDR               = cidrFlag(cagent.Flag("service-subnet", "IP range from which to assign service cluster IPs. This must not overlap with any IP ranges assigned to nodes for pods.").Default(DefaultServiceSubnet).Envar(EnvServiceSubnet))
+		cagentPodCIDR                   = cidrFlag(cagent.Flag("pod-subnet", "subnet dedicated to the pods in the cluster").Default(DefaultPodSubnet).OverrideDefaultFromEnvar("PLANET_POD_SUBNET"))
+		cagentRepairIPTables            = cagent.Flag("repair-iptables", "Automatically restore the planet-owned iptables rules (masquerade, FORWARD accepts) when found missing").OverrideDefaultFromEnvar("PLANET_REPAIR_IPTABLES").Bool()
+		cagentFixSysctls                = cagent.Flag("fix-sysctls", "Automatically remediate the IP-forwarding and br_netfilter sysctl parameters when found missing or misconfigured").OverrideDefaultFromEnvar("PLANET_FIX_SYSCTLS").Bool()
+		cagentDisabledMetricsCollectors = List(cagent.Flag("disable-metrics-collector", "Name of a Prometheus metrics sub-collector (etcd, kube, docker, systemd, sysctl) to disable. Can be specified multiple times").OverrideDefaultFromEnvar("PLANET_DISABLE_METRICS_COLLECTOR"))
+		cagentKubeProxyHealthzAddr      = cagent.Flag("kube-proxy-healthz-addr", "Address of the kube-proxy healthz endpoint").Default(monitoring.DefaultKubeProxyHealthzAddr).OverrideDefaultFromEnvar("PLANET_KUBE_PROXY_HEALTHZ_ADDR").String()
+		cagentDisableKubeProxyCheck     = cagent.Flag("disable-kube-proxy-check", "Skip the kube-proxy healthz check, for deployments that intentionally run without kube-proxy (e.g. proxyless CNI)").OverrideDefaultFromEnvar("PLANET_DISABLE_KUBE_PROXY_CHECK").Bool()
+		cagentCriticalNamespaces        = List(cagent.Flag("critical-namespaces", "List of Kubernetes namespaces to search for critical system pods").Default(DefaultCriticalNamespaces).OverrideDefaultFromEnvar(EnvCriticalNamespaces))
+		cagentHighAvailability          = cagent.Flag("high-availability", "Boolean flag to enable/disable kubernetes high availability mode.").OverrideDefaultFromEnvar(EnvHighAvailability).Bool()
+		cagentNoCluster                 = cagent.Flag("no-cluster", "Skip Kubernetes-informer-based cluster membership and report only the local node as a cluster member. Intended for single-node clusters").OverrideDefaultFromEnvar("PLANET_NO_CLUSTER").Bool()
+		cagentDNSSetupTimeout           = cagent.Flag("dns-setup-timeout", "Maximum time to wait for the DNS services to become available during startup, formatted as a Go duration. Zero waits indefinitely").OverrideDefaultFromEnvar("PLANET_DNS_SETUP_TIMEOUT").Duration()
+		cagentClusterID                 = cagent.Flag("cluster-id", "ID of the cluster").OverrideDefaultFromEnvar(EnvClusterID).String()
+		cagentDisableHostsSync          = cagent.Flag("disable-hosts-sync", "Disable periodic synchronization of /etc/hosts with cluster membership, for deployments that manage it externally").OverrideDefaultFromEnvar("PLANET_DISABLE_HOSTS_SYNC").Bool()
+		cagentHostsSyncInterval         = cagent.Flag("hosts-sync-interval", "How often to reconcile /etc/hosts with cluster membership, formatted as a Go duration").Default(defaultHostsSyncInterval.String()).OverrideDefaultFromEnvar("PLANET_HOSTS_SYNC_INTERVAL").Duration()
+		cagentCertRotationThreshold     = cagent.Flag("cert-rotation-threshold", "Automatically rotate planet-managed certificates once less than this much of their validity remains, formatted as a Go duration. Unset disables automatic rotation").OverrideDefaultFromEnvar("PLANET_CERT_ROTATION_THRESHOLD").Duration()
+		cagentDisableNodeEvents         = cagent.Flag("disable-node-events", "Disable publishing node lifecycle changes (join, leave, failed, role change) as Kubernetes Events").OverrideDefaultFromEnvar("PLANET_DISABLE_NODE_EVENTS").Bool()
+		cagentCertRotationCheckInterval = cagent.Flag("cert-rotation-check-interval", "How often to check managed certificates for expiry, formatted as a Go duration").Default(defaultCertRotationCheckInterval.String()).OverrideDefaultFromEnvar("PLANET_CERT_ROTATION_CHECK_INTERVAL").Duration()
+		cagentCertRotationTTL           = cagent.Flag("cert-rotation-ttl", "Validity period assigned to certificates issued by automatic rotation, formatted as a Go duration").Default(defaultCertTTL.String()).OverrideDefaultFromEnvar("PLANET_CERT_ROTATION_TTL").Duration()
+		cagentCertRotationCAFile        = cagent.Flag("cert-rotation-ca-file", "Path to the cluster CA certificate used to sign rotated certificates").Default(DefaultEtcdctlCAFile).OverrideDefaultFromEnvar("PLANET_CERT_ROTATION_CA_FILE").String()
+		cagentCertRotationCAKeyFile     = cagent.Flag("cert-rotation-ca-key-file", "Path to the cluster CA private key used to sign rotated certificates. Required for automatic rotation to run").OverrideDefaultFromEnvar("PLANET_CERT_ROTATION_CA_KEY_FILE").String()
+		cagentDisableChecker            = List(cagent.Flag("disable-checker", "Name of a checker (built-in or an external drop-in under --exec-checks-dir) to disable. Can be specified multiple times").OverrideDefaultFromEnvar("PLANET_DISABLE_CHECKER"))
+		cagentExecChecksDir             = cagent.Flag("exec-checks-dir", "Directory of executable health checker drop-ins, each run on every check cycle and registered under its file name. Empty disables the feature").Default(monitoring.DefaultExecCheckerDir).OverrideDefaultFromEnvar("PLANET_EXEC_CHECKS_DIR").String()
+		cagentExecChecksTimeout         = cagent.Flag("exec-checks-timeout", "Maximum time an individual exec checker drop-in may run before it's killed and reported as failed, formatted as a Go duration").OverrideDefaultFromEnvar("PLANET_EXEC_CHECKS_TIMEOUT").Duration()
+		cagentCheckerConfigFile         = cagent.Flag("checker-config-file", "Path to a YAML/JSON file of per-checker parameter overrides (e.g. disk thresholds), applied on top of the flags above. Empty disables loading overrides").OverrideDefaultFromEnvar("PLANET_CHECKER_CONFIG_FILE").String()
+		cagentDockerStorageDriver       = cagent.Flag("docker-storage-driver", "Storage driver the docker storage checker expects to be in effect, e.g. when intentionally running a driver other than overlay2").Default(monitoring.DefaultDockerStorageDriver).OverrideDefaultFromEnvar("PLANET_DOCKER_STORAGE_DRIVER").String()
+
+		// force-rotate planet-managed TLS certificates from the cluster CA
+		crotateCerts         = app.Command("rotate-certs", "Force-rotate planet-managed TLS certificates (etcd, apiserver) from the cluster CA, backing up and restarting their consumers")
+		crotateCertsCAFile   = crotateCerts.Flag("ca-file", "Path to the cluster CA certificate").Default(DefaultEtcdctlCAFile).String()
+		crotateCertsCAKey    = crotateCerts.Flag("ca-key-file", "Path to the cluster CA private key used to sign replacement certificates").Required().String()
+		crotateCertsTTL      = crotateCerts.Flag("ttl", "Validity period for replacement certificates, formatted as a Go duration").Default(defaultCertTTL.String()).Duration()
+		crotateCertsMinValid = crotateCerts.Flag("min-remaining-validity", "Only rotate certificates with less than this much validity remaining, formatted as a Go duration. Unset rotates every managed certificate unconditionally").Duration()
 
 		// stop a running container
-		cstop        = app.Command("stop", "Stop planet container")
-		cstopSELinux = cstop.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
+		cstop              = app.Command("stop", "Stop planet container")
+		cstopSELinux       = cstop.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
+		cstopShutdownOrder = List(cstop.Flag("shutdown-order", "Comma-separated list of unit or unit:timeout entries (timeout as a Go duration) to stop in order, each bounded by its own timeout, before the final halt").OverrideDefaultFromEnvar("PLANET_SHUTDOWN_ORDER"))
+
+		// gracefully restart a running container in place
+		crestart              = app.Command("restart", "Gracefully restart Planet container, reusing its persisted start configuration")
+		crestartSELinux       = crestart.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
+		crestartShutdownOrder = List(crestart.Flag("shutdown-order", "Comma-separated list of unit or unit:timeout entries (timeout as a Go duration) to stop in order, each bounded by its own timeout, before the final halt").OverrideDefaultFromEnvar("PLANET_SHUTDOWN_ORDER"))
+		crestartLogMaxSizeMB  = crestart.Flag("log-max-size-mb", "Maximum size in megabytes of the daemon log file before it is rotated").Default(strconv.Itoa(DefaultLogMaxSizeMB)).OverrideDefaultFromEnvar("PLANET_LOG_MAX_SIZE_MB").Int()
+		crestartLogMaxBackups = crestart.Flag("log-max-backups", "Number of rotated daemon log files to retain").Default(strconv.Itoa(DefaultLogMaxBackups)).OverrideDefaultFromEnvar("PLANET_LOG_MAX_BACKUPS").Int()
 
 		// enter a running container, deprecated, so hide it
-		center        = app.Command("enter", "[DEPRECATED] Enter running planet container").Hidden().Interspersed(false)
-		centerNoTTY   = center.Flag("notty", "Do not attach TTY to this process").Bool()
-		centerUser    = center.Flag("user", "User to execute the command").Default("root").String()
-		centerSELinux = center.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
-		centerCmd     = center.Arg("cmd", "Command to execute").Default("/bin/bash").String()
+		center                 = app.Command("enter", "[DEPRECATED] Enter running planet container").Hidden().Interspersed(false)
+		centerNoTTY            = center.Flag("notty", "Do not attach TTY to this process").Bool()
+		centerUser             = center.Flag("user", "User to execute the command as, either a name resolved inside the container or a numeric uid[:gid]").Default("root").String()
+		centerSELinux          = center.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
+		centerUnit             = center.Flag("unit", "Join the cgroup and environment of this systemd unit's main process").String()
+		centerListUnits        = center.Flag("list-units", "List the container's systemd units and exit").Bool()
+		centerReadonly         = center.Flag("readonly", "Remount this process' own view of the rootfs read-only").Bool()
+		centerCapAdd           = List(center.Flag("cap-add", "Linux capability to add to this process, on top of the container's own set. Can be specified multiple times"))
+		centerCapDrop          = List(center.Flag("cap-drop", "Linux capability to drop from this process. Can be specified multiple times"))
+		centerNoNewPrivileges  = center.Flag("no-new-privileges", "Prevent this process and its children from gaining privileges beyond what they start with").Bool()
+		centerOutputBufferSize = center.Flag("output-buffer-size", "Bound this process' stdout/stderr to this many bytes of in-memory buffering, dropping the oldest output once exceeded, so a slow terminal can't block it. 0 (the default) applies today's unbounded backpressure instead").Int()
+		centerCmd              = center.Arg("cmd", "Command to execute").Default("/bin/bash").String()
+
+		// show resource usage of the container's managed components
+		ctop         = app.Command("top", "Show CPU and memory usage of the container's managed components (etcd, kubernetes control plane, docker)")
+		ctopSELinux  = ctop.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
+		ctopOnce     = ctop.Flag("once", "Print a single snapshot and exit, instead of refreshing the table in place").Bool()
+		ctopInterval = ctop.Flag("interval", "How often to refresh the table, formatted as a Go duration. Ignored with --once").Default(topDefaultInterval.String()).Duration()
 
 		// exec into running container
-		cexec        = app.Command("exec", "Run a command in a running container").Interspersed(false)
-		cexecTTY     = cexec.Flag("tty", "Allocate a pseudo-TTY").Short('t').Bool()
-		cexecStdin   = cexec.Flag("interactive", "Keep stdin open").Short('i').Bool()
-		cexecUser    = cexec.Flag("user", "User to execute the command with").String()
-		cexecSELinux = cexec.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
-		cexecCmd     = cexec.Arg("command", "Command to execute").Required().String()
-		cexecArgs    = cexec.Arg("arg", "Additional arguments to command").Strings()
+		cexec                 = app.Command("exec", "Run a command in a running container").Interspersed(false)
+		cexecTTY              = cexec.Flag("tty", "Allocate a pseudo-TTY").Short('t').Bool()
+		cexecStdin            = cexec.Flag("interactive", "Keep stdin open").Short('i').Bool()
+		cexecUser             = cexec.Flag("user", "User to execute the command as, either a name resolved inside the container or a numeric uid[:gid]").String()
+		cexecSELinux          = cexec.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
+		cexecReadonly         = cexec.Flag("readonly", "Remount this process' own view of the rootfs read-only").Bool()
+		cexecCapAdd           = List(cexec.Flag("cap-add", "Linux capability to add to this process, on top of the container's own set. Can be specified multiple times"))
+		cexecCapDrop          = List(cexec.Flag("cap-drop", "Linux capability to drop from this process. Can be specified multiple times"))
+		cexecNoNewPrivileges  = cexec.Flag("no-new-privileges", "Prevent this process and its children from gaining privileges beyond what they start with").Bool()
+		cexecOutputBufferSize = cexec.Flag("output-buffer-size", "Bound this process' stdout/stderr to this many bytes of in-memory buffering, dropping the oldest output once exceeded, so a slow destination can't block it. 0 (the default) applies today's unbounded backpressure instead").Int()
+		cexecCmd              = cexec.Arg("command", "Command to execute").Required().String()
+		cexecArgs             = cexec.Arg("arg", "Additional arguments to command").Strings()
+
+		// stream container logs via journalctl
+		clogs        = app.Command("logs", "Stream logs from the planet container via journalctl")
+		clogsUnit    = clogs.Arg("unit", "Systemd unit to filter logs for, e.g. etcd or kubelet").String()
+		clogsSince   = clogs.Flag("since", "Show logs since this time, in any format accepted by journalctl's --since").String()
+		clogsLines   = clogs.Flag("lines", "Number of most recent journal lines to show before following").Default("100").Int()
+		clogsSELinux = clogs.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
+
+		// get/set the log level of a running planet process
+		cloglevel = app.Command("loglevel", "Query or change the log level of a running planet process")
+
+		cloglevelGet         = cloglevel.Command("get", "Print the current log level")
+		cloglevelGetEndpoint = cloglevelGet.Flag("endpoint", "Address of the running process' profiling endpoint (see --httpprofile)").Default(DefaultProfileEndpoint).String()
+
+		cloglevelSet         = cloglevel.Command("set", "Change the log level")
+		cloglevelSetEndpoint = cloglevelSet.Flag("endpoint", "Address of the running process' profiling endpoint (see --httpprofile)").Default(DefaultProfileEndpoint).String()
+		cloglevelSetLevel    = cloglevelSet.Arg("level", "New log level, one of: debug, info, warning, error").Required().String()
+
+		// run a single checker on demand on a running planet agent
+		ccheck         = app.Command("check", "Run a single checker on a running planet agent immediately, outside its normal cycle, and print its raw probe results")
+		ccheckName     = ccheck.Arg("name", "Name of the checker to run").Required().String()
+		ccheckEndpoint = ccheck.Flag("endpoint", "Address of the running process' profiling endpoint (see --httpprofile)").Default(DefaultProfileEndpoint).String()
+
+		// DNS resolver configuration utilities
+		cdns = app.Command("dns", "DNS resolver configuration utilities")
+
+		cdnsDiff            = cdns.Command("diff", "Show drift between the recorded DNS resolver addresses and what would be computed now")
+		cdnsDiffRole        = cdnsDiff.Flag("role", "Server role").OverrideDefaultFromEnvar(EnvRole).String()
+		cdnsDiffServiceCIDR = cidrFlag(cdnsDiff.Flag("service-subnet", "IP range from which to assign service cluster IPs. This must not overlap with any IP ranges assigned to nodes for pods.").Default(DefaultServiceSubnet).Envar(EnvServiceSubnet))
+
+		// validate cluster configuration
+		cvalidate = app.Command("validate", "Cluster configuration diagnostics")
+
+		cvalidatePodCIDR          = cvalidate.Command("pod-cidr", "Verify that nodes' allocated pod CIDRs are subnets of --pod-subnet and non-overlapping")
+		cvalidatePodCIDRPodSubnet = cidrFlag(cvalidatePodCIDR.Flag("pod-subnet", "subnet dedicated to the pods in the cluster").Default(DefaultPodSubnet).OverrideDefaultFromEnvar("PLANET_POD_SUBNET"))
+
+		cvalidateSystemPods = cvalidate.Command("system-pods", "Verify that required kube-system workloads are present and fully ready")
 
 		// report status of the cluster
-		cstatus            = app.Command("status", "Query the planet cluster status")
-		cstatusLocal       = cstatus.Flag("local", "Query the status of the local node").Bool()
-		cstatusRPCPort     = cstatus.Flag("rpc-port", "Local agent RPC port.").Default("7575").Int()
-		cstatusPrettyPrint = cstatus.Flag("pretty", "Pretty-print the output").Default("true").Bool()
-		cstatusTimeout     = cstatus.Flag("timeout", "Status timeout").Default(AgentStatusTimeout.String()).Duration()
-		cstatusCAFile      = cstatus.Flag("ca-file", "CA to authenticate server").
-					Default(ClientRPCCAPath).OverrideDefaultFromEnvar(EnvPlanetAgentCAFile).String()
+		cstatus                   = app.Command("status", "Query the planet cluster status")
+		cstatusLocal              = cstatus.Flag("local", "Query the status of the local node").Bool()
+		cstatusRPCPort            = cstatus.Flag("rpc-port", "Local agent RPC port.").Default("7575").Int()
+		cstatusFormat             = cstatus.Flag("format", "Output format: text (pretty-printed JSON), json (compact JSON), nagios (a single summary line and an NRPE-compatible exit code) or openmetrics (OpenMetrics text, scrapable without the metrics collector subsystem). With --watch, text/json instead select how each transition is printed").Default("text").Enum("text", "json", "nagios", "openmetrics")
+		cstatusWatch              = cstatus.Flag("watch", "Instead of printing the current status once, poll it at --watch-interval and print only what changed since the last poll").Bool()
+		cstatusWatchInterval      = cstatus.Flag("watch-interval", "Interval between polls in --watch mode").Default("5s").Duration()
+		cstatusTimeout            = cstatus.Flag("timeout", "Status timeout").Default(AgentStatusTimeout.String()).Duration()
+		cstatusMemberFailureGrace = cstatus.Flag("member-failure-grace", "Suppress the cluster status flipping to degraded until a failed member has been failed for at least this long, to ride out transient blips. 0 disables the grace period").Default("0s").Duration()
+		cstatusPretty             = cstatus.Flag("pretty", "Render status as subsystem (etcd/network/kubernetes/storage/system) sections instead of JSON, showing only failing probes by default. Only applies with --format text").Bool()
+		cstatusVerbose            = cstatus.Flag("verbose", "With --pretty, show every probe instead of only failing ones").Bool()
+		cstatusCAFile             = cstatus.Flag("ca-file", "CA to authenticate server").
+						Default(ClientRPCCAPath).OverrideDefaultFromEnvar(EnvPlanetAgentCAFile).String()
 		cstatusClientCertFile = cstatus.Flag("client-cert-file", "mTLS client certificate file").
 					Default(ClientRPCCertPath).OverrideDefaultFromEnvar(EnvPlanetAgentClientCertFile).String()
 		cstatusClientKeyFile = cstatus.Flag("client-key-file", "mTLS client key file").
 					Default(ClientRPCKeyPath).OverrideDefaultFromEnvar(EnvPlanetAgentClientKeyFile).String()
 
+		// tail a checker's recent probe results across the cluster
+		ccheckerHistory            = app.Command("checker-history", "Query the cross-cluster history of a checker's probe results")
+		ccheckerHistoryName        = ccheckerHistory.Arg("name", "Name of the checker to query").Required().String()
+		ccheckerHistoryRPCPort     = ccheckerHistory.Flag("rpc-port", "Local agent RPC port.").Default("7575").Int()
+		ccheckerHistoryPrettyPrint = ccheckerHistory.Flag("pretty", "Pretty-print the output").Default("true").Bool()
+		ccheckerHistoryTimeout     = ccheckerHistory.Flag("timeout", "Query timeout").Default(AgentStatusTimeout.String()).Duration()
+		ccheckerHistoryCAFile      = ccheckerHistory.Flag("ca-file", "CA to authenticate server").
+						Default(ClientRPCCAPath).OverrideDefaultFromEnvar(EnvPlanetAgentCAFile).String()
+		ccheckerHistoryClientCertFile = ccheckerHistory.Flag("client-cert-file", "mTLS client certificate file").
+						Default(ClientRPCCertPath).OverrideDefaultFromEnvar(EnvPlanetAgentClientCertFile).String()
+		ccheckerHistoryClientKeyFile = ccheckerHistory.Flag("client-key-file", "mTLS client key file").
+						Default(ClientRPCKeyPath).OverrideDefaultFromEnvar(EnvPlanetAgentClientKeyFile).String()
+
 		// test command
 		ctest             = app.Command("test", "Run end-to-end tests on a running cluster")
 		ctestKubeAddr     = HostPort(ctest.Flag("kube-addr", "Address of the kubernetes api server").Required())
 		ctestKubeRepoPath = ctest.Flag("kube-repo", "Path to a kubernetes repository").String()
 		ctestAssetPath    = ctest.Flag("asset-dir", "Path to test executables and data files").String()
 
+		// cleanup command
+		ccleanup = app.Command("cleanup", "Remove leftover test namespaces and nettest pods/services from aborted checks")
+
+		// debugging commands
+		cdebug = app.Command("debug", "Debugging utilities")
+
+		cdebugPprof         = cdebug.Command("pprof", "Capture goroutine, heap and CPU profiles from a running planet process")
+		cdebugPprofEndpoint = cdebugPprof.Flag("endpoint", "Address of the running process' profiling endpoint (see --httpprofile)").Default(DefaultProfileEndpoint).String()
+		cdebugPprofOutDir   = cdebugPprof.Flag("output-dir", "Directory to write the captured profiles to").Default(".").String()
+		cdebugPprofCPU      = cdebugPprof.Flag("cpu-seconds", "Duration in seconds to sample the CPU profile for").Default("30").Int()
+
+		cdebugDiag        = cdebug.Command("diag", "Capture a diagnostic snapshot (status, versions, effective environment, mounts, network namespace, iptables rules, cgroup stats, journal excerpts, DNS configuration and etcd status) into a single tarball")
+		cdebugDiagOutput  = cdebugDiag.Flag("output", "Path to write the diagnostic tarball to").Default("planet-diag.tar.gz").String()
+		cdebugDiagRPCPort = cdebugDiag.Flag("rpc-port", "Local agent RPC port").Default("7575").Int()
+		cdebugDiagSELinux = cdebugDiag.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
+
+		// upgrade-daemon takes over the profiling/debug endpoint listener of a
+		// running planet process (started with --upgrade-handover-socket) so a
+		// newer planet binary can serve it without ever closing the port. It
+		// does not take over the running process' other responsibilities
+		// (etcd, kubelet, docker supervision remain with the old process).
+		cupgradeDaemon       = app.Command("upgrade-daemon", "Take over another running planet process' debug endpoint listener via its --upgrade-handover-socket, without downtime")
+		cupgradeDaemonSocket = cupgradeDaemon.Flag("handover-socket", "Unix socket the running planet process is offering its debug endpoint listener on").Default(DefaultUpgradeHandoverSocket).String()
+
 		// device management
 		cdevice = app.Command("device", "Manage devices in container")
 
@@ -228,6 +400,30 @@ func run() error {
 		cdeviceRemove     = cdevice.Command("remove", "Remove device from container")
 		cdeviceRemoveNode = cdeviceRemove.Flag("node", "Device node to remove").Required().String()
 
+		// network namespace inspection
+		cnetns = app.Command("netns", "Inspect the container's network namespace")
+
+		cnetnsInfo        = cnetns.Command("info", "Show network interfaces, addresses, routes and iptables rule counts inside the container")
+		cnetnsInfoOutput  = cnetnsInfo.Flag("output", "Output format, text or json").Short('o').Default("text").Enum("text", "json")
+		cnetnsInfoSELinux = cnetnsInfo.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
+		cnetnsCollect     = cnetns.Command("collect", "Collect network namespace info and print it as JSON").Hidden()
+
+		// metrics
+		cmetrics           = app.Command("metrics", "Prometheus metrics utilities")
+		cmetricsDump       = cmetrics.Command("dump", "Collect a one-shot snapshot of the registered Prometheus metrics")
+		cmetricsDumpAddr   = cmetricsDump.Flag("addr", "Address of the agent's metrics endpoint").Default("127.0.0.1:7580").String()
+		cmetricsDumpOutput = cmetricsDump.Flag("output", "Output format, text or json").Short('o').Default("text").Enum("text", "json")
+
+		// wait for critical units to become ready
+		cwait        = app.Command("wait", "Wait for planet's critical systemd units to become active")
+		cwaitTimeout = cwait.Flag("timeout", "Maximum time to wait for units to become active").Default("3m").Duration()
+		cwaitUnits   = List(cwait.Flag("unit", "Systemd unit required to be active (may be repeated); defaults to etcd, kubelet and docker").
+				Default(ETCDServiceName, KubeletServiceName, DefaultDockerUnit).
+				OverrideDefaultFromEnvar("PLANET_WAIT_UNITS"))
+		cwaitSELinux      = cwait.Flag("selinux", "Turn on SELinux support").Envar(EnvPlanetSELinux).Bool()
+		cwaitCollect      = cwait.Command("collect", "Collect the active state of the given units and print it as JSON").Hidden()
+		cwaitCollectUnits = cwaitCollect.Arg("unit", "Systemd unit to check").Required().Strings()
+
 		// etcd related commands
 		cetcd = app.Command("etcd", "Commands related to etcd")
 
@@ -245,7 +441,16 @@ func run() error {
 		cetcdEnableUpgrade    = cetcdEnable.Flag("upgrade", "enable the upgrade service").Bool()
 		cetcdEnableJoinMaster = cetcdEnable.Flag("join-master", "join this node to an existing master node").String()
 
-		cetcdUpgrade  = cetcd.Command("upgrade", "Upgrade etcd to the latest version")
+		cetcdStatus       = cetcd.Command("status", "Show etcd cluster status: members, leader, DB size and alarms")
+		cetcdStatusOutput = cetcdStatus.Flag("output", "Output format, text or json").Short('o').Default("text").Enum("text", "json")
+
+		cetcdSnapshot       = cetcd.Command("snapshot", "Write a consistent etcd snapshot to a file")
+		cetcdSnapshotOutput = cetcdSnapshot.Flag("output", "Path to write the snapshot .db file to").Required().String()
+
+		cetcdUpgrade          = cetcd.Command("upgrade", "Upgrade etcd to the latest version")
+		cetcdUpgradeToVersion = cetcdUpgrade.Flag("to", "Orchestrate the full upgrade to this etcd version automatically (backup, stop, restore, restart, verify) instead of just switching the target version for an externally driven upgrade").String()
+		cetcdUpgradeRollback  = cetcdUpgrade.Flag("rollback", "Automatically roll back to the previous etcd version (stop, switch data directory, restart, verify)").Bool()
+
 		cetcdRollback = cetcd.Command("rollback", "Rollback etcd to the previous release")
 
 		cetcdRestore     = cetcd.Command("restore", "Restore etcd backup as part of the upgrade")
@@ -266,6 +471,19 @@ func run() error {
 		cleaderResume        = cleader.Command("resume", "Resume leader election participation for this node")
 		cleaderView          = cleader.Command("view", "Display the IP address of the active master")
 		cleaderViewKey       = cleaderView.Flag("leader-key", "Etcd key holding the new leader").Required().String()
+
+		// maintenance mode
+		cmaintenance         = app.Command("maintenance", "Suppress degraded-status alerts during planned maintenance")
+		cmaintenanceOn       = cmaintenance.Command("on", "Enable maintenance mode")
+		cmaintenanceOnReason = cmaintenanceOn.Flag("reason", "Optional note describing why maintenance mode was enabled").String()
+		cmaintenanceOnTTL    = cmaintenanceOn.Flag("ttl", "Automatically end maintenance mode after this long (0 disables auto-expiry)").Default("0").Duration()
+		cmaintenanceOff      = cmaintenance.Command("off", "Disable maintenance mode")
+
+		// update-resources updates the resource limits of the already-running container
+		cupdateResources           = app.Command("update-resources", "Update the resource limits of the running planet container")
+		cupdateResourcesMemLimit   = cupdateResources.Flag("memory-limit", "Cap the container's total memory usage, e.g. \"4G\". Unset leaves the current limit in place").String()
+		cupdateResourcesMemReserve = cupdateResources.Flag("memory-reserve-host", "Guarantee this much memory, e.g. \"2G\", stays free on the host by capping the container to the host's total memory minus this amount").String()
+		cupdateResourcesCPUQuota   = cupdateResources.Flag("cpu-quota", "Cap the container's CPU usage as a percentage of a single CPU, e.g. \"200%\" for two full CPUs. Unset leaves the current quota in place").String()
 	)
 
 	args, extraArgs := cstrings.SplitAt(os.Args[1:], "--")
@@ -276,10 +494,99 @@ func run() error {
 
 	initLogging(*debug)
 
+	if cmd != cinit.FullCommand() {
+		utils.SetGOMAXPROCS()
+	}
+
 	if *profileEndpoint != "" {
+		if err := checkLoopbackEndpoint(*profileEndpoint); err != nil {
+			return trace.Wrap(err)
+		}
+		registerLogLevelHandler()
+		profileListener, err := net.Listen("tcp", *profileEndpoint)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		profileServer := &http.Server{Addr: *profileEndpoint}
 		go func() {
-			log.Error(http.ListenAndServe(*profileEndpoint, nil))
+			if err := profileServer.Serve(profileListener); err != nil && err != http.ErrServerClosed {
+				log.Error(err)
+			}
+		}()
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := profileServer.Shutdown(ctx); err != nil {
+				log.WithError(err).Warn("Failed to shut down the profiling endpoint.")
+			}
 		}()
+
+		if *upgradeHandoverSocket != "" {
+			if tcpListener, ok := profileListener.(*net.TCPListener); ok {
+				handoverCtx, cancelHandover := context.WithCancel(context.Background())
+				defer cancelHandover()
+				go func() {
+					if err := serveHandoverRequests(handoverCtx, *upgradeHandoverSocket, tcpListener); err != nil && handoverCtx.Err() == nil {
+						log.WithError(err).Warn("Stopped offering the profiling endpoint for handover.")
+						return
+					}
+					if handoverCtx.Err() == nil {
+						ctx, cancel := context.WithTimeout(context.Background(), handoverShutdownGrace)
+						defer cancel()
+						if err := profileServer.Shutdown(ctx); err != nil {
+							log.WithError(err).Warn("Failed to shut down the profiling endpoint after handover.")
+						}
+					}
+				}()
+			} else {
+				log.Warn("--upgrade-handover-socket requires a TCP profiling endpoint, disabling handover.")
+			}
+		}
+	}
+
+	var cstartPublicIPsSecondary []string
+	if cmd == cstart.FullCommand() && *cstartBindInterface != "" {
+		ip, secondary, err := resolveInterfaceIPs(*cstartBindInterface)
+		if err != nil {
+			return trace.Wrap(err, "failed to resolve --bind-interface %q", *cstartBindInterface)
+		}
+		cstartPublicIP = &ip
+		if secondary != nil {
+			cstartPublicIPsSecondary = []string{secondary.String()}
+		}
+	} else if cmd == cstart.FullCommand() && !emptyIP(cstartPublicIP) {
+		if err := validateLocalAddr(*cstartPublicIP); err != nil {
+			return trace.Wrap(err, "invalid --public-ip")
+		}
+	}
+
+	if cmd == cstart.FullCommand() && len(*cstartPublicIPSecondary) > 0 {
+		if cstartPublicIPsSecondary != nil {
+			return trace.BadParameter("--public-ip-secondary cannot be combined with --bind-interface, which already resolves a secondary address where available")
+		}
+		for _, addr := range *cstartPublicIPSecondary {
+			ip := net.ParseIP(addr)
+			if ip == nil {
+				return trace.BadParameter("--public-ip-secondary: %q is not a valid IP address", addr)
+			}
+			if err := validateLocalAddr(ip); err != nil {
+				return trace.Wrap(err, "invalid --public-ip-secondary")
+			}
+			if !emptyIP(cstartPublicIP) && sameIPFamily(*cstartPublicIP, ip) {
+				return trace.BadParameter("--public-ip-secondary %v is the same address family as --public-ip %v, dual-stack requires one of each", ip, *cstartPublicIP)
+			}
+		}
+		cstartPublicIPsSecondary = *cstartPublicIPSecondary
+	}
+
+	if cmd == cstart.FullCommand() && *cstartMasterDiscovery != "" {
+		discoveredIP, err := discoverMasterIP(context.Background(), *cstartMasterDiscovery)
+		if err != nil {
+			log.WithError(err).Warn("Master discovery failed, falling back to --master-ip.")
+		} else {
+			ip := net.ParseIP(discoveredIP)
+			cstartMasterIP = &ip
+		}
 	}
 
 	if emptyIP(cstartMasterIP) {
@@ -291,11 +598,22 @@ func run() error {
 
 	// "version" command
 	case cversion.FullCommand():
-		version.Print()
+		err = printVersion(*cversionOutput)
 
 	// "agent" command
 	case cagent.FullCommand():
-		cache := inmemory.New()
+		agentCache := newAgentCache(context.TODO(), *cagentCache, *cagentTimelineDir)
+		if *cagentStatusWebhook != "" {
+			var webhook *StatusWebhook
+			webhook, err = NewStatusWebhook(StatusWebhookConfig{
+				URL:     *cagentStatusWebhook,
+				Timeout: *cagentStatusWebhookTimeout,
+			})
+			if err != nil {
+				break
+			}
+			agentCache = agentcache.NewNotifying(agentCache, webhook.Notify)
+		}
 		if *cagentKubeAddr == "" {
 			*cagentKubeAddr = "127.0.0.1:8080"
 		}
@@ -332,7 +650,7 @@ func run() error {
 				Name:        *cagentNodeName,
 				RPCAddrs:    *cagentRPCAddrs,
 				MetricsAddr: *cagentMetricsAddr,
-				Cache:       cache,
+				Cache:       agentCache,
 				CAFile:      *cagentEtcdCAFile,
 				CertFile:    *cagentEtcdCertFile,
 				KeyFile:     *cagentEtcdKeyFile,
@@ -350,16 +668,35 @@ func run() error {
 				DNSZones:              (map[string][]string)(*cagentDNSZones),
 				RegistryAddr:          fmt.Sprintf("https://%v", *cagentRegistryAddr),
 				NettestContainerImage: fmt.Sprintf("%v/gcr.io/google_containers/nettest:1.8", *cagentRegistryAddr),
+				PauseContainerImage:   fmt.Sprintf("%v/gcr.io/google_containers/pause:3.2", *cagentRegistryAddr),
 				ETCDConfig:            etcdConf,
-				DisableInterPodCheck:  disableInterPodCheck,
-				CloudProvider:         *cagentCloudProvider,
-				LowWatermark:          uint(*cagentLowWatermark),
-				HighWatermark:         uint(*cagentHighWatermark),
-				NodeName:              *cagentNodeName,
-				HTTPTimeout:           *cagentHTTPTimeout,
-				ServiceUID:            *cagentServiceUID,
-				ServiceGID:            *cagentServiceGID,
-				CriticalNamespaces:    *cagentCriticalNamespaces,
+				PKIComponentCerts: map[string]string{
+					"etcd":      etcdConf.CertFile,
+					"apiserver": APIServerCertPath,
+					"rpc":       ClientRPCCertPath,
+				},
+				DisableInterPodCheck:      disableInterPodCheck,
+				CloudProvider:             *cagentCloudProvider,
+				LowWatermark:              uint(*cagentLowWatermark),
+				HighWatermark:             uint(*cagentHighWatermark),
+				NodeName:                  *cagentNodeName,
+				HTTPTimeout:               *cagentHTTPTimeout,
+				ServiceUID:                *cagentServiceUID,
+				ServiceGID:                *cagentServiceGID,
+				CriticalNamespaces:        *cagentCriticalNamespaces,
+				PodSubnet:                 cagentPodCIDR.ipNet,
+				ServiceSubnet:             cagentServiceCIDR.ipNet,
+				RepairIPTables:            *cagentRepairIPTables,
+				CriticalSystemdUnits:      []string{ETCDServiceName, KubeletServiceName, DefaultDockerUnit},
+				FixSysctls:                *cagentFixSysctls,
+				DisabledMetricsCollectors: *cagentDisabledMetricsCollectors,
+				KubeProxyHealthzAddr:      *cagentKubeProxyHealthzAddr,
+				DisableKubeProxyCheck:     *cagentDisableKubeProxyCheck,
+				ExecCheckerDir:            *cagentExecChecksDir,
+				ExecCheckerTimeout:        *cagentExecChecksTimeout,
+				RootFSCheckPaths:          []string{"/", DefaultSecretsMountDir},
+				CheckerConfigFile:         *cagentCheckerConfigFile,
+				DockerStorageDriver:       *cagentDockerStorageDriver,
 			},
 			leader: &LeaderConfig{
 				PublicIP:         cagentPublicIP.String(),
@@ -372,8 +709,27 @@ func run() error {
 				ElectionEnabled:  bool(*cagentElectionEnabled),
 				HighAvailability: bool(*cagentHighAvailability),
 			},
-			peers:       toAddrList(*cagentInitialCluster),
-			serviceCIDR: cagentServiceCIDR.ipNet,
+			peers:             toAddrList(*cagentInitialCluster),
+			serviceCIDR:       cagentServiceCIDR.ipNet,
+			noCluster:         *cagentNoCluster,
+			disableNodeEvents: *cagentDisableNodeEvents,
+			dnsSetupTimeout:   *cagentDNSSetupTimeout,
+			hostsSync: hostsSyncConfig{
+				Disabled:  *cagentDisableHostsSync,
+				Interval:  *cagentHostsSyncInterval,
+				ClusterID: *cagentClusterID,
+			},
+			certRotation: certRotationConfig{
+				Threshold:     *cagentCertRotationThreshold,
+				CheckInterval: *cagentCertRotationCheckInterval,
+				TTL:           *cagentCertRotationTTL,
+				CAFile:        *cagentCertRotationCAFile,
+				CAKeyFile:     *cagentCertRotationCAKeyFile,
+				PublicIP:      cagentPublicIP.String(),
+				LeaderKey:     *cagentLeaderKey,
+				ETCD:          etcdConf,
+			},
+			disabledCheckers: *cagentDisableChecker,
 		}
 		err = runAgent(config)
 
@@ -399,9 +755,17 @@ func run() error {
 		}
 		err = leaderView(*cleaderViewKey, etcdConf)
 
+	case cmaintenanceOn.FullCommand():
+		err = enableMaintenance(*cmaintenanceOnReason, *cmaintenanceOnTTL)
+	case cmaintenanceOff.FullCommand():
+		err = disableMaintenance()
+
+	case cupdateResources.FullCommand():
+		err = updateResources(*cupdateResourcesMemLimit, *cupdateResourcesMemReserve, *cupdateResourcesCPUQuota)
+
 	// "start" command
 	case cstart.FullCommand():
-		if emptyIP(cstartPublicIP) && os.Getpid() > 5 {
+		if emptyIP(cstartPublicIP) && *cstartFromConfig == "" && os.Getpid() > 5 {
 			err = trace.Errorf("public-ip is not set")
 			break
 		}
@@ -412,7 +776,6 @@ func run() error {
 		if err != nil {
 			break
 		}
-		setupSignalHandlers(*cstartSELinux)
 		initialCluster := *cstartEtcdInitialCluster
 		if initialCluster == nil {
 			initialCluster = *cstartInitialCluster
@@ -426,6 +789,7 @@ func run() error {
 			Roles:                *cstartRoles,
 			MasterIP:             cstartMasterIP.String(),
 			PublicIP:             cstartPublicIP.String(),
+			PublicIPs:            cstartPublicIPsSecondary,
 			CloudProvider:        *cstartCloudProvider,
 			ClusterID:            *cstartClusterID,
 			GCENodeTags:          *cstartGCENodeTags,
@@ -458,16 +822,23 @@ func run() error {
 				Zones:       *cstartDNSZones,
 				ListenAddrs: *cstartDNSListenAddrs,
 				Port:        *cstartDNSPort,
+				Ndots:       *cstartDNSNdots,
+				Timeout:     *cstartDNSTimeout,
+				Attempts:    *cstartDNSAttempts,
 			},
 			KubeletOptions:     *cstartKubeletOptions,
 			APIServerOptions:   *cstartAPIServerOptions,
+			KubeletArgs:        *cstartKubeletArgs,
+			APIServerArgs:      *cstartAPIServerArgs,
 			Taints:             *cstartTaints,
 			NodeLabels:         *cstartNodeLabels,
 			DisableFlannel:     *cstartDisableFlannel,
 			KubeletConfig:      *cstartKubeletConfig,
 			CloudConfig:        *cstartCloudConfig,
+			CloudConfigPath:    *cstartCloudConfigFile,
 			AllowPrivileged:    *cstartAllowPrivileged,
 			SELinux:            *cstartSELinux,
+			ReadonlyRootfs:     *cstartReadonlyRootfs,
 			HighAvailability:   *cstartHighAvailability,
 			FlannelBackend:     *cstartFlannelBackend,
 			EncryptionProvider: *cstartEncryptionProvider,
@@ -476,55 +847,207 @@ func run() error {
 				KeyID:     *cstartAWSKeyID,
 				Region:    *cstartAWSKeyRegion,
 			},
+			CapAdd:            *cstartCapAdd,
+			CapDrop:           *cstartCapDrop,
+			MemoryLimit:       *cstartMemoryLimit,
+			MemoryReserveHost: *cstartMemoryReserveHost,
+			CPUQuota:          *cstartCPUQuota,
+		}
+		if *cstartFromConfig != "" {
+			var base *Config
+			base, err = loadStartConfig(*cstartFromConfig)
+			if err != nil {
+				err = trace.Wrap(err, "failed to load --from-config %v", *cstartFromConfig)
+				break
+			}
+			merged := mergeFromConfig(*config, *base)
+			config = &merged
+		}
+		if config.PublicIP == "" && os.Getpid() > 5 {
+			err = trace.Errorf("public-ip is not set")
+			break
+		}
+		setupSignalHandlers(config)
+		if *cstartForeground || os.Getenv(EnvPlanetDaemonChild) != "" {
+			err = runForeground(config)
+		} else {
+			daemonArgs := args
+			if len(extraArgs) > 0 {
+				daemonArgs = append(append([]string{}, args...), append([]string{"--"}, extraArgs...)...)
+			}
+			err = daemonize(daemonArgs, *cstartLogMaxSizeMB, *cstartLogMaxBackups)
 		}
-		err = startAndWait(config)
 
 	// "init" command
 	case cinit.FullCommand():
 		err = box.Init()
 
+	// "config show" command
+	case cconfigShow.FullCommand():
+		rootfs, err = findRootfs()
+		if err != nil {
+			break
+		}
+		err = showConfig(rootfs)
+
 	// "enter" command
 	case center.FullCommand():
 		err = enterConsole(enterConfig{
-			cmd:     *centerCmd,
-			user:    *centerUser,
-			tty:     !*centerNoTTY,
-			stdin:   true,
-			args:    extraArgs,
-			seLinux: *centerSELinux,
+			cmd:              *centerCmd,
+			user:             *centerUser,
+			tty:              !*centerNoTTY,
+			stdin:            true,
+			args:             extraArgs,
+			seLinux:          *centerSELinux,
+			unit:             *centerUnit,
+			listUnits:        *centerListUnits,
+			readonly:         *centerReadonly,
+			capAdd:           *centerCapAdd,
+			capDrop:          *centerCapDrop,
+			noNewPrivileges:  *centerNoNewPrivileges,
+			outputBufferSize: *centerOutputBufferSize,
+		})
+
+	// "top" command
+	case ctop.FullCommand():
+		err = top(topConfig{
+			seLinux:  *ctopSELinux,
+			once:     *ctopOnce,
+			interval: *ctopInterval,
 		})
 
 	// "exec" command
 	case cexec.FullCommand():
 		err = enterConsole(enterConfig{
-			cmd:     *cexecCmd,
-			user:    *cexecUser,
-			tty:     *cexecTTY,
-			stdin:   *cexecStdin,
-			args:    *cexecArgs,
-			seLinux: *cexecSELinux,
+			cmd:              *cexecCmd,
+			user:             *cexecUser,
+			tty:              *cexecTTY,
+			stdin:            *cexecStdin,
+			args:             *cexecArgs,
+			seLinux:          *cexecSELinux,
+			readonly:         *cexecReadonly,
+			capAdd:           *cexecCapAdd,
+			capDrop:          *cexecCapDrop,
+			noNewPrivileges:  *cexecNoNewPrivileges,
+			outputBufferSize: *cexecOutputBufferSize,
 		})
 
+	// "logs" command
+	case clogs.FullCommand():
+		err = logs(logsConfig{
+			unit:    *clogsUnit,
+			since:   *clogsSince,
+			lines:   *clogsLines,
+			seLinux: *clogsSELinux,
+		})
+
+	// "loglevel get" command
+	case cloglevelGet.FullCommand():
+		var level string
+		level, err = getLogLevel(*cloglevelGetEndpoint)
+		if err == nil {
+			fmt.Println(level)
+		}
+
+	// "loglevel set" command
+	case cloglevelSet.FullCommand():
+		err = setLogLevel(*cloglevelSetEndpoint, *cloglevelSetLevel)
+
+	// "check" command
+	case ccheck.FullCommand():
+		var probes []byte
+		probes, err = runChecker(*ccheckEndpoint, *ccheckName)
+		if err == nil {
+			fmt.Println(string(probes))
+		}
+
+	// "rotate-certs" command
+	case crotateCerts.FullCommand():
+		err = rotateCerts(managedCerts, certRotationConfig{
+			CAFile:    *crotateCertsCAFile,
+			CAKeyFile: *crotateCertsCAKey,
+			TTL:       *crotateCertsTTL,
+			Threshold: *crotateCertsMinValid,
+		}, time.Now())
+
+	// "dns diff" command
+	case cdnsDiff.FullCommand():
+		var client *kubernetes.Clientset
+		client, err = satellitecmd.GetKubeClientFromPath(constants.KubeletConfigPath)
+		if err == nil {
+			err = diffDNSAddresses(context.TODO(), client, agent.Role(*cdnsDiffRole), cdnsDiffServiceCIDR.ipNet)
+		}
+
+	// "validate pod-cidr" command
+	case cvalidatePodCIDR.FullCommand():
+		var client *kubernetes.Clientset
+		client, err = satellitecmd.GetKubeClientFromPath(constants.KubeletConfigPath)
+		if err == nil {
+			err = validatePodCIDRAllocations(context.TODO(), client, cvalidatePodCIDRPodSubnet.ipNet)
+		}
+
+	// "validate system-pods" command
+	case cvalidateSystemPods.FullCommand():
+		var client *kubernetes.Clientset
+		client, err = satellitecmd.GetKubeClientFromPath(constants.KubeletConfigPath)
+		if err == nil {
+			err = validateSystemPods(context.TODO(), client)
+		}
+
 	// "stop" command
 	case cstop.FullCommand():
-		err = stop(*cstopSELinux)
+		var shutdownOrder []ShutdownUnit
+		shutdownOrder, err = parseShutdownOrder(*cstopShutdownOrder)
+		if err != nil {
+			break
+		}
+		err = stop(*cstopSELinux, shutdownOrder)
+
+	// "restart" command
+	case crestart.FullCommand():
+		var shutdownOrder []ShutdownUnit
+		shutdownOrder, err = parseShutdownOrder(*crestartShutdownOrder)
+		if err != nil {
+			break
+		}
+		err = restart(*crestartSELinux, shutdownOrder, *crestartLogMaxSizeMB, *crestartLogMaxBackups)
 
 	// "status" command
 	case cstatus.FullCommand():
+		sc := statusConfig{
+			rpcPort:            *cstatusRPCPort,
+			local:              *cstatusLocal,
+			format:             statusFormat(*cstatusFormat),
+			timeout:            *cstatusTimeout,
+			caFile:             *cstatusCAFile,
+			clientCertFile:     *cstatusClientCertFile,
+			clientKeyFile:      *cstatusClientKeyFile,
+			memberFailureGrace: *cstatusMemberFailureGrace,
+			pretty:             *cstatusPretty,
+			verbose:            *cstatusVerbose,
+		}
+		if *cstatusWatch {
+			err = watchStatus(watchStatusConfig{statusConfig: sc, interval: *cstatusWatchInterval})
+			break
+		}
 		var ok bool
-		ok, err = status(statusConfig{
-			rpcPort:        *cstatusRPCPort,
-			local:          *cstatusLocal,
-			prettyPrint:    *cstatusPrettyPrint,
-			timeout:        *cstatusTimeout,
-			caFile:         *cstatusCAFile,
-			clientCertFile: *cstatusClientCertFile,
-			clientKeyFile:  *cstatusClientKeyFile,
-		})
+		ok, err = status(sc)
 		if err == nil && !ok {
 			err = trace.Errorf("status degraded")
 		}
 
+	// "checker-history" command
+	case ccheckerHistory.FullCommand():
+		err = checkerHistory(checkerHistoryConfig{
+			rpcPort:        *ccheckerHistoryRPCPort,
+			checker:        *ccheckerHistoryName,
+			timeout:        *ccheckerHistoryTimeout,
+			prettyPrint:    *ccheckerHistoryPrettyPrint,
+			caFile:         *ccheckerHistoryCAFile,
+			clientCertFile: *ccheckerHistoryClientCertFile,
+			clientKeyFile:  *ccheckerHistoryClientKeyFile,
+		})
+
 	// "test" command
 	case ctest.FullCommand():
 		config := &e2e.Config{
@@ -534,6 +1057,28 @@ func run() error {
 		}
 		err = e2e.RunTests(config, extraArgs)
 
+	// "cleanup" command
+	case ccleanup.FullCommand():
+		var client *kubernetes.Clientset
+		client, err = monitoring.GetPrivilegedKubeClient()
+		if err != nil {
+			break
+		}
+		err = cleanup(client)
+
+	case cdebugPprof.FullCommand():
+		err = capturePprofProfiles(*cdebugPprofEndpoint, *cdebugPprofOutDir, *cdebugPprofCPU)
+
+	case cupgradeDaemon.FullCommand():
+		err = upgradeDaemon(*cupgradeDaemonSocket)
+
+	case cdebugDiag.FullCommand():
+		err = diag(diagConfig{
+			output:  *cdebugDiagOutput,
+			rpcPort: *cdebugDiagRPCPort,
+			seLinux: *cdebugDiagSELinux,
+		})
+
 	case cdeviceAdd.FullCommand():
 		var device configs.Device
 		if err = json.Unmarshal([]byte(*cdeviceAddData), &device); err != nil {
@@ -544,6 +1089,21 @@ func run() error {
 	case cdeviceRemove.FullCommand():
 		err = removeDevice(*cdeviceRemoveNode)
 
+	case cnetnsInfo.FullCommand():
+		err = netnsInfo(*cnetnsInfoOutput, *cnetnsInfoSELinux)
+
+	case cnetnsCollect.FullCommand():
+		err = netnsCollect()
+
+	case cmetricsDump.FullCommand():
+		err = metricsDump(*cmetricsDumpAddr, *cmetricsDumpOutput)
+
+	case cwait.FullCommand():
+		err = waitForReady(*cwaitUnits, *cwaitTimeout, *cwaitSELinux)
+
+	case cwaitCollect.FullCommand():
+		err = waitCollect(*cwaitCollectUnits)
+
 	case cetcdInit.FullCommand():
 		err = etcdInit()
 
@@ -556,8 +1116,21 @@ func run() error {
 	case cetcdDisable.FullCommand():
 		err = etcdDisable(*cetcdDisableUpgrade, *cetcdStopApiserver)
 
+	case cetcdStatus.FullCommand():
+		err = etcdStatus(*cetcdStatusOutput)
+
+	case cetcdSnapshot.FullCommand():
+		err = etcdSnapshot(*cetcdSnapshotOutput)
+
 	case cetcdUpgrade.FullCommand():
-		err = etcdUpgrade(false)
+		switch {
+		case *cetcdUpgradeRollback:
+			err = etcdUpgradeRollback()
+		case *cetcdUpgradeToVersion != "":
+			err = etcdUpgradeTo(*cetcdUpgradeToVersion)
+		default:
+			err = etcdUpgrade(false)
+		}
 
 	case cetcdRollback.FullCommand():
 		err = etcdUpgrade(true)
@@ -649,10 +1222,13 @@ func findRootfs() (string, error) {
 }
 
 // setupSignalHandlers sets up a handler to handle common unix process signal traps.
-// Some signals are handled to avoid the default handling which might be termination (SIGPIPE, SIGHUP, etc)
+// Some signals are handled to avoid the default handling which might be termination (SIGPIPE, etc)
+// SIGHUP triggers a reload of the settings reload() knows how to re-read without a restart
+// (DNS upstream nameservers, /etc/hosts entries). Every other setting "planet start" was given
+// is only read once at start and requires a full restart (stop, then start again) to change.
 // The rest are considered as termination signals and the handler initiates shutdown upon receiving
 // such a signal.
-func setupSignalHandlers(seLinux bool) {
+func setupSignalHandlers(config *Config) {
 	oneOf := func(list []os.Signal, sig os.Signal) bool {
 		for _, signal := range list {
 			if signal == sig {
@@ -662,17 +1238,22 @@ func setupSignalHandlers(seLinux bool) {
 		return false
 	}
 
-	var ignores = []os.Signal{syscall.SIGPIPE, syscall.SIGHUP, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGALRM}
+	var ignores = []os.Signal{syscall.SIGPIPE, syscall.SIGUSR1, syscall.SIGUSR2, syscall.SIGALRM}
 	var terminals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT}
 	c := make(chan os.Signal, 1)
 	go func() {
 		for sig := range c {
 			switch {
+			case sig == syscall.SIGHUP:
+				log.Info("received a SIGHUP signal, reloading configuration...")
+				if err := reload(config); err != nil {
+					log.WithError(err).Error("Failed to reload configuration.")
+				}
 			case oneOf(ignores, sig):
 				log.Debugf("received a %s signal, ignoring...", sig)
 			default:
 				log.Infof("received a %s signal, stopping...", sig)
-				err := stop(seLinux)
+				err := stop(config.SELinux, nil)
 				if err != nil {
 					log.Errorf("error: %v", err)
 				}
@@ -680,7 +1261,7 @@ func setupSignalHandlers(seLinux bool) {
 			}
 		}
 	}()
-	signal.Notify(c, append(ignores, terminals...)...)
+	signal.Notify(c, append(append(ignores, terminals...), syscall.SIGHUP)...)
 }
 
 func emptyIP(addr *net.IP) bool {
@@ -710,7 +1291,12 @@ func initLogging(debug bool) {
 
 // die prints the error message in red to the console and exits with a non-zero exit code
 func die(err error) {
+	dieWithCode(err, 255)
+}
+
+// dieWithCode prints the error message in red to the console and exits with code
+func dieWithCode(err error, code int) {
 	log.WithError(err).Warn("Failed to run.")
 	color.Red("[ERROR]: %v\n", trace.UserMessage(err))
-	os.Exit(255)
+	os.Exit(code)
 }
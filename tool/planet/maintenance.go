@@ -0,0 +1,141 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/planet/lib/utils"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+
+	"github.com/gravitational/trace"
+)
+
+// maintenanceStateFile records whether maintenance mode is active. It lives
+// alongside the RPC client credentials rather than inside the container's
+// rootfs, since - like them - it needs to be readable by a freshly started
+// "planet status"/"planet maintenance" process, not just the long-running
+// agent.
+var maintenanceStateFile = filepath.Join(DefaultSecretsMountDir, "maintenance.json")
+
+// maintenanceState is the on-disk representation of an active maintenance
+// window.
+type maintenanceState struct {
+	// Since is when maintenance mode was enabled.
+	Since time.Time `json:"since"`
+	// Reason is an optional operator-supplied note explaining the window.
+	Reason string `json:"reason,omitempty"`
+	// ExpiresAt, if set, is when maintenance mode ends on its own.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// active reports whether m describes a maintenance window that is still in
+// effect. A nil m (no window recorded) is never active.
+func (m *maintenanceState) active() bool {
+	if m == nil {
+		return false
+	}
+	return m.ExpiresAt == nil || time.Now().Before(*m.ExpiresAt)
+}
+
+// enableMaintenance persists a new maintenance window, overwriting any
+// existing one. A zero ttl means the window has no auto-expiry and lasts
+// until "planet maintenance off" is run.
+func enableMaintenance(reason string, ttl time.Duration) error {
+	state := maintenanceState{Since: time.Now().UTC(), Reason: reason}
+	if ttl > 0 {
+		expiresAt := state.Since.Add(ttl)
+		state.ExpiresAt = &expiresAt
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	if err := utils.SafeWriteFile(maintenanceStateFile, data, SharedFileMask); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
+
+// disableMaintenance ends the current maintenance window, if any. It is not
+// an error to call this when no window is active.
+func disableMaintenance() error {
+	if err := os.Remove(maintenanceStateFile); err != nil && !os.IsNotExist(err) {
+		return trace.ConvertSystemError(err)
+	}
+	return nil
+}
+
+// readMaintenance loads the persisted maintenance window, if any. It
+// returns nil, nil when no window has ever been recorded.
+func readMaintenance() (*maintenanceState, error) {
+	data, err := ioutil.ReadFile(maintenanceStateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	var state maintenanceState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &state, nil
+}
+
+// activeMaintenance returns m if it describes a window that is still in
+// effect, or nil otherwise - so an expired window is silently dropped from
+// status output instead of being reported as still active.
+func activeMaintenance(m *maintenanceState) *maintenanceState {
+	if !m.active() {
+		return nil
+	}
+	return m
+}
+
+// nodeStatusReport is what "planet status --local" renders: a node's raw
+// status alongside whether maintenance mode is currently suppressing
+// alerts for it. satellite's own computation of NodeStatus.Status is
+// vendored and unaware of maintenance mode, so this is layered on as an
+// annotation here rather than changed at the source.
+type nodeStatusReport struct {
+	*pb.NodeStatus
+	Maintenance *maintenanceState `json:"maintenance,omitempty"`
+	// ResourceLimits are the resource limits this node's container was
+	// started with, if any (see resources.go). Not part of cluster-wide
+	// status since it's local, per-node configuration.
+	ResourceLimits *ResourceLimits `json:"resourceLimits,omitempty"`
+	// Subsystems rolls up this node's probes by subsystem (etcd,
+	// networking, kubernetes, etc. - see groupProbesBySubsystem), so a
+	// long flat probe list doesn't have to be read in full to see where a
+	// problem lies.
+	Subsystems []subsystemReport `json:"subsystems,omitempty"`
+}
+
+// clusterStatusReport is the cluster-wide equivalent of nodeStatusReport.
+type clusterStatusReport struct {
+	*pb.SystemStatus
+	Maintenance *maintenanceState `json:"maintenance,omitempty"`
+	// Subsystems rolls up every node's probes by subsystem, keyed by node
+	// name, the cluster-wide equivalent of nodeStatusReport.Subsystems.
+	Subsystems map[string][]subsystemReport `json:"subsystems,omitempty"`
+}
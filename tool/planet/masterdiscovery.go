@@ -0,0 +1,109 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gravitational/planet/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// masterDiscoveryAttempts bounds how many times discoverMasterIP retries a
+// failing (or malformed) discovery endpoint before giving up.
+const masterDiscoveryAttempts = 10
+
+// masterDiscoveryRetryPeriod is how long discoverMasterIP waits between
+// attempts.
+const masterDiscoveryRetryPeriod = 3 * time.Second
+
+// masterDiscoveryTimeout bounds a single request to the discovery endpoint.
+const masterDiscoveryTimeout = 5 * time.Second
+
+// discoverMasterIP fetches the current master IP from url, retrying on
+// failure or a malformed response. It returns the first address in the
+// response, so a discovery endpoint that reports multiple masters (e.g.
+// during a failover) is expected to list the preferred one first.
+func discoverMasterIP(ctx context.Context, url string) (ip string, err error) {
+	err = utils.Retry(ctx, masterDiscoveryAttempts, masterDiscoveryRetryPeriod, func() error {
+		ips, err := fetchMasterIPs(ctx, url)
+		if err != nil {
+			log.WithError(err).Warn("Failed to query master discovery endpoint.")
+			return trace.Wrap(err)
+		}
+		ip = ips[0]
+		return nil
+	})
+	return ip, trace.Wrap(err)
+}
+
+// fetchMasterIPs queries url and parses its response body as a whitespace-
+// or comma-separated list of IP addresses, validating each one.
+func fetchMasterIPs(ctx context.Context, url string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, masterDiscoveryTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, trace.BadParameter("master discovery endpoint %v returned status %v", url, resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	ips := parseMasterIPList(string(body))
+	if len(ips) == 0 {
+		return nil, trace.BadParameter("master discovery endpoint %v returned no IP addresses", url)
+	}
+	for _, ip := range ips {
+		if net.ParseIP(ip) == nil {
+			return nil, trace.BadParameter("master discovery endpoint %v returned invalid IP address %q", url, ip)
+		}
+	}
+	return ips, nil
+}
+
+// parseMasterIPList splits a discovery response into individual addresses,
+// tolerating commas, newlines and surrounding whitespace.
+func parseMasterIPList(body string) (ips []string) {
+	for _, field := range strings.FieldsFunc(body, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r' || r == ' ' || r == '\t'
+	}) {
+		if field != "" {
+			ips = append(ips, field)
+		}
+	}
+	return ips
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParseMasterIPList(t *testing.T) {
+	cases := []struct {
+		body     string
+		expected []string
+	}{
+		{"10.0.0.1", []string{"10.0.0.1"}},
+		{"10.0.0.1,10.0.0.2", []string{"10.0.0.1", "10.0.0.2"}},
+		{"10.0.0.1\n10.0.0.2\n", []string{"10.0.0.1", "10.0.0.2"}},
+		{" 10.0.0.1 , 10.0.0.2 ", []string{"10.0.0.1", "10.0.0.2"}},
+		{"", nil},
+	}
+	for _, c := range cases {
+		if got := parseMasterIPList(c.body); !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("parseMasterIPList(%q) = %v, expected %v", c.body, got, c.expected)
+		}
+	}
+}
+
+func TestFetchMasterIPsValidatesAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("not-an-ip"))
+	}))
+	defer server.Close()
+
+	if _, err := fetchMasterIPs(context.Background(), server.URL); err == nil {
+		t.Fatal("expected fetchMasterIPs to reject a non-IP response")
+	}
+}
+
+func TestFetchMasterIPsRejectsEmptyResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	if _, err := fetchMasterIPs(context.Background(), server.URL); err == nil {
+		t.Fatal("expected fetchMasterIPs to reject an empty response")
+	}
+}
+
+func TestFetchMasterIPsReturnsParsedAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("10.0.0.1,10.0.0.2"))
+	}))
+	defer server.Close()
+
+	ips, err := fetchMasterIPs(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("fetchMasterIPs failed: %v", err)
+	}
+	if !reflect.DeepEqual(ips, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Fatalf("unexpected addresses: %v", ips)
+	}
+}
+
+func TestFetchMasterIPsRejectsNonOKStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	if _, err := fetchMasterIPs(context.Background(), server.URL); err == nil {
+		t.Fatal("expected fetchMasterIPs to reject a non-200 status")
+	}
+}
+
+func TestDiscoverMasterIPReturnsFirstAddress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("10.0.0.5,10.0.0.6"))
+	}))
+	defer server.Close()
+
+	ip, err := discoverMasterIP(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("discoverMasterIP failed: %v", err)
+	}
+	if ip != "10.0.0.5" {
+		t.Fatalf("expected 10.0.0.5, got %v", ip)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gravitational/trace"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// metricsDumpTimeout bounds how long metricsDump waits for the metrics
+// endpoint to respond.
+const metricsDumpTimeout = 10 * time.Second
+
+// metricsDump fetches the Prometheus exposition-format snapshot currently
+// served by the agent's metrics endpoint (the registry populated by
+// AddMetrics) and prints it in the requested output format, either "text"
+// (the raw exposition format) or "json" (parsed metric families).
+func metricsDump(addr, output string) error {
+	client := &http.Client{Timeout: metricsDumpTimeout}
+	resp, err := client.Get(fmt.Sprintf("http://%v/metrics", addr))
+	if err != nil {
+		return trace.Wrap(err, "failed to reach metrics endpoint at %v", addr)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return trace.BadParameter("metrics endpoint at %v returned %v", addr, resp.Status)
+	}
+
+	if output == "text" {
+		_, err := io.Copy(os.Stdout, resp.Body)
+		return trace.Wrap(err)
+	}
+
+	families, err := parseMetricsText(resp.Body)
+	if err != nil {
+		return trace.Wrap(err, "failed to parse metrics snapshot")
+	}
+	return trace.Wrap(printMetricFamilies(families))
+}
+
+// parseMetricsText parses a Prometheus exposition-format snapshot into its
+// metric families.
+func parseMetricsText(r io.Reader) (map[string]*dto.MetricFamily, error) {
+	families, err := new(expfmt.TextParser).TextToMetricFamilies(r)
+	return families, trace.Wrap(err)
+}
+
+// printMetricFamilies prints families as a JSON array, sorted by name for
+// stable output.
+func printMetricFamilies(families map[string]*dto.MetricFamily) error {
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ordered := make([]*dto.MetricFamily, 0, len(names))
+	for _, name := range names {
+		ordered = append(ordered, families[name])
+	}
+	payload, err := json.Marshal(ordered)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	fmt.Fprintln(os.Stdout, string(payload))
+	return nil
+}
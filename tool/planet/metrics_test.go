@@ -0,0 +1,51 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMetricsSnapshot = `# HELP planet_test_requests_total Total number of test requests.
+# TYPE planet_test_requests_total counter
+planet_test_requests_total 42
+# HELP planet_test_up Whether the test subsystem is up.
+# TYPE planet_test_up gauge
+planet_test_up 1
+`
+
+func TestParseMetricsTextContainsExpectedFamilies(t *testing.T) {
+	families, err := parseMetricsText(strings.NewReader(testMetricsSnapshot))
+	if err != nil {
+		t.Fatalf("failed to parse metrics: %v", err)
+	}
+	for _, name := range []string{"planet_test_requests_total", "planet_test_up"} {
+		if _, ok := families[name]; !ok {
+			t.Errorf("expected metric family %v in dump, got %v", name, families)
+		}
+	}
+	if got := families["planet_test_requests_total"].GetMetric()[0].GetCounter().GetValue(); got != 42 {
+		t.Errorf("expected planet_test_requests_total=42, got %v", got)
+	}
+}
+
+func TestParseMetricsTextInvalid(t *testing.T) {
+	if _, err := parseMetricsText(strings.NewReader("planet_test_bad_value not_a_number\n")); err == nil {
+		t.Error("expected an error parsing invalid exposition format")
+	}
+}
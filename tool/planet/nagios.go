@@ -0,0 +1,119 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+// Nagios/NRPE plugin exit codes, per the Nagios plugin API.
+const (
+	nagiosOK       = 0
+	nagiosWarning  = 1
+	nagiosCritical = 2
+)
+
+// nagiosNodeStatus formats a single node's status as an NRPE-compatible
+// plugin result: a one-line summary on stdout and one of the nagiosOK,
+// nagiosWarning or nagiosCritical exit codes.
+func nagiosNodeStatus(status *pb.NodeStatus) (string, int) {
+	if status == nil {
+		return "PLANET CRITICAL - no status returned", nagiosCritical
+	}
+	failed := failedProbes(status.Probes)
+	code := nagiosCodeForProbes(status.Status == pb.NodeStatus_Running, failed)
+	return nagiosSummary(code, failed), code
+}
+
+// nagiosClusterStatus formats a cluster-wide status as an NRPE-compatible
+// plugin result, aggregating probes across every node.
+func nagiosClusterStatus(status *pb.SystemStatus) (string, int) {
+	if status == nil {
+		return "PLANET CRITICAL - no status returned", nagiosCritical
+	}
+	var failed []*pb.Probe
+	for _, node := range status.Nodes {
+		failed = append(failed, failedProbes(node.Probes)...)
+	}
+	code := nagiosCodeForProbes(status.Status == pb.SystemStatus_Running, failed)
+	return nagiosSummary(code, failed), code
+}
+
+// failedProbes returns the probes in probes that did not pass.
+func failedProbes(probes []*pb.Probe) []*pb.Probe {
+	var failed []*pb.Probe
+	for _, probe := range probes {
+		if probe.Status != pb.Probe_Running {
+			failed = append(failed, probe)
+		}
+	}
+	return failed
+}
+
+// nagiosCodeForProbes maps a set of failed probes to a Nagios exit code.
+// A probe with Critical severity (or unspecified) maps to CRITICAL, a
+// Warning-severity probe maps to WARNING. If running is true and there are
+// no failed probes, the result is OK regardless.
+func nagiosCodeForProbes(running bool, failed []*pb.Probe) int {
+	if running && len(failed) == 0 {
+		return nagiosOK
+	}
+	code := nagiosWarning
+	for _, probe := range failed {
+		if probe.Severity != pb.Probe_Warning {
+			code = nagiosCritical
+			break
+		}
+	}
+	if len(failed) == 0 {
+		// The aggregate status is not Running, but no individual probe
+		// reported failure - treat this as an unexplained degradation.
+		code = nagiosCritical
+	}
+	return code
+}
+
+// nagiosSummary renders the single summary line NRPE displays for a check.
+func nagiosSummary(code int, failed []*pb.Probe) string {
+	label := nagiosLabel(code)
+	if len(failed) == 0 {
+		return fmt.Sprintf("PLANET %v - all checks passed", label)
+	}
+	names := make([]string, 0, len(failed))
+	for _, probe := range failed {
+		names = append(names, probe.Checker)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("PLANET %v - failed checkers: %v", label, strings.Join(names, ", "))
+}
+
+// nagiosLabel returns the human-readable state name NRPE expects to see
+// alongside the plugin's exit code.
+func nagiosLabel(code int) string {
+	switch code {
+	case nagiosOK:
+		return "OK"
+	case nagiosWarning:
+		return "WARNING"
+	default:
+		return "CRITICAL"
+	}
+}
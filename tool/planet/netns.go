@@ -0,0 +1,217 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/gravitational/planet/lib/box"
+	"github.com/gravitational/planet/lib/constants"
+
+	"github.com/gravitational/trace"
+)
+
+// NetnsInfo describes the state of the container's network namespace, as
+// reported by "planet netns info".
+type NetnsInfo struct {
+	// Interfaces lists the network interfaces visible inside the namespace.
+	Interfaces []NetnsInterface `json:"interfaces"`
+	// Routes lists the routing table entries inside the namespace.
+	Routes []NetnsRoute `json:"routes"`
+	// IPTablesRuleCount is the number of iptables rules currently loaded.
+	IPTablesRuleCount int `json:"iptables_rule_count"`
+}
+
+// NetnsInterface describes a single network interface.
+type NetnsInterface struct {
+	// Name is the interface name, e.g. eth0.
+	Name string `json:"name"`
+	// Addresses lists the interface's addresses in CIDR notation.
+	Addresses []string `json:"addresses"`
+}
+
+// NetnsRoute describes a single routing table entry.
+type NetnsRoute struct {
+	// Destination is the route's destination, e.g. "default" or a CIDR.
+	Destination string `json:"destination"`
+	// Gateway is the next-hop address, if any.
+	Gateway string `json:"gateway,omitempty"`
+	// Device is the outgoing interface for the route.
+	Device string `json:"device"`
+}
+
+// netnsInfo dispatches the collection of network namespace details into the
+// container and prints the result in the requested output format.
+func netnsInfo(output string, seLinux bool) error {
+	var out bytes.Buffer
+	cfg := box.EnterConfig{
+		Process: box.ProcessConfig{
+			User:         "root",
+			Out:          &out,
+			Args:         []string{"/usr/bin/planet", "--debug", "netns", "collect"},
+			ProcessLabel: constants.ContainerRuntimeProcessLabel,
+		},
+		SELinux: seLinux,
+	}
+	if err := enter(cfg); err != nil {
+		return trace.Wrap(err)
+	}
+	var info NetnsInfo
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &info); err != nil {
+		return trace.Wrap(err, "failed to parse netns info result: %q", out.String())
+	}
+	return trace.Wrap(printNetnsInfo(&info, output))
+}
+
+// netnsCollect gathers network namespace details from within the namespace
+// it is running in and prints them as JSON. It is invoked by netnsInfo via
+// enter and is not intended to be run directly by operators.
+func netnsCollect() error {
+	info, err := collectNetnsInfo(context.TODO())
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(json.NewEncoder(os.Stdout).Encode(info))
+}
+
+// collectNetnsInfo gathers interface, route and iptables rule information
+// from the network namespace of the calling process.
+func collectNetnsInfo(ctx context.Context) (*NetnsInfo, error) {
+	addrOut, err := exec.CommandContext(ctx, "ip", "addr", "show").CombinedOutput()
+	if err != nil {
+		return nil, trace.Wrap(err, "ip addr show: %v", string(addrOut))
+	}
+	routeOut, err := exec.CommandContext(ctx, "ip", "route", "show").CombinedOutput()
+	if err != nil {
+		return nil, trace.Wrap(err, "ip route show: %v", string(routeOut))
+	}
+	ruleCount, err := countIPTablesRules(ctx)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &NetnsInfo{
+		Interfaces:        parseIPAddrOutput(string(addrOut)),
+		Routes:            parseIPRouteOutput(string(routeOut)),
+		IPTablesRuleCount: ruleCount,
+	}, nil
+}
+
+// countIPTablesRules returns the number of iptables rules currently loaded,
+// as reported by iptables-save.
+func countIPTablesRules(ctx context.Context) (int, error) {
+	out, err := exec.CommandContext(ctx, "iptables-save").CombinedOutput()
+	if err != nil {
+		return 0, trace.Wrap(err, "iptables-save: %v", string(out))
+	}
+	return countIPTablesRulesFromSave(string(out)), nil
+}
+
+// countIPTablesRulesFromSave counts the append rules ("-A ...") in the
+// output of iptables-save.
+func countIPTablesRulesFromSave(output string) int {
+	count := 0
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "-A ") {
+			count++
+		}
+	}
+	return count
+}
+
+// interfaceHeader matches the first line of an interface's "ip addr show"
+// block, e.g. "2: eth0@if3: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 ...".
+var interfaceHeader = regexp.MustCompile(`^\d+:\s+([^:@]+)`)
+
+// interfaceAddress matches an "inet"/"inet6" address line, e.g.
+// "    inet 172.17.0.2/16 brd 172.17.255.255 scope global eth0".
+var interfaceAddress = regexp.MustCompile(`^\s*inet6?\s+(\S+)`)
+
+// parseIPAddrOutput parses the output of "ip addr show" into a list of
+// interfaces and their addresses.
+func parseIPAddrOutput(output string) []NetnsInterface {
+	var interfaces []NetnsInterface
+	var current *NetnsInterface
+	for _, line := range strings.Split(output, "\n") {
+		if m := interfaceHeader.FindStringSubmatch(line); m != nil {
+			interfaces = append(interfaces, NetnsInterface{Name: m[1]})
+			current = &interfaces[len(interfaces)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if m := interfaceAddress.FindStringSubmatch(line); m != nil {
+			current.Addresses = append(current.Addresses, m[1])
+		}
+	}
+	return interfaces
+}
+
+// parseIPRouteOutput parses the output of "ip route show" into a list of
+// routing table entries.
+func parseIPRouteOutput(output string) []NetnsRoute {
+	var routes []NetnsRoute
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		route := NetnsRoute{Destination: fields[0]}
+		for i := 1; i < len(fields)-1; i++ {
+			switch fields[i] {
+			case "via":
+				route.Gateway = fields[i+1]
+			case "dev":
+				route.Device = fields[i+1]
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// printNetnsInfo prints info in the requested output format, either "text"
+// (a human-readable summary) or "json" (the full NetnsInfo payload).
+func printNetnsInfo(info *NetnsInfo, output string) error {
+	if output == "json" {
+		payload, err := json.Marshal(info)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Fprintln(os.Stdout, string(payload))
+		return nil
+	}
+	for _, iface := range info.Interfaces {
+		fmt.Fprintf(os.Stdout, "%v: %v\n", iface.Name, strings.Join(iface.Addresses, ", "))
+	}
+	for _, route := range info.Routes {
+		if route.Gateway != "" {
+			fmt.Fprintf(os.Stdout, "%v via %v dev %v\n", route.Destination, route.Gateway, route.Device)
+		} else {
+			fmt.Fprintf(os.Stdout, "%v dev %v\n", route.Destination, route.Device)
+		}
+	}
+	fmt.Fprintf(os.Stdout, "iptables rules: %v\n", info.IPTablesRuleCount)
+	return nil
+}
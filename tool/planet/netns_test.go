@@ -0,0 +1,72 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseIPAddrOutput(t *testing.T) {
+	output := `1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN group default qlen 1000
+    link/loopback 00:00:00:00:00:00 brd 00:00:00:00:00:00
+    inet 127.0.0.1/8 scope host lo
+       valid_lft forever preferred_lft forever
+    inet6 ::1/128 scope host
+       valid_lft forever preferred_lft forever
+2: eth0@if3: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP group default
+    link/ether 02:42:ac:11:00:02 brd ff:ff:ff:ff:ff:ff
+    inet 172.17.0.2/16 brd 172.17.255.255 scope global eth0
+       valid_lft forever preferred_lft forever
+`
+	expected := []NetnsInterface{
+		{Name: "lo", Addresses: []string{"127.0.0.1/8", "::1/128"}},
+		{Name: "eth0", Addresses: []string{"172.17.0.2/16"}},
+	}
+	interfaces := parseIPAddrOutput(output)
+	if !reflect.DeepEqual(interfaces, expected) {
+		t.Errorf("expected %+v, got %+v", expected, interfaces)
+	}
+}
+
+func TestParseIPRouteOutput(t *testing.T) {
+	output := `default via 172.17.0.1 dev eth0
+10.244.0.0/16 dev flannel.1 scope link
+172.17.0.0/16 dev eth0 proto kernel scope link src 172.17.0.2
+`
+	expected := []NetnsRoute{
+		{Destination: "default", Gateway: "172.17.0.1", Device: "eth0"},
+		{Destination: "10.244.0.0/16", Device: "flannel.1"},
+		{Destination: "172.17.0.0/16", Device: "eth0"},
+	}
+	routes := parseIPRouteOutput(output)
+	if !reflect.DeepEqual(routes, expected) {
+		t.Errorf("expected %+v, got %+v", expected, routes)
+	}
+}
+
+func TestCountIPTablesRulesFromSave(t *testing.T) {
+	output := `*filter
+:FORWARD DROP
+-A FORWARD -s 10.244.0.0/16 -j ACCEPT
+-A FORWARD -d 10.100.0.0/16 -j ACCEPT
+COMMIT
+`
+	if count := countIPTablesRulesFromSave(output); count != 2 {
+		t.Errorf("expected 2 rules, got %v", count)
+	}
+}
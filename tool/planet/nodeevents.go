@@ -0,0 +1,172 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	k8smembership "github.com/gravitational/satellite/lib/membership/kubernetes"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// NodeJoinedReason is recorded when a node is first observed by the
+	// cluster's Node informer.
+	NodeJoinedReason = "NodeJoined"
+	// NodeLeftReason is recorded when a node is removed from the cluster.
+	NodeLeftReason = "NodeLeft"
+	// NodeFailedReason is recorded when a node's Ready condition transitions
+	// away from true.
+	NodeFailedReason = "NodeFailed"
+	// NodeRoleChangedReason is recorded when a node's k8s-role label changes.
+	NodeRoleChangedReason = "NodeRoleChanged"
+)
+
+// nodeEventTimeout bounds how long a single Event create call is allowed to
+// take, mirroring the timeout used to record leader election events.
+const nodeEventTimeout = 10 * time.Second
+
+// nodeEventRateLimit and nodeEventBurst bound how many node lifecycle events
+// this agent will attempt to write per second, so a flapping node cannot
+// flood the kube-system Event stream.
+const (
+	nodeEventRateLimit = 1
+	nodeEventBurst     = 20
+)
+
+// nodeEventRecorder publishes node lifecycle changes as Kubernetes Events in
+// the kube-system namespace.
+type nodeEventRecorder struct {
+	events  corev1.EventInterface
+	limiter *rate.Limiter
+}
+
+// newNodeEventRecorder returns a recorder that creates Events using events.
+func newNodeEventRecorder(events corev1.EventInterface) *nodeEventRecorder {
+	return &nodeEventRecorder{
+		events:  events,
+		limiter: rate.NewLimiter(nodeEventRateLimit, nodeEventBurst),
+	}
+}
+
+// emit records a single Event of the given reason/type against node.
+//
+// The Event's name is derived deterministically from reason, the node name
+// and the node's resource version, so that the same underlying change
+// reported by more than one master (or replayed by the informer's initial
+// cache sync) collides on create rather than producing duplicate events.
+func (r *nodeEventRecorder) emit(reason, eventType string, node *v1.Node, message string) {
+	if !r.limiter.Allow() {
+		log.Warnf("Rate limit exceeded, dropping node event %v for %v.", reason, node.Name)
+		return
+	}
+
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%v.%v", node.Name, reason, node.ResourceVersion),
+			Namespace: metav1.NamespaceSystem,
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:       "Node",
+			Name:       node.Name,
+			UID:        node.UID,
+			APIVersion: "v1",
+		},
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         v1.EventSource{Component: "planet-agent"},
+		FirstTimestamp: metav1.Now(),
+		LastTimestamp:  metav1.Now(),
+		Count:          1,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), nodeEventTimeout)
+	defer cancel()
+
+	_, err := r.events.Create(ctx, event, metav1.CreateOptions{})
+	if err != nil && !errors.IsAlreadyExists(err) {
+		log.WithError(err).Warnf("Failed to record %v event for node %v.", reason, node.Name)
+	}
+}
+
+// isNodeReady returns true if node's Ready condition is true.
+func isNodeReady(node v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// registerNodeEventHandlers wires up recorder to informer so that node
+// join/leave/failed/role-change transitions are published as Kubernetes
+// Events.
+func registerNodeEventHandlers(informer cache.SharedIndexInformer, recorder *nodeEventRecorder) {
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			node, ok := obj.(*v1.Node)
+			if !ok {
+				return
+			}
+			recorder.emit(NodeJoinedReason, v1.EventTypeNormal, node,
+				fmt.Sprintf("Node %v joined the cluster.", node.Name))
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNode, ok := oldObj.(*v1.Node)
+			if !ok {
+				return
+			}
+			newNode, ok := newObj.(*v1.Node)
+			if !ok {
+				return
+			}
+			if wasReady, isReady := isNodeReady(*oldNode), isNodeReady(*newNode); wasReady && !isReady {
+				recorder.emit(NodeFailedReason, v1.EventTypeWarning, newNode,
+					fmt.Sprintf("Node %v is no longer ready.", newNode.Name))
+			}
+			oldRole, newRole := oldNode.Labels[k8smembership.RoleKey], newNode.Labels[k8smembership.RoleKey]
+			if oldRole != newRole {
+				recorder.emit(NodeRoleChangedReason, v1.EventTypeNormal, newNode,
+					fmt.Sprintf("Node %v role changed from %q to %q.", newNode.Name, oldRole, newRole))
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			node, ok := obj.(*v1.Node)
+			if !ok {
+				if tombstone, isTombstone := obj.(cache.DeletedFinalStateUnknown); isTombstone {
+					node, ok = tombstone.Obj.(*v1.Node)
+				}
+				if !ok {
+					return
+				}
+			}
+			recorder.emit(NodeLeftReason, v1.EventTypeWarning, node,
+				fmt.Sprintf("Node %v left the cluster.", node.Name))
+		},
+	})
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+
+	"github.com/gravitational/trace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// validatePodCIDRAllocations queries the cluster nodes and verifies that
+// each node's allocated PodCIDR is a subnet of podSubnet and does not
+// overlap with any other node's PodCIDR.
+func validatePodCIDRAllocations(ctx context.Context, client *kubernetes.Clientset, podSubnet net.IPNet) error {
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	nodeCIDRs := make(map[string]string, len(nodes.Items))
+	for _, node := range nodes.Items {
+		nodeCIDRs[node.Name] = node.Spec.PodCIDR
+	}
+	problems := checkPodCIDRAllocations(podSubnet, nodeCIDRs)
+	if len(problems) == 0 {
+		fmt.Printf("all node pod CIDRs are valid subnets of %v and do not overlap\n", podSubnet.String())
+		return nil
+	}
+	fmt.Println("found misallocated pod CIDRs:")
+	for _, problem := range problems {
+		fmt.Printf("  - %v\n", problem)
+	}
+	return trace.BadParameter("%v node(s) have misallocated pod CIDRs", len(problems))
+}
+
+// checkPodCIDRAllocations validates the PodCIDR allocated to each node
+// (nodeCIDRs maps node name to its Spec.PodCIDR) against podSubnet and
+// against each other, returning a human-readable description of each
+// problem found. A node is considered misallocated if it has no PodCIDR,
+// an unparseable PodCIDR, a PodCIDR that isn't a subnet of podSubnet, or a
+// PodCIDR that overlaps with another node's.
+func checkPodCIDRAllocations(podSubnet net.IPNet, nodeCIDRs map[string]string) []string {
+	var problems []string
+	var allocated []struct {
+		node string
+		cidr net.IPNet
+	}
+	for _, name := range sortedNames(nodeCIDRs) {
+		podCIDR := nodeCIDRs[name]
+		if podCIDR == "" {
+			problems = append(problems, fmt.Sprintf("node %v has no PodCIDR allocated", name))
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(podCIDR)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("node %v has unparseable PodCIDR %v: %v", name, podCIDR, err))
+			continue
+		}
+		if !isSubnetOf(podSubnet, *ipNet) {
+			problems = append(problems, fmt.Sprintf("node %v PodCIDR %v is not a subnet of %v", name, podCIDR, podSubnet.String()))
+			continue
+		}
+		for _, other := range allocated {
+			if cidrsOverlap(*ipNet, other.cidr) {
+				problems = append(problems, fmt.Sprintf("node %v PodCIDR %v overlaps with node %v PodCIDR %v", name, podCIDR, other.node, other.cidr.String()))
+			}
+		}
+		allocated = append(allocated, struct {
+			node string
+			cidr net.IPNet
+		}{node: name, cidr: *ipNet})
+	}
+	return problems
+}
+
+// isSubnetOf reports whether child is a subnet of parent.
+func isSubnetOf(parent, child net.IPNet) bool {
+	parentOnes, parentBits := parent.Mask.Size()
+	childOnes, childBits := child.Mask.Size()
+	if parentBits != childBits || childOnes < parentOnes {
+		return false
+	}
+	return parent.Contains(child.IP)
+}
+
+// cidrsOverlap reports whether a and b share any addresses.
+func cidrsOverlap(a, b net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+func sortedNames(nodeCIDRs map[string]string) []string {
+	names := make([]string, 0, len(nodeCIDRs))
+	for name := range nodeCIDRs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func mustParsePodSubnet(t *testing.T, s string) net.IPNet {
+	_, ipNet, err := net.ParseCIDR(s)
+	assert.NoError(t, err)
+	return *ipNet
+}
+
+func TestCheckPodCIDRAllocationsValid(t *testing.T) {
+	podSubnet := mustParsePodSubnet(t, "10.244.0.0/16")
+	nodeCIDRs := map[string]string{
+		"node-1": "10.244.0.0/24",
+		"node-2": "10.244.1.0/24",
+	}
+	assert.Empty(t, checkPodCIDRAllocations(podSubnet, nodeCIDRs))
+}
+
+func TestCheckPodCIDRAllocationsOutsideSubnet(t *testing.T) {
+	podSubnet := mustParsePodSubnet(t, "10.244.0.0/16")
+	nodeCIDRs := map[string]string{
+		"node-1": "10.244.0.0/24",
+		"node-2": "10.245.0.0/24",
+	}
+	problems := checkPodCIDRAllocations(podSubnet, nodeCIDRs)
+	assert.Len(t, problems, 1)
+	assert.Contains(t, problems[0], "node-2")
+	assert.Contains(t, problems[0], "not a subnet")
+}
+
+func TestCheckPodCIDRAllocationsOverlap(t *testing.T) {
+	podSubnet := mustParsePodSubnet(t, "10.244.0.0/16")
+	nodeCIDRs := map[string]string{
+		"node-1": "10.244.0.0/23",
+		"node-2": "10.244.1.0/24",
+	}
+	problems := checkPodCIDRAllocations(podSubnet, nodeCIDRs)
+	assert.Len(t, problems, 1)
+	assert.Contains(t, problems[0], "overlaps")
+}
+
+func TestCheckPodCIDRAllocationsMissingOrInvalid(t *testing.T) {
+	podSubnet := mustParsePodSubnet(t, "10.244.0.0/16")
+	nodeCIDRs := map[string]string{
+		"node-1": "",
+		"node-2": "not-a-cidr",
+	}
+	problems := checkPodCIDRAllocations(podSubnet, nodeCIDRs)
+	assert.Len(t, problems, 2)
+	assert.Contains(t, problems[0], "no PodCIDR allocated")
+	assert.Contains(t, problems[1], "unparseable PodCIDR")
+}
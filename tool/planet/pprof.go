@@ -0,0 +1,98 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// DefaultProfileEndpoint is the default loopback address the profiling
+// endpoint is bound to when enabled with --httpprofile.
+const DefaultProfileEndpoint = "127.0.0.1:6060"
+
+// pprofProfiles lists the profiles captured by "planet debug pprof".
+// "profile" (CPU) is handled separately since it takes a duration.
+var pprofProfiles = []string{"goroutine", "heap"}
+
+// checkLoopbackEndpoint verifies that addr resolves to a loopback address,
+// so the profiling endpoint is never accidentally exposed off-host.
+func checkLoopbackEndpoint(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsLoopback() {
+		return trace.BadParameter("httpprofile endpoint %q must be bound to a loopback address", addr)
+	}
+	return nil
+}
+
+// capturePprofProfiles fetches goroutine, heap and CPU profiles from the
+// pprof endpoint of a running planet process (enabled with --httpprofile)
+// and writes them to outputDir. This is a convenience wrapper around the
+// standard "go tool pprof" HTTP interface for diagnosing a wedged process.
+func capturePprofProfiles(endpoint, outputDir string, cpuSeconds int) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return trace.ConvertSystemError(err)
+	}
+
+	for _, profile := range pprofProfiles {
+		url := fmt.Sprintf("http://%v/debug/pprof/%v", endpoint, profile)
+		if err := fetchProfile(url, filepath.Join(outputDir, profile+".pprof")); err != nil {
+			return trace.Wrap(err, "failed to capture %v profile", profile)
+		}
+	}
+
+	url := fmt.Sprintf("http://%v/debug/pprof/profile?seconds=%v", endpoint, cpuSeconds)
+	if err := fetchProfile(url, filepath.Join(outputDir, "cpu.pprof")); err != nil {
+		return trace.Wrap(err, "failed to capture cpu profile")
+	}
+	return nil
+}
+
+// fetchProfile downloads the profile at url and writes it to path.
+func fetchProfile(url, path string) error {
+	client := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return trace.BadParameter("unexpected status %v from %v", resp.Status, url)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		return trace.Wrap(err)
+	}
+	return nil
+}
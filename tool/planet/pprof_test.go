@@ -0,0 +1,44 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapturePprofProfiles(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/goroutine", pprof.Index)
+	mux.HandleFunc("/debug/pprof/heap", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	outputDir, err := ioutil.TempDir("", "pprof-test")
+	require.NoError(t, err)
+
+	endpoint := server.Listener.Addr().String()
+	err = capturePprofProfiles(endpoint, outputDir, 1)
+	require.NoError(t, err)
+
+	for _, name := range []string{"goroutine.pprof", "heap.pprof", "cpu.pprof"} {
+		info, err := os.Stat(filepath.Join(outputDir, name))
+		require.NoError(t, err)
+		assert.NotZero(t, info.Size())
+	}
+}
+
+func TestCheckLoopbackEndpoint(t *testing.T) {
+	assert.NoError(t, checkLoopbackEndpoint("127.0.0.1:6060"))
+	assert.NoError(t, checkLoopbackEndpoint("[::1]:6060"))
+	assert.Error(t, checkLoopbackEndpoint("0.0.0.0:6060"))
+	assert.Error(t, checkLoopbackEndpoint("10.0.0.5:6060"))
+	assert.Error(t, checkLoopbackEndpoint("not-an-address"))
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+
+	"github.com/gravitational/planet/lib/constants"
+	"github.com/gravitational/planet/lib/utils"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// reload re-reads the parts of "planet start" that can change without a
+// restart and rewrites the files planet generated from them into the
+// already-running container's rootfs:
+//   - the host's /etc/resolv.conf, which determines the upstream
+//     nameservers coredns forwards unresolved queries to
+//   - the generated /etc/hosts entries
+//   - the per-zone forwarders in the CoreDNS configuration
+//     (config.DNS.Zones); the config file includes CoreDNS' own "reload"
+//     directive, so rewriting it is picked up without restarting CoreDNS
+//
+// Everything else "planet start" was given - subnets, mounted volumes,
+// the cloud provider, etcd/kubelet/docker settings - is only read once at
+// start and requires a full restart to change.
+func reload(config *Config) error {
+	logger := log.WithField(trace.Component, "reload")
+
+	nameservers, err := reloadResolv(config)
+	if err != nil {
+		return trace.Wrap(err, "failed to reload DNS upstream nameservers")
+	}
+	logger.WithField("nameservers", nameservers).Info("Reloaded DNS upstream nameservers.")
+
+	if err := reloadHosts(config); err != nil {
+		return trace.Wrap(err, "failed to reload /etc/hosts entries")
+	}
+	logger.Info("Reloaded /etc/hosts entries.")
+
+	if err := setCoreDNS(config); err != nil {
+		return trace.Wrap(err, "failed to reload CoreDNS zone forwarders")
+	}
+	logger.Info("Reloaded CoreDNS zone forwarders.")
+
+	return nil
+}
+
+// reloadResolv rewrites the resolv.conf planet mounted into the
+// container's /etc/resolv.conf with the host's current nameservers.
+func reloadResolv(config *Config) (nameservers []string, err error) {
+	cfg, err := readHostResolv()
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+
+	var dnsAddrs []string
+	if len(config.DNS.ListenAddrs) != 0 {
+		dnsAddrs = config.DNS.ListenAddrs[:1]
+	}
+	planetResolv := config.inRootfs("etc", PlanetResolv)
+	if err := copyResolvFile(*cfg, planetResolv, dnsAddrs, config.DNS); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return cfg.Servers, nil
+}
+
+// reloadHosts rewrites the /etc/hosts planet wrote into the container at
+// start with freshly generated entries.
+func reloadHosts(config *Config) error {
+	out := &bytes.Buffer{}
+	if err := utils.WriteHosts(out, generateHosts()); err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(utils.SafeWriteFile(config.inRootfs(HostsFile), out.Bytes(), constants.SharedReadWriteMask))
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/gravitational/planet/lib/utils"
+	"github.com/gravitational/trace"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ResourceLimits are the resolved limits to apply to the planet container's
+// own cgroup - the outer cgroup that bounds the whole container, as opposed
+// to the internal hierarchy defined in cgroup.go that divides resources
+// between planet, user tasks and kubernetes pods inside it. A zero value
+// means "no limit", matching how the underlying cgroup settings treat zero.
+type ResourceLimits struct {
+	// MemoryLimit caps the container's memory usage, in bytes.
+	MemoryLimit int64 `json:"memoryLimit,omitempty"`
+	// MemoryReservation is the soft memory limit, in bytes, the kernel
+	// tries to keep the container under whenever the host is under memory
+	// pressure, even though MemoryLimit permits more.
+	MemoryReservation int64 `json:"memoryReservation,omitempty"`
+	// CPUQuota is the CPU quota, in microseconds of CPU time allowed per
+	// CPUPeriod.
+	CPUQuota int64 `json:"cpuQuota,omitempty"`
+	// CPUPeriod is the length of the CPU quota accounting period, in
+	// microseconds.
+	CPUPeriod uint64 `json:"cpuPeriod,omitempty"`
+}
+
+// resolveResourceLimits translates the human-friendly memoryLimit,
+// memoryReserveHost and cpuQuota flag values (e.g. "4G", "512Mi", "200%")
+// into the concrete cgroup settings in ResourceLimits, validated against
+// the host's actual resources so a misconfigured node fails at startup
+// instead of silently over-committing. Any of the three may be empty, in
+// which case the corresponding limit is left unset.
+func resolveResourceLimits(memoryLimit, memoryReserveHost, cpuQuota string) (*ResourceLimits, error) {
+	var limits ResourceLimits
+
+	if cpuQuota != "" {
+		quota, period, err := parseCPUQuota(cpuQuota)
+		if err != nil {
+			return nil, trace.Wrap(err)
+		}
+		if numCPU := runtime.NumCPU(); quota > int64(period)*int64(numCPU) {
+			return nil, trace.BadParameter(
+				"cpu-quota %q requests more CPU than the %v CPU(s) available on this host", cpuQuota, numCPU)
+		}
+		limits.CPUQuota, limits.CPUPeriod = quota, period
+	}
+
+	if memoryLimit == "" && memoryReserveHost == "" {
+		return &limits, nil
+	}
+
+	hostMemory, err := hostMemoryBytes()
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to determine host memory")
+	}
+
+	var reserve int64
+	if memoryReserveHost != "" {
+		reserve, err = parseMemoryQuantity(memoryReserveHost)
+		if err != nil {
+			return nil, trace.Wrap(err, "invalid memory-reserve-host %q", memoryReserveHost)
+		}
+		if reserve >= int64(hostMemory) {
+			return nil, trace.BadParameter(
+				"memory-reserve-host %q leaves no memory for the container on this %v host",
+				memoryReserveHost, formatResourceBytes(hostMemory))
+		}
+	}
+
+	limit := int64(hostMemory) - reserve
+	if memoryLimit != "" {
+		explicit, err := parseMemoryQuantity(memoryLimit)
+		if err != nil {
+			return nil, trace.Wrap(err, "invalid memory-limit %q", memoryLimit)
+		}
+		if explicit > limit {
+			return nil, trace.BadParameter(
+				"memory-limit %q exceeds the %v available on this %v host after reserving %v for the host",
+				memoryLimit, formatResourceBytes(uint64(limit)), formatResourceBytes(hostMemory), memoryReserveHost)
+		}
+		limit = explicit
+	}
+	limits.MemoryLimit = limit
+	return &limits, nil
+}
+
+// parseMemoryQuantity parses a human-friendly memory quantity (e.g. "4G",
+// "512Mi") into a positive number of bytes.
+func parseMemoryQuantity(s string) (int64, error) {
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return 0, trace.Wrap(err)
+	}
+	value := q.Value()
+	if value <= 0 {
+		return 0, trace.BadParameter("must be a positive quantity, got %q", s)
+	}
+	return value, nil
+}
+
+// parseCPUQuota parses a CPU quota given as a percentage of a single CPU
+// (e.g. "200%" for two full CPUs) into a quota/period pair suitable for a
+// cgroup's cpu.cfs_quota_us/cpu.cfs_period_us, using the same accounting
+// period as the internal cgroup hierarchy (DefaultCgroupCPUPeriod).
+func parseCPUQuota(s string) (quota int64, period uint64, err error) {
+	if !strings.HasSuffix(s, "%") {
+		return 0, 0, trace.BadParameter("cpu-quota %q must be a percentage of a CPU, e.g. \"200%%\"", s)
+	}
+	pct, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	if err != nil || pct <= 0 {
+		return 0, 0, trace.BadParameter("cpu-quota %q must be a positive percentage of a CPU, e.g. \"200%%\"", s)
+	}
+	period = uint64(DefaultCgroupCPUPeriod)
+	quota = int64(float64(period) * pct / 100)
+	return quota, period, nil
+}
+
+// hostMemoryBytes returns the total physical memory of the host, read
+// directly from /proc/meminfo since no other means of querying it is
+// available inside the container's pid/mount namespace at the point
+// resource limits are validated.
+func hostMemoryBytes() (uint64, error) {
+	data, err := ioutil.ReadFile("/proc/meminfo")
+	if err != nil {
+		return 0, trace.ConvertSystemError(err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "MemTotal:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, trace.Wrap(err)
+			}
+			return kb * 1024, nil
+		}
+	}
+	return 0, trace.NotFound("MemTotal not found in /proc/meminfo")
+}
+
+// formatResourceBytes renders a byte count in the same human-friendly form the
+// --memory-limit/--memory-reserve-host flags accept, so validation errors
+// speak the same units the operator typed.
+func formatResourceBytes(b uint64) string {
+	return resource.NewQuantity(int64(b), resource.BinarySI).String()
+}
+
+// resourceLimitsStateFile records the resource limits "planet start" was
+// given, so a later "planet status" can report them without an RPC round
+// trip. It lives alongside the RPC client credentials and the maintenance
+// state file for the same reason they do - it must be readable by a
+// freshly started CLI process, not just the long-running agent.
+var resourceLimitsStateFile = filepath.Join(DefaultSecretsMountDir, "resource-limits.json")
+
+// writeResourceLimits persists the resource limits the container was
+// started with, overwriting any previously recorded ones.
+func writeResourceLimits(limits *ResourceLimits) error {
+	data, err := json.Marshal(limits)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(utils.SafeWriteFile(resourceLimitsStateFile, data, SharedFileMask))
+}
+
+// readResourceLimits loads the resource limits most recently recorded by
+// writeResourceLimits. It returns nil, nil if none have ever been recorded,
+// e.g. the container was started without any of --memory-limit,
+// --memory-reserve-host or --cpu-quota.
+func readResourceLimits() (*ResourceLimits, error) {
+	data, err := ioutil.ReadFile(resourceLimitsStateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	var limits ResourceLimits
+	if err := json.Unmarshal(data, &limits); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &limits, nil
+}
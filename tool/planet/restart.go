@@ -0,0 +1,74 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// restartStopWait bounds how long restart waits for the previous planet
+// daemon to exit (and release its pidfile) after a graceful stop, before
+// giving up rather than racing the new daemon's own checkPidFile against a
+// process that is still tearing down.
+const restartStopWait = 60 * time.Second
+
+// restart gracefully stops the running planet container (draining units in
+// shutdownOrder, transferring etcd leadership, then halting, exactly as the
+// "stop" command does) and starts a new one from the configuration persisted
+// by the previous start, so an operator can pick up a config change with a
+// single command instead of separately reconstructing the original start
+// arguments.
+func restart(seLinux bool, shutdownOrder []ShutdownUnit, logMaxSizeMB, logMaxBackups int) error {
+	rootfs, err := findRootfs()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	path := configStatePath(rootfs)
+	if _, err := os.Stat(path); err != nil {
+		return trace.NotFound("no persisted start configuration found at %v - this container was started by a version of planet that predates config persistence, so restart cannot reconstruct its start arguments; stop and start it explicitly instead", path)
+	}
+
+	if err := stop(seLinux, shutdownOrder); err != nil {
+		return trace.Wrap(err, "failed to gracefully stop the running container")
+	}
+
+	if err := waitForPidFileRemoved(restartStopWait); err != nil {
+		return trace.Wrap(err, "timed out waiting for the previous planet daemon to exit")
+	}
+
+	return trace.Wrap(daemonize([]string{"start", "--from-config", path}, logMaxSizeMB, logMaxBackups))
+}
+
+// waitForPidFileRemoved polls the planet pidfile until it's gone (the
+// previous daemon has exited and cleaned up after itself) or timeout
+// elapses.
+func waitForPidFileRemoved(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		if _, err := readPidFile(); trace.IsNotFound(err) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return trace.LimitExceeded("planet daemon did not exit within %v", timeout)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,370 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"time"
+
+	"github.com/gravitational/planet/lib/constants"
+	"github.com/gravitational/planet/lib/utils"
+
+	etcdconf "github.com/gravitational/coordinate/v4/config"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultCertRotationCheckInterval is how often runCertRotation checks
+// managed certificates for expiry when no interval has been configured.
+const defaultCertRotationCheckInterval = 1 * time.Hour
+
+// defaultCertTTL is the validity period a replacement certificate is issued
+// with when no TTL has been configured.
+const defaultCertTTL = 365 * 24 * time.Hour
+
+// certKeyFileMask restricts replacement private keys to owner-only, unlike
+// the SharedFileMask used for most planet-managed files - a private key is
+// exactly the file that mask shouldn't apply to.
+const certKeyFileMask = 0600
+
+// certBackupTimeFormat names the timestamp suffix backupFile appends to the
+// certificate/key it's about to replace.
+const certBackupTimeFormat = "20060102-150405"
+
+// managedCert describes one planet-managed TLS certificate/key pair: where
+// it lives, which systemd unit reads it, and what a replacement should be
+// issued for.
+type managedCert struct {
+	// Name identifies the certificate for logging and backup file naming.
+	Name string
+	// CertPath and KeyPath are the on-disk locations of the certificate and
+	// its private key.
+	CertPath, KeyPath string
+	// Unit is the systemd unit that needs restarting for a replacement
+	// CertPath/KeyPath to take effect.
+	Unit string
+	// DNSNames and IPAddresses are the subject alternative names a
+	// replacement certificate for this component is issued with.
+	DNSNames    []string
+	IPAddresses []net.IP
+}
+
+// managedCerts lists the certificates rotate-certs knows how to replace, in
+// restart order: etcd before the apiserver that depends on it.
+var managedCerts = []managedCert{
+	{
+		Name:        "etcd",
+		CertPath:    DefaultEtcdctlCertFile,
+		KeyPath:     DefaultEtcdctlKeyFile,
+		Unit:        ETCDServiceName,
+		DNSNames:    []string{"etcd", "localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+	},
+	{
+		Name:        "apiserver",
+		CertPath:    APIServerCertPath,
+		KeyPath:     APIServerKeyPath,
+		Unit:        APIServerServiceName,
+		DNSNames:    []string{constants.APIServerDNSName, "kubernetes", "kubernetes.default", "localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+	},
+}
+
+// certRotationConfig configures the automatic certificate rotation loop
+// started by runCertRotation, and doubles as the parameters for a one-off
+// rotateCerts call from the rotate-certs command.
+type certRotationConfig struct {
+	// Disabled skips the automatic loop entirely. The rotate-certs command
+	// is unaffected.
+	Disabled bool
+	// CheckInterval is how often the automatic loop checks managed
+	// certificates for expiry. Defaults to defaultCertRotationCheckInterval.
+	CheckInterval time.Duration
+	// Threshold rotates a certificate once less than Threshold remains
+	// before it expires. Zero from the rotate-certs command means rotate
+	// unconditionally; zero on the automatic loop means the loop doesn't
+	// run at all, since there's no safe default for "how close is too
+	// close" to a cluster's actual certificate lifetime.
+	Threshold time.Duration
+	// TTL is the validity period issued replacement certificates get.
+	// Defaults to defaultCertTTL.
+	TTL time.Duration
+	// CAFile and CAKeyFile locate the cluster CA certificate and private
+	// key used to sign replacements. planet doesn't generate or store a CA
+	// key itself - it's provisioned out of band by the installer - so
+	// CAKeyFile must be supplied explicitly, or rotation refuses to run.
+	CAFile, CAKeyFile string
+	// PublicIP is this node's IP as used for master election, so the
+	// automatic loop can tell whether it's the current elected master.
+	PublicIP string
+	// LeaderKey is the etcd key holding the current master's IP.
+	LeaderKey string
+	// ETCD is the etcd client configuration used to read LeaderKey.
+	ETCD etcdconf.Config
+}
+
+func (r *certRotationConfig) checkAndSetDefaults() {
+	if r.CheckInterval <= 0 {
+		r.CheckInterval = defaultCertRotationCheckInterval
+	}
+	if r.TTL <= 0 {
+		r.TTL = defaultCertTTL
+	}
+}
+
+// runCertRotation periodically checks managed certificates for expiry and
+// rotates the ones due. It restricts itself to the currently elected
+// master, so masters don't race to replace the same certificate at once -
+// a non-leader's check is a no-op. It blocks until ctx is cancelled.
+func runCertRotation(ctx context.Context, config certRotationConfig) error {
+	if config.Disabled || config.Threshold <= 0 {
+		log.Info("Automatic certificate rotation is disabled.")
+		return nil
+	}
+	config.checkAndSetDefaults()
+
+	checkAndRotateCerts(config)
+
+	ticker := time.NewTicker(config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			checkAndRotateCerts(config)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// checkAndRotateCerts rotates managed certificates due for renewal, but
+// only on the currently elected master.
+func checkAndRotateCerts(config certRotationConfig) {
+	isLeader, err := isCurrentLeader(config.PublicIP, config.LeaderKey, &config.ETCD)
+	if err != nil {
+		log.WithError(err).Warn("Failed to determine election leader for certificate rotation.")
+		return
+	}
+	if !isLeader {
+		return
+	}
+	if err := rotateCerts(managedCerts, config, time.Now()); err != nil {
+		log.WithError(err).Warn("Certificate rotation failed.")
+	}
+}
+
+// isCurrentLeader reports whether publicIP is the value currently stored at
+// leaderKey, i.e. whether this node is the elected master.
+func isCurrentLeader(publicIP, leaderKey string, etcd *etcdconf.Config) (bool, error) {
+	client, err := getEtcdClient(etcd)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	resp, err := client.Get(context.TODO(), leaderKey, nil)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	return resp.Node.Value == publicIP, nil
+}
+
+// rotateCerts backs up, replaces and restarts the unit for every certificate
+// in certs that's within config.Threshold of expiring (or unconditionally,
+// if config.Threshold is zero).
+func rotateCerts(certs []managedCert, config certRotationConfig, now time.Time) error {
+	if config.CAKeyFile == "" {
+		return trace.BadParameter("a CA key file is required to sign replacement certificates; planet does not generate or store one itself")
+	}
+	config.checkAndSetDefaults()
+
+	caCert, caKey, err := loadCA(config.CAFile, config.CAKeyFile)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	var errors []error
+	for _, cert := range certs {
+		due, err := certDueForRotation(cert.CertPath, config.Threshold, now)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to check %v certificate", cert.Name))
+			continue
+		}
+		if !due {
+			continue
+		}
+		if err := rotateCert(cert, caCert, caKey, config.TTL, now); err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to rotate %v certificate", cert.Name))
+			continue
+		}
+		log.WithField("cert", cert.Name).Info("Rotated certificate.")
+	}
+	return trace.NewAggregate(errors...)
+}
+
+// certDueForRotation reports whether the certificate at certPath expires
+// within threshold of now. threshold <= 0 always reports true.
+func certDueForRotation(certPath string, threshold time.Duration, now time.Time) (bool, error) {
+	if threshold <= 0 {
+		return true, nil
+	}
+	expiry, err := certExpiry(certPath)
+	if err != nil {
+		return false, trace.Wrap(err)
+	}
+	return expiry.Sub(now) < threshold, nil
+}
+
+// certExpiry returns the NotAfter time of the PEM certificate at certPath.
+func certExpiry(certPath string) (time.Time, error) {
+	data, err := ioutil.ReadFile(certPath)
+	if err != nil {
+		return time.Time{}, trace.ConvertSystemError(err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return time.Time{}, trace.BadParameter("%v does not contain a PEM certificate", certPath)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, trace.Wrap(err)
+	}
+	return cert.NotAfter, nil
+}
+
+// loadCA reads and parses the cluster CA certificate and RSA private key
+// used to sign replacement certificates.
+func loadCA(caFile, caKeyFile string) (*x509.Certificate, *rsa.PrivateKey, error) {
+	certPEM, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, nil, trace.ConvertSystemError(err)
+	}
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, nil, trace.BadParameter("%v does not contain a PEM certificate", caFile)
+	}
+	caCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	keyPEM, err := ioutil.ReadFile(caKeyFile)
+	if err != nil {
+		return nil, nil, trace.ConvertSystemError(err)
+	}
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		return nil, nil, trace.BadParameter("%v does not contain a PEM private key", caKeyFile)
+	}
+	caKey, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, trace.Wrap(err, "only RSA (PKCS1) CA keys are supported")
+	}
+	return caCert, caKey, nil
+}
+
+// rotateCert issues a fresh certificate for cert, backs up the pair it's
+// replacing, atomically installs the replacement and restarts the unit
+// that consumes it.
+func rotateCert(cert managedCert, caCert *x509.Certificate, caKey *rsa.PrivateKey, ttl time.Duration, now time.Time) error {
+	certPEM, keyPEM, err := issueCert(cert, caCert, caKey, ttl, now)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	if err := backupFile(cert.CertPath, now); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := backupFile(cert.KeyPath, now); err != nil {
+		return trace.Wrap(err)
+	}
+
+	if err := utils.SafeWriteFile(cert.CertPath, certPEM, SharedFileMask); err != nil {
+		return trace.Wrap(err)
+	}
+	if err := utils.SafeWriteFile(cert.KeyPath, keyPEM, certKeyFileMask); err != nil {
+		return trace.Wrap(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownUnitTimeout)
+	defer cancel()
+	return trace.Wrap(systemctlCmd(ctx, "restart", cert.Unit))
+}
+
+// issueCert generates a fresh key pair and signs a certificate for it with
+// caCert/caKey, valid for ttl starting shortly before now (to tolerate
+// modest clock skew between nodes).
+func issueCert(cert managedCert, caCert *x509.Certificate, caKey *rsa.PrivateKey, ttl time.Duration, now time.Time) (certPEM, keyPEM []byte, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cert.Name},
+		NotBefore:    now.Add(-1 * time.Hour),
+		NotAfter:     now.Add(ttl),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     cert.DNSNames,
+		IPAddresses:  cert.IPAddresses,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, trace.Wrap(err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	return certPEM, keyPEM, nil
+}
+
+// backupFile copies path aside to path.<timestamp>.bak before it's
+// overwritten, preserving its original permissions, so a bad rotation can
+// be rolled back by hand. A missing path (e.g. a key that was never
+// provisioned) is not an error.
+func backupFile(path string, now time.Time) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return trace.ConvertSystemError(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return trace.ConvertSystemError(err)
+	}
+	backupPath := fmt.Sprintf("%v.%v.bak", path, now.Format(certBackupTimeFormat))
+	return trace.Wrap(utils.SafeWriteFile(backupPath, data, info.Mode()))
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// generateTestCA creates a throwaway self-signed CA certificate/key pair
+// for exercising loadCA/issueCert without any real cluster PKI.
+func generateTestCA(t *testing.T, notAfter time.Time) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              notAfter,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to self-sign CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse generated CA certificate: %v", err)
+	}
+	return caCert, key
+}
+
+func writePEMFile(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("failed to write %v: %v", path, err)
+	}
+}
+
+func TestCertExpiry(t *testing.T) {
+	dir := t.TempDir()
+	caCert, _ := generateTestCA(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	certPath := filepath.Join(dir, "test.cert")
+	writePEMFile(t, certPath, "CERTIFICATE", caCert.Raw)
+
+	expiry, err := certExpiry(certPath)
+	if err != nil {
+		t.Fatalf("certExpiry failed: %v", err)
+	}
+	if !expiry.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected expiry 2030-01-01, got %v", expiry)
+	}
+}
+
+func TestCertDueForRotation(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	caCert, _ := generateTestCA(t, now.Add(10*24*time.Hour))
+
+	certPath := filepath.Join(dir, "test.cert")
+	writePEMFile(t, certPath, "CERTIFICATE", caCert.Raw)
+
+	due, err := certDueForRotation(certPath, 30*24*time.Hour, now)
+	if err != nil {
+		t.Fatalf("certDueForRotation failed: %v", err)
+	}
+	if !due {
+		t.Fatal("expected certificate expiring in 10 days to be due within a 30 day threshold")
+	}
+
+	due, err = certDueForRotation(certPath, time.Hour, now)
+	if err != nil {
+		t.Fatalf("certDueForRotation failed: %v", err)
+	}
+	if due {
+		t.Fatal("expected certificate expiring in 10 days not to be due within a 1 hour threshold")
+	}
+
+	due, err = certDueForRotation(certPath, 0, now)
+	if err != nil {
+		t.Fatalf("certDueForRotation failed: %v", err)
+	}
+	if !due {
+		t.Fatal("expected a zero threshold to always report due")
+	}
+}
+
+func TestIssueCertIsSignedByCA(t *testing.T) {
+	caCert, caKey := generateTestCA(t, time.Now().Add(365*24*time.Hour))
+
+	cert := managedCert{Name: "test", DNSNames: []string{"test.local"}}
+	certPEM, keyPEM, err := issueCert(cert, caCert, caKey, 24*time.Hour, time.Now())
+	if err != nil {
+		t.Fatalf("issueCert failed: %v", err)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		t.Fatal("expected a PEM certificate block")
+	}
+	issued, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse issued certificate: %v", err)
+	}
+	if err := issued.CheckSignatureFrom(caCert); err != nil {
+		t.Fatalf("issued certificate is not signed by the test CA: %v", err)
+	}
+	if len(issued.DNSNames) != 1 || issued.DNSNames[0] != "test.local" {
+		t.Fatalf("expected DNSNames [test.local], got %v", issued.DNSNames)
+	}
+
+	keyBlock, _ := pem.Decode(keyPEM)
+	if keyBlock == nil {
+		t.Fatal("expected a PEM private key block")
+	}
+	if _, err := x509.ParsePKCS1PrivateKey(keyBlock.Bytes); err != nil {
+		t.Fatalf("failed to parse issued private key: %v", err)
+	}
+}
+
+func TestLoadCARejectsGarbage(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "ca.cert")
+	keyPath := filepath.Join(dir, "ca.key")
+	if err := ioutil.WriteFile(certPath, []byte("not a certificate"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(keyPath, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := loadCA(certPath, keyPath); err == nil {
+		t.Fatal("expected loadCA to reject a non-PEM certificate")
+	}
+}
+
+func TestBackupFilePreservesContentAndMode(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "secret.key")
+	if err := ioutil.WriteFile(path, []byte("original"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	if err := backupFile(path, now); err != nil {
+		t.Fatalf("backupFile failed: %v", err)
+	}
+
+	backupPath := path + "." + now.Format(certBackupTimeFormat) + ".bak"
+	data, err := ioutil.ReadFile(backupPath)
+	if err != nil {
+		t.Fatalf("expected backup file at %v: %v", backupPath, err)
+	}
+	if string(data) != "original" {
+		t.Fatalf("expected backup to contain %q, got %q", "original", data)
+	}
+	info, err := os.Stat(backupPath)
+	if err != nil {
+		t.Fatalf("failed to stat backup file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected backup to keep mode 0600, got %v", info.Mode().Perm())
+	}
+}
+
+func TestBackupFileIgnoresMissingSource(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "missing.key")
+	if err := backupFile(path, time.Now()); err != nil {
+		t.Fatalf("expected backupFile to ignore a missing source file, got %v", err)
+	}
+}
+
+func TestRotateCertsRequiresCAKeyFile(t *testing.T) {
+	err := rotateCerts(nil, certRotationConfig{}, time.Now())
+	if err == nil {
+		t.Fatal("expected rotateCerts to fail without a CA key file")
+	}
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gravitational/satellite/agent"
+	"github.com/gravitational/satellite/agent/health"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	log "github.com/sirupsen/logrus"
+)
+
+// selfHealthPath is the path of the self-health handler on the debug
+// endpoint (see --httpprofile).
+const selfHealthPath = "/debug/selfhealth"
+
+// selfHealthCheckerID identifies the checker that surfaces the self-health
+// watchdog's result in the checker registry, so it's included alongside
+// every other checker's probes in the LocalStatus RPC response.
+const selfHealthCheckerID = "agent"
+
+// selfHealthHeartbeatTag is the member tag that carries the time the agent
+// started, set once on agent construction (see runAgent). This is set once
+// rather than periodically refreshed: config.agent.Tags is read
+// concurrently by the running satellite agent (e.g. LastSeen,
+// RecordLastSeen) with no synchronization of its own, so mutating it after
+// Start() risks a concurrent map read/write; and in this tree, member tags
+// visible to *peer* agents come from Kubernetes node labels populated
+// outside planet (see membership/kubernetes.Cluster.Members), not from
+// this agent's own config.agent.Tags, so refreshing it wouldn't reach
+// peers regardless. The selfHealthCheckerID probe registered above is the
+// mechanism peers can actually rely on: a peer that can still reach this
+// agent's LocalStatus RPC but sees a stale agent probe knows the agent
+// process is up but its collection loop has stalled.
+const selfHealthHeartbeatTag = "agent-started"
+
+// selfHealthCheckInterval is how often runSelfHealthWatchdog polls the
+// agent's own status collection cycle for staleness.
+const selfHealthCheckInterval = 30 * time.Second
+
+// selfHealthStallThreshold is how long the agent's system status may go
+// without a fresh collection cycle before the watchdog considers it
+// stalled and restarts the agent.
+//
+// satellite's agent.Agent has no finer-grained handle on its internal
+// statusUpdateLoop goroutine than Start/Close, so a detected stall is
+// recovered by restarting the whole agent (RPC server included) rather
+// than just the stuck loop.
+const selfHealthStallThreshold = 5 * time.Minute
+
+// selfHealthStackDumpSize bounds the goroutine stack dump logged when a
+// stall is detected. satellite's Status() exposes no information about
+// which goroutine is stuck, so the dump covers every goroutine in the
+// process rather than just the collection loop.
+const selfHealthStackDumpSize = 1 << 20
+
+// selfHealthStatus reports whether the agent's own status collection is
+// still making progress.
+type selfHealthStatus struct {
+	// Healthy is false once SinceLastUpdate has exceeded the stall
+	// threshold and a restart has been triggered.
+	Healthy bool `json:"healthy"`
+	// LastUpdate is the timestamp of the agent's most recent system
+	// status collection cycle.
+	LastUpdate time.Time `json:"last_update"`
+	// SinceLastUpdate is how long ago LastUpdate was.
+	SinceLastUpdate time.Duration `json:"since_last_update"`
+}
+
+// selfHealthState holds the latest selfHealthStatus for concurrent access
+// by the watchdog goroutine, the HTTP handler and the checker registered
+// with newSelfHealthChecker.
+type selfHealthState struct {
+	mu     sync.RWMutex
+	status selfHealthStatus
+}
+
+// get returns the most recently recorded status.
+func (s *selfHealthState) get() selfHealthStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// set records status as the most recent result.
+func (s *selfHealthState) set(status selfHealthStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+// runSelfHealthWatchdog periodically checks monitoringAgent's own system
+// status collection cycle for staleness and restarts it if the cycle
+// appears to have stalled. It records its latest result in state, which
+// backs both the debug endpoint at selfHealthPath and the checker returned
+// by newSelfHealthChecker, and runs until ctx is canceled.
+//
+// Unlike the checkers AddCheckers registers, this isn't watching the
+// health of the node's components - it's watching whether the agent
+// itself is still alive enough to keep collecting and serving their
+// status at all.
+func runSelfHealthWatchdog(ctx context.Context, monitoringAgent agent.Agent, state *selfHealthState) {
+	http.HandleFunc(selfHealthPath, selfHealthHandler(state))
+
+	ticker := time.NewTicker(selfHealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			last := checkSelfHealth(monitoringAgent, state.get())
+			state.set(last)
+			if !last.Healthy {
+				restartSelfHealthAgent(monitoringAgent)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// checkSelfHealth compares the agent's current system status timestamp
+// against prev and reports whether it's stalled.
+func checkSelfHealth(monitoringAgent agent.Agent, prev selfHealthStatus) selfHealthStatus {
+	status, err := monitoringAgent.Status()
+	if err != nil {
+		log.WithError(err).Warn("Self-health watchdog failed to read agent status.")
+		return prev
+	}
+
+	lastUpdate := prev.LastUpdate
+	if status.Timestamp != nil {
+		if updated := status.Timestamp.ToTime(); updated.After(lastUpdate) {
+			lastUpdate = updated
+		}
+	}
+	if lastUpdate.IsZero() {
+		lastUpdate = time.Now()
+	}
+
+	since := time.Since(lastUpdate)
+	healthy := since <= selfHealthStallThreshold
+	if !healthy {
+		log.WithField("since-last-update", since).Error(
+			"Self-health watchdog detected a stalled status collection cycle, dumping goroutines and restarting the agent.")
+		dumpGoroutineStacks()
+	}
+	return selfHealthStatus{Healthy: healthy, LastUpdate: lastUpdate, SinceLastUpdate: since}
+}
+
+// dumpGoroutineStacks logs every goroutine's stack trace.
+func dumpGoroutineStacks() {
+	buf := make([]byte, selfHealthStackDumpSize)
+	n := runtime.Stack(buf, true)
+	log.Warnf("Goroutine dump:\n%s", buf[:n])
+}
+
+// restartSelfHealthAgent restarts monitoringAgent. Restarting is the only
+// recovery lever agent.Agent exposes - there's no way to restart just the
+// stuck internal loop without it.
+func restartSelfHealthAgent(monitoringAgent agent.Agent) {
+	if err := monitoringAgent.Close(); err != nil {
+		log.WithError(err).Warn("Self-health watchdog failed to close agent before restart.")
+	}
+	if err := monitoringAgent.Start(); err != nil {
+		log.WithError(err).Error("Self-health watchdog failed to restart agent.")
+	}
+}
+
+// selfHealthHandler reports the most recent self-health check as JSON.
+func selfHealthHandler(state *selfHealthState) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		status := state.get()
+		w.Header().Set("Content-Type", "application/json")
+		if !status.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	}
+}
+
+// newSelfHealthChecker returns a checker that reports state's most recent
+// self-health result as an "agent" probe, so the watchdog's view of
+// whether status collection is stalled is included in LocalStatus/Status
+// RPC responses alongside every other checker's probes, not just the
+// standalone debug endpoint.
+func newSelfHealthChecker(state *selfHealthState) health.Checker {
+	return &selfHealthChecker{state: state}
+}
+
+type selfHealthChecker struct {
+	state *selfHealthState
+}
+
+// Name returns the name of this checker.
+func (r *selfHealthChecker) Name() string { return selfHealthCheckerID }
+
+// Check reports state's most recent self-health result. Before the first
+// check has run, it reports running: the watchdog hasn't had a chance yet
+// to determine whether collection is stalled.
+func (r *selfHealthChecker) Check(ctx context.Context, reporter health.Reporter) {
+	status := r.state.get()
+	if status.LastUpdate.IsZero() || status.Healthy {
+		reporter.Add(&pb.Probe{Checker: r.Name(), Status: pb.Probe_Running})
+		return
+	}
+	reporter.Add(&pb.Probe{
+		Checker:  r.Name(),
+		Status:   pb.Probe_Failed,
+		Severity: pb.Probe_Critical,
+		Detail:   status.SinceLastUpdate.String(),
+		Error:    "status collection has stalled: no update since " + status.LastUpdate.String(),
+	})
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultShutdownUnitTimeout bounds how long stopUnitsInOrder waits for a
+// unit to stop when its entry didn't specify a timeout.
+const defaultShutdownUnitTimeout = 30 * time.Second
+
+// ShutdownUnit names a systemd unit to stop, in order, before the final
+// `systemctl halt`, and how long to wait for it to stop cleanly.
+type ShutdownUnit struct {
+	// Name is the systemd unit name, e.g. "kubelet.service".
+	Name string
+	// Timeout bounds how long to wait for the unit to stop before moving on
+	// to the next one regardless. Defaults to defaultShutdownUnitTimeout.
+	Timeout time.Duration
+}
+
+// parseShutdownOrder parses a list of "unit" or "unit:timeout" entries (as
+// produced by the --shutdown-order flag) into an ordered shutdown sequence.
+func parseShutdownOrder(entries []string) ([]ShutdownUnit, error) {
+	units := make([]ShutdownUnit, 0, len(entries))
+	for _, entry := range entries {
+		name, rawTimeout := entry, ""
+		if i := strings.LastIndex(entry, ":"); i >= 0 {
+			name, rawTimeout = entry[:i], entry[i+1:]
+		}
+		if name == "" {
+			return nil, trace.BadParameter("invalid shutdown-order entry %q, expected unit or unit:timeout", entry)
+		}
+		unit := ShutdownUnit{Name: name}
+		if rawTimeout != "" {
+			timeout, err := time.ParseDuration(rawTimeout)
+			if err != nil {
+				return nil, trace.BadParameter("invalid timeout in shutdown-order entry %q: %v", entry, err)
+			}
+			unit.Timeout = timeout
+		}
+		units = append(units, unit)
+	}
+	return units, nil
+}
+
+// unitStopper stops a single systemd unit, blocking until it exits or ctx
+// is cancelled. Overridable in tests with a fake systemctl runner.
+type unitStopper func(ctx context.Context, unit string) error
+
+// stopUnitsInOrder stops each of units in sequence via stop, one at a time,
+// so that (for example) kubelet can finish draining before etcd is stopped
+// out from under it. A unit that doesn't stop within its timeout is logged
+// and skipped rather than aborting the rest of the sequence - a stuck unit
+// shouldn't prevent the remaining units, or the final halt, from proceeding.
+func stopUnitsInOrder(ctx context.Context, units []ShutdownUnit, stop unitStopper) {
+	for _, unit := range units {
+		timeout := unit.Timeout
+		if timeout <= 0 {
+			timeout = defaultShutdownUnitTimeout
+		}
+
+		unitCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := stop(unitCtx, unit.Name)
+		cancel()
+		if err != nil {
+			log.WithError(err).WithField("unit", unit.Name).Warn("Failed to stop unit during ordered shutdown.")
+		}
+	}
+}
+
+// stopSystemdUnit stops a systemd unit, blocking (unlike the --no-block
+// systemctl helper used elsewhere) until it exits or ctx is cancelled.
+func stopSystemdUnit(ctx context.Context, unit string) error {
+	return trace.Wrap(systemctlCmd(ctx, "stop", unit))
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gravitational/trace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseShutdownOrder(t *testing.T) {
+	units, err := parseShutdownOrder([]string{"kubelet.service:10s", "etcd.service"})
+	assert.NoError(t, err)
+	assert.Equal(t, []ShutdownUnit{
+		{Name: "kubelet.service", Timeout: 10 * time.Second},
+		{Name: "etcd.service"},
+	}, units)
+}
+
+func TestParseShutdownOrderRejectsInvalidTimeout(t *testing.T) {
+	_, err := parseShutdownOrder([]string{"kubelet.service:not-a-duration"})
+	assert.Error(t, err)
+}
+
+func TestStopUnitsInOrderStopsEachUnitInSequence(t *testing.T) {
+	var stopped []string
+	fakeStop := func(ctx context.Context, unit string) error {
+		stopped = append(stopped, unit)
+		return nil
+	}
+
+	stopUnitsInOrder(context.Background(), []ShutdownUnit{
+		{Name: "kubelet.service"},
+		{Name: "docker.service"},
+		{Name: "etcd.service"},
+	}, fakeStop)
+
+	assert.Equal(t, []string{"kubelet.service", "docker.service", "etcd.service"}, stopped)
+}
+
+func TestStopUnitsInOrderContinuesPastFailingUnit(t *testing.T) {
+	var stopped []string
+	fakeStop := func(ctx context.Context, unit string) error {
+		stopped = append(stopped, unit)
+		if unit == "kubelet.service" {
+			return trace.Errorf("simulated failure to stop %v", unit)
+		}
+		return nil
+	}
+
+	stopUnitsInOrder(context.Background(), []ShutdownUnit{
+		{Name: "kubelet.service"},
+		{Name: "etcd.service"},
+	}, fakeStop)
+
+	assert.Equal(t, []string{"kubelet.service", "etcd.service"}, stopped)
+}
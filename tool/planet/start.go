@@ -104,6 +104,17 @@ func start(config *Config) (*runtimeContext, error) {
 		log.WithError(err).Warn("Ignore kernel supported version check.")
 	}
 
+	if err := checkRootFSFeatures(config); err != nil {
+		if !config.IgnoreChecks {
+			return nil, trace.Wrap(err)
+		}
+		log.WithError(err).Warn("Ignoring rootfs/state directory filesystem feature check failures.")
+	}
+
+	if err := migrateLegacyCubeState(config); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	// check & mount cgroups:
 	if err = box.MountCgroups("/"); err != nil {
 		return nil, trace.Wrap(err)
@@ -145,6 +156,7 @@ func start(config *Config) (*runtimeContext, error) {
 		box.EnvPair{Name: EnvServiceNodePortRange, Val: config.ServiceNodePortRange},
 		box.EnvPair{Name: EnvProxyPortRange, Val: config.ProxyPortRange},
 		box.EnvPair{Name: EnvPublicIP, Val: config.PublicIP},
+		box.EnvPair{Name: EnvPublicIPs, Val: strings.Join(config.PublicIPs, ",")},
 		box.EnvPair{Name: EnvVxlanPort, Val: strconv.Itoa(config.VxlanPort)},
 		// Default agent name to the name of the etcd member
 		box.EnvPair{Name: EnvAgentName, Val: config.EtcdMemberName},
@@ -263,6 +275,21 @@ func start(config *Config) (*runtimeContext, error) {
 		})
 	}
 
+	capabilities, err := startCapabilities(allCaps, config.CapAdd, config.CapDrop)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	log.Infof("Effective container capabilities: %v.", capabilities)
+
+	resourceLimits, err := resolveResourceLimits(config.MemoryLimit, config.MemoryReserveHost, config.CPUQuota)
+	if err != nil {
+		return nil, trace.Wrap(err)
+	}
+	log.Infof("Effective container resource limits: %+v.", resourceLimits)
+	if err := writeResourceLimits(resourceLimits); err != nil {
+		return nil, trace.Wrap(err)
+	}
+
 	cfg := box.Config{
 		Rootfs: config.Rootfs,
 		EnvFiles: []box.EnvFile{
@@ -275,19 +302,24 @@ func start(config *Config) (*runtimeContext, error) {
 				Env:  config.ProxyEnv,
 			},
 		},
-		Files:        config.Files,
-		Mounts:       config.Mounts,
-		Devices:      config.Devices,
-		DataDir:      defaults.RuncDataDir,
-		InitUser:     defaults.InitUser,
-		InitArgs:     defaults.InitArgs,
-		InitEnv:      []string{"container=container-other", "LC_ALL=en_US.UTF-8"},
-		Capabilities: allCaps,
-		ProcessLabel: constants.ContainerInitProcessLabel,
-		SELinux:      config.SELinux,
-	}
-
-	listener, err := newUdevListener(config.SELinux)
+		Files:             config.Files,
+		Mounts:            config.Mounts,
+		Devices:           config.Devices,
+		DataDir:           defaults.RuncDataDir,
+		InitUser:          defaults.InitUser,
+		InitArgs:          defaults.InitArgs,
+		InitEnv:           []string{"container=container-other", "LC_ALL=en_US.UTF-8"},
+		Capabilities:      capabilities,
+		ProcessLabel:      constants.ContainerInitProcessLabel,
+		SELinux:           config.SELinux,
+		ReadonlyRootfs:    config.ReadonlyRootfs,
+		MemoryLimit:       resourceLimits.MemoryLimit,
+		MemoryReservation: resourceLimits.MemoryReservation,
+		CPUQuota:          resourceLimits.CPUQuota,
+		CPUPeriod:         resourceLimits.CPUPeriod,
+	}
+
+	listener, err := newUdevListener(config.SELinux, config.Devices)
 	if err != nil {
 		return nil, trace.Wrap(err)
 	}
@@ -304,12 +336,58 @@ func start(config *Config) (*runtimeContext, error) {
 	}
 	go monitorUnits(box, units...)
 
+	if err := persistStartConfig(config); err != nil {
+		log.WithError(err).Warn("Failed to persist start configuration.")
+	}
+
 	return &runtimeContext{
 		process:  box,
 		listener: listener,
 	}, nil
 }
 
+// startCapabilities returns the capability set to start the container with,
+// computed by removing drop from base and then adding add back to it.
+// Unlike adjustCapabilities (used to restrict an already-entered process to
+// a subset of its container's existing capabilities), add is not required
+// to already be present in base - it only has to name a real capability -
+// since base here is just planet's default set for a container that hasn't
+// started yet, not a ceiling imposed by an untrusted caller.
+func startCapabilities(base, add, drop []string) ([]string, error) {
+	known := make(map[string]bool, len(allCaps))
+	for _, c := range allCaps {
+		known[c] = true
+	}
+	for _, c := range add {
+		if !known[c] {
+			return nil, trace.BadParameter("unknown capability %v in --cap-add", c)
+		}
+	}
+	for _, c := range drop {
+		if !known[c] {
+			return nil, trace.BadParameter("unknown capability %v in --cap-drop", c)
+		}
+	}
+
+	kept := make(map[string]bool, len(base))
+	for _, c := range base {
+		kept[c] = true
+	}
+	for _, c := range drop {
+		delete(kept, c)
+	}
+	for _, c := range add {
+		kept[c] = true
+	}
+
+	caps := make([]string, 0, len(kept))
+	for c := range kept {
+		caps = append(caps, c)
+	}
+	sort.Strings(caps)
+	return caps, nil
+}
+
 // addUserToContainer adds a record for the specified service user to the
 // container's /etc/passwd
 func addUserToContainer(rootfs string, u serviceUser) error {
@@ -317,6 +395,9 @@ func addUserToContainer(rootfs string, u serviceUser) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if existing, found := passwdFile.FindByUID(u.Uid); found && existing.Name != ServiceUser {
+		return trace.AlreadyExists("uid %v is already assigned to user %q", u.Uid, existing.Name)
+	}
 	u.Name = ServiceUser
 	passwdFile.Upsert(*u.User)
 	writer, err := os.OpenFile(filepath.Join(rootfs, UsersDatabase), os.O_WRONLY|os.O_TRUNC, 0644)
@@ -339,6 +420,9 @@ func addGroupToContainer(rootfs string, u serviceUser) error {
 	if err != nil {
 		return trace.Wrap(err)
 	}
+	if existing, found := groupFile.FindByGID(group.Gid); found && existing.Name != ServiceGroup {
+		return trace.AlreadyExists("gid %v is already assigned to group %q", group.Gid, existing.Name)
+	}
 	group.Name = ServiceGroup
 	groupFile.Upsert(*group)
 	writer, err := os.OpenFile(filepath.Join(rootfs, GroupsDatabase), os.O_WRONLY|os.O_TRUNC, 0644)
@@ -416,6 +500,13 @@ func configureProxy(c *Config) {
 }
 
 func generateCloudConfig(config *Config) (cloudConfig string, err error) {
+	if config.CloudConfigPath != "" {
+		contents, err := ioutil.ReadFile(config.CloudConfigPath)
+		if err != nil {
+			return "", trace.ConvertSystemError(err)
+		}
+		return string(contents), nil
+	}
 	if config.CloudConfig != "" {
 		decoded, err := base64.StdEncoding.DecodeString(config.CloudConfig)
 		if err != nil {
@@ -547,6 +638,13 @@ func addComponentOptions(config *Config) error {
 		config.Env.Append(EnvAPIServerOptions,
 			fmt.Sprintf("--encryption-provider-config=%s", constants.EncryptionProviderConfig))
 	}
+	for _, arg := range config.APIServerArgs {
+		validArg, err := validateExtraArg(APIServerServiceName, arg)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		config.Env.Append(EnvAPIServerOptions, validArg)
+	}
 	if config.ProxyPortRange != "" {
 		config.Env.Append(EnvKubeProxyOptions,
 			fmt.Sprintf("--proxy-port-range=%v", config.ProxyPortRange))
@@ -562,6 +660,13 @@ func addKubeletOptions(config *Config) error {
 	if config.KubeletOptions != "" {
 		config.Env.Append(EnvKubeletOptions, config.KubeletOptions)
 	}
+	for _, arg := range config.KubeletArgs {
+		validArg, err := validateExtraArg(KubeletServiceName, arg)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		config.Env.Append(EnvKubeletOptions, validArg)
+	}
 	kubeletConfig := KubeletConfig
 	if config.KubeletConfig != "" {
 		decoded, err := base64.StdEncoding.DecodeString(config.KubeletConfig)
@@ -657,6 +762,10 @@ func setCoreDNS(config *Config) error {
 		return trace.Wrap(err)
 	}
 
+	for _, warning := range validateDNSZones(config.DNS.Zones) {
+		log.Warn(warning)
+	}
+
 	corednsConfig, err := generateCoreDNSConfig(coreDNSConfig{
 		Zones:               config.DNS.Zones,
 		Hosts:               config.DNS.Hosts,
@@ -741,7 +850,7 @@ func addResolv(config *Config) (upstreamNameservers []string, err error) {
 		// DNS resolution
 		dnsAddrs = config.DNS.ListenAddrs[:1]
 	}
-	if err := copyResolvFile(*cfg, planetResolv, dnsAddrs); err != nil {
+	if err := copyResolvFile(*cfg, planetResolv, dnsAddrs, config.DNS); err != nil {
 		return nil, trace.Wrap(err)
 	}
 
@@ -775,13 +884,14 @@ func readHostResolv() (*utils.DNSConfig, error) {
 }
 
 // copyResolvFile adds DNS resolver configuration from the host's /etc/resolv.conf
-func copyResolvFile(cfg utils.DNSConfig, destination string, upstreamNameservers []string) error {
+func copyResolvFile(cfg utils.DNSConfig, destination string, upstreamNameservers []string, dns DNS) error {
 	// Make sure upstream nameservers go first in the order supplied by caller
 	nameservers := append(upstreamNameservers, cfg.Servers...)
 
 	cfg.Servers = nameservers
-	cfg.Ndots = DNSNdots
-	cfg.Timeout = DNSTimeout
+	cfg.Ndots = dns.Ndots
+	cfg.Timeout = dns.Timeout
+	cfg.Attempts = dns.Attempts
 	// Don't copy rotate option, we rely on query order for internal resolution
 	cfg.Rotate = false
 
@@ -1037,6 +1147,32 @@ func checkRequiredMounts(cfg *Config) error {
 	return nil
 }
 
+// checkRootFSFeatures validates that the filesystems backing the rootfs
+// and state directories (on the host, before they're bind-mounted into
+// the container) support what planet needs from them: directory entry
+// types for docker's overlay2 storage driver (missing on XFS created
+// with ftype=0), and execute/device/setuid permissions on the paths
+// planet runs binaries from. It aggregates every failure found across
+// both directories into a single error.
+func checkRootFSFeatures(cfg *Config) error {
+	paths := []string{cfg.Rootfs}
+	if cfg.SecretsDir != "" {
+		paths = append(paths, cfg.SecretsDir)
+	}
+	var errors []error
+	for _, path := range paths {
+		result, err := check.CheckRootFS(path)
+		if err != nil {
+			errors = append(errors, trace.Wrap(err, "failed to check filesystem features of %v", path))
+			continue
+		}
+		for _, failure := range result.Failures() {
+			errors = append(errors, trace.BadParameter(failure))
+		}
+	}
+	return trace.NewAggregate(errors...)
+}
+
 // chownDir recursively chowns a directory and everything inside to
 // a given uid:gid.
 // It is a Golang equivalent of chown uid:gid dirPath -R
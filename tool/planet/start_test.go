@@ -17,7 +17,12 @@ limitations under the License.
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
 	"github.com/gravitational/planet/lib/test"
+	"github.com/gravitational/planet/lib/user"
 
 	"gopkg.in/check.v1"
 )
@@ -136,3 +141,43 @@ func (_ *StartSuite) TestCoreDNSConf(c *check.C) {
 		c.Assert(config, test.DeepEquals, tt.expected)
 	}
 }
+
+func (_ *StartSuite) TestGenerateCloudConfigFromFile(c *check.C) {
+	dir, err := ioutil.TempDir("", "planet-cloud-config-test")
+	c.Assert(err, check.IsNil)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "cloud-config.conf")
+	c.Assert(ioutil.WriteFile(path, []byte("[Global]\n"), 0644), check.IsNil)
+
+	config, err := generateCloudConfig(&Config{CloudConfigPath: path})
+	c.Assert(err, check.IsNil)
+	c.Assert(config, test.DeepEquals, "[Global]\n")
+
+	// A missing file is reported rather than silently ignored.
+	_, err = generateCloudConfig(&Config{CloudConfigPath: filepath.Join(dir, "missing.conf")})
+	c.Assert(err, check.NotNil)
+}
+
+func (_ *StartSuite) TestAddUserToContainerRejectsColliding(c *check.C) {
+	rootfs := c.MkDir()
+	c.Assert(os.MkdirAll(filepath.Join(rootfs, "etc"), 0755), check.IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(rootfs, UsersDatabase),
+		[]byte("root:x:0:0:root:/root:/bin/bash\nexisting:x:5000:5000:Existing:/home/existing:/bin/false\n"), 0644),
+		check.IsNil)
+
+	// A requested uid already owned by an unrelated user is rejected rather
+	// than silently reassigned.
+	err := addUserToContainer(rootfs, serviceUser{User: &user.User{Uid: 5000, Gid: 5000}})
+	c.Assert(err, check.NotNil)
+
+	// A free uid is accepted and recorded under the planet service user
+	// name, upserting any prior planet entry rather than duplicating it.
+	c.Assert(addUserToContainer(rootfs, serviceUser{User: &user.User{Uid: 5001, Gid: 5001}}), check.IsNil)
+
+	passwdFile, err := user.NewPasswdFromFile(filepath.Join(rootfs, UsersDatabase))
+	c.Assert(err, check.IsNil)
+	added, found := passwdFile.FindByUID(5001)
+	c.Assert(found, check.Equals, true)
+	c.Assert(added.Name, check.Equals, ServiceUser)
+}
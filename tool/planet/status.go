@@ -0,0 +1,52 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+// unreachableNodeCheckerID identifies the synthetic probe added to a node
+// whose status could not be retrieved from its peer.
+const unreachableNodeCheckerID = "node-rpc"
+
+// annotateUnreachableNodes adds a synthetic failed probe to every node in
+// status whose status could not be retrieved from its peer, so a single
+// unreachable node doesn't blank out the rest of the cluster status without
+// explanation. The underlying RPC error itself isn't available here - the
+// aggregating agent only records it in its own log and reports the node as
+// pb.NodeStatus_Unknown with no probes - so the synthetic probe can only
+// describe that the node didn't respond, not why.
+func annotateUnreachableNodes(status *pb.SystemStatus) {
+	if status == nil {
+		return
+	}
+	for _, node := range status.Nodes {
+		if node.Status != pb.NodeStatus_Unknown || len(node.Probes) != 0 {
+			continue
+		}
+		node.Probes = append(node.Probes, &pb.Probe{
+			Checker:  unreachableNodeCheckerID,
+			Status:   pb.Probe_Failed,
+			Severity: pb.Probe_Critical,
+			Detail:   fmt.Sprintf("node/%v", node.Name),
+			Error:    fmt.Sprintf("node %v did not respond to the cluster status query", node.Name),
+		})
+	}
+}
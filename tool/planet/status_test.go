@@ -0,0 +1,63 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+func TestAnnotateUnreachableNodesAddsSyntheticProbe(t *testing.T) {
+	status := &pb.SystemStatus{
+		Nodes: []*pb.NodeStatus{
+			{Name: "healthy", Status: pb.NodeStatus_Running, Probes: []*pb.Probe{{Checker: "some-checker"}}},
+			{Name: "unreachable", Status: pb.NodeStatus_Unknown},
+		},
+	}
+
+	annotateUnreachableNodes(status)
+
+	if len(status.Nodes[0].Probes) != 1 {
+		t.Fatalf("expected healthy node's probes to be left untouched, got %v", status.Nodes[0].Probes)
+	}
+
+	probes := status.Nodes[1].Probes
+	if len(probes) != 1 {
+		t.Fatalf("expected exactly one synthetic probe for the unreachable node, got %v", probes)
+	}
+	if probes[0].Status != pb.Probe_Failed {
+		t.Fatalf("expected synthetic probe to report failure, got %v", probes[0].Status)
+	}
+	if probes[0].Error == "" {
+		t.Fatal("expected synthetic probe to explain why the node's status is unknown")
+	}
+}
+
+func TestAnnotateUnreachableNodesLeavesUnknownWithProbesAlone(t *testing.T) {
+	status := &pb.SystemStatus{
+		Nodes: []*pb.NodeStatus{
+			{Name: "degraded", Status: pb.NodeStatus_Unknown, Probes: []*pb.Probe{{Checker: "already-explained"}}},
+		},
+	}
+
+	annotateUnreachableNodes(status)
+
+	if len(status.Nodes[0].Probes) != 1 || status.Nodes[0].Probes[0].Checker != "already-explained" {
+		t.Fatalf("expected existing probes to be preserved, got %v", status.Nodes[0].Probes)
+	}
+}
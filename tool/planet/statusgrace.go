@@ -0,0 +1,132 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gravitational/planet/lib/utils"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+
+	"github.com/gravitational/trace"
+)
+
+// memberFailureStateFile persists, across "planet status" invocations, when
+// each currently-Failed member was first observed Failed, so
+// --member-failure-grace can tell a transient blip from a sustained outage.
+// It lives alongside maintenanceStateFile for the same reason: a freshly
+// started CLI process has no memory of previous polls otherwise.
+var memberFailureStateFile = filepath.Join(DefaultSecretsMountDir, "member-failure.json")
+
+// applyMemberFailureGracePeriod keeps status.Status at the Running that
+// setSystemStatus would have reported absent the Failed member(s), as long
+// as every member currently reporting Failed has been Failed for less than
+// grace and no other reason (an unhealthy node) also contributed to the
+// Degraded verdict. Raw member and node statuses are left untouched -
+// only the aggregate status.Status/Summary are overridden.
+//
+// setSystemStatus (vendored) flips status.Status to Degraded the instant
+// any member is Failed, with no grace period of its own, so this is applied
+// as a client-side override rather than changed at the source.
+func applyMemberFailureGracePeriod(status *pb.SystemStatus, grace time.Duration) error {
+	if grace <= 0 || status == nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	since, err := readMemberFailureState()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	// Recompute the set of currently-Failed members (and their first-seen
+	// timestamps) and persist it unconditionally, even when status isn't
+	// Degraded - otherwise a member that recovers keeps its old
+	// first-seen timestamp, and a later failure wrongly looks like it's
+	// already past the grace period.
+	failedSince := make(map[string]time.Time)
+	nodesHealthy := true
+	for _, node := range status.Nodes {
+		if node.Status != pb.NodeStatus_Running {
+			nodesHealthy = false
+		}
+		if node.MemberStatus == nil || node.MemberStatus.Status != pb.MemberStatus_Failed {
+			continue
+		}
+		first, ok := since[node.MemberStatus.Name]
+		if !ok {
+			first = now
+		}
+		failedSince[node.MemberStatus.Name] = first
+	}
+	if err := writeMemberFailureState(failedSince); err != nil {
+		return trace.Wrap(err)
+	}
+
+	if status.Status != pb.SystemStatus_Degraded || !nodesHealthy || len(failedSince) == 0 {
+		return nil
+	}
+	for _, first := range failedSince {
+		if now.Sub(first) >= grace {
+			return nil
+		}
+	}
+
+	status.Status = pb.SystemStatus_Running
+	status.Summary = fmt.Sprintf("%v member(s) reporting Failed within the %v grace period", len(failedSince), grace)
+	return nil
+}
+
+// readMemberFailureState loads the persisted first-seen-Failed timestamps,
+// if any. It returns a nil map when no state has ever been recorded.
+func readMemberFailureState() (map[string]time.Time, error) {
+	data, err := ioutil.ReadFile(memberFailureStateFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, trace.ConvertSystemError(err)
+	}
+	var state map[string]time.Time
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return state, nil
+}
+
+// writeMemberFailureState persists state, removing the state file entirely
+// once no member is Failed anymore so a later failure starts its grace
+// period from scratch rather than reusing a stale timestamp.
+func writeMemberFailureState(state map[string]time.Time) error {
+	if len(state) == 0 {
+		if err := os.Remove(memberFailureStateFile); err != nil && !os.IsNotExist(err) {
+			return trace.ConvertSystemError(err)
+		}
+		return nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	return trace.Wrap(utils.SafeWriteFile(memberFailureStateFile, data, SharedFileMask))
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+// withMemberFailureStateFile points memberFailureStateFile at a fresh
+// temporary file for the duration of the test, so tests don't interfere
+// with each other or a real agent's persisted state.
+func withMemberFailureStateFile(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	orig := memberFailureStateFile
+	memberFailureStateFile = dir + "/member-failure.json"
+	t.Cleanup(func() { memberFailureStateFile = orig })
+}
+
+func degradedStatusWithFailedMember(name string) *pb.SystemStatus {
+	return &pb.SystemStatus{
+		Status: pb.SystemStatus_Degraded,
+		Nodes: []*pb.NodeStatus{
+			{
+				Name:         name,
+				Status:       pb.NodeStatus_Running,
+				MemberStatus: &pb.MemberStatus{Name: name, Status: pb.MemberStatus_Failed},
+			},
+		},
+	}
+}
+
+func TestApplyMemberFailureGracePeriodDisabledByDefault(t *testing.T) {
+	withMemberFailureStateFile(t)
+
+	status := degradedStatusWithFailedMember("node-1")
+	if err := applyMemberFailureGracePeriod(status, 0); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != pb.SystemStatus_Degraded {
+		t.Fatalf("expected a zero grace period to leave status Degraded, got %v", status.Status)
+	}
+}
+
+func TestApplyMemberFailureGracePeriodSuppressesWithinWindow(t *testing.T) {
+	withMemberFailureStateFile(t)
+
+	status := degradedStatusWithFailedMember("node-1")
+	if err := applyMemberFailureGracePeriod(status, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != pb.SystemStatus_Running {
+		t.Fatalf("expected a freshly-failed member within the grace period to stay Running, got %v", status.Status)
+	}
+	if status.Nodes[0].MemberStatus.Status != pb.MemberStatus_Failed {
+		t.Fatal("expected the raw member status to be left untouched")
+	}
+}
+
+func TestApplyMemberFailureGracePeriodDegradesPastWindow(t *testing.T) {
+	withMemberFailureStateFile(t)
+
+	// Simulate a member that's already been Failed for a while by seeding
+	// the persisted state with a first-seen timestamp in the past.
+	if err := writeMemberFailureState(map[string]time.Time{
+		"node-1": time.Now().UTC().Add(-time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	status := degradedStatusWithFailedMember("node-1")
+	if err := applyMemberFailureGracePeriod(status, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != pb.SystemStatus_Degraded {
+		t.Fatalf("expected a member failed past the grace period to report Degraded, got %v", status.Status)
+	}
+}
+
+func TestApplyMemberFailureGracePeriodMemberRecoversWithinWindow(t *testing.T) {
+	withMemberFailureStateFile(t)
+
+	status := degradedStatusWithFailedMember("node-1")
+	if err := applyMemberFailureGracePeriod(status, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != pb.SystemStatus_Running {
+		t.Fatalf("expected the first poll to stay Running, got %v", status.Status)
+	}
+
+	// The member recovers before the grace period elapses.
+	recovered := &pb.SystemStatus{
+		Status: pb.SystemStatus_Running,
+		Nodes: []*pb.NodeStatus{
+			{
+				Name:         "node-1",
+				Status:       pb.NodeStatus_Running,
+				MemberStatus: &pb.MemberStatus{Name: "node-1", Status: pb.MemberStatus_Alive},
+			},
+		},
+	}
+	if err := applyMemberFailureGracePeriod(recovered, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(memberFailureStateFile); !os.IsNotExist(err) {
+		t.Fatalf("expected the recovered member's tracked failure state to be cleared, stat err: %v", err)
+	}
+
+	// If it fails again later, its grace period should start over rather
+	// than immediately degrading because of the earlier occurrence.
+	refailed := degradedStatusWithFailedMember("node-1")
+	if err := applyMemberFailureGracePeriod(refailed, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if refailed.Status != pb.SystemStatus_Running {
+		t.Fatalf("expected a fresh failure to restart the grace period, got %v", refailed.Status)
+	}
+}
+
+func TestApplyMemberFailureGracePeriodKeepsDegradedIfNodeUnhealthy(t *testing.T) {
+	withMemberFailureStateFile(t)
+
+	status := degradedStatusWithFailedMember("node-1")
+	status.Nodes = append(status.Nodes, &pb.NodeStatus{
+		Name:         "node-2",
+		Status:       pb.NodeStatus_Degraded,
+		MemberStatus: &pb.MemberStatus{Name: "node-2", Status: pb.MemberStatus_Alive},
+	})
+
+	if err := applyMemberFailureGracePeriod(status, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if status.Status != pb.SystemStatus_Degraded {
+		t.Fatalf("expected Degraded to stick when a node is unhealthy for reasons other than a failed member, got %v", status.Status)
+	}
+}
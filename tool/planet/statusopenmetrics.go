@@ -0,0 +1,81 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/gravitational/planet/lib/monitoring"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+// renderOpenMetricsStatus renders statusBlob - a nodeStatusReport or
+// clusterStatusReport, as built by status() - as OpenMetrics text, so it
+// can be scraped without running the full in-process metrics collector
+// subsystem (see lib/monitoring/metrics.go). Unlike that subsystem, this
+// reads directly off the already-fetched status rather than performing its
+// own checks.
+func renderOpenMetricsStatus(w io.Writer, statusBlob interface{}) {
+	fmt.Fprintln(w, "# HELP planet_node_status_running Whether the node is in the Running state (1) or not (0).")
+	fmt.Fprintln(w, "# TYPE planet_node_status_running gauge")
+	fmt.Fprintln(w, "# HELP planet_probe_status_running Whether a health probe is in the Running state (1) or not (0).")
+	fmt.Fprintln(w, "# TYPE planet_probe_status_running gauge")
+
+	switch report := statusBlob.(type) {
+	case nodeStatusReport:
+		writeNodeStatusMetric(w, report.NodeName, report.Status == pb.NodeStatus_Running)
+		writeProbeMetrics(w, report.NodeName, report.Probes)
+	case clusterStatusReport:
+		nodeNames := make([]string, 0, len(report.Nodes))
+		byName := make(map[string]*pb.NodeStatus, len(report.Nodes))
+		for _, node := range report.Nodes {
+			nodeNames = append(nodeNames, node.NodeName)
+			byName[node.NodeName] = node
+		}
+		sort.Strings(nodeNames)
+		for _, nodeName := range nodeNames {
+			node := byName[nodeName]
+			writeNodeStatusMetric(w, nodeName, node.Status == pb.NodeStatus_Running)
+			writeProbeMetrics(w, nodeName, node.Probes)
+		}
+	}
+
+	fmt.Fprintln(w, "# EOF")
+}
+
+func writeNodeStatusMetric(w io.Writer, nodeName string, running bool) {
+	fmt.Fprintf(w, "planet_node_status_running{node=%q} %v\n", nodeName, boolToMetricValue(running))
+}
+
+func writeProbeMetrics(w io.Writer, nodeName string, probes []*pb.Probe) {
+	for _, probe := range probes {
+		subsystem := string(monitoring.CheckerSubsystem(probe.Checker))
+		running := probe.Status == pb.Probe_Running
+		fmt.Fprintf(w, "planet_probe_status_running{node=%q,checker=%q,subsystem=%q} %v\n",
+			nodeName, probe.Checker, subsystem, boolToMetricValue(running))
+	}
+}
+
+func boolToMetricValue(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
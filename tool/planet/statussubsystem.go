@@ -0,0 +1,134 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/gravitational/planet/lib/monitoring"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+)
+
+// subsystemReport rolls up the probes belonging to a single subsystem (see
+// monitoring.Subsystem) into a single status, so "planet status" can show
+// operators whether a problem lies in etcd, networking, kubernetes,
+// storage or the general system at a glance, instead of making them read
+// a flat list of 20+ probes to work that out for themselves.
+type subsystemReport struct {
+	// Subsystem is the subsystem's name, e.g. "etcd" or "network".
+	Subsystem string `json:"subsystem"`
+	// Status is the most severe status among Probes: "running" if every
+	// probe in the subsystem is running, "warning" or "critical" if any
+	// have failed, named after the failed probe of the highest severity.
+	Status string `json:"status"`
+	// Probes lists every probe belonging to this subsystem.
+	Probes []*pb.Probe `json:"probes"`
+}
+
+// subsystemStatus names used in subsystemReport.Status.
+const (
+	subsystemStatusRunning  = "running"
+	subsystemStatusWarning  = "warning"
+	subsystemStatusCritical = "critical"
+)
+
+// groupProbesBySubsystem groups probes by monitoring.CheckerSubsystem(probe.Checker)
+// and rolls each group up to a single subsystemReport, sorted by subsystem
+// name for a stable, diffable JSON rendering.
+func groupProbesBySubsystem(probes []*pb.Probe) []subsystemReport {
+	bySubsystem := make(map[string][]*pb.Probe)
+	for _, probe := range probes {
+		subsystem := string(monitoring.CheckerSubsystem(probe.Checker))
+		bySubsystem[subsystem] = append(bySubsystem[subsystem], probe)
+	}
+
+	reports := make([]subsystemReport, 0, len(bySubsystem))
+	for subsystem, subsystemProbes := range bySubsystem {
+		reports = append(reports, subsystemReport{
+			Subsystem: subsystem,
+			Status:    rollupSubsystemStatus(subsystemProbes),
+			Probes:    subsystemProbes,
+		})
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Subsystem < reports[j].Subsystem })
+	return reports
+}
+
+// rollupSubsystemStatus reports the status of the worst probe in probes:
+// critical beats warning beats running, mirroring how satellite itself
+// picks a single NodeStatus/SystemStatus out of many probes.
+func rollupSubsystemStatus(probes []*pb.Probe) string {
+	status := subsystemStatusRunning
+	for _, probe := range probes {
+		if probe.Status != pb.Probe_Failed {
+			continue
+		}
+		if probe.Severity == pb.Probe_Critical {
+			return subsystemStatusCritical
+		}
+		status = subsystemStatusWarning
+	}
+	return status
+}
+
+// renderPrettyStatus writes statusBlob (a nodeStatusReport or
+// clusterStatusReport, as built by status()) as subsystem sections rather
+// than JSON: one line per subsystem with its rolled-up status, and one
+// line per probe underneath - only failing probes unless verbose is set.
+func renderPrettyStatus(w io.Writer, statusBlob interface{}, verbose bool) {
+	switch report := statusBlob.(type) {
+	case nodeStatusReport:
+		fmt.Fprintf(w, "NODE %v: %v\n", report.NodeName, report.Status)
+		renderSubsystemSections(w, report.Subsystems, verbose)
+	case clusterStatusReport:
+		fmt.Fprintf(w, "CLUSTER: %v\n", report.Status)
+		nodeNames := make([]string, 0, len(report.Subsystems))
+		for nodeName := range report.Subsystems {
+			nodeNames = append(nodeNames, nodeName)
+		}
+		sort.Strings(nodeNames)
+		for _, nodeName := range nodeNames {
+			fmt.Fprintf(w, "\nNODE %v\n", nodeName)
+			renderSubsystemSections(w, report.Subsystems[nodeName], verbose)
+		}
+	}
+}
+
+// renderSubsystemSections writes one section per subsystem, expanding only
+// its failing probes unless verbose is set.
+func renderSubsystemSections(w io.Writer, subsystems []subsystemReport, verbose bool) {
+	for _, subsystem := range subsystems {
+		fmt.Fprintf(w, "  [%v] %v\n", subsystem.Subsystem, subsystem.Status)
+		for _, probe := range subsystem.Probes {
+			if !verbose && probe.Status != pb.Probe_Failed {
+				continue
+			}
+			fmt.Fprintf(w, "    - %v: %v", probe.Checker, strings.ToLower(probe.Status.String()))
+			if probe.Detail != "" {
+				fmt.Fprintf(w, " (%v)", probe.Detail)
+			}
+			fmt.Fprintln(w)
+			if probe.Error != "" {
+				fmt.Fprintf(w, "      %v\n", probe.Error)
+			}
+		}
+	}
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/satellite/lib/rpc/client"
+	agentutils "github.com/gravitational/satellite/utils"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// watchStatusConfig configures watchStatus.
+type watchStatusConfig struct {
+	statusConfig
+	// interval is the time to wait between polls of the agent.
+	interval time.Duration
+}
+
+// statusEventKind identifies the kind of transition a statusEvent reports.
+type statusEventKind string
+
+const (
+	// eventNodeStatusChanged reports a node (with --local, this node)
+	// transitioning between health states, e.g. Running to Degraded. The
+	// cluster's aggregate status is not diffed.
+	eventNodeStatusChanged statusEventKind = "status-changed"
+	// eventProbeFailed reports a probe that was previously passing (or
+	// unseen) starting to fail.
+	eventProbeFailed statusEventKind = "probe-failed"
+	// eventProbeCleared reports a probe that was previously failing
+	// passing again.
+	eventProbeCleared statusEventKind = "probe-cleared"
+)
+
+// statusEvent describes a single transition observed between two
+// consecutive polls of the agent.
+type statusEvent struct {
+	// Time is when the transition was observed, not when it actually
+	// happened - polling can only bound the latter to within one interval.
+	Time time.Time `json:"time"`
+	// Kind identifies the kind of transition.
+	Kind statusEventKind `json:"kind"`
+	// Node is the node the transition applies to.
+	Node string `json:"node"`
+	// Checker is the probe checker name, set for probe-failed and
+	// probe-cleared events.
+	Checker string `json:"checker,omitempty"`
+	// From is the status before the transition, set for
+	// status-changed events.
+	From string `json:"from,omitempty"`
+	// To is the status after the transition, set for status-changed
+	// events.
+	To string `json:"to,omitempty"`
+	// Error is the probe's error message, set for probe-failed events.
+	Error string `json:"error,omitempty"`
+}
+
+// watchStatus polls the agent for status at c.interval and prints only the
+// transitions observed between consecutive polls - a node (or, with
+// --local, this node) changing health state, and probes starting or
+// ceasing to fail - instead of redrawing the full status on every poll.
+// It runs until interrupted, reconnecting on its next poll if the agent is
+// temporarily unavailable (e.g. it restarted).
+func watchStatus(c watchStatusConfig) error {
+	if c.format == statusFormatNagios {
+		return trace.BadParameter("--watch does not support the nagios output format")
+	}
+
+	signalc := make(chan os.Signal, 1)
+	signal.Notify(signalc, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalc)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	var prevSystem *pb.SystemStatus
+	var prevNode *pb.NodeStatus
+	for {
+		events, err := pollStatusOnce(c, prevSystem, prevNode)
+		switch {
+		case err == nil:
+			for _, event := range events.events {
+				if err := printStatusEvent(c.format, event); err != nil {
+					return trace.Wrap(err)
+				}
+			}
+			prevSystem, prevNode = events.system, events.node
+		case agentutils.IsUnavailableError(err):
+			log.WithError(err).Warn("Agent unavailable, will retry.")
+		default:
+			return trace.Wrap(err)
+		}
+
+		select {
+		case <-signalc:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollResult carries the outcome of a single pollStatusOnce call: the
+// transitions observed relative to the previous poll, and the snapshot to
+// diff the next poll against.
+type pollResult struct {
+	events []statusEvent
+	system *pb.SystemStatus
+	node   *pb.NodeStatus
+}
+
+// pollStatusOnce queries the agent once and diffs the result against
+// prevSystem/prevNode (whichever applies, given c.local). A fresh client is
+// dialed on every call, so a poll following an agent restart simply
+// reconnects rather than requiring any special handling.
+func pollStatusOnce(c watchStatusConfig, prevSystem *pb.SystemStatus, prevNode *pb.NodeStatus) (pollResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	agentClient, err := client.NewClient(ctx, client.Config{
+		Address:  rpcAddr(c.rpcPort),
+		CAFile:   c.caFile,
+		CertFile: c.clientCertFile,
+		KeyFile:  c.clientKeyFile,
+	})
+	if err != nil {
+		return pollResult{}, trace.Wrap(err)
+	}
+	defer agentClient.Close()
+
+	now := time.Now().UTC()
+	if c.local {
+		status, err := agentClient.LocalStatus(ctx)
+		if err != nil {
+			return pollResult{}, trace.Wrap(err)
+		}
+		var events []statusEvent
+		if prevNode != nil {
+			events = diffNodeStatus(prevNode, status, now)
+		}
+		return pollResult{events: events, node: status}, nil
+	}
+
+	status, err := agentClient.Status(ctx)
+	if err != nil {
+		return pollResult{}, trace.Wrap(err)
+	}
+	annotateUnreachableNodes(status)
+	if err := applyMemberFailureGracePeriod(status, c.memberFailureGrace); err != nil {
+		return pollResult{}, trace.Wrap(err)
+	}
+	var events []statusEvent
+	if prevSystem != nil {
+		events = diffClusterStatus(prevSystem, status, now)
+	}
+	return pollResult{events: events, system: status}, nil
+}
+
+// diffClusterStatus returns the transitions observed between two
+// consecutive cluster status snapshots. Nodes present in curr but not prev
+// (or vice versa) are not reported - there is no prior state to diff a
+// newly-seen node against.
+func diffClusterStatus(prev, curr *pb.SystemStatus, now time.Time) []statusEvent {
+	prevNodes := indexNodesByName(prev.Nodes)
+	var events []statusEvent
+	for _, node := range curr.Nodes {
+		prevNode, ok := prevNodes[node.NodeName]
+		if !ok {
+			continue
+		}
+		events = append(events, diffNodeStatus(prevNode, node, now)...)
+	}
+	return events
+}
+
+// indexNodesByName indexes nodes by NodeName for lookup during diffing.
+func indexNodesByName(nodes []*pb.NodeStatus) map[string]*pb.NodeStatus {
+	index := make(map[string]*pb.NodeStatus, len(nodes))
+	for _, node := range nodes {
+		index[node.NodeName] = node
+	}
+	return index
+}
+
+// diffNodeStatus returns the transitions observed for a single node
+// (its own health status and each of its probes) between two polls.
+func diffNodeStatus(prev, curr *pb.NodeStatus, now time.Time) []statusEvent {
+	var events []statusEvent
+	if prev.Status != curr.Status {
+		events = append(events, statusEvent{
+			Time: now,
+			Kind: eventNodeStatusChanged,
+			Node: curr.NodeName,
+			From: prev.Status.String(),
+			To:   curr.Status.String(),
+		})
+	}
+	events = append(events, diffProbes(curr.NodeName, prev.Probes, curr.Probes, now)...)
+	return events
+}
+
+// diffProbes returns probe-failed/probe-cleared events for probes whose
+// pass/fail state differs between prevProbes and currProbes on node.
+func diffProbes(node string, prevProbes, currProbes []*pb.Probe, now time.Time) []statusEvent {
+	prevByChecker := indexProbesByChecker(prevProbes)
+	var events []statusEvent
+	for checker, curr := range indexProbesByChecker(currProbes) {
+		prev, seen := prevByChecker[checker]
+		wasFailing := seen && prev.Status != pb.Probe_Running
+		isFailing := curr.Status != pb.Probe_Running
+		switch {
+		case isFailing && !wasFailing:
+			events = append(events, statusEvent{
+				Time: now, Kind: eventProbeFailed, Node: node, Checker: checker, Error: curr.Error,
+			})
+		case !isFailing && wasFailing:
+			events = append(events, statusEvent{
+				Time: now, Kind: eventProbeCleared, Node: node, Checker: checker,
+			})
+		}
+	}
+	return events
+}
+
+// indexProbesByChecker indexes probes by their checker name for lookup
+// during diffing.
+func indexProbesByChecker(probes []*pb.Probe) map[string]*pb.Probe {
+	index := make(map[string]*pb.Probe, len(probes))
+	for _, probe := range probes {
+		index[probe.Checker] = probe
+	}
+	return index
+}
+
+// printStatusEvent writes a single event to stdout, either as a
+// human-readable line (statusFormatText) or as one JSON object per line
+// for machine consumption (statusFormatJSON).
+func printStatusEvent(format statusFormat, event statusEvent) error {
+	if format == statusFormatJSON {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		_, err = fmt.Println(string(data))
+		return trace.Wrap(err)
+	}
+
+	timestamp := event.Time.Format(time.RFC3339)
+	var err error
+	switch event.Kind {
+	case eventNodeStatusChanged:
+		_, err = fmt.Printf("%v node %v: %v -> %v\n", timestamp, event.Node, event.From, event.To)
+	case eventProbeFailed:
+		_, err = fmt.Printf("%v node %v: probe %v started failing: %v\n", timestamp, event.Node, event.Checker, event.Error)
+	case eventProbeCleared:
+		_, err = fmt.Printf("%v node %v: probe %v cleared\n", timestamp, event.Node, event.Checker)
+	}
+	return trace.Wrap(err)
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gravitational/trace"
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// systemWorkloadKind identifies the kind of controller a systemWorkload
+// expects to find its pods behind.
+type systemWorkloadKind string
+
+const (
+	systemWorkloadDaemonSet  systemWorkloadKind = "DaemonSet"
+	systemWorkloadDeployment systemWorkloadKind = "Deployment"
+)
+
+// systemWorkload names a well-known cluster-critical workload whose pods
+// should be Ready on every node they're scheduled to.
+type systemWorkload struct {
+	kind      systemWorkloadKind
+	namespace string
+	name      string
+}
+
+// defaultSystemWorkloads lists the workloads validateSystemPods checks by
+// default: the DNS, kube-proxy and overlay network components planet
+// deploys onto every cluster.
+var defaultSystemWorkloads = []systemWorkload{
+	{kind: systemWorkloadDeployment, namespace: "kube-system", name: "coredns"},
+	{kind: systemWorkloadDaemonSet, namespace: "kube-system", name: "kube-proxy"},
+	{kind: systemWorkloadDaemonSet, namespace: "kube-system", name: "kube-flannel-ds"},
+}
+
+// systemWorkloadsClient is the narrow slice of the kube client
+// checkSystemWorkloads needs, so tests can supply a fake without pulling in
+// a full kubernetes.Interface implementation.
+type systemWorkloadsClient interface {
+	// GetDaemonSet returns the named DaemonSet, or a trace.NotFound error
+	// if it doesn't exist.
+	GetDaemonSet(ctx context.Context, namespace, name string) (*appsv1.DaemonSet, error)
+	// GetDeployment returns the named Deployment, or a trace.NotFound
+	// error if it doesn't exist.
+	GetDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error)
+}
+
+// kubeSystemWorkloadsClient adapts a real kubernetes.Clientset to
+// systemWorkloadsClient.
+type kubeSystemWorkloadsClient struct {
+	client kubernetes.Interface
+}
+
+// GetDaemonSet implements systemWorkloadsClient.
+func (r kubeSystemWorkloadsClient) GetDaemonSet(ctx context.Context, namespace, name string) (*appsv1.DaemonSet, error) {
+	return r.client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// GetDeployment implements systemWorkloadsClient.
+func (r kubeSystemWorkloadsClient) GetDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
+	return r.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// validateSystemPods queries the cluster for each of the well-known system
+// workloads and reports any that are missing or not fully Ready.
+func validateSystemPods(ctx context.Context, client *kubernetes.Clientset) error {
+	problems := checkSystemWorkloads(ctx, kubeSystemWorkloadsClient{client: client}, defaultSystemWorkloads)
+	if len(problems) == 0 {
+		fmt.Println("all system pods are present and ready")
+		return nil
+	}
+	fmt.Println("found unhealthy system pods:")
+	for _, problem := range problems {
+		fmt.Printf("  - %v\n", problem)
+	}
+	return trace.BadParameter("%v system workload(s) are not fully ready", len(problems))
+}
+
+// checkSystemWorkloads queries client for each of workloads and returns a
+// human-readable description of each that's missing or has fewer ready
+// pods than desired (e.g. crash-looping or not yet scheduled).
+func checkSystemWorkloads(ctx context.Context, client systemWorkloadsClient, workloads []systemWorkload) []string {
+	var problems []string
+	for _, workload := range workloads {
+		switch workload.kind {
+		case systemWorkloadDaemonSet:
+			daemonSet, err := client.GetDaemonSet(ctx, workload.namespace, workload.name)
+			if apierrors.IsNotFound(err) {
+				problems = append(problems, fmt.Sprintf("daemonset %v/%v not found", workload.namespace, workload.name))
+				continue
+			}
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("daemonset %v/%v: %v", workload.namespace, workload.name, err))
+				continue
+			}
+			if daemonSet.Status.NumberReady < daemonSet.Status.DesiredNumberScheduled {
+				problems = append(problems, fmt.Sprintf("daemonset %v/%v has %v/%v pods ready",
+					workload.namespace, workload.name, daemonSet.Status.NumberReady, daemonSet.Status.DesiredNumberScheduled))
+			}
+		case systemWorkloadDeployment:
+			deployment, err := client.GetDeployment(ctx, workload.namespace, workload.name)
+			if apierrors.IsNotFound(err) {
+				problems = append(problems, fmt.Sprintf("deployment %v/%v not found", workload.namespace, workload.name))
+				continue
+			}
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("deployment %v/%v: %v", workload.namespace, workload.name, err))
+				continue
+			}
+			desired := int32(1)
+			if deployment.Spec.Replicas != nil {
+				desired = *deployment.Spec.Replicas
+			}
+			if deployment.Status.ReadyReplicas < desired {
+				problems = append(problems, fmt.Sprintf("deployment %v/%v has %v/%v pods ready",
+					workload.namespace, workload.name, deployment.Status.ReadyReplicas, desired))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeSystemWorkloadsClient struct {
+	daemonSets  map[string]*appsv1.DaemonSet
+	deployments map[string]*appsv1.Deployment
+}
+
+func (r fakeSystemWorkloadsClient) GetDaemonSet(ctx context.Context, namespace, name string) (*appsv1.DaemonSet, error) {
+	daemonSet, ok := r.daemonSets[namespace+"/"+name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "daemonsets"}, name)
+	}
+	return daemonSet, nil
+}
+
+func (r fakeSystemWorkloadsClient) GetDeployment(ctx context.Context, namespace, name string) (*appsv1.Deployment, error) {
+	deployment, ok := r.deployments[namespace+"/"+name]
+	if !ok {
+		return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "deployments"}, name)
+	}
+	return deployment, nil
+}
+
+func readyDaemonSet(desired, ready int32) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: desired, NumberReady: ready}}
+}
+
+func readyDeployment(replicas *int32, ready int32) *appsv1.Deployment {
+	return &appsv1.Deployment{Spec: appsv1.DeploymentSpec{Replicas: replicas}, Status: appsv1.DeploymentStatus{ReadyReplicas: ready}}
+}
+
+func TestCheckSystemWorkloadsReportsMissingDaemonSet(t *testing.T) {
+	client := fakeSystemWorkloadsClient{
+		deployments: map[string]*appsv1.Deployment{
+			"kube-system/coredns": readyDeployment(nil, 1),
+		},
+	}
+	problems := checkSystemWorkloads(context.Background(), client, defaultSystemWorkloads)
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 problems for the two missing daemonsets, got %v", problems)
+	}
+}
+
+func TestCheckSystemWorkloadsReportsNotFullyReady(t *testing.T) {
+	client := fakeSystemWorkloadsClient{
+		daemonSets: map[string]*appsv1.DaemonSet{
+			"kube-system/kube-proxy":      readyDaemonSet(3, 2),
+			"kube-system/kube-flannel-ds": readyDaemonSet(3, 3),
+		},
+		deployments: map[string]*appsv1.Deployment{
+			"kube-system/coredns": readyDeployment(nil, 1),
+		},
+	}
+	problems := checkSystemWorkloads(context.Background(), client, defaultSystemWorkloads)
+	if len(problems) != 1 {
+		t.Fatalf("expected 1 problem for the not-fully-ready daemonset, got %v", problems)
+	}
+}
+
+func TestCheckSystemWorkloadsAllHealthy(t *testing.T) {
+	replicas := int32(2)
+	client := fakeSystemWorkloadsClient{
+		daemonSets: map[string]*appsv1.DaemonSet{
+			"kube-system/kube-proxy":      readyDaemonSet(3, 3),
+			"kube-system/kube-flannel-ds": readyDaemonSet(3, 3),
+		},
+		deployments: map[string]*appsv1.Deployment{
+			"kube-system/coredns": readyDeployment(&replicas, 2),
+		},
+	}
+	problems := checkSystemWorkloads(context.Background(), client, defaultSystemWorkloads)
+	if len(problems) != 0 {
+		t.Fatalf("expected no problems, got %v", problems)
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gravitational/trace"
+)
+
+// topDefaultInterval is how often "planet top" refreshes its table by
+// default.
+const topDefaultInterval = 2 * time.Second
+
+// topProperties are the systemd unit properties collected for each unit in
+// topUnits, in the order they're requested from systemctl.
+var topProperties = []string{"ActiveState", "MemoryCurrent", "CPUUsageNSec"}
+
+// topUnits lists the systemd units "planet top" reports resource usage for.
+var topUnits = []string{
+	ETCDServiceName,
+	APIServerServiceName,
+	ProxyServiceName,
+	KubeletServiceName,
+	PlanetAgentServiceName,
+	FlannelServiceName,
+	CorednsServiceName,
+	DefaultDockerUnit,
+}
+
+// topConfig configures "planet top".
+type topConfig struct {
+	// seLinux enables SELinux support on commands run inside the container.
+	seLinux bool
+	// once collects a single snapshot and exits instead of refreshing the
+	// table in place, for scripting.
+	once bool
+	// interval is how often the table refreshes. Ignored when once is set.
+	// Defaults to topDefaultInterval.
+	interval time.Duration
+}
+
+// unitResourceUsage is a single unit's resource usage, as read from
+// systemctl show via the topProperties above.
+type unitResourceUsage struct {
+	state       string
+	memoryBytes uint64
+	cpuNSec     uint64
+	sampledAt   time.Time
+}
+
+// top prints a table of CPU and memory usage for the container's managed
+// systemd units (etcd, the kubernetes control plane, docker, ...), entering
+// the container the same way "planet enter"/"planet debug diag" do. With
+// --once it prints a single snapshot and exits; otherwise it clears the
+// screen and reprints the table every config.interval, computing a CPU
+// percentage from the change in each unit's cumulative CPU time between
+// samples, until interrupted.
+func top(config topConfig) error {
+	if config.interval <= 0 {
+		config.interval = topDefaultInterval
+	}
+
+	var prev map[string]unitResourceUsage
+	for {
+		usage, err := collectTopUsage(config.seLinux)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+
+		if !config.once {
+			fmt.Print("\033[H\033[2J")
+		}
+		printTopTable(os.Stdout, usage, prev)
+		if config.once {
+			return nil
+		}
+
+		prev = usage
+		time.Sleep(config.interval)
+	}
+}
+
+// collectTopUsage reads topProperties for every unit in topUnits with a
+// single systemctl invocation inside the container.
+func collectTopUsage(seLinux bool) (map[string]unitResourceUsage, error) {
+	args := append(append([]string{}, topUnits...), "--property="+strings.Join(topProperties, ","))
+	out, err := runInContainer(seLinux, "/bin/systemctl", append([]string{"show"}, args...)...)
+	if err != nil {
+		return nil, trace.Wrap(err, "failed to query unit resource usage")
+	}
+	return parseTopUsage(out)
+}
+
+// parseTopUsage parses the output of "systemctl show <units...>
+// --property=...": one block of len(topProperties) "Key=Value" lines per
+// unit, in the same order the units were requested in - systemctl show
+// does not otherwise label which block belongs to which unit.
+func parseTopUsage(out string) (map[string]unitResourceUsage, error) {
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	if len(lines) != len(topUnits)*len(topProperties) {
+		return nil, trace.BadParameter("unexpected systemctl show output: got %v lines, expected %v", len(lines), len(topUnits)*len(topProperties))
+	}
+
+	sampledAt := time.Now()
+	usage := make(map[string]unitResourceUsage, len(topUnits))
+	for i, unit := range topUnits {
+		props := make(map[string]string, len(topProperties))
+		for _, line := range lines[i*len(topProperties) : (i+1)*len(topProperties)] {
+			if idx := strings.Index(line, "="); idx >= 0 {
+				props[line[:idx]] = line[idx+1:]
+			}
+		}
+		memoryBytes, _ := strconv.ParseUint(props["MemoryCurrent"], 10, 64)
+		cpuNSec, _ := strconv.ParseUint(props["CPUUsageNSec"], 10, 64)
+		usage[unit] = unitResourceUsage{
+			state:       props["ActiveState"],
+			memoryBytes: memoryBytes,
+			cpuNSec:     cpuNSec,
+			sampledAt:   sampledAt,
+		}
+	}
+	return usage, nil
+}
+
+// printTopTable writes usage as a table, in topUnits order. When prev is
+// non-nil, each unit's CPU column shows the percentage of wall-clock time
+// spent on CPU since the previous sample instead of raw cumulative CPU
+// time.
+func printTopTable(w *os.File, usage, prev map[string]unitResourceUsage) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "UNIT\tSTATE\tMEMORY\tCPU")
+	for _, unit := range topUnits {
+		u := usage[unit]
+		fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", unit, u.state, formatBytes(u.memoryBytes), formatTopCPU(u, prev[unit]))
+	}
+	tw.Flush()
+}
+
+// formatTopCPU renders a unit's CPU column: a percentage of wall-clock
+// time since prev when a previous sample is available, or the raw
+// cumulative CPU time otherwise (e.g. on the first sample, or with
+// --once).
+func formatTopCPU(current, prev unitResourceUsage) string {
+	elapsed := current.sampledAt.Sub(prev.sampledAt)
+	if prev.sampledAt.IsZero() || current.cpuNSec < prev.cpuNSec || elapsed <= 0 {
+		return (time.Duration(current.cpuNSec) * time.Nanosecond).String()
+	}
+	used := time.Duration(current.cpuNSec-prev.cpuNSec) * time.Nanosecond
+	return fmt.Sprintf("%.1f%%", float64(used)/float64(elapsed)*100)
+}
+
+// formatBytes renders a byte count in the largest unit that keeps it
+// above 1, e.g. "512.0MiB".
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := uint64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -17,9 +17,10 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"os"
+	"path/filepath"
 
 	"github.com/gravitational/planet/lib/box"
 	"github.com/gravitational/planet/lib/constants"
@@ -32,8 +33,11 @@ import (
 )
 
 // newUdevListener creates a new udev event listener listening
-// for events on block devices of type `disk`
-func newUdevListener(seLinux bool) (*udevListener, error) {
+// for events on block devices of type `disk` or `partition`. configuredDevices are the
+// devices specified with --device at startup and are used to determine the
+// ownership/permissions to apply to devices hot-plugged after startup that
+// match one of their (glob) paths
+func newUdevListener(seLinux bool, configuredDevices box.Devices) (*udevListener, error) {
 	udev := udev.Udev{}
 	monitor := udev.NewMonitorFromNetlink("udev")
 	if monitor == nil {
@@ -52,11 +56,12 @@ func newUdevListener(seLinux bool) (*udevListener, error) {
 	}
 
 	listener := &udevListener{
-		monitor: monitor,
-		ctx:     ctx,
-		cancel:  cancel,
-		recvC:   recvC,
-		seLinux: seLinux,
+		monitor:           monitor,
+		ctx:               ctx,
+		cancel:            cancel,
+		recvC:             recvC,
+		seLinux:           seLinux,
+		configuredDevices: configuredDevices,
 	}
 	go listener.loop()
 
@@ -77,27 +82,27 @@ func (r *udevListener) Close() error {
 // udevListener defines the task of listening to udev events
 // and dispatching corresponding device commands into the planet container
 type udevListener struct {
-	monitor *udev.Monitor
-	ctx     context.Context
-	cancel  context.CancelFunc
-	recvC   <-chan *udev.Device
-	seLinux bool
+	monitor           *udev.Monitor
+	ctx               context.Context
+	cancel            context.CancelFunc
+	recvC             <-chan *udev.Device
+	seLinux           bool
+	configuredDevices box.Devices
 }
 
 // loop runs the actual udev event loop
 func (r *udevListener) loop() {
-	const cgroupPermissions = "rwm"
-
 	for {
 		select {
 		case device := <-r.recvC:
 			switch device.Action() {
 			case "add":
-				deviceData, err := devices.DeviceFromPath(device.Devnode(), cgroupPermissions)
+				deviceData, err := devices.DeviceFromPath(device.Devnode(), cgroupDevicePermissions)
 				if err != nil {
 					log.Warnf("failed to query device: %v", err)
 					continue
 				}
+				r.applyConfiguredDevice(deviceData)
 				if err := r.createDevice(deviceData); err != nil {
 					log.Warnf("failed to create device `%v` in container: %v", device.Devnode(), err)
 				}
@@ -115,6 +120,36 @@ func (r *udevListener) loop() {
 	}
 }
 
+// applyConfiguredDevice overrides the permissions, file mode and ownership
+// of device with the values from the --device entry whose (glob) path
+// matches device's node, if any. Devices that were not explicitly
+// configured keep the values queried from the host device
+func (r *udevListener) applyConfiguredDevice(device *configs.Device) {
+	for _, configured := range r.configuredDevices {
+		matched, err := filepath.Match(configured.Path, device.Path)
+		if err != nil {
+			log.Warnf("invalid device path pattern %q: %v", configured.Path, err)
+			continue
+		}
+		if !matched {
+			continue
+		}
+		if configured.Permissions != "" {
+			device.Permissions = configured.Permissions
+		}
+		if configured.FileMode != 0 {
+			device.FileMode = configured.FileMode
+		}
+		if configured.UID != 0 {
+			device.Uid = configured.UID
+		}
+		if configured.GID != 0 {
+			device.Gid = configured.GID
+		}
+		return
+	}
+}
+
 // createDevice dispatches a command to add a new device in the container
 func (r *udevListener) createDevice(device *configs.Device) error {
 	log.Infof("createDevice: %v", device)
@@ -124,16 +159,37 @@ func (r *udevListener) createDevice(device *configs.Device) error {
 		return trace.Wrap(err)
 	}
 
-	err = enter(r.deviceCmd("add", "--data", string(deviceJson)))
-	return trace.Wrap(err)
+	return trace.Wrap(r.runDeviceCmd("add", "--data", string(deviceJson)))
 }
 
 // removeDevice dispatches a command to remove a device in the container
 func (r *udevListener) removeDevice(node string) error {
 	log.Infof("removeDevice: %v", node)
 
-	err := enter(r.deviceCmd("remove", "--node", node))
-	return trace.Wrap(err)
+	return trace.Wrap(r.runDeviceCmd("remove", "--node", node))
+}
+
+// runDeviceCmd dispatches the device subcommand identified by args into the
+// container and interprets the structured JSON result it prints to report
+// success or failure precisely
+func (r *udevListener) runDeviceCmd(args ...string) error {
+	var out bytes.Buffer
+	cfg := r.deviceCmd(args...)
+	cfg.Process.Out = &out
+
+	runErr := enter(cfg)
+
+	var result deviceResult
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &result); err != nil {
+		if runErr != nil {
+			return trace.Wrap(runErr)
+		}
+		return trace.Wrap(err, "failed to parse device command result: %q", out.String())
+	}
+	if !result.Success {
+		return trace.BadParameter("%v", result.Error)
+	}
+	return nil
 }
 
 // deviceCmd creates a configuration object to invoke the device agent
@@ -144,8 +200,6 @@ func (r *udevListener) deviceCmd(args ...string) box.EnterConfig {
 		Process: box.ProcessConfig{
 			User:         "root",
 			Args:         append([]string{cmd, "--debug", "device"}, args...),
-			In:           os.Stdin,
-			Out:          os.Stdout,
 			ProcessLabel: constants.ContainerRuntimeProcessLabel,
 		},
 		SELinux: r.seLinux,
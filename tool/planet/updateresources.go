@@ -0,0 +1,95 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package main
+
+import (
+	"io/ioutil"
+
+	"github.com/gravitational/planet/lib/defaults"
+	"github.com/gravitational/trace"
+
+	"github.com/containerd/cgroups"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// updateResources applies new memory/CPU limits to the already-running
+// planet container's own cgroup - the same cgroup box.Config.MemoryLimit,
+// MemoryReservation, CPUQuota and CPUPeriod configure at "planet start"
+// (see lib/box/srv.go) - without restarting the container. Any of
+// memoryLimit, memoryReserveHost or cpuQuota may be left empty, in which
+// case that setting is left unchanged.
+func updateResources(memoryLimit, memoryReserveHost, cpuQuota string) error {
+	if memoryLimit == "" && memoryReserveHost == "" && cpuQuota == "" {
+		return trace.BadParameter("specify at least one of --memory-limit, --memory-reserve-host or --cpu-quota")
+	}
+
+	limits, err := resolveResourceLimits(memoryLimit, memoryReserveHost, cpuQuota)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	containerID, err := runningContainerID()
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	// The planet container's cgroup is created by the systemd cgroup driver
+	// (see libcontainer.SystemdCgroups in lib/box/srv.go), which places a
+	// configs.Cgroup{Name: "planet-<id>"} with no parent slice under the
+	// scope unit "system.slice/-planet-<id>.scope" rather than under the
+	// literal path "/planet-<id>" a cgroups.StaticPath would look for.
+	control, err := cgroups.Load(cgroups.Systemd, cgroups.Slice("system.slice", "-planet-"+containerID+".scope"))
+	if err != nil {
+		return trace.Wrap(err, "failed to find the cgroup of the running planet container")
+	}
+
+	var resources specs.LinuxResources
+	if limits.MemoryLimit != 0 {
+		resources.Memory = &specs.LinuxMemory{Limit: &limits.MemoryLimit}
+	}
+	if limits.CPUQuota != 0 {
+		resources.CPU = &specs.LinuxCPU{Quota: &limits.CPUQuota, Period: &limits.CPUPeriod}
+	}
+	if err := control.Update(&resources); err != nil {
+		return trace.Wrap(err, "failed to update the running planet container's cgroup")
+	}
+	return nil
+}
+
+// runningContainerID returns the id of the currently running planet
+// container, discovered from libcontainer's runtime state directory
+// (defaults.RuncDataDir). Planet is the only container libcontainer
+// manages there, so exactly one entry is expected.
+func runningContainerID() (string, error) {
+	entries, err := ioutil.ReadDir(defaults.RuncDataDir)
+	if err != nil {
+		return "", trace.ConvertSystemError(err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			ids = append(ids, entry.Name())
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", trace.NotFound("no running planet container found in %v", defaults.RuncDataDir)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", trace.BadParameter("expected a single running planet container in %v, found %v", defaults.RuncDataDir, ids)
+	}
+}
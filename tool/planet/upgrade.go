@@ -0,0 +1,127 @@
+/*
+Copyright 2026 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/gravitational/planet/lib/handover"
+
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultUpgradeHandoverSocket is the unix socket a running planet process
+// listens for handover requests on, when --upgrade-handover-socket is set.
+var DefaultUpgradeHandoverSocket = filepath.Join(DefaultSecretsMountDir, "upgrade-handover.sock")
+
+// handoverShutdownGrace bounds how long the offering process waits for
+// in-flight requests on its own copy of the debug endpoint to finish before
+// it forcibly closes them, once a handover has completed.
+const handoverShutdownGrace = 10 * time.Second
+
+// planet has no persistent control-socket daemon that the "planet enter"
+// and "planet status" clients talk to - "planet enter" attaches directly to
+// the running container's libcontainer state on disk for each invocation,
+// and cluster status is served by the vendored satellite agent over its own
+// RPC listener, which exposes no way to extract or inject its underlying
+// file descriptor short of changing the vendored package. Of everything a
+// running planet process owns, the one listener it creates and fully
+// controls itself is the --httpprofile debug endpoint (serving /debug/pprof,
+// /debug/loglevel and, for the long-running master process, /debug/checker
+// and /debug/selfhealth). serveHandoverRequests and "upgrade-daemon" hand
+// that listener over between successive binary invocations without ever
+// closing the port, as the one genuinely achievable piece of a broader
+// zero-downtime upgrade.
+
+// serveHandoverRequests offers listener for handover, once, to whichever
+// process next connects to socketPath, blocking until ctx is cancelled.
+// Each offered handover that fails (a stale or incompatible requester) is
+// logged and retried - listener is only ever closed by its own caller,
+// never by this function, which returns without error once a handover
+// actually succeeds, leaving listener closing to the caller.
+func serveHandoverRequests(ctx context.Context, socketPath string, listener *net.TCPListener) error {
+	os.Remove(socketPath)
+	handoverListener, err := net.ListenUnix("unix", &net.UnixAddr{Name: socketPath, Net: "unix"})
+	if err != nil {
+		return trace.Wrap(err)
+	}
+	defer handoverListener.Close()
+	defer os.Remove(socketPath)
+
+	go func() {
+		<-ctx.Done()
+		handoverListener.Close()
+	}()
+
+	for {
+		err := handover.Offer(handoverListener, listener)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err == nil:
+			log.Info("Handed over the debug endpoint listener to a newer planet binary.")
+			return nil
+		default:
+			log.WithError(err).Warn("Handover request failed, the debug endpoint listener stays with this process.")
+		}
+	}
+}
+
+// upgradeDaemon requests the debug endpoint listener bound by a running
+// planet process over socketPath, then serves it under this process until
+// interrupted. It does not attempt to take over any of the other
+// subsystems (etcd, kubelet, docker) a running planet master supervises -
+// those remain the responsibility of the process that's handing over the
+// listener, which keeps running.
+func upgradeDaemon(socketPath string) error {
+	listener, err := handover.Request(socketPath)
+	if err != nil {
+		return trace.Wrap(err, "failed to take over the debug endpoint listener")
+	}
+
+	registerLogLevelHandler()
+	server := &http.Server{}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(listener) }()
+	log.WithField("addr", listener.Addr()).Info("Serving the handed-over debug endpoint.")
+
+	signalc := make(chan os.Signal, 1)
+	signal.Notify(signalc, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalc)
+
+	select {
+	case err := <-serveErr:
+		if err != nil && err != http.ErrServerClosed {
+			return trace.Wrap(err)
+		}
+	case <-signalc:
+		ctx, cancel := context.WithTimeout(context.Background(), handoverShutdownGrace)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			return trace.Wrap(err)
+		}
+	}
+	return nil
+}
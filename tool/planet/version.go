@@ -0,0 +1,44 @@
+/*
+Copyright 2021 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/gravitational/trace"
+	"github.com/gravitational/version"
+)
+
+// printVersion prints the planet build version in the requested output
+// format, either "text" (just the version string) or "json" (the full
+// version.Info payload, including git commit and tree state).
+func printVersion(output string) error {
+	info := version.Get()
+	switch output {
+	case "json":
+		payload, err := json.Marshal(info)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		fmt.Fprintln(os.Stdout, string(payload))
+	default:
+		fmt.Fprintln(os.Stdout, info.Version)
+	}
+	return nil
+}
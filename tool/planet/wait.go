@@ -0,0 +1,173 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gravitational/planet/lib/box"
+	"github.com/gravitational/planet/lib/constants"
+
+	"github.com/gravitational/trace"
+)
+
+// waitPollInterval is how often waitForReady polls unit states inside the
+// container while waiting for it to become ready.
+const waitPollInterval = time.Second
+
+// Exit codes returned by "planet wait" for its distinct failure modes, so
+// callers like "planet start && planet wait && kubectl ..." can tell a
+// timeout (units may still come up) from a degraded unit (they won't).
+const (
+	// waitExitCodeTimeout is returned when the timeout expires before all
+	// required units become active.
+	waitExitCodeTimeout = 2
+	// waitExitCodeDegraded is returned when a required unit is found to
+	// have failed.
+	waitExitCodeDegraded = 3
+)
+
+// UnitState describes the systemd active state of a single unit, as
+// reported by "planet wait collect".
+type UnitState struct {
+	// Name is the systemd unit name, e.g. "etcd.service".
+	Name string `json:"name"`
+	// ActiveState is the unit's active state as reported by systemctl, e.g.
+	// "active", "activating", "failed".
+	ActiveState string `json:"active_state"`
+}
+
+// errWaitTimeout is returned by waitForReady when units haven't all become
+// active before the timeout expires.
+type errWaitTimeout struct {
+	units []UnitState
+}
+
+func (e *errWaitTimeout) Error() string {
+	return fmt.Sprintf("timed out waiting for units to become active: %v", formatUnitStates(e.units))
+}
+
+// errWaitDegraded is returned by waitForReady when a required unit has
+// entered the "failed" state, meaning it will not become active on its own.
+type errWaitDegraded struct {
+	units []UnitState
+}
+
+func (e *errWaitDegraded) Error() string {
+	return fmt.Sprintf("required unit(s) failed: %v", formatUnitStates(e.units))
+}
+
+// formatUnitStates renders units as "name=state, ...".
+func formatUnitStates(units []UnitState) string {
+	parts := make([]string, 0, len(units))
+	for _, unit := range units {
+		parts = append(parts, fmt.Sprintf("%v=%v", unit.Name, unit.ActiveState))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// waitForReady blocks until every unit in units is active inside the
+// container, timeout expires, or a unit is found to have failed. It
+// dispatches into the container via enter to poll unit states, so it works
+// without a running planet agent.
+func waitForReady(units []string, timeout time.Duration, seLinux bool) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		states, err := collectUnitStates(units, seLinux)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		var pending []UnitState
+		var failed []UnitState
+		for _, state := range states {
+			switch state.ActiveState {
+			case "active":
+			case "failed":
+				failed = append(failed, state)
+			default:
+				pending = append(pending, state)
+			}
+		}
+		if len(failed) > 0 {
+			return trace.Wrap(&errWaitDegraded{units: failed})
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return trace.Wrap(&errWaitTimeout{units: pending})
+		}
+		time.Sleep(waitPollInterval)
+	}
+}
+
+// collectUnitStates dispatches a single round of unit state collection into
+// the container and returns the result.
+func collectUnitStates(units []string, seLinux bool) ([]UnitState, error) {
+	var out bytes.Buffer
+	cfg := box.EnterConfig{
+		Process: box.ProcessConfig{
+			User:         "root",
+			Out:          &out,
+			Args:         append([]string{"/usr/bin/planet", "--debug", "wait", "collect"}, units...),
+			ProcessLabel: constants.ContainerRuntimeProcessLabel,
+		},
+		SELinux: seLinux,
+	}
+	if err := enter(cfg); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	var states []UnitState
+	if err := json.Unmarshal(bytes.TrimSpace(out.Bytes()), &states); err != nil {
+		return nil, trace.Wrap(err, "failed to parse unit state result: %q", out.String())
+	}
+	return states, nil
+}
+
+// waitCollect reports the active state of the given units and prints them
+// as JSON. It is invoked by waitForReady via enter and is not intended to
+// be run directly by operators.
+func waitCollect(units []string) error {
+	states := make([]UnitState, 0, len(units))
+	for _, unit := range units {
+		states = append(states, UnitState{Name: unit, ActiveState: unitActiveState(unit)})
+	}
+	return trace.Wrap(json.NewEncoder(os.Stdout).Encode(states))
+}
+
+// unitActiveState returns the active state of a systemd unit as reported by
+// "systemctl is-active". A unit that systemctl cannot find is reported as
+// "inactive" rather than an error, since that's indistinguishable from "not
+// started yet" for the purposes of waiting.
+func unitActiveState(unit string) string {
+	out, err := exec.CommandContext(context.TODO(), "systemctl", "is-active", unit).CombinedOutput()
+	state := strings.TrimSpace(string(out))
+	if state == "" {
+		if err != nil {
+			return "unknown"
+		}
+		return "inactive"
+	}
+	return state
+}
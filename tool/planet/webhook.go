@@ -0,0 +1,131 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gravitational/planet/lib/utils"
+
+	"github.com/cenkalti/backoff"
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/gravitational/trace"
+	log "github.com/sirupsen/logrus"
+)
+
+// StatusWebhookConfig configures NewStatusWebhook.
+type StatusWebhookConfig struct {
+	// URL is the endpoint status transitions are POSTed to.
+	URL string
+	// Timeout bounds a single POST attempt, retries included.
+	Timeout time.Duration
+	// Client sends the POST request. Defaults to http.DefaultClient.
+	Client *http.Client
+}
+
+// CheckAndSetDefaults validates the configuration and sets default values.
+func (r *StatusWebhookConfig) CheckAndSetDefaults() error {
+	if r.URL == "" {
+		return trace.BadParameter("webhook URL is required")
+	}
+	if r.Timeout == 0 {
+		r.Timeout = 30 * time.Second
+	}
+	if r.Client == nil {
+		r.Client = http.DefaultClient
+	}
+	return nil
+}
+
+// NewStatusWebhook returns a webhook that posts pb.SystemStatus JSON to
+// config.URL whenever the aggregated status transitions between Running and
+// Degraded, debouncing repeated notifications of the same status. Intended
+// to be passed as the notify callback to agentcache.NewNotifying.
+func NewStatusWebhook(config StatusWebhookConfig) (*StatusWebhook, error) {
+	if err := config.CheckAndSetDefaults(); err != nil {
+		return nil, trace.Wrap(err)
+	}
+	return &StatusWebhook{StatusWebhookConfig: config}, nil
+}
+
+// StatusWebhook posts SystemStatus transitions to an external URL.
+type StatusWebhook struct {
+	StatusWebhookConfig
+
+	mu   sync.Mutex
+	last pb.SystemStatus_Type
+	seen bool
+}
+
+// Notify posts status to the configured URL if it represents a transition
+// from the last status reported - i.e. it is dropped (debounced) if the
+// status type is unchanged from the previous call.
+func (w *StatusWebhook) Notify(status *pb.SystemStatus) {
+	if !w.transitioned(status.GetStatus()) {
+		return
+	}
+	if err := w.post(status); err != nil {
+		log.WithError(err).Warn("Failed to post status to webhook.")
+	}
+}
+
+// transitioned reports whether statusType differs from the last status
+// reported, recording it as the new last status if so.
+func (w *StatusWebhook) transitioned(statusType pb.SystemStatus_Type) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.seen && w.last == statusType {
+		return false
+	}
+	w.last = statusType
+	w.seen = true
+	return true
+}
+
+func (w *StatusWebhook) post(status *pb.SystemStatus) error {
+	payload, err := json.Marshal(status)
+	if err != nil {
+		return trace.Wrap(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.Timeout)
+	defer cancel()
+
+	_, err = utils.RetryWithIntervalE(ctx, utils.NewUnlimitedExponentialBackOff(), func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(payload))
+		if err != nil {
+			return backoff.Permanent(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := w.Client.Do(req)
+		if err != nil {
+			return trace.Wrap(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		return trace.Errorf("webhook returned status %v", resp.StatusCode)
+	})
+	return trace.Wrap(err)
+}
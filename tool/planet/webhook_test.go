@@ -0,0 +1,34 @@
+/*
+Copyright 2022 Gravitational, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"testing"
+
+	pb "github.com/gravitational/satellite/agent/proto/agentpb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusWebhookTransitionedDebouncesRepeats(t *testing.T) {
+	w := &StatusWebhook{}
+
+	assert.True(t, w.transitioned(pb.SystemStatus_Running), "first status is always a transition")
+	assert.False(t, w.transitioned(pb.SystemStatus_Running), "repeat of the same status is debounced")
+	assert.True(t, w.transitioned(pb.SystemStatus_Degraded), "change of status is a transition")
+	assert.False(t, w.transitioned(pb.SystemStatus_Degraded), "repeat of the new status is debounced")
+	assert.True(t, w.transitioned(pb.SystemStatus_Running), "reverting to the earlier status is still a transition")
+}